@@ -0,0 +1,190 @@
+package bigcsv
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Runner is a configured Parser of any type.
+type Runner interface {
+	Run(ctx context.Context, workers int) error
+	Stats() Stats
+}
+
+// Route selects the Parser for archive entries whose name matches Pattern,
+// see path.Match. Patterns without a slash match the base name of entries.
+type Route struct {
+	Pattern string
+
+	// Parser creates the Parser for an entry, see ParserFor.
+	Parser func(stream Stream) (Runner, error)
+}
+
+// ParserFor returns a Route.Parser creating a Parser of type T with the
+// options, set up by configure.
+//
+//	bigcsv.Route{Pattern: "orders*.csv", Parser: bigcsv.ParserFor(func(p *bigcsv.Parser[Order]) error {
+//		p.Parse, p.OnData = parseOrder, store.AddOrder
+//		return nil
+//	}, bigcsv.WithHeaders())}
+func ParserFor[T any](configure func(p *Parser[T]) error, opts ...Option) func(Stream) (Runner, error) {
+	return func(stream Stream) (Runner, error) {
+		p, err := New[T](stream, opts...)
+		if err != nil {
+			return nil, err
+		}
+		if err = configure(p); err != nil {
+			p.Close()
+			return nil, err
+		}
+		return p, nil
+	}
+}
+
+// ArchiveRouter runs separately configured Parsers on the entries of a zip or
+// tar archive, e.g. customers.csv and orders.csv of one export. Entries are
+// processed one after another, in archive order, by the first matching Route.
+// Entries ending in .gz are decompressed.
+type ArchiveRouter struct {
+	Routes []Route
+
+	// Workers is passed to each Run.
+	Workers int
+
+	// Unmatched is called for entries without a Route. An error fails the
+	// entry, else it is ignored. If nil, unmatched entries are ignored.
+	Unmatched func(name string) error
+}
+
+// ArchiveStats holds the Stats of the entries processed by an ArchiveRouter.
+type ArchiveStats struct {
+	// Total combines the Stats of all entries.
+	Total Stats
+
+	// Entries holds the Stats by entry name.
+	Entries map[string]Stats
+}
+
+// Run processes the entries of the archive, continuing with the next entry if
+// one fails. The errors of all entries are returned together.
+func (a *ArchiveRouter) Run(ctx context.Context, archive Stream) (ArchiveStats, error) {
+	stats := ArchiveStats{Entries: map[string]Stats{}}
+	rc, err := archive.Open()
+	if err != nil {
+		return stats, fmt.Errorf("could not open archive: %w", err)
+	}
+	defer rc.Close()
+
+	r := bufio.NewReader(rc)
+	magic, _ := r.Peek(4)
+	var errs []error
+	entry := func(name string, modTime time.Time, open func() (io.ReadCloser, error)) {
+		s, err := a.entry(ctx, entryStream{name: name, archive: describe(archive), modTime: modTime, open: open})
+		if s != nil {
+			stats.Entries[name] = *s
+			stats.Total = stats.Total.Add(*s)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %s: %w", name, err))
+		}
+	}
+	if bytes.Equal(magic, []byte("PK\x03\x04")) {
+		src := io.Reader(r)
+		if _, ok := rc.(io.ReaderAt); ok { // a file, read at offsets
+			src = rc
+		}
+		z, err := openZip(src)
+		if err != nil {
+			return stats, fmt.Errorf("could not read zip archive: %w", err)
+		}
+		for _, f := range z.File {
+			if ctx.Err() != nil {
+				break
+			}
+			if !f.FileInfo().IsDir() {
+				entry(f.Name, f.Modified, f.Open)
+			}
+		}
+		return stats, errors.Join(errs...)
+	}
+
+	tr := tar.NewReader(r)
+	for ctx.Err() == nil {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stats, errors.Join(append(errs, fmt.Errorf("could not read tar archive: %w", err))...)
+		}
+		if h.Typeflag == tar.TypeReg {
+			entry(h.Name, h.ModTime, func() (io.ReadCloser, error) {
+				return io.NopCloser(tr), nil
+			})
+		}
+	}
+	return stats, errors.Join(errs...)
+}
+
+// entry runs the Parser of the matching route on an entry, returning nil
+// Stats if none ran.
+func (a *ArchiveRouter) entry(ctx context.Context, stream entryStream) (*Stats, error) {
+	name := stream.name
+	route, ok := a.route(name)
+	if !ok {
+		if a.Unmatched != nil {
+			return nil, a.Unmatched(name)
+		}
+		return nil, nil
+	}
+	p, err := route.Parser(stream)
+	if err != nil {
+		return nil, err
+	}
+	err = p.Run(ctx, a.Workers)
+	s := p.Stats()
+	return &s, err
+}
+
+// route returns the first route matching the entry name.
+func (a *ArchiveRouter) route(name string) (Route, bool) {
+	for _, route := range a.Routes {
+		target := name
+		if !strings.Contains(route.Pattern, "/") {
+			target = path.Base(name)
+		}
+		if ok, _ := path.Match(route.Pattern, target); ok {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// entryStream is the Stream of an archive entry.
+type entryStream struct {
+	name    string
+	archive string
+	modTime time.Time
+	open    func() (io.ReadCloser, error)
+}
+
+func (e entryStream) Open() (io.ReadCloser, error) {
+	rc, err := e.open()
+	if err != nil || !strings.HasSuffix(strings.ToLower(e.name), ".gz") {
+		return rc, err
+	}
+	gz, err := gunzip(rc)
+	if err != nil {
+		rc.Close()
+		return nil, fmt.Errorf("gzip failed for '%s': %w", e.name, err)
+	}
+	return gz, nil
+}
@@ -0,0 +1,64 @@
+package bigcsv_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestArchiveRouter routes the entries of a tar and a zip archive to Parsers
+// of different types.
+func TestArchiveRouter(t *testing.T) {
+	files := map[string]string{
+		"export/numbers.csv": "1,one\n2,two\nx,three\n",
+		"export/places.csv":  "Berlin,3500000\n",
+		"export/README":      "not a CSV",
+	}
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, name := range []string{"export/numbers.csv", "export/places.csv", "export/README"} {
+		tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		tw.Write([]byte(files[name]))
+	}
+	tw.Close()
+
+	var numbers, places atomic.Int32
+	router := &bigcsv.ArchiveRouter{
+		Routes: []bigcsv.Route{
+			{Pattern: "numbers*.csv", Parser: bigcsv.ParserFor(func(p *bigcsv.Parser[Number]) error {
+				p.Parse = ParseNumber
+				p.OnData = func(Number) error {
+					numbers.Add(1)
+					return nil
+				}
+				return nil
+			})},
+			{Pattern: "export/places.csv", Parser: bigcsv.ParserFor(func(p *bigcsv.Parser[[]string]) error {
+				p.OnRow = func([]string) error {
+					places.Add(1)
+					return nil
+				}
+				return nil
+			})},
+		},
+		Workers: 2,
+	}
+	for name, archive := range map[string][]byte{"tar": buf.Bytes(), "zip": zipFiles(t, files)} {
+		numbers.Store(0)
+		places.Store(0)
+		stats, err := router.Run(context.Background(), bigcsv.ReadStream(bytes.NewReader(archive)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if numbers.Load() != 2 || places.Load() != 1 {
+			t.Errorf("%s: processed %d numbers and %d places", name, numbers.Load(), places.Load())
+		}
+		if stats.Total.Rows != 4 || stats.Total.ParseErrors != 1 || stats.Entries["export/numbers.csv"].Rows != 3 || len(stats.Entries) != 2 {
+			t.Errorf("%s: unexpected stats: %+v", name, stats)
+		}
+	}
+}
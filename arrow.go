@@ -0,0 +1,373 @@
+package bigcsv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ArrowType is the Arrow data type of a RecordBatch column.
+type ArrowType int
+
+const (
+	// ArrowString is a UTF-8 string column of type []string.
+	ArrowString ArrowType = iota
+	// ArrowInt64 is a signed integer column of type []int64.
+	ArrowInt64
+	// ArrowUint64 is an unsigned integer column of type []uint64.
+	ArrowUint64
+	// ArrowFloat64 is a floating point column of type []float64.
+	ArrowFloat64
+	// ArrowBool is a boolean column of type []bool.
+	ArrowBool
+	// ArrowTimestamp is a column of type []int64 holding nanoseconds since the
+	// Unix epoch in UTC.
+	ArrowTimestamp
+	// ArrowDuration is a column of type []int64 holding nanoseconds.
+	ArrowDuration
+)
+
+// String returns the Arrow name of the type.
+func (t ArrowType) String() string {
+	switch t {
+	case ArrowString:
+		return "utf8"
+	case ArrowInt64:
+		return "int64"
+	case ArrowUint64:
+		return "uint64"
+	case ArrowFloat64:
+		return "float64"
+	case ArrowBool:
+		return "bool"
+	case ArrowTimestamp:
+		return "timestamp[ns, tz=UTC]"
+	case ArrowDuration:
+		return "duration[ns]"
+	}
+	return fmt.Sprintf("ArrowType(%d)", int(t))
+}
+
+// MarshalText returns the Arrow name of the type.
+func (t ArrowType) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// ArrowField describes a column of a RecordBatch.
+type ArrowField struct {
+	Name     string
+	Type     ArrowType
+	Nullable bool
+}
+
+// RecordBatch holds records in the columnar layout of an Arrow record batch,
+// so it can be handed to Arrow builders (e.g. array.Int64Builder.AppendValues)
+// without converting values one by one, or written as an Arrow IPC stream by
+// an ArrowWriter.
+type RecordBatch struct {
+	// Schema describes the columns.
+	Schema []ArrowField
+
+	// Rows is the number of records in the batch.
+	Rows int
+
+	// Columns holds a slice per column, its type given by the ArrowType.
+	Columns []any
+
+	// Valid holds the validity of the values per column, or nil if the
+	// column has no nulls in this batch.
+	Valid [][]bool
+}
+
+// ArrowBatches returns a Batcher passing records to onBatch as RecordBatches
+// of size rows. The schema is derived from the fields of a struct type T as
+// by WithStructTags, pointer fields being nullable. For rows of type []string
+// the column types are inferred from the first batch: numbers and booleans
+// where all values parse, empty values being nulls, else strings, including
+// columns without any value in the first batch. As all batches share the
+// schema, a later batch with a value not parsing as the type of its column,
+// or with more columns, fails with a *ColumnError instead of being passed on;
+// use a struct type, or a first batch large enough to be representative, if
+// the data may vary. The names, if given, replace the column names, which are
+// "column_1" etc. for []string.
+func ArrowBatches[T any](size int, onBatch func(batch *RecordBatch) error, names ...string) *Batcher[T] {
+	var schema *arrowSchema
+	return Batch(size, func(batch []T) error {
+		if schema == nil {
+			var err error
+			if schema, err = newArrowSchema(batch, names); err != nil {
+				return err
+			}
+		}
+		rb, err := schema.build(batch)
+		if err != nil {
+			return err
+		}
+		return onBatch(rb)
+	})
+}
+
+// arrowSchema converts records to RecordBatches.
+type arrowSchema struct {
+	fields []ArrowField
+	index  [][]int // struct field per column, nil for []string rows
+}
+
+// newArrowSchema derives the schema of T, inferring it from the batch for
+// []string rows.
+func newArrowSchema[T any](batch []T, names []string) (*arrowSchema, error) {
+	s := &arrowSchema{}
+	typ := reflect.TypeFor[T]()
+	switch {
+	case typ.Kind() == reflect.Struct:
+		for _, f := range taggedFields(typ, nil) {
+			ft := f.typ
+			nullable := ft.Kind() == reflect.Pointer
+			if nullable {
+				ft = ft.Elem()
+			}
+			s.fields = append(s.fields, ArrowField{Name: f.tag.name, Type: arrowTypeOf(ft), Nullable: nullable})
+			s.index = append(s.index, f.index)
+		}
+	case typ == reflect.TypeFor[[]string]():
+		rows := any(batch).([][]string)
+		columns := 0
+		for _, row := range rows {
+			columns = max(columns, len(row))
+		}
+		for i := 0; i < columns; i++ {
+			t, nullable := inferArrowType(rows, i)
+			s.fields = append(s.fields, ArrowField{Name: fmt.Sprintf("column_%d", i+1), Type: t, Nullable: nullable})
+		}
+	default:
+		return nil, fmt.Errorf("no Arrow schema for type %s", typ)
+	}
+	if len(names) > len(s.fields) {
+		return nil, fmt.Errorf("got %d column names for %d columns", len(names), len(s.fields))
+	}
+	for i, name := range names {
+		s.fields[i].Name = name
+	}
+	return s, nil
+}
+
+// arrowTypeOf maps a Go type to an Arrow type, using strings for types
+// without a numeric or boolean representation.
+func arrowTypeOf(typ reflect.Type) ArrowType {
+	switch typ {
+	case timeType:
+		return ArrowTimestamp
+	case durationType:
+		return ArrowDuration
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return ArrowBool
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return ArrowInt64
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return ArrowUint64
+	case reflect.Float32, reflect.Float64:
+		return ArrowFloat64
+	}
+	return ArrowString
+}
+
+// inferArrowType finds the narrowest type all values of a column parse as,
+// using strings for columns without values.
+func inferArrowType(rows [][]string, column int) (t ArrowType, nullable bool) {
+	isInt, isFloat, isBool := true, true, true
+	seen := false
+	for _, row := range rows {
+		if column >= len(row) || row[column] == "" {
+			nullable = true
+			continue
+		}
+		s := row[column]
+		seen = true
+		if _, err := strconv.ParseInt(s, 10, 64); err != nil {
+			isInt = false
+		}
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			isFloat = false
+		}
+		if _, err := strconv.ParseBool(s); err != nil {
+			isBool = false
+		}
+	}
+	switch {
+	case !seen:
+	case isInt:
+		return ArrowInt64, nullable
+	case isFloat:
+		return ArrowFloat64, nullable
+	case isBool:
+		return ArrowBool, nullable
+	}
+	return ArrowString, false // empty strings are valid values
+}
+
+// build converts a batch of records to the columnar layout, failing for
+// []string rows not matching the schema.
+func (s *arrowSchema) build(batch any) (*RecordBatch, error) {
+	rb := &RecordBatch{Schema: s.fields, Columns: make([]any, len(s.fields)), Valid: make([][]bool, len(s.fields))}
+	cols := make([]arrowColumn, len(s.fields))
+	for i, f := range s.fields {
+		cols[i].typ = f.Type
+	}
+	if rows, ok := batch.([][]string); ok {
+		rb.Rows = len(rows)
+		for _, row := range rows {
+			if len(row) > len(cols) {
+				return nil, &ColumnError{Index: len(cols), Err: errors.New("column not in the schema inferred from the first batch")}
+			}
+			for i := range cols {
+				if i >= len(row) {
+					cols[i].appendNull()
+				} else if err := cols[i].appendString(row[i]); err != nil {
+					return nil, &ColumnError{Column: s.fields[i].Name, Index: i, Err: err}
+				}
+			}
+		}
+	} else {
+		v := reflect.ValueOf(batch)
+		rb.Rows = v.Len()
+		for j := 0; j < v.Len(); j++ {
+			for i := range cols {
+				cols[i].appendValue(v.Index(j).FieldByIndex(s.index[i]))
+			}
+		}
+	}
+	for i := range cols {
+		rb.Columns[i] = cols[i].values()
+		if cols[i].nulls {
+			rb.Valid[i] = cols[i].valid
+		}
+	}
+	return rb, nil
+}
+
+// arrowColumn builds a single column.
+type arrowColumn struct {
+	typ     ArrowType
+	strings []string
+	ints    []int64
+	uints   []uint64
+	floats  []float64
+	bools   []bool
+	valid   []bool
+	nulls   bool
+}
+
+// values returns the column slice.
+func (c *arrowColumn) values() any {
+	switch c.typ {
+	case ArrowString:
+		return c.strings
+	case ArrowUint64:
+		return c.uints
+	case ArrowFloat64:
+		return c.floats
+	case ArrowBool:
+		return c.bools
+	}
+	return c.ints
+}
+
+// appendNull appends a null as the zero value.
+func (c *arrowColumn) appendNull() {
+	switch c.typ {
+	case ArrowString:
+		c.strings = append(c.strings, "")
+	case ArrowUint64:
+		c.uints = append(c.uints, 0)
+	case ArrowFloat64:
+		c.floats = append(c.floats, 0)
+	case ArrowBool:
+		c.bools = append(c.bools, false)
+	default:
+		c.ints = append(c.ints, 0)
+	}
+	c.valid = append(c.valid, false)
+	c.nulls = true
+}
+
+// appendString parses and appends a field of an inferred column, empty values
+// being nulls unless it is a string column.
+func (c *arrowColumn) appendString(s string) error {
+	if s == "" && c.typ != ArrowString {
+		c.appendNull()
+		return nil
+	}
+	var err error
+	switch c.typ {
+	case ArrowString:
+		c.strings = append(c.strings, s)
+	case ArrowInt64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, 64); err == nil {
+			c.ints = append(c.ints, i)
+		}
+	case ArrowFloat64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err == nil {
+			c.floats = append(c.floats, f)
+		}
+	case ArrowBool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			c.bools = append(c.bools, b)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("value %q is not of the inferred type %s", s, c.typ)
+	}
+	c.valid = append(c.valid, true)
+	return nil
+}
+
+// appendValue appends a struct field value.
+func (c *arrowColumn) appendValue(v reflect.Value) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			c.appendNull()
+			return
+		}
+		v = v.Elem()
+	}
+	switch c.typ {
+	case ArrowTimestamp:
+		c.ints = append(c.ints, v.Interface().(time.Time).UnixNano())
+	case ArrowInt64, ArrowDuration:
+		c.ints = append(c.ints, v.Int())
+	case ArrowUint64:
+		c.uints = append(c.uints, v.Uint())
+	case ArrowFloat64:
+		c.floats = append(c.floats, v.Float())
+	case ArrowBool:
+		c.bools = append(c.bools, v.Bool())
+	default:
+		c.strings = append(c.strings, formatValue(v))
+	}
+	c.valid = append(c.valid, true)
+}
+
+// formatValue formats a value as text, preferring encoding.TextMarshaler.
+// Floats are formatted without exponent.
+func formatValue(v reflect.Value) string {
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits())
+	}
+	return fmt.Sprint(v.Interface())
+}
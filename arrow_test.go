@@ -0,0 +1,115 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestArrowBatches checks the schema and columns derived from a struct.
+func TestArrowBatches(t *testing.T) {
+	type Reading struct {
+		Sensor string   `csv:"sensor"`
+		Value  *float64 `csv:"value"`
+		Count  int      `csv:"count"`
+	}
+	parser, err := bigcsv.NewFromString[Reading]("sensor,value,count\na,1.5,3\nb,,4\n", bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var batches []*bigcsv.RecordBatch
+	batcher := bigcsv.ArrowBatches[Reading](10, func(batch *bigcsv.RecordBatch) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	parser.OnData = batcher.OnData
+	if err = errors.Join(parser.Run(context.Background(), 1), batcher.Flush()); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 1 || batches[0].Rows != 2 {
+		t.Fatalf("Unexpected batches: %v", batches)
+	}
+	rb := batches[0]
+	want := []bigcsv.ArrowField{
+		{Name: "sensor", Type: bigcsv.ArrowString},
+		{Name: "value", Type: bigcsv.ArrowFloat64, Nullable: true},
+		{Name: "count", Type: bigcsv.ArrowInt64},
+	}
+	if !slices.Equal(rb.Schema, want) {
+		t.Fatalf("Schema is %v, expected %v", rb.Schema, want)
+	}
+	if !slices.Equal(rb.Columns[2].([]int64), []int64{3, 4}) || !slices.Equal(rb.Valid[1], []bool{true, false}) || rb.Valid[0] != nil {
+		t.Fatalf("Unexpected columns %v, validity %v", rb.Columns, rb.Valid)
+	}
+}
+
+// TestArrowBatchesInferred checks the column types inferred for raw rows.
+func TestArrowBatchesInferred(t *testing.T) {
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader("1,x,true,0.5\n2,y,false,\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema []bigcsv.ArrowField
+	batcher := bigcsv.ArrowBatches[[]string](10, func(batch *bigcsv.RecordBatch) error {
+		schema = batch.Schema
+		return nil
+	}, "id")
+	for row, err := range parser.Rows(context.Background()) {
+		if err == nil {
+			err = batcher.OnData(row)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = batcher.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := []bigcsv.ArrowField{
+		{Name: "id", Type: bigcsv.ArrowInt64},
+		{Name: "column_2", Type: bigcsv.ArrowString},
+		{Name: "column_3", Type: bigcsv.ArrowBool},
+		{Name: "column_4", Type: bigcsv.ArrowFloat64, Nullable: true},
+	}
+	if !slices.Equal(schema, want) {
+		t.Fatalf("Schema is %v, expected %v", schema, want)
+	}
+}
+
+// TestArrowBatchesMismatch checks that later batches must match the types
+// inferred from the first one.
+func TestArrowBatchesMismatch(t *testing.T) {
+	var batches []*bigcsv.RecordBatch
+	batcher := bigcsv.ArrowBatches[[]string](2, func(batch *bigcsv.RecordBatch) error {
+		batches = append(batches, batch)
+		return nil
+	})
+	for _, row := range [][]string{{"1", ""}, {"2", ""}, {"3", "x"}} {
+		if err := batcher.OnData(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := batcher.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0].Schema[1].Type != bigcsv.ArrowString || batches[1].Columns[1].([]string)[0] != "x" {
+		t.Fatalf("Expected the empty column to hold strings, got %v", batches)
+	}
+
+	var cerr *bigcsv.ColumnError
+	batcher.OnData([]string{"4", "y"})
+	if err := batcher.OnData([]string{"five", "z"}); !errors.As(err, &cerr) || cerr.Index != 0 {
+		t.Errorf("Expected a *ColumnError for the first column, got: %v", err)
+	}
+	batcher.OnData([]string{"6", "", "extra"})
+	if err := batcher.Flush(); !errors.As(err, &cerr) || cerr.Index != 2 {
+		t.Errorf("Expected a *ColumnError for the extra column, got: %v", err)
+	}
+	if len(batches) != 2 {
+		t.Errorf("Got %d batches, expected the failing ones left out", len(batches))
+	}
+}
@@ -0,0 +1,372 @@
+package bigcsv
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// ArrowStreamType is the media type of the Arrow IPC streaming format.
+const ArrowStreamType = "application/vnd.apache.arrow.stream"
+
+// ArrowWriter writes RecordBatches in the Arrow IPC streaming format, which
+// Arrow implementations such as pyarrow (pyarrow.ipc.open_stream), DuckDB,
+// DataFusion or the Go arrow/ipc package read without converting values, and
+// which Arrow Flight carries. Its Write can be passed to ArrowBatches:
+//
+//	aw := bigcsv.NewArrowWriter(f)
+//	batcher := bigcsv.ArrowBatches[Place](65536, aw.Write)
+//	parser.OnData = batcher.OnData
+//	err = errors.Join(parser.Run(ctx, 4), batcher.Flush(), aw.Close())
+type ArrowWriter struct {
+	w   io.Writer
+	enc arrowEncoder
+}
+
+// NewArrowWriter creates an ArrowWriter writing to w.
+func NewArrowWriter(w io.Writer) *ArrowWriter {
+	return &ArrowWriter{w: w}
+}
+
+// Write writes a batch, preceded by the schema for the first one. All batches
+// must have the same schema.
+func (w *ArrowWriter) Write(batch *RecordBatch) error {
+	return w.enc.encode(batch, w.message)
+}
+
+// Close writes the end of the stream. It does not close the underlying writer.
+func (w *ArrowWriter) Close() error {
+	if _, err := w.w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); err != nil {
+		return fmt.Errorf("could not write Arrow stream: %w", err)
+	}
+	return nil
+}
+
+// message writes an encapsulated IPC message.
+func (w *ArrowWriter) message(meta, body []byte) error {
+	prefix := binary.LittleEndian.AppendUint32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(len(meta)))
+	for _, b := range [][]byte{prefix, meta, body} {
+		if _, err := w.w.Write(b); err != nil {
+			return fmt.Errorf("could not write Arrow stream: %w", err)
+		}
+	}
+	return nil
+}
+
+// arrowEncoder encodes RecordBatches as IPC messages, preceded by the schema.
+type arrowEncoder struct {
+	schema []ArrowField
+}
+
+// encode passes the metadata and body of the messages of a batch to emit.
+func (e *arrowEncoder) encode(batch *RecordBatch, emit func(meta, body []byte) error) error {
+	if e.schema == nil {
+		if err := emit(arrowMessage(1, arrowSchemaTable(batch.Schema), 0), nil); err != nil {
+			return err
+		}
+		e.schema = batch.Schema
+	} else if !equalArrowSchema(e.schema, batch.Schema) {
+		return fmt.Errorf("batch schema %v differs from the stream schema %v", batch.Schema, e.schema)
+	}
+	header, body, err := arrowRecordBatch(batch)
+	if err != nil {
+		return err
+	}
+	return emit(arrowMessage(3, header, len(body)), body)
+}
+
+// arrowMessage returns the metadata of an IPC message with a Schema (1) or
+// RecordBatch (3) header.
+func arrowMessage(headerType uint8, header fbTable, bodyLength int) []byte {
+	return fbFinish(fbTable{
+		fbInt16(4), // metadata version V5
+		fbUint8(headerType),
+		{object: header},
+		fbInt64(int64(bodyLength)),
+	})
+}
+
+// equalArrowSchema tells whether two schemas are the same.
+func equalArrowSchema(a, b []ArrowField) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrowSchemaTable returns the Schema table of the IPC metadata.
+func arrowSchemaTable(schema []ArrowField) fbTable {
+	fields := make(fbTables, len(schema))
+	for i, f := range schema {
+		typeID, typ := arrowTypeTable(f.Type)
+		fields[i] = fbTable{
+			{object: fbString(f.Name)},
+			fbBool(f.Nullable),
+			fbUint8(typeID),
+			{object: typ},
+			{},                   // dictionary
+			{object: fbTables{}}, // children, required by some readers
+		}
+	}
+	return fbTable{fbInt16(0), {object: fields}} // little endian
+}
+
+// arrowTypeTable returns the id and table of a type in the Type union.
+func arrowTypeTable(t ArrowType) (uint8, fbTable) {
+	switch t {
+	case ArrowInt64:
+		return 2, fbTable{fbInt32(64), fbBool(true)}
+	case ArrowUint64:
+		return 2, fbTable{fbInt32(64), fbBool(false)}
+	case ArrowFloat64:
+		return 3, fbTable{fbInt16(2)} // double precision
+	case ArrowBool:
+		return 6, fbTable{}
+	case ArrowTimestamp:
+		return 10, fbTable{fbInt16(3), {object: fbString("UTC")}} // nanoseconds
+	case ArrowDuration:
+		return 18, fbTable{fbInt16(3)}
+	}
+	return 5, fbTable{} // utf8
+}
+
+// arrowRecordBatch returns the RecordBatch table and the body of a batch.
+func arrowRecordBatch(batch *RecordBatch) (fbTable, []byte, error) {
+	var nodes, buffers, body []byte
+	add := func(b []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(b)))
+		body = append(body, b...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for i, f := range batch.Schema {
+		nulls := 0
+		if i < len(batch.Valid) && batch.Valid[i] != nil {
+			valid := batch.Valid[i]
+			for _, ok := range valid {
+				if !ok {
+					nulls++
+				}
+			}
+			add(arrowBitmap(valid))
+		} else {
+			add(nil)
+		}
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(batch.Rows))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(nulls))
+
+		var values []byte
+		switch col := batch.Columns[i].(type) {
+		case []string:
+			offsets := make([]byte, 0, 4*(len(col)+1))
+			var data []byte
+			for _, s := range col {
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+				data = append(data, s...)
+			}
+			if len(data) > math.MaxInt32 {
+				return nil, nil, &ColumnError{Column: f.Name, Index: i, Err: fmt.Errorf("%d bytes of strings exceed a batch", len(data))}
+			}
+			add(binary.LittleEndian.AppendUint32(offsets, uint32(len(data))))
+			values = data
+		case []int64:
+			for _, v := range col {
+				values = binary.LittleEndian.AppendUint64(values, uint64(v))
+			}
+		case []uint64:
+			for _, v := range col {
+				values = binary.LittleEndian.AppendUint64(values, v)
+			}
+		case []float64:
+			for _, v := range col {
+				values = binary.LittleEndian.AppendUint64(values, math.Float64bits(v))
+			}
+		case []bool:
+			values = arrowBitmap(col)
+		default:
+			return nil, nil, &ColumnError{Column: f.Name, Index: i, Err: fmt.Errorf("unexpected values of type %T", col)}
+		}
+		add(values)
+	}
+	return fbTable{
+		fbInt64(int64(batch.Rows)),
+		{object: fbStructs{n: len(batch.Schema), data: nodes}},
+		{object: fbStructs{n: len(buffers) / 16, data: buffers}},
+	}, body, nil
+}
+
+// arrowBitmap packs booleans into a bitmap, least significant bit first.
+func arrowBitmap(bits []bool) []byte {
+	b := make([]byte, (len(bits)+7)/8)
+	for i, bit := range bits {
+		if bit {
+			b[i/8] |= 1 << (i % 8)
+		}
+	}
+	return b
+}
+
+// fbBuilder lays out a flatbuffer front to back, which suffices for the Arrow
+// metadata: offsets point forward to the objects written after the table or
+// vector referring to them.
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbObject is a table, vector or string of a flatbuffer.
+type fbObject interface {
+	// write appends the object, returning its position.
+	write(b *fbBuilder) int
+}
+
+// fbField is a table field, either a little endian scalar or an object.
+type fbField struct {
+	scalar []byte
+	object fbObject
+}
+
+// fbTable is a table of fields indexed by their id, zero for absent fields.
+type fbTable []fbField
+
+// fbString is a string.
+type fbString string
+
+// fbTables is a vector of tables.
+type fbTables []fbTable
+
+// fbStructs is a vector of n structs of 8 byte fields.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+func fbBool(v bool) fbField {
+	if v {
+		return fbUint8(1)
+	}
+	return fbUint8(0)
+}
+
+func fbUint8(v uint8) fbField {
+	return fbField{scalar: []byte{v}}
+}
+
+func fbInt16(v int16) fbField {
+	return fbField{scalar: binary.LittleEndian.AppendUint16(nil, uint16(v))}
+}
+
+func fbInt32(v int32) fbField {
+	return fbField{scalar: binary.LittleEndian.AppendUint32(nil, uint32(v))}
+}
+
+func fbInt64(v int64) fbField {
+	return fbField{scalar: binary.LittleEndian.AppendUint64(nil, uint64(v))}
+}
+
+// fbFinish returns a flatbuffer with the root table t, padded to 8 bytes.
+func fbFinish(t fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4, 256)}
+	binary.LittleEndian.PutUint32(b.buf, uint32(t.write(b)))
+	b.align(8, 0)
+	return b.buf
+}
+
+// align pads the buffer until its length plus offset is a multiple of n.
+func (b *fbBuilder) align(n, offset int) {
+	for (len(b.buf)+offset)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// reference writes an object and stores its offset at pos.
+func (b *fbBuilder) reference(pos int, o fbObject) {
+	at := o.write(b) // before indexing, as writing may grow the buffer
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(at-pos))
+}
+
+func (t fbTable) write(b *fbBuilder) int {
+	size := func(f fbField) int {
+		if f.object != nil {
+			return 4
+		}
+		return len(f.scalar)
+	}
+	var order []int
+	for id, f := range t {
+		if size(f) > 0 {
+			order = append(order, id)
+		}
+	}
+	// The table starts 4 bytes before an 8 byte boundary, so its fields are
+	// aligned when laid out largest first after the vtable offset.
+	sort.SliceStable(order, func(i, j int) bool { return size(t[order[i]]) > size(t[order[j]]) })
+	offsets := make([]uint16, len(t))
+	end := 4
+	for _, id := range order {
+		s := size(t[id])
+		for (end+4)%s != 0 {
+			end++
+		}
+		offsets[id] = uint16(end)
+		end += s
+	}
+
+	b.align(2, 0)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(4+2*len(t)))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(end))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, off)
+	}
+	b.align(8, 4)
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, end)...)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(int32(pos-vtable)))
+	for _, id := range order {
+		if f := t[id]; f.object == nil {
+			copy(b.buf[pos+int(offsets[id]):], f.scalar)
+		}
+	}
+	for _, id := range order {
+		if f := t[id]; f.object != nil {
+			b.reference(pos+int(offsets[id]), f.object)
+		}
+	}
+	return pos
+}
+
+func (s fbString) write(b *fbBuilder) int {
+	b.align(4, 0)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(append(b.buf, s...), 0)
+	return pos
+}
+
+func (v fbTables) write(b *fbBuilder) int {
+	b.align(4, 0)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(v)))
+	b.buf = append(b.buf, make([]byte, 4*len(v))...)
+	for i, t := range v {
+		b.reference(pos+4+4*i, t)
+	}
+	return pos
+}
+
+func (v fbStructs) write(b *fbBuilder) int {
+	b.align(8, 4)
+	pos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(v.n))
+	b.buf = append(b.buf, v.data...)
+	return pos
+}
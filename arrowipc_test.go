@@ -0,0 +1,72 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestArrowWriter checks the framing of the messages in an Arrow stream.
+func TestArrowWriter(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string]("1,a\n2,\n3,c\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	aw := bigcsv.NewArrowWriter(&out)
+	batcher := bigcsv.ArrowBatches[[]string](2, aw.Write, "id", "name")
+	parser.Parse = func(row []string) ([]string, error) { return slices.Clone(row), nil }
+	parser.OnData = batcher.OnData
+	if err = errors.Join(parser.Run(context.Background(), 1), batcher.Flush(), aw.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// A schema and two record batches, each prefixed by the continuation
+	// marker and the metadata length, followed by the end of stream.
+	var bodies [][]byte
+	b := out.Bytes()
+	for len(b) >= 8 && binary.LittleEndian.Uint32(b) == 0xffffffff {
+		n := int(binary.LittleEndian.Uint32(b[4:]))
+		if n == 0 {
+			b = b[8:]
+			break
+		}
+		if n%8 != 0 {
+			t.Fatalf("Metadata of %d bytes is not padded", n)
+		}
+		size := bodyLength(b[8 : 8+n])
+		bodies = append(bodies, b[8+n:8+n+size])
+		b = b[8+n+size:]
+	}
+	if len(bodies) != 3 || len(b) != 0 {
+		t.Fatalf("Got %d messages with %d bytes left, expected 3 and an end of stream", len(bodies), len(b))
+	}
+	if id := binary.LittleEndian.Uint64(bodies[1]); id != 1 {
+		t.Errorf("Got id %d in the first batch, expected 1", id)
+	}
+	if id := binary.LittleEndian.Uint64(bodies[2]); id != 3 {
+		t.Errorf("Got id %d in the second batch, expected 3", id)
+	}
+
+	if err = aw.Write(&bigcsv.RecordBatch{Schema: []bigcsv.ArrowField{{Name: "id"}}}); err == nil {
+		t.Error("Expected an error for a batch with another schema")
+	}
+}
+
+// bodyLength reads the bodyLength field of a flatbuffer Message.
+func bodyLength(meta []byte) int {
+	table := int(binary.LittleEndian.Uint32(meta))
+	vtable := table - int(int32(binary.LittleEndian.Uint32(meta[table:])))
+	if binary.LittleEndian.Uint16(meta[vtable:]) < 12 {
+		return 0
+	}
+	if off := int(binary.LittleEndian.Uint16(meta[vtable+10:])); off > 0 {
+		return int(binary.LittleEndian.Uint64(meta[table+off:]))
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package bigcsv
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultMaxPending is the number of rows pending in OnDataAsync unless set
+// by WithMaxPending.
+const defaultMaxPending = 1024
+
+// Pending is a row accepted by OnDataAsync whose processing completes later,
+// e.g. once a bulk request including it is acknowledged.
+type Pending struct {
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// NewPending returns a Pending to be completed by the sink.
+func NewPending() *Pending {
+	return &Pending{done: make(chan struct{})}
+}
+
+// Complete marks the row as processed, failed if err is not nil. It is safe to
+// call from any goroutine, calls after the first have no effect.
+func (p *Pending) Complete(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+	})
+}
+
+// WithMaxPending limits the number of rows handed to OnDataAsync but not yet
+// completed, 1024 by default. Once reached, the workers wait for rows to
+// complete, so reading slows down to the pace of the sink.
+func WithMaxPending(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of pending rows: %d", n)
+		}
+		cfg.maxPending = n
+		return nil
+	}
+}
+
+// handOff passes data to onDataAsync once a pending slot is free. The row is
+// done when the sink completes it, which is awaited in the background. If the
+// run is cancelled while waiting, the row is left undone.
+func (r *run[T]) handOff(ctx context.Context, rec record, row []string, data T) {
+	select {
+	case r.pending <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	r.watch.stage(rec.line, ErrOnData)
+	start := time.Now()
+	pending, err := r.onDataAsync(data)
+	r.tally.spent(ErrOnData, start)
+	rec.sample.spent(ErrOnData, start)
+	if err != nil || pending == nil {
+		<-r.pending
+		if err != nil {
+			r.report(row, rec.error(ErrOnData, err))
+		}
+		r.done(ctx, rec, err)
+		return
+	}
+	r.async.Add(1)
+	go func() {
+		defer r.async.Done()
+		<-pending.done
+		<-r.pending
+		if pending.err != nil {
+			r.report(row, rec.error(ErrOnData, pending.err))
+		}
+		r.done(ctx, rec, pending.err)
+	}()
+}
@@ -0,0 +1,71 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestOnDataAsync completes rows in the background, checking that the number
+// of pending rows is bounded and that Run waits for them.
+func TestOnDataAsync(t *testing.T) {
+	var input strings.Builder
+	for i := range 100 {
+		input.WriteString(strconv.Itoa(i) + "\n")
+	}
+	parser, err := bigcsv.NewFromString[int](input.String(), bigcsv.WithMaxPending(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) (int, error) {
+		return strconv.Atoi(row[0])
+	}
+	var outstanding, peak, completed atomic.Int64
+	var wg sync.WaitGroup
+	parser.OnDataAsync = func(n int) (*bigcsv.Pending, error) {
+		if n == 0 {
+			return nil, nil // processed right away
+		}
+		pending := bigcsv.NewPending()
+		cur := outstanding.Add(1)
+		for old := peak.Load(); cur > old && !peak.CompareAndSwap(old, cur); old = peak.Load() {
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			time.Sleep(time.Millisecond)
+			outstanding.Add(-1)
+			completed.Add(1)
+			if n == 50 {
+				pending.Complete(errors.New("rejected by sink"))
+				return
+			}
+			pending.Complete(nil)
+		}()
+		return pending, nil
+	}
+	var errs []error
+	parser.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	if err = parser.Run(context.Background(), 8); err != nil {
+		t.Fatal(err)
+	}
+	if completed.Load() != 99 {
+		t.Errorf("Run returned with %d of 99 rows completed", completed.Load())
+	}
+	if peak.Load() > 4 {
+		t.Errorf("Got %d pending rows, expected at most 4", peak.Load())
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], bigcsv.ErrOnData) {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,387 @@
+package bigcsv
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AvroSchema is an Avro record schema bound to the fields of a struct type T,
+// to encode records of T in Avro binary encoding.
+type AvroSchema[T any] struct {
+	json     string
+	encoders []avroEncoder
+}
+
+// avroEncoder appends the encoding of a struct field.
+type avroEncoder struct {
+	index  []int
+	encode func(b []byte, v reflect.Value) []byte
+}
+
+// avroField is a field of a record schema in JSON.
+type avroField struct {
+	Name    string `json:"name"`
+	Type    any    `json:"type"`
+	Default any    `json:"default,omitempty"`
+}
+
+// GenerateAvroSchema generates the Avro record schema with the given name for
+// a struct type T. Fields are named by their `csv` tag as for WithStructTags,
+// with characters invalid in Avro names replaced by underscores. Pointer
+// fields are unions with null and time.Time fields are timestamp-micros.
+func GenerateAvroSchema[T any](name string) (*AvroSchema[T], error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Avro schemas need a struct type, got %s", typ)
+	}
+	schema := struct {
+		Type   string      `json:"type"`
+		Name   string      `json:"name"`
+		Fields []avroField `json:"fields"`
+	}{Type: "record", Name: avroName(name)}
+	for _, f := range taggedFields(typ, nil) {
+		field := avroField{Name: avroName(f.tag.name), Type: avroTypeOf(f.typ)}
+		if f.typ.Kind() == reflect.Pointer {
+			field.Type = []any{"null", avroTypeOf(f.typ.Elem())}
+		}
+		schema.Fields = append(schema.Fields, field)
+	}
+	b, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	return ParseAvroSchema[T](string(b))
+}
+
+// ParseAvroSchema binds a supplied Avro record schema to a struct type T,
+// matching the schema fields to struct fields by name (see
+// GenerateAvroSchema). Field types may be primitive types, timestamp-millis or
+// timestamp-micros, and unions of null with one of these; a null value is
+// written for nil pointers.
+func ParseAvroSchema[T any](schema string) (*AvroSchema[T], error) {
+	var record struct {
+		Type   string      `json:"type"`
+		Fields []avroField `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(schema), &record); err != nil {
+		return nil, fmt.Errorf("invalid Avro schema: %w", err)
+	}
+	if record.Type != "record" {
+		return nil, fmt.Errorf("invalid Avro schema: type %q is not a record", record.Type)
+	}
+	byName := map[string]taggedField{}
+	for _, f := range taggedFields(reflect.TypeFor[T](), nil) {
+		byName[avroName(f.tag.name)] = f
+	}
+	s := &AvroSchema[T]{json: schema}
+	for _, af := range record.Fields {
+		f, ok := byName[af.Name]
+		if !ok {
+			return nil, fmt.Errorf("no struct field for Avro field %s", af.Name)
+		}
+		enc, err := avroEncoderOf(af.Type, f.typ)
+		if err != nil {
+			return nil, fmt.Errorf("Avro field %s: %w", af.Name, err)
+		}
+		s.encoders = append(s.encoders, avroEncoder{index: f.index, encode: enc})
+	}
+	return s, nil
+}
+
+// String returns the schema in JSON.
+func (s *AvroSchema[T]) String() string {
+	return s.json
+}
+
+// Append appends the Avro binary encoding of the record to b.
+func (s *AvroSchema[T]) Append(b []byte, data T) []byte {
+	v := reflect.ValueOf(data)
+	for _, e := range s.encoders {
+		b = e.encode(b, v.FieldByIndex(e.index))
+	}
+	return b
+}
+
+// avroName replaces the characters not allowed in Avro names.
+func avroName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// avroTypeOf returns the Avro type of a Go type, strings for types without
+// a numeric or boolean representation.
+func avroTypeOf(typ reflect.Type) any {
+	switch typ {
+	case timeType:
+		return map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+	case durationType:
+		return "long"
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "long"
+	case reflect.Float32:
+		return "float"
+	case reflect.Float64:
+		return "double"
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "bytes"
+		}
+	}
+	return "string"
+}
+
+// avroEncoderOf returns the encoder of a schema type for a Go type.
+func avroEncoderOf(schemaType any, typ reflect.Type) (func([]byte, reflect.Value) []byte, error) {
+	switch t := schemaType.(type) {
+	case []any: // union
+		null, other := -1, -1
+		for i, member := range t {
+			if member == "null" {
+				null = i
+			} else {
+				other = i
+			}
+		}
+		if len(t) > 2 || other < 0 {
+			return nil, fmt.Errorf("unsupported union %v", t)
+		}
+		elemType := typ
+		if typ.Kind() == reflect.Pointer {
+			elemType = typ.Elem()
+		}
+		enc, err := avroEncoderOf(t[other], elemType)
+		if err != nil {
+			return nil, err
+		}
+		return func(b []byte, v reflect.Value) []byte {
+			if v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					if null < 0 {
+						return enc(binary.AppendVarint(b, int64(other)), reflect.Zero(elemType))
+					}
+					return binary.AppendVarint(b, int64(null))
+				}
+				v = v.Elem()
+			}
+			return enc(binary.AppendVarint(b, int64(other)), v)
+		}, nil
+	case map[string]any:
+		logical, _ := t["logicalType"].(string)
+		switch {
+		case logical == "timestamp-micros" && typ == timeType:
+			return func(b []byte, v reflect.Value) []byte {
+				return binary.AppendVarint(b, v.Interface().(time.Time).UnixMicro())
+			}, nil
+		case logical == "timestamp-millis" && typ == timeType:
+			return func(b []byte, v reflect.Value) []byte {
+				return binary.AppendVarint(b, v.Interface().(time.Time).UnixMilli())
+			}, nil
+		}
+		return avroEncoderOf(t["type"], typ)
+	case string:
+		return avroPrimitive(t, typ)
+	}
+	return nil, fmt.Errorf("unsupported type %v", schemaType)
+}
+
+// avroPrimitive returns the encoder of a primitive type for a Go type.
+func avroPrimitive(name string, typ reflect.Type) (func([]byte, reflect.Value) []byte, error) {
+	if typ.Kind() == reflect.Pointer { // a nil pointer is written as zero value
+		enc, err := avroPrimitive(name, typ.Elem())
+		if err != nil {
+			return nil, err
+		}
+		zero := reflect.New(typ.Elem()).Elem()
+		return func(b []byte, v reflect.Value) []byte {
+			if v.IsNil() {
+				return enc(b, zero)
+			}
+			return enc(b, v.Elem())
+		}, nil
+	}
+	kind := typ.Kind()
+	isInt := kind >= reflect.Int && kind <= reflect.Int64
+	isUint := kind >= reflect.Uint && kind <= reflect.Uint64
+	isFloat := kind == reflect.Float32 || kind == reflect.Float64
+	switch {
+	case name == "null":
+		return func(b []byte, v reflect.Value) []byte { return b }, nil
+	case name == "boolean" && kind == reflect.Bool:
+		return func(b []byte, v reflect.Value) []byte {
+			if v.Bool() {
+				return append(b, 1)
+			}
+			return append(b, 0)
+		}, nil
+	case (name == "int" || name == "long") && isInt:
+		return func(b []byte, v reflect.Value) []byte { return binary.AppendVarint(b, v.Int()) }, nil
+	case (name == "int" || name == "long") && isUint:
+		return func(b []byte, v reflect.Value) []byte { return binary.AppendVarint(b, int64(v.Uint())) }, nil
+	case name == "float" && (isFloat || isInt):
+		return func(b []byte, v reflect.Value) []byte {
+			return binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(toFloat(v))))
+		}, nil
+	case name == "double" && (isFloat || isInt):
+		return func(b []byte, v reflect.Value) []byte {
+			return binary.LittleEndian.AppendUint64(b, math.Float64bits(toFloat(v)))
+		}, nil
+	case name == "bytes" && kind == reflect.Slice && typ.Elem().Kind() == reflect.Uint8:
+		return func(b []byte, v reflect.Value) []byte { return appendAvroBytes(b, v.Bytes()) }, nil
+	case name == "string":
+		return func(b []byte, v reflect.Value) []byte { return appendAvroBytes(b, []byte(formatValue(v))) }, nil
+	}
+	return nil, fmt.Errorf("cannot encode %s as %s", typ, name)
+}
+
+// toFloat returns a numeric value as float64.
+func toFloat(v reflect.Value) float64 {
+	if v.CanFloat() {
+		return v.Float()
+	}
+	return float64(v.Int())
+}
+
+// appendAvroBytes appends length-prefixed bytes.
+func appendAvroBytes(b, data []byte) []byte {
+	return append(binary.AppendVarint(b, int64(len(data))), data...)
+}
+
+// AvroWriter writes records to an Avro object container file. Its OnData is
+// safe for use with multiple workers. Close must be called to write the last
+// block.
+type AvroWriter[T any] struct {
+	mu        sync.Mutex
+	w         io.Writer
+	schema    *AvroSchema[T]
+	sync      [16]byte
+	block     []byte
+	count     int
+	blockSize int
+}
+
+// NewAvroWriter writes the container file header to w. Records are written in
+// blocks of blockSize records, uncompressed.
+func NewAvroWriter[T any](w io.Writer, schema *AvroSchema[T], blockSize int) (*AvroWriter[T], error) {
+	a := &AvroWriter[T]{w: w, schema: schema, blockSize: max(blockSize, 1)}
+	rand.Read(a.sync[:])
+	header := []byte("Obj\x01")
+	header = binary.AppendVarint(header, 2)
+	header = appendAvroBytes(header, []byte("avro.schema"))
+	header = appendAvroBytes(header, []byte(schema.String()))
+	header = appendAvroBytes(header, []byte("avro.codec"))
+	header = appendAvroBytes(header, []byte("null"))
+	header = append(binary.AppendVarint(header, 0), a.sync[:]...)
+	if _, err := w.Write(header); err != nil {
+		return nil, fmt.Errorf("could not write Avro header: %w", err)
+	}
+	return a, nil
+}
+
+// OnData adds the record to the current block, writing it when full.
+func (a *AvroWriter[T]) OnData(data T) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.block = a.schema.Append(a.block, data)
+	if a.count++; a.count < a.blockSize {
+		return nil
+	}
+	return a.flush()
+}
+
+// Close writes the last block. It does not close the underlying writer.
+func (a *AvroWriter[T]) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.count == 0 {
+		return nil
+	}
+	return a.flush()
+}
+
+// flush writes the current block. The caller holds the lock.
+func (a *AvroWriter[T]) flush() error {
+	b := binary.AppendVarint(nil, int64(a.count))
+	b = binary.AppendVarint(b, int64(len(a.block)))
+	b = append(append(b, a.block...), a.sync[:]...)
+	a.block, a.count = a.block[:0], 0
+	if _, err := a.w.Write(b); err != nil {
+		return fmt.Errorf("could not write Avro block: %w", err)
+	}
+	return nil
+}
+
+// SchemaRegistry is a client of a Confluent compatible schema registry.
+type SchemaRegistry struct {
+	URL string
+
+	// Client is used for requests, http.DefaultClient if nil.
+	Client *http.Client
+}
+
+// Register registers the schema under the subject, returning its ID. The
+// registry returns the existing ID if the schema is registered already.
+func (r *SchemaRegistry) Register(ctx context.Context, subject, schema string) (int, error) {
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+	u := strings.TrimSuffix(r.URL, "/") + "/subjects/" + url.PathEscape(subject) + "/versions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("could not register schema: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return 0, fmt.Errorf("could not register schema: %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	var result struct {
+		ID int `json:"id"`
+	}
+	if err = json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("invalid schema registry response: %w", err)
+	}
+	return result.ID, nil
+}
+
+// AvroMessages returns an OnData function encoding each record in the
+// Confluent wire format, a zero byte and the big-endian schema ID followed by
+// the Avro binary encoding, and passing it to send, e.g. a Kafka producer.
+func AvroMessages[T any](schema *AvroSchema[T], id int, send func(msg []byte) error) func(T) error {
+	return func(data T) error {
+		msg := binary.BigEndian.AppendUint32([]byte{0}, uint32(id))
+		return send(schema.Append(msg, data))
+	}
+}
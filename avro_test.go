@@ -0,0 +1,84 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// Measurement is encoded in the Avro tests.
+type Measurement struct {
+	Station string   `csv:"station id"`
+	Value   *float64 `csv:"value"`
+	Count   int      `csv:"count"`
+}
+
+// TestAvroSchema checks the generated schema and the binary encoding.
+func TestAvroSchema(t *testing.T) {
+	schema, err := bigcsv.GenerateAvroSchema[Measurement]("measurement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"record","name":"measurement","fields":[{"name":"station_id","type":"string"},{"name":"value","type":["null","double"]},{"name":"count","type":"long"}]}`
+	if schema.String() != want {
+		t.Fatalf("Schema is %s, expected %s", schema, want)
+	}
+	got := schema.Append(nil, Measurement{Station: "ab", Count: -2})
+	if !bytes.Equal(got, []byte{4, 'a', 'b', 0, 3}) {
+		t.Fatalf("Encoding is %v", got)
+	}
+
+	var buf bytes.Buffer
+	w, err := bigcsv.NewAvroWriter(&buf, schema, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.OnData(Measurement{Station: "ab"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("Obj\x01")) || !bytes.Contains(buf.Bytes(), []byte(want)) {
+		t.Fatalf("Unexpected container file: %q", buf.Bytes())
+	}
+}
+
+// TestSchemaRegistry registers a schema and encodes a message with its ID.
+func TestSchemaRegistry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if r.URL.Path != "/subjects/measurements-value/versions" || json.NewDecoder(r.Body).Decode(&body) != nil || body["schema"] == "" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":7}`))
+	}))
+	defer srv.Close()
+
+	schema, err := bigcsv.GenerateAvroSchema[Measurement]("measurement")
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry := &bigcsv.SchemaRegistry{URL: srv.URL, Client: srv.Client()}
+	id, err := registry.Register(context.Background(), "measurements-value", schema.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg []byte
+	onData := bigcsv.AvroMessages(schema, id, func(m []byte) error {
+		msg = m
+		return nil
+	})
+	if err = onData(Measurement{Station: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(msg, []byte{0, 0, 0, 0, 7, 2, 'x'}) {
+		t.Fatalf("Unexpected message: %v", msg)
+	}
+}
@@ -0,0 +1,54 @@
+package bigcsv
+
+import "sync"
+
+// Batcher collects records into batches, e.g. for bulk inserts into a
+// database. Its OnData is safe for use with multiple workers, and the batch
+// function is never called concurrently. Call Flush after the Run to pass on
+// the last, partial batch.
+//
+//	batcher := bigcsv.Batch(500, store.InsertMany)
+//	parser.OnData = batcher.OnData
+//	err = errors.Join(parser.Run(ctx, 4), batcher.Flush())
+type Batcher[T any] struct {
+	mu      sync.Mutex
+	size    int
+	batch   []T
+	onBatch func(batch []T) error
+}
+
+// Batch creates a Batcher passing batches of size records to onBatch. A size
+// below 1 is treated as 1. The batch slice is not reused by the Batcher.
+func Batch[T any](size int, onBatch func(batch []T) error) *Batcher[T] {
+	size = max(size, 1)
+	return &Batcher[T]{size: size, batch: make([]T, 0, size), onBatch: onBatch}
+}
+
+// OnData adds the record to the batch, passing the batch on once it is full.
+// An error from the batch function is returned as OnData error.
+func (b *Batcher[T]) OnData(data T) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.batch = append(b.batch, data)
+	if len(b.batch) < b.size {
+		return nil
+	}
+	return b.flush()
+}
+
+// Flush passes on the records collected so far, if any.
+func (b *Batcher[T]) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.batch) == 0 {
+		return nil
+	}
+	return b.flush()
+}
+
+// flush passes on the batch and starts a new one. The caller holds the lock.
+func (b *Batcher[T]) flush() error {
+	batch := b.batch
+	b.batch = make([]T, 0, b.size)
+	return b.onBatch(batch)
+}
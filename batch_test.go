@@ -0,0 +1,39 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestBatch checks that all records are passed on in batches, the last one
+// by Flush.
+func TestBatch(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n3,three\n4,four\n5,five\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var sizes []int
+	sum := 0
+	batcher := bigcsv.Batch(2, func(batch []Number) error {
+		mu.Lock()
+		defer mu.Unlock()
+		sizes = append(sizes, len(batch))
+		for _, n := range batch {
+			sum += n.Integer
+		}
+		return nil
+	})
+	parser.Parse = ParseNumber
+	parser.OnData = batcher.OnData
+	if err = errors.Join(parser.Run(context.Background(), 3), batcher.Flush()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 3 || sizes[2] != 1 || sum != 15 {
+		t.Fatalf("Unexpected batches %v with sum %d", sizes, sum)
+	}
+}
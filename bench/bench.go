@@ -0,0 +1,120 @@
+// Package bench provides reproducible synthetic CSV data for benchmarks, and
+// benchmarks of the Run loop comparing worker counts, reader backends and
+// batch sizes. Run them with
+//
+//	go test -bench . github.com/typeduck/bigcsv/bench
+//
+// to catch performance regressions or to size workers for a given load.
+package bench
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// Spec describes synthetic CSV data. The same Spec always generates the same
+// data.
+type Spec struct {
+	// Rows and Columns are the size of the data, not counting the header
+	// row.
+	Rows    int
+	Columns int
+
+	// FieldSize is the average length of fields in bytes, varying by half
+	// in both directions.
+	FieldSize int
+
+	// QuoteRate is the share of fields containing commas or quotes, which
+	// need quoting.
+	QuoteRate float64
+
+	// NewlineRate is the share of fields containing a line break.
+	NewlineRate float64
+
+	// Seed seeds the random generator.
+	Seed uint64
+}
+
+// Header returns the header row, naming the columns "c1", "c2" and so on.
+func (s Spec) Header() []string {
+	header := make([]string, s.Columns)
+	for i := range header {
+		header[i] = fmt.Sprintf("c%d", i+1)
+	}
+	return header
+}
+
+// Records generates the rows, without the header row.
+func (s Spec) Records() [][]string {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	rnd := rand.New(rand.NewPCG(s.Seed, s.Seed))
+	rows := make([][]string, s.Rows)
+	for i := range rows {
+		row := make([]string, s.Columns)
+		for j := range row {
+			n := s.FieldSize/2 + rnd.IntN(s.FieldSize+1)
+			b := make([]byte, n)
+			for k := range b {
+				b[k] = chars[rnd.IntN(len(chars))]
+			}
+			if n > 0 && rnd.Float64() < s.QuoteRate {
+				b[rnd.IntN(n)] = ",\""[rnd.IntN(2)]
+			}
+			if n > 0 && rnd.Float64() < s.NewlineRate {
+				b[rnd.IntN(n)] = '\n'
+			}
+			row[j] = string(b)
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// CSV generates the data as CSV with a header row.
+func (s Spec) CSV() []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(s.Header())
+	w.WriteAll(s.Records())
+	return buf.Bytes()
+}
+
+// JSONLines generates the data as JSON Lines, one object per row keyed by
+// the header, see bigcsv.JSONLines.
+func (s Spec) JSONLines() []byte {
+	var buf bytes.Buffer
+	header := s.Header()
+	for _, row := range s.Records() {
+		buf.WriteByte('{')
+		for i, field := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, _ := json.Marshal(header[i])
+			value, _ := json.Marshal(field)
+			buf.Write(key)
+			buf.WriteByte(':')
+			buf.Write(value)
+		}
+		buf.WriteString("}\n")
+	}
+	return buf.Bytes()
+}
+
+// Stream returns the data as CSV stream, see CSV.
+func (s Spec) Stream() bigcsv.Stream {
+	return stream(s.CSV())
+}
+
+// stream is a Stream of bytes in memory, which can be opened repeatedly.
+type stream []byte
+
+func (s stream) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(s)), nil
+}
@@ -0,0 +1,122 @@
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+	"github.com/typeduck/bigcsv/bench"
+)
+
+// spec is the data used by the benchmarks.
+var spec = bench.Spec{Rows: 20000, Columns: 12, FieldSize: 16, QuoteRate: 0.05, NewlineRate: 0.01, Seed: 1}
+
+// TestSpec checks that the data is reproducible and parses back into the rows.
+func TestSpec(t *testing.T) {
+	small := bench.Spec{Rows: 100, Columns: 5, FieldSize: 8, QuoteRate: 0.5, NewlineRate: 0.2, Seed: 7}
+	if !bytes.Equal(small.CSV(), small.CSV()) {
+		t.Fatal("Data is not reproducible")
+	}
+	for name, opts := range map[string][]bigcsv.Option{
+		"csv":   nil,
+		"jsonl": {bigcsv.WithRecordReader(bigcsv.JSONLines)},
+	} {
+		data := small.CSV()
+		if name == "jsonl" {
+			data = small.JSONLines()
+		}
+		parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(bytes.NewReader(data)), append(opts, bigcsv.WithHeaders())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rows [][]string
+		parser.OnData = func(row []string) error {
+			rows = append(rows, row)
+			return nil
+		}
+		if err = parser.Run(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(rows) != fmt.Sprint(small.Records()) {
+			t.Errorf("%s: rows differ from the generated ones", name)
+		}
+	}
+}
+
+// work simulates processing a row, hashing it rounds times.
+func work(row []string, rounds int) {
+	h := fnv.New64a()
+	for range rounds {
+		for _, field := range row {
+			io.WriteString(h, field)
+		}
+	}
+}
+
+// run benchmarks a Run over data with the given options and callbacks.
+func run(b *testing.B, data []byte, workers int, onData func([]string) error, opts ...bigcsv.Option) {
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for range b.N {
+		parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(bytes.NewReader(data)), append(opts, bigcsv.WithHeaders())...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		parser.OnData = onData
+		if err = parser.Run(context.Background(), workers); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkWorkers compares worker counts for light and heavy rows.
+func BenchmarkWorkers(b *testing.B) {
+	data := spec.CSV()
+	for _, rounds := range []int{0, 50} {
+		for _, workers := range []int{1, 2, 4, 8} {
+			b.Run(fmt.Sprintf("work=%d/workers=%d", rounds, workers), func(b *testing.B) {
+				run(b, data, workers, func(row []string) error {
+					work(row, rounds)
+					return nil
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkReaders compares the reader backends on the same data.
+func BenchmarkReaders(b *testing.B) {
+	noop := func([]string) error { return nil }
+	b.Run("csv", func(b *testing.B) {
+		run(b, spec.CSV(), 1, noop)
+	})
+	b.Run("csv-comments", func(b *testing.B) {
+		run(b, spec.CSV(), 1, noop, bigcsv.WithCommentPrefix("#"))
+	})
+	b.Run("jsonl", func(b *testing.B) {
+		run(b, spec.JSONLines(), 1, noop, bigcsv.WithRecordReader(bigcsv.JSONLines))
+	})
+}
+
+// BenchmarkBatchSizes compares batch sizes for a sink with a fixed cost per
+// batch, like a database round trip.
+func BenchmarkBatchSizes(b *testing.B) {
+	data := spec.CSV()
+	for _, size := range []int{1, 10, 100, 1000} {
+		b.Run(fmt.Sprintf("size=%d", size), func(b *testing.B) {
+			var batcher *bigcsv.Batcher[[]string]
+			batcher = bigcsv.Batch(size, func(batch [][]string) error {
+				work(batch[0], 20) // the cost of a round trip
+				return nil
+			})
+			run(b, data, 4, batcher.OnData)
+			if err := batcher.Flush(); err != nil {
+				b.Fatal(err)
+			}
+		})
+	}
+}
@@ -5,14 +5,22 @@
 package bigcsv
 
 import (
+	"cmp"
 	"context"
 	"encoding/csv"
 	"errors"
 	"fmt"
 	"io"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// ErrRead is passed to OnError when a row cannot be read.
+var ErrRead = errors.New("read error")
+
 // ErrOnRow is passed to OnError when OnRow returns an error.
 var ErrOnRow = errors.New("OnRow error")
 
@@ -22,17 +30,119 @@ var ErrParse = errors.New("Parse error")
 // ErrOnData is passed to OnError when OnData returns an error.
 var ErrOnData = errors.New("OnData error")
 
+// ErrClosed is returned when processing a Parser whose stream was already
+// consumed. Use Reset to process a stream again.
+var ErrClosed = errors.New("parser stream is closed")
+
 // Parser provides streaming CSV parsing. It must be created with New.
 type Parser[T any] struct {
+	// stream is kept to be re-opened by Reset, along with its provenance.
+	stream     Stream
+	provenance Provenance
+
 	// closer is kept from the Stream.Open() to close after processing.
 	closer io.Closer
+	closed bool
+
+	// cfg holds the settings made by options passed to New.
+	cfg *config
+
+	// headers is the header row, if consumed by the Parser, and columns maps
+	// the header names to their index.
+	headers []string
+	columns map[string]int
+
+	// consumed counts the rows read so far, including skipped rows and
+	// headers, so that line numbers in errors match the stream.
+	consumed int
+
+	// offsetBase is added to the offsets of the RecordReader, after seeking
+	// to a checkpoint.
+	offsetBase int64
+
+	// limiter cuts records spanning too many lines, see WithMaxRecordLines.
+	limiter *lineLimiter
+
+	// lastLine and lastOffset are the position of the row read last, see
+	// Position.
+	lastLine   atomic.Int64
+	lastOffset atomic.Int64
+
+	// tally counts the rows and errors of the current or last run, see
+	// Stats.
+	tally atomic.Pointer[tally]
+
+	// state is the lifecycle State, and active the callbacks of the current
+	// or last run, see State.
+	state  atomic.Int32
+	active callbacks[T]
+
+	// prepared and prepareErr record the result of prepare.
+	prepared   bool
+	prepareErr error
+
+	// inBody tells whether the rows before the body were consumed, bodyRows
+	// counts the rows read since, and trailer is the trailer row, see
+	// WithTrailer.
+	inBody   bool
+	bodyRows int64
+	trailer  []string
+
+	// metaHeader and metaFooter are the metadata lines, and inFooter tells
+	// whether the footer started, see Metadata.
+	metaHeader []string
+	metaFooter []string
+	inFooter   bool
+
+	// peeked holds the rows read ahead by Peek, and peekedEOF whether the
+	// stream ended while peeking.
+	peeked    []peeked
+	peekedEOF bool
+
+	// refs are the references of columns, and cleanups the preprocessing of
+	// columns, bound once the headers are known.
+	refs     []reference
+	cleanups []cleanup
+
+	// defaults are the defaults of columns, bound once the headers are
+	// known, and repairedLines the lines with quotes repaired but not yet
+	// read, see WithDefault and WithRepairQuotes.
+	defaults      []fieldDefault
+	repairedLines []int
+
+	// schema holds the columns of the Schema found in the headers, see
+	// WithSchema.
+	schema []schemaCheck
+
+	// hashColumns are the columns hashed by RowHash, nil for all, see
+	// WithRowHash.
+	hashColumns []int
+
+	// bind is called once the headers are known, to bind a Parse function
+	// to the columns.
+	bind func() error
+
+	// nice slows down the workers, see WithNice.
+	nice niceness
+
+	// pool replaces the worker slots of each run if shared with other
+	// Parsers, see FileSet.ShareWorkers.
+	pool chan struct{}
+
+	// fieldsPerRecord is the Reader setting before the Parser read any rows,
+	// as the csv.Reader changes it on the first read.
+	fieldsPerRecord int
 
 	// Reader is the CSV reader which can be modified prior to processing.
 	//
 	// To change CSV settings, use the Reader directly after creating the Parser
-	// and prior to calling Run
+	// and prior to calling Run. It is nil when using WithRecordReader.
 	Reader *csv.Reader
 
+	// records is the source of rows, the Reader unless set by
+	// WithRecordReader.
+	records RecordReader
+
 	// OnRow accepts a CSV row prior to parsing.
 	//
 	// If an error is returned, the OnError function is called and the row is
@@ -49,110 +159,584 @@ type Parser[T any] struct {
 	// to process their row until this signal is received.
 	OnData func(data T) error
 
+	// ParseContext and OnDataContext replace Parse and OnData, receiving
+	// the context passed to Run, Chan or Rows. Values of the context, such
+	// as trace IDs, then reach the rows, so that logs written while
+	// processing them correlate with the job which started them.
+	ParseContext  func(ctx context.Context, row []string) (T, error)
+	OnDataContext func(ctx context.Context, data T) error
+
+	// OnDataAsync accepts a processed row instead of OnData, for sinks
+	// completing rows later such as batching network clients. It returns
+	// a Pending which the sink completes once done, or nil if the row was
+	// processed already. An error returned or passed to Complete is handled
+	// like an error of OnData.
+	//
+	// Rows count as processed, e.g. for checkpoints, once completed. The
+	// number of pending rows is bounded by WithMaxPending, and Run waits
+	// for all pending rows to complete before returning, so each Pending
+	// must be completed eventually.
+	OnDataAsync func(data T) (*Pending, error)
+
 	// OnError handles errors arising during processing.
 	//
 	// If the Parse method returns an error, this method will receive it.
 	// Other, errors from the underlying *csv.Reader will be passed here, too.
+	// Row errors are of type *RowError, giving the position of the row.
 	OnError func(error)
+
+	// OnWarning handles non-fatal issues of rows which were repaired, such
+	// as padded rows, see Warning. The rows are processed as usual. Like
+	// OnError, it may be called by several workers at once.
+	OnWarning func(*Warning)
+}
+
+// New opens the given stream and starts the CSV reader.
+//
+// Options are validated before the stream is opened.
+func New[T any](stream Stream, opts ...Option) (*Parser[T], error) {
+	cfg, err := newConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	p, err := newParser[T](stream, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.open(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// newParser creates a Parser with the configuration, without opening the
+// stream yet.
+func newParser[T any](stream Stream, cfg *config) (*Parser[T], error) {
+	p := &Parser[T]{
+		stream: stream,
+		cfg:    cfg,
+	}
+	if cfg.nice > 0 {
+		p.nice.set(cfg.nice)
+	}
+	if cfg.structTags {
+		m, err := newMapper(p)
+		if err != nil {
+			return nil, err
+		}
+		p.Parse = m.parse
+		p.bind = m.bind
+	}
+	return p, nil
 }
 
-// NewParser opens the given stream and starts the CSV reader.
-func New[T any](stream Stream) (*Parser[T], error) {
-	// Open our CSV stream.
-	r, err := stream.Open()
+// open opens the stream of a new Parser and starts the CSV reader.
+func (p *Parser[T]) open() error {
+	r, err := p.stream.Open()
 	if err != nil {
-		return nil, fmt.Errorf("could not open stream: %w", err)
+		return fmt.Errorf("could not open stream: %w", err)
 	}
+	p.cfg.log(context.Background(), "bigcsv: stream opened", "stream", describe(p.stream))
+	p.provenance = provenanceOf(p.stream)
 
 	// Create the CSV reader.
-	return &Parser[T]{
-		closer: r,
-		Reader: csv.NewReader(r),
-	}, nil
+	p.attach(r)
+	if p.Reader != nil {
+		p.Reader.Comma = p.cfg.comma
+		p.Reader.LazyQuotes = p.cfg.repairQuotes
+		if p.cfg.fields != nil {
+			p.Reader.FieldsPerRecord = *p.cfg.fields
+		}
+	}
+	return nil
+}
+
+// NewFromReader creates a Parser reading from r, see ReadStream.
+func NewFromReader[T any](r io.Reader, opts ...Option) (*Parser[T], error) {
+	return New[T](ReadStream(r), opts...)
+}
+
+// NewFromString creates a Parser reading the CSV in s.
+func NewFromString[T any](s string, opts ...Option) (*Parser[T], error) {
+	return New[T](ReadStream(strings.NewReader(s)), opts...)
+}
+
+// prepare consumes the skipped rows and the header row, if configured. It only
+// reads from the stream once, later calls return the first result.
+func (p *Parser[T]) prepare() error {
+	if p.prepared {
+		return p.prepareErr
+	}
+	p.prepared = true
+
+	// Skipped rows must not determine the number of fields per record.
+	if p.Reader != nil {
+		p.fieldsPerRecord = p.Reader.FieldsPerRecord
+		p.Reader.FieldsPerRecord = -1
+	}
+	restore := func() {}
+	if p.cfg.headerMetadata {
+		restore = p.lenient()
+	}
+	for i := 0; i < p.cfg.skipRows; i++ {
+		rec, n := p.next()
+		p.consumed += n
+		if rec.err != nil && !(p.cfg.headerMetadata && isParseError(rec.err)) {
+			p.prepareErr = fmt.Errorf("could not skip line #%d: %w", p.consumed, rec.err)
+			break
+		}
+		if p.cfg.headerMetadata && rec.row != nil {
+			p.metaHeader = append(p.metaHeader, p.joinRow(rec.row))
+		}
+	}
+	restore()
+	if p.Reader != nil {
+		p.Reader.FieldsPerRecord = p.fieldsPerRecord
+	}
+	if p.prepareErr != nil {
+		return p.prepareErr
+	}
+
+	if p.cfg.headers {
+		rec, n := p.next()
+		p.consumed += n
+		if rec.err != nil {
+			p.prepareErr = fmt.Errorf("could not read headers: %w", rec.err)
+			return p.prepareErr
+		}
+		if p.prepareErr = p.bindHeaders(rec.row); p.prepareErr != nil {
+			return p.prepareErr
+		}
+	}
+	p.inBody = true
+	if p.bind != nil {
+		if err := p.bind(); err != nil {
+			p.prepareErr = err
+			return err
+		}
+	}
+	return nil
+}
+
+// bindHeaders sets the header row, binding the options referring to columns.
+func (p *Parser[T]) bindHeaders(row []string) error {
+	p.headers = slices.Clone(row)
+	p.columns = indexColumns(p.headers)
+	var err error
+	if p.refs, err = p.cfg.bindReferences(p.columns); err != nil {
+		return err
+	}
+	if p.cleanups, err = p.cfg.bindPreprocessing(p.columns); err != nil {
+		return err
+	}
+	if p.defaults, err = p.cfg.bindDefaults(p.columns); err != nil {
+		return err
+	}
+	if p.hashColumns, err = p.cfg.bindRowHash(p.columns); err != nil {
+		return err
+	}
+	p.schema, err = p.bindSchema()
+	return err
 }
 
 // Run begins parsing the CSV records, invoking the configured functions.
 //
-// This method will not return until all workers have finished processing.
+// If workers is 0, the number set by WithWorkers is used. This method will not
+// return until all workers have finished processing. The stream is closed
+// afterwards unless using WithKeepOpen, see Reset to process it again.
+//
+// With the StopOnError policy, the first row error stops the Run and is
+// returned. Other row errors are only passed to OnError, but fatal errors are
+// also returned: when the stream itself fails, after passing the error to
+// OnError, reading stops and that RowError is returned. Run also returns the
+// error of ctx if it was cancelled, and the error of closing the stream. Use
+// WithIgnoreFatal to return nil in these cases. A *TrailerError is returned
+// if the trailer does not match the rows, see WithTrailer.
+//
+// Run returns ErrRunning if the Parser is running already, and
+// ErrReconfigured in race builds if callbacks were replaced while running,
+// which other builds do not check, see State.
 func (p *Parser[T]) Run(ctx context.Context, workers int) error {
-	defer p.closer.Close()
-	if p.OnData != nil && p.Parse == nil {
-		return fmt.Errorf("cannot call OnData without Parse")
+	if err := p.begin(); err != nil {
+		return err
+	}
+	return p.runBegun(ctx, workers)
+}
+
+// runBegun is Run after begin, so that Start begins synchronously.
+func (p *Parser[T]) runBegun(ctx context.Context, workers int) (err error) {
+	defer func() {
+		if cerr := p.finish(); cerr != nil && err == nil && !p.cfg.ignoreFatal {
+			err = fmt.Errorf("could not close stream: %w", cerr)
+		}
+		if rerr := p.end(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	workers, err = p.setup(workers)
+	if err != nil {
+		return err
+	}
+	return p.run(ctx, workers, p.onData(ctx), p.active.onDataAsync, p.active.onError)
+}
+
+// parses tells whether Parse or ParseContext is set for the run.
+func (p *Parser[T]) parses() bool {
+	return p.active.parse != nil || p.active.parseContext != nil
+}
+
+// onData returns OnData, or OnDataContext bound to ctx, as set for the run.
+func (p *Parser[T]) onData(ctx context.Context) func(T) error {
+	if onData := p.active.onDataContext; onData != nil {
+		return func(data T) error {
+			return onData(ctx, data)
+		}
+	}
+	return p.active.onData
+}
+
+// setup validates the number of workers (0 meaning the configured default) and
+// prepares the reader for processing.
+func (p *Parser[T]) setup(workers int) (int, error) {
+	if workers == 0 {
+		workers = p.cfg.workers
 	}
 	if workers < 1 {
-		return fmt.Errorf("invalid number of workers: %d", workers)
+		return 0, fmt.Errorf("invalid number of workers: %d", workers)
+	}
+	if err := p.prepare(); err != nil {
+		return 0, err
 	}
 
-	// It is safe to reuse records with 1 worker.
-	p.Reader.ReuseRecord = workers == 1
+	// It is safe to reuse records with 1 worker and no rows queued.
+	if p.Reader != nil {
+		p.Reader.ReuseRecord = workers == 1 && p.cfg.maxInFlight <= 1
+	}
+	return workers, nil
+}
+
+// run holds the state of a single pass over the rows.
+type run[T any] struct {
+	p       *Parser[T]
+	wg      sync.WaitGroup
+	sem     chan struct{}
+	onData  func(T) error
+	onError func(error)
+
+	// slots are the workers if sem bounds the rows in flight instead, see
+	// WithMaxInFlight.
+	slots chan struct{}
+
+	// onDataAsync replaces onData if set, pending bounds the rows it has not
+	// completed and async tracks them.
+	onDataAsync func(T) (*Pending, error)
+	pending     chan struct{}
+	async       sync.WaitGroup
+
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+	stopErr  error
+	fatal    error // the stream failed
 
-	wg := &sync.WaitGroup{}
-	sem := make(chan struct{}, workers)
+	// rowsRead counts the rows read, to sample every nth, see
+	// WithSampling.
+	rowsRead int
+
+	tally      tally
+	watch      *watch
+	unique     *uniqueness
+	checkpoint *checkpointer
+}
+
+// run reads all rows, dispatching them to workers which pass parsed data to
+// onData or onDataAsync and errors to onError.
+func (p *Parser[T]) run(parent context.Context, workers int, onData func(T) error, onDataAsync func(T) (*Pending, error), onError func(error)) error {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	sem := p.pool
+	if sem == nil {
+		sem = make(chan struct{}, workers)
+	}
+	var slots chan struct{}
+	if p.cfg.maxInFlight > workers {
+		sem, slots = make(chan struct{}, p.cfg.maxInFlight), sem
+	}
+	r := &run[T]{
+		p:           p,
+		sem:         sem,
+		slots:       slots,
+		onData:      onData,
+		onError:     onError,
+		onDataAsync: onDataAsync,
+		pending:     make(chan struct{}, cmp.Or(p.cfg.maxPending, defaultMaxPending)),
+		cancel:      cancel,
+	}
+	r.tally.started = time.Now()
+	var err error
+	if r.unique, err = p.cfg.uniqueness(p.columns); err != nil {
+		return err
+	}
+	if r.checkpoint, err = p.resume(ctx); err != nil {
+		return err
+	}
+	if p.cfg.profile {
+		r.tally.profiler = NewProfiler(p.headers)
+	}
+	p.tally.Store(&r.tally)
+	r.logStart(ctx, workers)
+	untrap := func() {}
+	if p.cfg.signals != nil {
+		trapCtx, stopTrap := context.WithCancel(ctx)
+		trapping := make(chan struct{})
+		go func() {
+			defer close(trapping)
+			r.trap(trapCtx)
+		}()
+		untrap = func() {
+			stopTrap()
+			<-trapping
+		}
+	}
+	if p.cfg.stallAfter > 0 {
+		r.watch = &watch{last: r.tally.started, rows: map[int]error{}}
+		watching := make(chan struct{})
+		go func() {
+			defer close(watching)
+			r.watchdog(ctx)
+		}()
+		defer func() {
+			cancel()
+			<-watching
+		}()
+	}
 
+	eof := false
 LoopOverRows:
-	for ixRow := 1; ixRow > 0; ixRow++ { // NOTE: breaks on EOF intentionally
+	for { // NOTE: breaks on EOF intentionally
 		// Check each iteration whether the parser has been stopped.
+		waiting := time.Now()
 		select {
 		case <-ctx.Done():
 			break LoopOverRows
-		case sem <- struct{}{}:
-			row, err := p.Reader.Read()
-			if errors.Is(err, io.EOF) {
+		case r.sem <- struct{}{}:
+			r.tally.spent(errWait, waiting)
+			// The slot is released when breaking, as it may be shared
+			// with other Parsers.
+			if ctx.Err() != nil {
+				<-r.sem
+				break LoopOverRows
+			}
+			if raceEnabled && p.reconfigured() {
+				<-r.sem
+				r.stop(ErrReconfigured)
+				break LoopOverRows
+			}
+			reading := time.Now()
+			rec := p.read()
+			r.tally.spent(ErrRead, reading)
+			if errors.Is(rec.err, io.EOF) {
+				<-r.sem
+				eof = true
 				break LoopOverRows
-			} else if err != nil {
-				if p.OnError != nil {
-					p.OnError(fmt.Errorf("could not read line #%d: %w", ixRow, err))
+			} else if rec.err != nil {
+				if ctx.Err() != nil { // the read was interrupted
+					<-r.sem
+					break LoopOverRows
+				}
+				r.checkpoint.start(rec, p.inputOffset())
+				err := rec.error(ErrRead, rec.err)
+				r.traceFail(rec.line, err)
+				r.report(rec.row, err)
+				r.done(ctx, rec, rec.err)
+				<-r.sem
+				if !isParseError(rec.err) { // the stream itself failed
+					r.fatal = err
+					break LoopOverRows
 				}
-				<-sem
 				continue LoopOverRows
 			}
 
-			wg.Add(1)
-			go p.processRow(wg, sem, ixRow, row)
+			r.logRow(ctx)
+			rec.sample = r.sample(rec, waiting, reading)
+			if r.tally.profiler != nil {
+				r.tally.profiler.OnRowAt(rec.line, rec.row)
+			}
+			r.checkpoint.start(rec, p.inputOffset())
+			if err := r.unique.check(rec); err != nil {
+				r.traceFail(rec.line, rec.error(ErrOnRow, err))
+				r.report(rec.row, rec.error(ErrOnRow, err))
+				r.done(ctx, rec, err)
+				<-r.sem
+				continue LoopOverRows
+			}
+			r.wg.Add(1)
+			go r.processRow(ctx, rec)
 		}
 	}
-	wg.Wait()
-	return nil
+	r.wg.Wait()
+	r.async.Wait()
+	untrap()
+	r.logEnd(ctx)
+	err = r.stopErr
+	if err == nil && !p.cfg.ignoreFatal {
+		err = cmp.Or(r.fatal, parent.Err())
+	}
+	if err == nil && eof {
+		err = p.checkTrailer()
+	}
+	if cerr := r.checkpoint.finish(ctx); cerr != nil && err == nil {
+		return cerr
+	}
+	return err
+}
+
+// report passes the error of a row on, stopping the run if the policy demands
+// it.
+func (r *run[T]) report(row []string, err error) {
+	r.tally.countError(err)
+	if dl := r.p.cfg.deadLetter; dl != nil {
+		if werr := dl.write(r.p.headers, row, err); werr != nil {
+			r.stop(fmt.Errorf("could not write dead letter: %w", werr))
+		}
+	}
+	if r.onError != nil {
+		r.onError(err)
+	}
+	if r.p.cfg.errorPolicy == StopOnError {
+		r.stop(err)
+	}
+}
+
+// stop cancels the run, which returns err unless stopped before.
+func (r *run[T]) stop(err error) {
+	r.stopOnce.Do(func() {
+		r.stopErr = err
+		r.cancel()
+	})
+}
+
+// done records the completion of a row, failed with err if not nil. A row
+// failing with the StopOnError policy is not checkpointed, so it is processed
+// again when resuming. A checkpoint failing to save is logged, the final
+// checkpoint is tried again when the run ends.
+func (r *run[T]) done(ctx context.Context, rec record, err error) {
+	r.watch.done(rec.line)
+	if err != nil && r.p.cfg.errorPolicy == StopOnError {
+		return
+	}
+	if err := r.checkpoint.done(rec); err != nil {
+		r.p.cfg.log(ctx, "bigcsv: checkpoint failed", "error", err, "line", rec.line)
+	}
 }
 
 // processRow handles a single row according to parser settings.
-func (p *Parser[T]) processRow(wg *sync.WaitGroup, sem <-chan struct{}, ix int, row []string) {
+func (r *run[T]) processRow(ctx context.Context, rec record) {
 	defer func() {
-		<-sem
-		wg.Done()
+		<-r.sem
+		r.wg.Done()
 	}()
-
-	// Hook for raw row processing.
-	if p.OnRow != nil {
-		if err := p.OnRow(row); err != nil {
-			if p.OnError != nil {
-				p.OnError(fmt.Errorf("%w: line %d: %w", ErrOnRow, ix, err))
-			}
+	if r.slots != nil {
+		select {
+		case r.slots <- struct{}{}:
+			defer func() { <-r.slots }()
+		case <-ctx.Done(): // cancelled while queued, the row stays undone
 			return
 		}
 	}
+	defer r.p.nice.yield(time.Now())
 
-	// Bail early if only dealing with raw rows.
-	if p.Parse == nil { // nil Parse implies nil OnData
-		return
-	}
+	var err error
+	handedOff := false
+	worker := r.traceStart(rec.line)
+	defer func() {
+		r.traceEnd(worker, rec.line, err)
+		r.sampleDone(rec.sample, err)
+		if !handedOff { // otherwise done when completed
+			r.done(ctx, rec, err)
+		}
+	}()
 
-	data, err := p.Parse(row)
+	row := rec.row
+	if r.p.cfg.deadLetter != nil { // keep the row as read
+		row = slices.Clone(row)
+	}
+	data, err := r.p.parseRow(ctx, rec, r.watch, &r.tally)
 	if err != nil {
-		if p.OnError != nil {
-			p.OnError(fmt.Errorf("%w: line %d: %w", ErrParse, ix, err))
-		}
+		r.report(row, err)
 		return
 	}
 
 	// OnData handler.
-	if p.OnData == nil {
+	if r.onDataAsync != nil && r.p.parses() {
+		r.traceData(worker, rec.line)
+		handedOff = true
+		r.handOff(ctx, rec, row, data)
 		return
 	}
+	if r.onData == nil || !r.p.parses() {
+		return
+	}
+
+	r.watch.stage(rec.line, ErrOnData)
+	r.traceData(worker, rec.line)
+	defer rec.sample.spent(ErrOnData, time.Now())
+	defer r.tally.spent(ErrOnData, time.Now())
+	if err = r.onData(data); err != nil {
+		r.report(row, rec.error(ErrOnData, err))
+	}
+}
+
+// isParseError tells whether a read error concerns a single malformed row, so
+// reading may continue with the next row. Other errors come from the stream.
+func isParseError(err error) bool {
+	var parseErr *csv.ParseError
+	return errors.As(err, &parseErr) || errors.Is(err, ErrMalformedRecord)
+}
+
+// parseRow preprocesses a row, checks its references and passes it through
+// OnRow and Parse, wrapping their errors. If Parse is nil, the zero value is
+// returned. ParseContext receives ctx. The stages are recorded in w and their time in t, if not nil.
+func (p *Parser[T]) parseRow(ctx context.Context, rec record, w *watch, t *tally) (data T, err error) {
+	preprocess(p.cleanups, rec.row)
+	p.applyDefaults(rec.line, rec.row)
+	if err = p.checkSchema(rec.line, rec.row); err != nil {
+		return data, rec.error(ErrOnRow, err)
+	}
+	if err = checkReferences(p.refs, rec.row); err != nil {
+		return data, rec.error(ErrOnRow, err)
+	}
 
-	if err = p.OnData(data); err != nil {
-		if p.OnError != nil {
-			p.OnError(fmt.Errorf("%w: line %d: %w", ErrOnData, ix, err))
+	// Hook for raw row processing.
+	if p.active.onRow != nil {
+		w.stage(rec.line, ErrOnRow)
+		start := time.Now()
+		err = p.active.onRow(rec.row)
+		t.spent(ErrOnRow, start)
+		rec.sample.spent(ErrOnRow, start)
+		if err != nil {
+			return data, rec.error(ErrOnRow, err)
 		}
 	}
+
+	// Bail early if only dealing with raw rows.
+	if !p.parses() {
+		return data, nil
+	}
+
+	w.stage(rec.line, ErrParse)
+	start := time.Now()
+	if p.active.parseContext != nil {
+		data, err = p.active.parseContext(ctx, rec.row)
+	} else {
+		data, err = p.active.parse(rec.row)
+	}
+	t.spent(ErrParse, start)
+	rec.sample.spent(ErrParse, start)
+	if err != nil {
+		return data, rec.error(ErrParse, err)
+	}
+	return data, nil
 }
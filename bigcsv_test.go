@@ -14,11 +14,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	"github.com/typeduck/bigcsv"
@@ -199,3 +201,109 @@ func TestParallelProcessing(t *testing.T) {
 		t.Fatalf("Time with 8 parallel workers: %v (should not be much more than 100ms)", diff)
 	}
 }
+
+// TestNewFromString checks the string convenience constructor.
+func TestNewFromString(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	n := 0
+	parser.OnData = func(Number) error {
+		n++
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("Processed %d rows, expected 2", n)
+	}
+}
+
+// failingCloser is a stream whose Close fails.
+type failingCloser struct {
+	io.Reader
+}
+
+func (failingCloser) Close() error {
+	return errors.New("broken pipe")
+}
+
+// TestFatalErrors checks that Run returns errors of the stream, but not row
+// errors, unless using WithIgnoreFatal.
+func TestFatalErrors(t *testing.T) {
+	broken := errors.New("connection reset")
+	streams := map[string]func() io.Reader{
+		"read": func() io.Reader {
+			return io.MultiReader(strings.NewReader("1,one\nx,two\n3,three\n"), iotest.ErrReader(broken))
+		},
+		"close": func() io.Reader {
+			return failingCloser{strings.NewReader("1,one\nx,two\n")}
+		},
+	}
+	for name, stream := range streams {
+		for _, ignore := range []bool{false, true} {
+			var opts []bigcsv.Option
+			if ignore {
+				opts = append(opts, bigcsv.WithIgnoreFatal())
+			}
+			parser, err := bigcsv.NewFromReader[Number](stream(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			parser.Parse = ParseNumber
+			parser.OnData = func(Number) error { return nil }
+			parser.OnError = func(error) {}
+			err = parser.Run(context.Background(), 1)
+			switch {
+			case ignore && err != nil:
+				t.Errorf("%s: expected nil with WithIgnoreFatal, got: %v", name, err)
+			case !ignore && name == "read" && !errors.Is(err, broken):
+				t.Errorf("%s: expected the read error, got: %v", name, err)
+			case !ignore && name == "read" && !errors.Is(err, bigcsv.ErrRead):
+				t.Errorf("%s: expected ErrRead, got: %v", name, err)
+			case !ignore && name == "close" && (err == nil || !strings.Contains(err.Error(), "broken pipe")):
+				t.Errorf("%s: expected the close error, got: %v", name, err)
+			}
+		}
+	}
+}
+
+// jobKey is the context key of TestContextCallbacks.
+type jobKey struct{}
+
+// TestContextCallbacks checks that ParseContext and OnDataContext receive the
+// values of the context passed to Run.
+func TestContextCallbacks(t *testing.T) {
+	parser, err := bigcsv.NewFromString[string]("1\n2\n3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen atomic.Int64
+	parser.ParseContext = func(ctx context.Context, row []string) (string, error) {
+		return fmt.Sprint(ctx.Value(jobKey{}), "/", row[0]), nil
+	}
+	parser.OnDataContext = func(ctx context.Context, data string) error {
+		if ctx.Value(jobKey{}) != "job-7" || !strings.HasPrefix(data, "job-7/") {
+			return fmt.Errorf("unexpected context for %s", data)
+		}
+		seen.Add(1)
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	ctx := context.WithValue(context.Background(), jobKey{}, "job-7")
+	if err = parser.Run(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if seen.Load() != 3 {
+		t.Errorf("Got %d rows, expected 3", seen.Load())
+	}
+	parser.Parse = func(row []string) (string, error) { return row[0], nil }
+	if err = parser.Validate(); err == nil {
+		t.Error("Expected an error for both Parse and ParseContext")
+	}
+}
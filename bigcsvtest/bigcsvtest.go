@@ -0,0 +1,251 @@
+// Package bigcsvtest provides helpers for testing code built on bigcsv without
+// real files: in-memory streams, row generators, a sink recording callbacks and
+// streams injecting faults.
+package bigcsvtest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// StreamBuilder builds an in-memory CSV stream. It must be created with
+// NewStream.
+type StreamBuilder struct {
+	buf  bytes.Buffer
+	w    *csv.Writer
+	gzip bool
+}
+
+// NewStream starts building an in-memory CSV stream.
+func NewStream() *StreamBuilder {
+	sb := &StreamBuilder{}
+	sb.w = csv.NewWriter(&sb.buf)
+	return sb
+}
+
+// Comma sets the delimiter for the following rows.
+func (sb *StreamBuilder) Comma(r rune) *StreamBuilder {
+	sb.w.Flush()
+	sb.w.Comma = r
+	return sb
+}
+
+// Row appends a row, quoting fields as needed.
+func (sb *StreamBuilder) Row(fields ...string) *StreamBuilder {
+	sb.w.Write(fields)
+	return sb
+}
+
+// Rows appends several rows.
+func (sb *StreamBuilder) Rows(rows [][]string) *StreamBuilder {
+	sb.w.WriteAll(rows)
+	return sb
+}
+
+// Raw appends s as is, e.g. to add malformed content.
+func (sb *StreamBuilder) Raw(s string) *StreamBuilder {
+	sb.w.Flush()
+	sb.buf.WriteString(s)
+	return sb
+}
+
+// Gzip compresses the stream content: Bytes returns it compressed, e.g. to
+// write a *.gz fixture file, and Stream decompresses it when opened, as a
+// FileStream of a *.gz file does, so that the gzip reading is exercised.
+func (sb *StreamBuilder) Gzip() *StreamBuilder {
+	sb.gzip = true
+	return sb
+}
+
+// Bytes returns the content built so far.
+func (sb *StreamBuilder) Bytes() []byte {
+	sb.w.Flush()
+	if !sb.gzip {
+		return bytes.Clone(sb.buf.Bytes())
+	}
+	out := &bytes.Buffer{}
+	gz := gzip.NewWriter(out)
+	gz.Write(sb.buf.Bytes())
+	gz.Close()
+	return out.Bytes()
+}
+
+// Stream returns the content as a Stream, which may be opened any number of
+// times, e.g. for Parser.Reset.
+func (sb *StreamBuilder) Stream() bigcsv.Stream {
+	if sb.gzip {
+		return gzipBytes(sb.Bytes())
+	}
+	return Bytes(sb.Bytes())
+}
+
+// Bytes is a Stream of in-memory content which may be opened any number of
+// times.
+type Bytes []byte
+
+func (b Bytes) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// gzipBytes is a Stream of in-memory gzip content, decompressed when opened.
+type gzipBytes []byte
+
+func (b gzipBytes) Open() (io.ReadCloser, error) {
+	return gzip.NewReader(bytes.NewReader(b))
+}
+
+// Generate returns n rows created by gen for the row indices 0 to n-1.
+func Generate(n int, gen func(i int) []string) [][]string {
+	rows := make([][]string, n)
+	for i := range rows {
+		rows[i] = gen(i)
+	}
+	return rows
+}
+
+// Numbered returns n rows of the given number of columns, the first column
+// being the row index and the others "r<row>c<column>".
+func Numbered(n, columns int) [][]string {
+	return Generate(n, func(i int) []string {
+		row := make([]string, columns)
+		row[0] = strconv.Itoa(i)
+		for j := 1; j < columns; j++ {
+			row[j] = fmt.Sprintf("r%dc%d", i, j)
+		}
+		return row
+	})
+}
+
+// Random returns n rows of the given number of columns with random field
+// content, including commas, quotes and newlines. The same seed always
+// generates the same rows.
+func Random(seed uint64, n, columns int) [][]string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789 ,\"\n"
+	rnd := rand.New(rand.NewPCG(seed, seed))
+	return Generate(n, func(int) []string {
+		row := make([]string, columns)
+		for j := range row {
+			b := make([]byte, rnd.IntN(16))
+			for k := range b {
+				b[k] = chars[rnd.IntN(len(chars))]
+			}
+			row[j] = string(b)
+		}
+		return row
+	})
+}
+
+// RecordingSink captures the OnData and OnError calls of a Parser. It is safe
+// for use with multiple workers.
+type RecordingSink[T any] struct {
+	mu     sync.Mutex
+	data   []T
+	errors []error
+}
+
+// Attach sets the OnData and OnError functions of the Parser to the sink.
+func (s *RecordingSink[T]) Attach(p *bigcsv.Parser[T]) {
+	p.OnData = s.OnData
+	p.OnError = s.OnError
+}
+
+// OnData records the data.
+func (s *RecordingSink[T]) OnData(data T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data = append(s.data, data)
+	return nil
+}
+
+// OnError records the error.
+func (s *RecordingSink[T]) OnError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.errors = append(s.errors, err)
+}
+
+// Data returns a copy of the recorded data, in the order received.
+func (s *RecordingSink[T]) Data() []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]T(nil), s.data...)
+}
+
+// Errors returns a copy of the recorded errors, in the order received.
+func (s *RecordingSink[T]) Errors() []error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]error(nil), s.errors...)
+}
+
+// FailOpen returns a Stream whose Open fails with err.
+func FailOpen(err error) bigcsv.Stream {
+	return failOpen{err}
+}
+
+type failOpen struct {
+	err error
+}
+
+func (f failOpen) Open() (io.ReadCloser, error) {
+	return nil, f.err
+}
+
+// ErrorAfter returns a Stream reading the first n bytes of inner, then failing
+// with err, like a connection dropping mid-stream.
+func ErrorAfter(inner bigcsv.Stream, n int64, err error) bigcsv.Stream {
+	return wrapped{inner, func(r io.Reader) io.Reader {
+		return io.MultiReader(io.LimitReader(r, n), errReader{err})
+	}}
+}
+
+// Slow returns a Stream delaying every read of inner, like a slow network.
+func Slow(inner bigcsv.Stream, delay time.Duration) bigcsv.Stream {
+	return wrapped{inner, func(r io.Reader) io.Reader {
+		return slowReader{r, delay}
+	}}
+}
+
+// wrapped applies a reader wrapper to an inner stream.
+type wrapped struct {
+	inner bigcsv.Stream
+	wrap  func(io.Reader) io.Reader
+}
+
+func (w wrapped) Open() (io.ReadCloser, error) {
+	rc, err := w.inner.Open()
+	if err != nil {
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{w.wrap(rc), rc}, nil
+}
+
+type errReader struct {
+	err error
+}
+
+func (e errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
+
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (s slowReader) Read(b []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(b)
+}
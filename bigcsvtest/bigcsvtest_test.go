@@ -0,0 +1,62 @@
+package bigcsvtest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+	"github.com/typeduck/bigcsv/bigcsvtest"
+)
+
+// TestRecordingSink checks that generated rows round-trip through a Parser
+// into the sink.
+func TestRecordingSink(t *testing.T) {
+	rows := bigcsvtest.Random(42, 100, 4)
+	parser, err := bigcsv.NewRowParser(bigcsvtest.NewStream().Rows(rows).Stream())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &bigcsvtest.RecordingSink[[]string]{}
+	sink.Attach(parser)
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.Errors()) > 0 {
+		t.Fatal(sink.Errors())
+	}
+	data := sink.Data()
+	if !slices.EqualFunc(data, rows, slices.Equal) {
+		t.Fatalf("Parsed rows differ from generated rows")
+	}
+}
+
+// TestGzip checks that a gzip stream is decompressed when opened.
+func TestGzip(t *testing.T) {
+	sb := bigcsvtest.NewStream().Rows(bigcsvtest.Numbered(3, 2)).Gzip()
+	if b := sb.Bytes(); len(b) < 2 || b[0] != 0x1f || b[1] != 0x8b {
+		t.Fatalf("Expected gzip content, got %q", b)
+	}
+	n, err := bigcsv.Count(context.Background(), sb.Stream())
+	if err != nil || n != 3 {
+		t.Fatalf("Counted %d rows (%v), expected 3", n, err)
+	}
+}
+
+// TestErrorAfter checks that a mid-stream failure reaches OnError.
+func TestErrorAfter(t *testing.T) {
+	errDropped := errors.New("connection dropped")
+	stream := bigcsvtest.NewStream().Rows(bigcsvtest.Numbered(100, 3)).Stream()
+	parser, err := bigcsv.NewRowParser(bigcsvtest.ErrorAfter(stream, 100, errDropped))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &bigcsvtest.RecordingSink[[]string]{}
+	sink.Attach(parser)
+	parser.Run(context.Background(), 1)
+	errs := sink.Errors()
+	if len(errs) == 0 || !errors.Is(errs[0], errDropped) {
+		t.Fatalf("Expected injected error, got: %v", errs)
+	}
+}
@@ -0,0 +1,88 @@
+package bigcsv
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// binaryEncodings are the `csv` tag options for []byte fields, see
+// WithStructTags.
+var binaryEncodings = []string{"base64", "base64url", "hex"}
+
+// binaryEncoding returns the binary encoding option of a tag, if any.
+func (t tag) binaryEncoding() string {
+	for _, enc := range binaryEncodings {
+		if t.has(enc) {
+			return enc
+		}
+	}
+	return ""
+}
+
+// binarySetter returns the decoding of a []byte field tagged with a binary
+// encoding, limited to the number of bytes given by the tag option "max".
+func binarySetter(typ reflect.Type, t tag) (setter, error) {
+	if typ != reflect.TypeFor[[]byte]() {
+		return nil, fmt.Errorf("binary encoding for type %s", typ)
+	}
+	limit := -1
+	if s, ok := t.options["max"]; ok {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid size limit %q", s)
+		}
+		limit = n
+	}
+	decode := decodeBase64(base64.StdEncoding)
+	switch t.binaryEncoding() {
+	case "base64url":
+		decode = decodeBase64(base64.URLEncoding)
+	case "hex":
+		decode = hex.DecodeString
+	}
+	return func(s string, v reflect.Value) error {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		if limit >= 0 && len(s) > 2*limit+4 { // reject before decoding
+			return fmt.Errorf("value exceeds %d bytes", limit)
+		}
+		b, err := decode(s)
+		if err != nil {
+			return err
+		}
+		if limit >= 0 && len(b) > limit {
+			return fmt.Errorf("value of %d bytes exceeds %d bytes", len(b), limit)
+		}
+		v.SetBytes(b)
+		return nil
+	}, nil
+}
+
+// decodeBase64 decodes values with or without padding.
+func decodeBase64(enc *base64.Encoding) func(string) ([]byte, error) {
+	return func(s string) ([]byte, error) {
+		if !strings.HasSuffix(s, "=") {
+			return enc.WithPadding(base64.NoPadding).DecodeString(s)
+		}
+		return enc.DecodeString(s)
+	}
+}
+
+// formatBinary encodes a []byte field tagged with a binary encoding.
+func formatBinary(v reflect.Value, t tag) string {
+	b := v.Bytes()
+	switch t.binaryEncoding() {
+	case "base64url":
+		return base64.URLEncoding.EncodeToString(b)
+	case "hex":
+		return hex.EncodeToString(b)
+	default:
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
@@ -0,0 +1,54 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestBinaryColumns checks decoding, size limits and writing binary columns.
+func TestBinaryColumns(t *testing.T) {
+	type Blob struct {
+		Hash    []byte `csv:"hash,hex"`
+		Payload []byte `csv:"payload,base64,max=4"`
+	}
+	input := "hash,payload\ncafe,AQID\n00,AQIDBA==\nff,AQIDBAU=\nzz,\n"
+	parser, err := bigcsv.NewFromString[Blob](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var blobs []Blob
+	var failed []string
+	for b, err := range parser.Rows(context.Background()) {
+		var colErr *bigcsv.ColumnError
+		if errors.As(err, &colErr) {
+			failed = append(failed, colErr.Column)
+			continue
+		}
+		blobs = append(blobs, b)
+	}
+	if len(blobs) != 2 || !bytes.Equal(blobs[0].Hash, []byte{0xca, 0xfe}) || !bytes.Equal(blobs[1].Payload, []byte{1, 2, 3, 4}) {
+		t.Errorf("Unexpected blobs: %+v", blobs)
+	}
+	if len(failed) != 2 || failed[0] != "payload" || failed[1] != "hash" {
+		t.Errorf("Unexpected failures: %q", failed)
+	}
+
+	var sb strings.Builder
+	w, err := bigcsv.NewWriter[Blob](&sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range blobs {
+		if err = w.OnData(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Flush(); err != nil || sb.String() != "hash,payload\ncafe,AQID\n00,AQIDBA==\n" {
+		t.Errorf("Unexpected output %q: %v", sb.String(), err)
+	}
+}
@@ -0,0 +1,98 @@
+package bigcsv
+
+import (
+	"context"
+)
+
+// Builder offers fluent construction of a Parser as an alternative to New. It
+// must be created with From.
+//
+//	err := bigcsv.From[Place](stream).
+//		WithParse(ParsePlace).
+//		OnData(store).
+//		Workers(8).
+//		Run(ctx)
+type Builder[T any] struct {
+	stream  Stream
+	opts    []Option
+	onRow   func(row []string) error
+	parse   func(row []string) (T, error)
+	onData  func(data T) error
+	onError func(error)
+}
+
+// From starts building a Parser for the given stream.
+func From[T any](stream Stream) *Builder[T] {
+	return &Builder[T]{stream: stream}
+}
+
+// Options adds options as they would be passed to New.
+func (b *Builder[T]) Options(opts ...Option) *Builder[T] {
+	b.opts = append(b.opts, opts...)
+	return b
+}
+
+// Workers sets the number of workers used by Run, see WithWorkers.
+func (b *Builder[T]) Workers(n int) *Builder[T] {
+	return b.Options(WithWorkers(n))
+}
+
+// OnRow sets Parser.OnRow.
+func (b *Builder[T]) OnRow(fn func(row []string) error) *Builder[T] {
+	b.onRow = fn
+	return b
+}
+
+// WithParse sets Parser.Parse.
+func (b *Builder[T]) WithParse(fn func(row []string) (T, error)) *Builder[T] {
+	b.parse = fn
+	return b
+}
+
+// OnData sets Parser.OnData.
+func (b *Builder[T]) OnData(fn func(data T) error) *Builder[T] {
+	b.onData = fn
+	return b
+}
+
+// OnError sets Parser.OnError.
+func (b *Builder[T]) OnError(fn func(error)) *Builder[T] {
+	b.onError = fn
+	return b
+}
+
+// Build validates the configuration and creates the Parser. Invalid
+// configurations are reported as *ConfigError before the stream is opened.
+func (b *Builder[T]) Build() (*Parser[T], error) {
+	if b.stream == nil {
+		return nil, &ConfigError{Field: "stream", Reason: "no stream given"}
+	}
+	cfg, err := newConfig(b.opts)
+	if err != nil {
+		return nil, &ConfigError{Field: "options", Err: err}
+	}
+	p, err := newParser[T](b.stream, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p.OnRow, p.OnData, p.OnError = b.onRow, b.onData, b.onError
+	if b.parse != nil {
+		p.Parse = b.parse // else the mapper of WithStructTags, if any
+	}
+	if err = p.Validate(); err != nil {
+		return nil, err
+	}
+	if err = p.open(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Run builds the Parser and runs it with the configured number of workers.
+func (b *Builder[T]) Run(ctx context.Context) error {
+	p, err := b.Build()
+	if err != nil {
+		return err
+	}
+	return p.Run(ctx, 0)
+}
@@ -0,0 +1,75 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestBuilder checks fluent construction and validation.
+func TestBuilder(t *testing.T) {
+	sum := &atomic.Int32{}
+	err := bigcsv.From[Number](bigcsv.ReadStream(strings.NewReader("1,one\n2,two\n3,three\n"))).
+		WithParse(ParseNumber).
+		OnData(func(n Number) error {
+			sum.Add(int32(n.Integer))
+			return nil
+		}).
+		OnError(func(err error) {
+			t.Error(err)
+		}).
+		Workers(3).
+		Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Load() != 6 {
+		t.Fatalf("Sum of processed rows is %d, expected 6", sum.Load())
+	}
+
+	_, err = bigcsv.From[Number](bigcsv.ReadStream(strings.NewReader(""))).
+		OnData(func(n Number) error { return nil }).
+		Build()
+	var cfgErr *bigcsv.ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Field != "OnData" {
+		t.Fatalf("Expected configuration error for OnData, got: %v", err)
+	}
+
+	_, err = bigcsv.From[Number](bigcsv.ReadStream(strings.NewReader(""))).
+		WithParse(ParseNumber).
+		OnData(func(n Number) error { return nil }).
+		Workers(0).
+		Build()
+	if !errors.As(err, &cfgErr) || cfgErr.Field != "options" || cfgErr.Err == nil {
+		t.Fatalf("Expected configuration error for the options, got: %v", err)
+	}
+	if want := "invalid configuration of options: invalid option: invalid number of workers: 0"; err.Error() != want {
+		t.Errorf("Got %q, expected %q", err, want)
+	}
+}
+
+// TestBuilderStructTags builds a Parser parsing with struct tags.
+func TestBuilderStructTags(t *testing.T) {
+	type server struct {
+		Name string `csv:"name"`
+		Port int    `csv:"port"`
+	}
+	var got []server
+	err := bigcsv.From[server](bigcsv.ReadStream(strings.NewReader("port,name\n80,web\n5432,db\n"))).
+		Options(bigcsv.WithHeaders(), bigcsv.WithStructTags()).
+		OnData(func(s server) error {
+			got = append(got, s)
+			return nil
+		}).
+		Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[1] != (server{"db", 5432}) {
+		t.Fatalf("Got %v", got)
+	}
+}
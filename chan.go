@@ -0,0 +1,69 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Chan processes the rows with the given number of workers (0 meaning the
+// configured default) and sends parsed records and errors on the returned
+// channels, as an alternative to setting OnData and OnError.
+//
+// Both channels are closed once the stream ends or ctx is cancelled, so the
+// caller should receive from both, e.g. in a select loop, until they are
+// closed. OnData and OnError are not used. Records arrive in no particular
+// order when using multiple workers.
+func (p *Parser[T]) Chan(ctx context.Context, workers int) (<-chan T, <-chan error) {
+	out := make(chan T)
+	errs := make(chan error)
+	go func() {
+		defer close(errs)
+		defer close(out)
+
+		sendErr := func(err error) {
+			select {
+			case errs <- err:
+			case <-ctx.Done():
+			}
+		}
+		if err := p.begin(); err != nil {
+			sendErr(err)
+			return
+		}
+		defer func() {
+			if err := p.end(); err != nil {
+				sendErr(err)
+			}
+		}()
+		defer p.finish()
+		if !p.parses() {
+			sendErr(fmt.Errorf("cannot use Chan without Parse"))
+			return
+		}
+		workers, err := p.setup(workers)
+		if err != nil {
+			sendErr(err)
+			return
+		}
+
+		// Records are held by the receiver after the worker is done, so they
+		// must never be reused.
+		if p.Reader != nil {
+			p.Reader.ReuseRecord = false
+		}
+
+		err = p.run(ctx, workers, func(data T) error {
+			select {
+			case out <- data:
+			case <-ctx.Done():
+			}
+			return nil
+		}, nil, sendErr)
+		// Other errors were sent already.
+		if errors.Is(err, ErrTrailer) {
+			sendErr(err)
+		}
+	}()
+	return out, errs
+}
@@ -0,0 +1,40 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestChan checks that records and errors arrive on their channels, and that
+// both channels are closed at the end of the stream.
+func TestChan(t *testing.T) {
+	parser, err := bigcsv.New[Number](bigcsv.ReadStream(strings.NewReader("1,one\nx,two\n3,three\n4,four\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	records, errs := parser.Chan(context.Background(), 2)
+	sum, nErrs := 0, 0
+	for records != nil || errs != nil {
+		select {
+		case n, ok := <-records:
+			if !ok {
+				records = nil
+				continue
+			}
+			sum += n.Integer
+		case _, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			nErrs++
+		}
+	}
+	if sum != 8 || nErrs != 1 {
+		t.Fatalf("Got sum %d and %d errors, expected 8 and 1", sum, nErrs)
+	}
+}
@@ -0,0 +1,114 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WithEncoding decodes a stream in the text encoding named as by
+// Dialect.Encoding to UTF-8: "utf-8" and "utf-8-bom" only drop a byte order
+// mark, "utf-16le" and "utf-16be" drop it and decode UTF-16, and "latin-1"
+// decodes ISO 8859-1. Without it, a byte order mark ends up in the first
+// header name. Offsets count the bytes of the decoded stream.
+func WithEncoding(name string) Option {
+	return func(cfg *config) error {
+		switch name {
+		case "utf-8", "utf-8-bom", "utf-16le", "utf-16be", "latin-1":
+		default:
+			return fmt.Errorf("unknown encoding %q", name)
+		}
+		cfg.encoding = name
+		return nil
+	}
+}
+
+// decoder converts a stream to UTF-8, see WithEncoding.
+type decoder struct {
+	r        *bufio.Reader
+	encoding string
+	started  bool
+
+	in, out, pending []byte
+	carry            []byte // bytes of an incomplete UTF-16 character
+	err              error
+}
+
+func newDecoder(r io.Reader, encoding string) *decoder {
+	return &decoder{r: bufio.NewReader(r), encoding: encoding, in: make([]byte, 4096)}
+}
+
+func (d *decoder) Read(b []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		bom := []byte{0xEF, 0xBB, 0xBF}
+		switch d.encoding {
+		case "utf-16le":
+			bom = []byte{0xFF, 0xFE}
+		case "utf-16be":
+			bom = []byte{0xFE, 0xFF}
+		case "latin-1":
+			bom = nil
+		}
+		if head, _ := d.r.Peek(len(bom)); len(bom) > 0 && bytes.Equal(head, bom) {
+			_, _ = d.r.Discard(len(bom))
+		}
+	}
+	if d.encoding == "utf-8" || d.encoding == "utf-8-bom" {
+		return d.r.Read(b)
+	}
+	for len(d.pending) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		n, err := d.r.Read(d.in)
+		d.err = err
+		d.out = d.out[:0]
+		if d.encoding == "latin-1" {
+			for _, c := range d.in[:n] {
+				d.out = utf8.AppendRune(d.out, rune(c))
+			}
+		} else {
+			d.decodeUTF16(d.in[:n], err != nil)
+		}
+		d.pending = d.out
+	}
+	n := copy(b, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
+
+// decodeUTF16 decodes the next chunk of UTF-16, keeping an incomplete
+// character for the next one unless at the end of the stream.
+func (d *decoder) decodeUTF16(chunk []byte, end bool) {
+	in := append(d.carry, chunk...)
+	unit := func(b []byte) rune {
+		if d.encoding == "utf-16be" {
+			return rune(b[0])<<8 | rune(b[1])
+		}
+		return rune(b[0]) | rune(b[1])<<8
+	}
+	for len(in) >= 2 {
+		r, size := unit(in), 2
+		if utf16.IsSurrogate(r) && r < 0xDC00 { // high surrogate
+			if len(in) < 4 && !end {
+				break
+			}
+			if len(in) >= 4 {
+				if pair := utf16.DecodeRune(r, unit(in[2:])); pair != utf8.RuneError {
+					r, size = pair, 4
+				}
+			}
+		}
+		d.out = utf8.AppendRune(d.out, r) // lone surrogates become U+FFFD
+		in = in[size:]
+	}
+	if end && len(in) > 0 {
+		d.out = utf8.AppendRune(d.out, utf8.RuneError)
+		in = in[:0]
+	}
+	d.carry = append(d.carry[:0], in...)
+}
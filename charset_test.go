@@ -0,0 +1,48 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestWithEncoding decodes streams to UTF-8.
+func TestWithEncoding(t *testing.T) {
+	text := "name,note\n" + strings.Repeat("Zoë,\U0001F600\n", 1000) // across chunks
+	utf16le := []byte{0xFF, 0xFE}
+	utf16be := []byte{0xFE, 0xFF}
+	for _, u := range utf16.Encode([]rune(text)) {
+		utf16le = append(utf16le, byte(u), byte(u>>8))
+		utf16be = append(utf16be, byte(u>>8), byte(u))
+	}
+	for _, test := range []struct {
+		encoding, input, want string
+	}{
+		{"utf-8-bom", "\xEF\xBB\xBF" + text, text},
+		{"utf-8", text, text},
+		{"utf-16le", string(utf16le), text},
+		{"utf-16be", string(utf16be), text},
+		{"latin-1", "name,note\nZo\xEB,\xA7\n", "name,note\nZoë,§\n"},
+	} {
+		parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(test.input)), bigcsv.WithEncoding(test.encoding))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got strings.Builder
+		for row, err := range parser.Rows(context.Background()) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.WriteString(strings.Join(row, ",") + "\n")
+		}
+		if got.String() != test.want {
+			t.Errorf("%s: got %q, expected %q", test.encoding, got.String(), test.want)
+		}
+	}
+	if _, err := bigcsv.NewFromString[[]string]("", bigcsv.WithEncoding("ebcdic")); err == nil {
+		t.Error("Expected an error for an unknown encoding")
+	}
+}
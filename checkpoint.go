@@ -0,0 +1,441 @@
+package bigcsv
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Checkpoint is the position up to which a stream was processed, to resume
+// an interrupted ingestion, see WithCheckpoint.
+type Checkpoint struct {
+	// Stream identifies the stream, e.g. the file name. A checkpoint of
+	// another stream is ignored.
+	Stream string `json:"stream"`
+
+	// Version identifies the content of the stream: the size and
+	// modification time of a file, including one passed to ReadStream, or
+	// the ETag or Last-Modified of an HTTPStream. A checkpoint of another
+	// version is ignored, so that a replaced file is read from the start.
+	// It is empty for other streams.
+	Version string `json:"version,omitempty"`
+
+	// Line and Offset are the position of the first row not processed yet,
+	// see RowError. All rows before it were processed.
+	Line   int   `json:"line"`
+	Offset int64 `json:"offset"`
+
+	// Rows is the number of rows processed in total, including those of
+	// earlier runs.
+	Rows int64 `json:"rows"`
+
+	// Output is the number of records written to the output, see
+	// WithCheckpointOutput.
+	Output int64 `json:"output,omitempty"`
+
+	// ETag and LastModified are the validators of an HTTP response, saved
+	// by ConditionalHTTPStream instead of a position.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// CheckpointStore persists checkpoints by key.
+type CheckpointStore interface {
+	// Load returns the checkpoint saved for the key, nil if there is none.
+	Load(ctx context.Context, key string) (*Checkpoint, error)
+
+	// Save replaces the checkpoint of the key.
+	Save(ctx context.Context, key string, cp Checkpoint) error
+}
+
+// WithCheckpoint resumes Run from the checkpoint saved for the key in the
+// store, skipping the rows processed before, and saves a new checkpoint every
+// n processed rows and when the Run ends. Rows count as processed once
+// completed by the workers, including failed rows. With multiple workers, a
+// checkpoint is at the first row still in progress, so some rows may be
+// processed again after an interruption.
+//
+// Uncompressed files are resumed by seeking to the offset of the checkpoint,
+// unless options such as WithCommentPrefix, WithStrict or WithTrailer
+// need the rows before; other streams are read up to it. Physical lines of
+// errors, see RowError, then count from the checkpoint.
+func WithCheckpoint(store CheckpointStore, key string, n int) Option {
+	return func(cfg *config) error {
+		if store == nil || key == "" || n < 1 {
+			return fmt.Errorf("invalid checkpoint settings")
+		}
+		cfg.checkpoint = &checkpointConfig{store: store, key: key, every: int64(n)}
+		return nil
+	}
+}
+
+// CheckpointOutput is an output whose position is saved along with
+// checkpoints, such as a FileWriter.
+type CheckpointOutput interface {
+	// Sync makes the records written so far durable, returning their
+	// number.
+	Sync() (int64, error)
+}
+
+// WithCheckpointOutput saves the number of records written to out along with
+// each checkpoint of WithCheckpoint, as Checkpoint.Output, so that the whole
+// transform can be resumed: the output is first truncated to that number of
+// records, see ResumeFile, dropping those written after the checkpoint. With a
+// single worker, each row is then written exactly once. With several, rows in
+// progress at the checkpoint may have been written and are written again.
+//
+//	cp, err := store.Load(ctx, "orders")
+//	...
+//	var written int64
+//	if cp != nil {
+//		written = cp.Output
+//	}
+//	out, err := bigcsv.ResumeFile[Order]("out/orders.csv", written)
+//	...
+//	parser, err := bigcsv.New[Order](stream, bigcsv.WithHeaders(), bigcsv.WithStructTags(),
+//		bigcsv.WithCheckpoint(store, "orders", 10000), bigcsv.WithCheckpointOutput(out))
+//	...
+//	parser.OnData = out.OnData
+//	err = errors.Join(parser.Run(ctx, 1), out.Close())
+func WithCheckpointOutput(out CheckpointOutput) Option {
+	return func(cfg *config) error {
+		if out == nil {
+			return fmt.Errorf("no checkpoint output")
+		}
+		cfg.checkpointOut = out
+		return nil
+	}
+}
+
+// checkpointConfig holds the settings made by WithCheckpoint.
+type checkpointConfig struct {
+	store CheckpointStore
+	key   string
+	every int64
+	out   CheckpointOutput
+}
+
+// checkpointer tracks the rows in progress during a run, saving checkpoints.
+type checkpointer struct {
+	*checkpointConfig
+	mu       sync.Mutex
+	inFlight map[int]int64 // offsets by line
+	next     Checkpoint    // the position after the row read last
+	pending  int64         // rows processed since the last save
+}
+
+// resume loads the checkpoint and skips the rows processed before. It returns
+// nil without WithCheckpoint.
+func (p *Parser[T]) resume(ctx context.Context) (*checkpointer, error) {
+	cfg := p.cfg.checkpoint
+	if cfg == nil {
+		return nil, nil
+	}
+	version, err := streamVersion(ctx, p.stream)
+	if err != nil {
+		return nil, fmt.Errorf("could not identify stream: %w", err)
+	}
+	c := &checkpointer{
+		checkpointConfig: cfg,
+		inFlight:         map[int]int64{},
+		next: Checkpoint{
+			Stream:  describe(p.stream),
+			Version: version,
+			Line:    p.consumed + 1,
+			Offset:  p.inputOffset(),
+		},
+	}
+	cp, err := cfg.store.Load(ctx, cfg.key)
+	if err != nil {
+		return nil, fmt.Errorf("could not load checkpoint: %w", err)
+	}
+	if cp == nil || cp.Stream != c.next.Stream || cp.Version != c.next.Version {
+		return c, nil
+	}
+	c.next.Rows = cp.Rows
+	if p.seek(cp.Line, cp.Offset) {
+		c.next.Line, c.next.Offset = cp.Line, cp.Offset
+	} else {
+		for {
+			rec := p.read()
+			if errors.Is(rec.err, io.EOF) {
+				p.peekedEOF = true
+				break
+			}
+			if rec.line >= cp.Line || rec.err != nil && !isParseError(rec.err) {
+				// Keep the row for processing, without counting it again.
+				p.peeked = append([]peeked{{rec, 0}}, p.peeked...)
+				break
+			}
+			c.next.Line, c.next.Offset = rec.line+1, p.inputOffset()
+		}
+	}
+	p.cfg.log(ctx, "bigcsv: resumed from checkpoint", "line", c.next.Line, "rows", c.next.Rows)
+	return c, nil
+}
+
+// seek moves to the row at the line and offset of a checkpoint without reading
+// the rows before, telling whether it could: the stream must be a file read
+// as is, without rows peeked beyond the current one.
+func (p *Parser[T]) seek(line int, offset int64) bool {
+	f, ok := p.closer.(io.ReadSeekCloser)
+	if !ok || p.Reader == nil || p.cfg.commentPrefix != "" || p.cfg.strict || p.cfg.repairQuotes ||
+		p.cfg.trailer != nil || len(p.peeked) > 0 || p.peekedEOF {
+		return false
+	}
+	if current := p.inputOffset(); current < 0 || offset <= current {
+		return false
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return false
+	}
+	p.reattach(f)
+	p.offsetBase = offset
+	p.consumed = line - 1
+	return true
+}
+
+// streamVersion returns the Checkpoint.Version of a stream.
+func streamVersion(ctx context.Context, stream Stream) (string, error) {
+	var info os.FileInfo
+	var err error
+	switch s := stream.(type) {
+	case FileStream:
+		info, err = os.Stat(string(s))
+	case readerAdapter:
+		f, ok := s.Reader.(*os.File)
+		if !ok {
+			return "", nil
+		}
+		info, err = f.Stat()
+	case HTTPStream:
+		return httpVersion(ctx, string(s))
+	case teeStream:
+		return streamVersion(ctx, s.inner)
+	default:
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() { // e.g. a pipe
+		return "", nil
+	}
+	return fmt.Sprintf("%d@%s", info.Size(), info.ModTime().UTC().Format(time.RFC3339Nano)), nil
+}
+
+// httpVersion returns the ETag, or else the Last-Modified and Content-Length,
+// of a URL by a HEAD request.
+func httpVersion(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	res.Body.Close()
+	switch {
+	case res.StatusCode/100 != 2:
+		return "", nil
+	case res.Header.Get("ETag") != "":
+		return res.Header.Get("ETag"), nil
+	case res.Header.Get("Last-Modified") != "":
+		return fmt.Sprintf("%d@%s", res.ContentLength, res.Header.Get("Last-Modified")), nil
+	}
+	return "", nil
+}
+
+// start records a row read, with the offset of the next row.
+func (c *checkpointer) start(rec record, next int64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.inFlight[rec.line] = rec.offset
+	c.next.Line, c.next.Offset = rec.line+1, next
+}
+
+// done records a row processed, saving a checkpoint if due.
+func (c *checkpointer) done(rec record) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inFlight, rec.line)
+	c.next.Rows++
+	if c.pending++; c.pending < c.every {
+		return nil
+	}
+	return c.save(context.Background())
+}
+
+// save saves the checkpoint at the first row in progress. The caller holds the
+// lock.
+func (c *checkpointer) save(ctx context.Context) error {
+	cp := c.next
+	for line, offset := range c.inFlight {
+		if line < cp.Line {
+			cp.Line, cp.Offset = line, offset
+		}
+	}
+	c.pending = 0
+	if c.out != nil {
+		var err error
+		if cp.Output, err = c.out.Sync(); err != nil {
+			return fmt.Errorf("could not sync checkpoint output: %w", err)
+		}
+	}
+	if err := c.store.Save(ctx, c.key, cp); err != nil {
+		return fmt.Errorf("could not save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// finish saves the final checkpoint of a run.
+func (c *checkpointer) finish(ctx context.Context) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save(context.WithoutCancel(ctx))
+}
+
+// FileCheckpointStore keeps checkpoints as JSON files in a directory, named
+// after their keys.
+type FileCheckpointStore string
+
+// Load reads the checkpoint of the key.
+func (s FileCheckpointStore) Load(_ context.Context, key string) (*Checkpoint, error) {
+	b, err := os.ReadFile(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err = json.Unmarshal(b, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Save writes the checkpoint of the key, replacing the file atomically.
+func (s FileCheckpointStore) Save(_ context.Context, key string, cp Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(string(s), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(string(s), ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// path returns the file of a key.
+func (s FileCheckpointStore) path(key string) string {
+	return filepath.Join(string(s), url.PathEscape(key)+".json")
+}
+
+// RedisCheckpointStore keeps checkpoints as JSON strings in Redis, talking
+// RESP over a connection, e.g. a net.Conn to the server. It is safe for
+// concurrent use.
+type RedisCheckpointStore struct {
+	mu     sync.Mutex
+	conn   *bufio.ReadWriter
+	prefix string
+}
+
+// NewRedisCheckpointStore creates a RedisCheckpointStore storing the
+// checkpoints under their keys prefixed with prefix, e.g. "checkpoint:".
+func NewRedisCheckpointStore(conn io.ReadWriter, prefix string) *RedisCheckpointStore {
+	return &RedisCheckpointStore{
+		conn:   bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
+		prefix: prefix,
+	}
+}
+
+// Load gets the checkpoint of the key.
+func (s *RedisCheckpointStore) Load(_ context.Context, key string) (*Checkpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeRedisCommand(s.conn.Writer, "GET", s.prefix+key)
+	if err := s.conn.Flush(); err != nil {
+		return nil, err
+	}
+	value, ok, err := readRedisString(s.conn.Reader)
+	if err != nil || !ok {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err = json.Unmarshal([]byte(value), &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// Save sets the checkpoint of the key.
+func (s *RedisCheckpointStore) Save(_ context.Context, key string, cp Checkpoint) error {
+	b, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	writeRedisCommand(s.conn.Writer, "SET", s.prefix+key, string(b))
+	if err = s.conn.Flush(); err != nil {
+		return err
+	}
+	return readRedisReply(s.conn.Reader)
+}
+
+// readRedisString reads a bulk string reply, telling whether it was not nil.
+func readRedisString(r *bufio.Reader) (string, bool, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", false, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch {
+	case strings.HasPrefix(line, "-"):
+		return "", false, redisError(line[1:])
+	case !strings.HasPrefix(line, "$"):
+		return "", false, fmt.Errorf("invalid reply %q", line)
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return "", false, err
+	}
+	b := make([]byte, n+2)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return "", false, err
+	}
+	return string(b[:n]), true, nil
+}
@@ -0,0 +1,143 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestCheckpoint checks resuming a failed Run from a file checkpoint.
+func TestCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(name, []byte("id\n1\n2\n3\n4\n5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := bigcsv.FileCheckpointStore(filepath.Join(dir, "checkpoints"))
+	run := func(fail string) ([]string, error) {
+		parser, err := bigcsv.NewRowParser(bigcsv.FileStream(name),
+			bigcsv.WithHeaders(),
+			bigcsv.WithErrorPolicy(bigcsv.StopOnError),
+			bigcsv.WithCheckpoint(store, "data/import", 2),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		parser.OnData = func(row []string) error {
+			if row[0] == fail {
+				return errors.New("database down")
+			}
+			ids = append(ids, row[0])
+			return nil
+		}
+		return ids, parser.Run(context.Background(), 1)
+	}
+
+	if ids, err := run("3"); err == nil || strings.Join(ids, ",") != "1,2" {
+		t.Fatalf("Unexpected first run %q: %v", ids, err)
+	}
+	cp, err := store.Load(context.Background(), "data/import")
+	if err != nil || cp == nil || cp.Stream != name || cp.Line != 4 || cp.Offset != 7 || cp.Rows != 2 {
+		t.Fatalf("Unexpected checkpoint %+v: %v", cp, err)
+	}
+	if ids, err := run(""); err != nil || strings.Join(ids, ",") != "3,4,5" {
+		t.Fatalf("Unexpected second run %q: %v", ids, err)
+	}
+	if ids, err := run(""); err != nil || len(ids) != 0 {
+		t.Fatalf("Unexpected third run %q: %v", ids, err)
+	}
+	if cp, _ = store.Load(context.Background(), "data/import"); cp.Line != 7 || cp.Rows != 5 {
+		t.Errorf("Unexpected final checkpoint %+v", cp)
+	}
+}
+
+// TestRedisCheckpointStore checks the commands and replies of the store.
+func TestRedisCheckpointStore(t *testing.T) {
+	conn := &fakeRedis{replies: strings.NewReader("+OK\r\n$43\r\n{\"stream\":\"a\",\"line\":3,\"offset\":9,\"rows\":2}\r\n$-1\r\n")}
+	store := bigcsv.NewRedisCheckpointStore(conn, "cp:")
+	ctx := context.Background()
+	if err := store.Save(ctx, "job", bigcsv.Checkpoint{Stream: "a", Line: 3, Offset: 9, Rows: 2}); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := store.Load(ctx, "job")
+	if err != nil || cp == nil || *cp != (bigcsv.Checkpoint{Stream: "a", Line: 3, Offset: 9, Rows: 2}) {
+		t.Fatalf("Unexpected checkpoint %+v: %v", cp, err)
+	}
+	if cp, err = store.Load(ctx, "other"); cp != nil || err != nil {
+		t.Fatalf("Expected no checkpoint, got %+v: %v", cp, err)
+	}
+	if !strings.HasPrefix(conn.sent.String(), "*3\r\n$3\r\nSET\r\n$6\r\ncp:job\r\n") {
+		t.Errorf("Unexpected commands: %q", conn.sent.String())
+	}
+}
+
+// TestCheckpointSeek checks that a file is resumed at the offset of the
+// checkpoint, and read from the start once it changed.
+func TestCheckpointSeek(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "data.csv")
+	if err := os.WriteFile(name, []byte("id\n1\n2\n3\n4\n5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := bigcsv.FileCheckpointStore(filepath.Join(dir, "checkpoints"))
+	run := func(fail string) ([]string, error) {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(f),
+			bigcsv.WithHeaders(),
+			bigcsv.WithErrorPolicy(bigcsv.StopOnError),
+			bigcsv.WithCheckpoint(store, "data", 1),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		parser.OnData = func(row []string) error {
+			if row[0] == fail {
+				return errors.New("database down")
+			}
+			ids = append(ids, row[0])
+			return nil
+		}
+		return ids, parser.Run(context.Background(), 1)
+	}
+
+	if ids, err := run("3"); err == nil || strings.Join(ids, ",") != "1,2" {
+		t.Fatalf("Unexpected first run %q: %v", ids, err)
+	}
+	// Rows before the checkpoint are not read again: damage them without
+	// changing the size or modification time of the file.
+	info, err := os.Stat(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(name, []byte("id\n\"\n\"\n3\n4\n5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(name, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	var rowErr *bigcsv.RowError
+	if ids, err := run("4"); !errors.As(err, &rowErr) || rowErr.Line != 5 || rowErr.Offset != 9 || strings.Join(ids, ",") != "3" {
+		t.Fatalf("Unexpected second run %q: %v", ids, err)
+	}
+	if cp, _ := store.Load(context.Background(), "data"); cp == nil || cp.Line != 5 || cp.Offset != 9 || cp.Rows != 3 {
+		t.Fatalf("Unexpected checkpoint %+v", cp)
+	}
+
+	// A replaced file is read from the start.
+	if err = os.WriteFile(name, []byte("id\n6\n7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := run(""); err != nil || strings.Join(ids, ",") != "6,7" {
+		t.Fatalf("Unexpected run of the replaced file %q: %v", ids, err)
+	}
+}
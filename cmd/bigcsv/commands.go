@@ -0,0 +1,562 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// cmdHead prints the header and the first n rows.
+func cmdHead(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "head")
+	n := fs.Int("n", 10, "number of rows to print")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := in.parser(env, fs)
+	if err != nil {
+		return err
+	}
+	w := newWriter(env.stdout, p.Reader.Comma)
+	if headers := p.Headers(); headers != nil {
+		w.Write(headers)
+	}
+	if *n > 0 {
+		printed := 0
+		for row, err := range p.Rows(context.Background()) {
+			if err != nil {
+				return err
+			}
+			w.Write(row)
+			if printed++; printed == *n {
+				break // before reading another row
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// cmdCount prints the number of records, not counting the header.
+func cmdCount(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "count")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	stream, err := in.stream(env, fs)
+	if err != nil {
+		return err
+	}
+	opts, err := in.options()
+	if err != nil {
+		return err
+	}
+	if !in.noHeader {
+		opts = append(opts, bigcsv.WithHeaders())
+	}
+	n, err := bigcsv.Count(context.Background(), stream, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(env.stdout, n)
+	return err
+}
+
+// cmdValidate reads all rows, reporting each row error. It fails if any row
+// is invalid, or with -strict on the first violation of RFC 4180.
+func cmdValidate(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "validate")
+	strict := fs.Bool("strict", false, "fail on input violating RFC 4180, such as bare CRs or trailing delimiters")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *strict {
+		in.extra = append(in.extra, bigcsv.WithStrict())
+	}
+	p, err := in.parser(env, fs)
+	if err != nil {
+		return err
+	}
+	var rows, invalid int
+	p.OnData = func([]string) error {
+		rows++
+		return nil
+	}
+	p.OnError = func(err error) {
+		invalid++
+		fmt.Fprintln(env.stderr, err)
+	}
+	if err = p.Run(context.Background(), 1); err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "%d valid rows, %d invalid rows\n", rows, invalid)
+	if invalid > 0 {
+		return fmt.Errorf("found %d invalid rows", invalid)
+	}
+	return nil
+}
+
+// cmdConvert converts between csv, tsv and jsonl. The first row of CSV input
+// provides the keys for jsonl output, the keys of the first object provide
+// the header for CSV output.
+func cmdConvert(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "convert")
+	from := fs.String("from", "csv", "input format: csv, tsv or jsonl")
+	to := fs.String("to", "", "output format: csv, tsv or jsonl")
+	quote := fs.String("quote", "minimal", "quoting of csv and tsv output: minimal, all, non-numeric or none")
+	crlf := fs.Bool("crlf", false, "end csv and tsv lines with CRLF")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var write func(headers, row []string) error
+	var flush func() error
+	switch *to {
+	case "csv", "tsv":
+		opts := []bigcsv.WriterOption{bigcsv.WithQuoting(bigcsv.QuoteStyle(*quote))}
+		if *to == "tsv" {
+			opts = append(opts, bigcsv.WithWriterComma('\t'))
+		}
+		if *crlf {
+			opts = append(opts, bigcsv.WithLineTerminator("\r\n"))
+		}
+		w, err := bigcsv.NewWriter[[]string](env.stdout, opts...)
+		if err != nil {
+			return err
+		}
+		wroteHeaders := false
+		write = func(headers, row []string) error {
+			if !wroteHeaders && headers != nil {
+				if err := w.Write(headers); err != nil {
+					return err
+				}
+			}
+			wroteHeaders = true
+			return w.Write(row)
+		}
+		flush = w.Flush
+	case "jsonl":
+		w := bufio.NewWriter(env.stdout)
+		write = func(headers, row []string) error {
+			return writeJSONLine(w, headers, row)
+		}
+		flush = w.Flush
+	default:
+		return fmt.Errorf("invalid output format '%s'", *to)
+	}
+
+	switch *from {
+	case "csv", "tsv":
+		if *from == "tsv" {
+			in.delimiter = "tab"
+		}
+		p, err := in.parser(env, fs)
+		if err != nil {
+			return err
+		}
+		headers := p.Headers()
+		for row, err := range p.Rows(context.Background()) {
+			if err != nil {
+				return err
+			}
+			if err = write(headers, row); err != nil {
+				return err
+			}
+		}
+	case "jsonl":
+		stream, err := in.stream(env, fs)
+		if err != nil {
+			return err
+		}
+		p, err := bigcsv.NewRowParser(stream, bigcsv.WithRecordReader(bigcsv.JSONLines), bigcsv.WithHeaders())
+		if err != nil {
+			return err
+		}
+		headers := p.Headers()
+		for row, err := range p.Rows(context.Background()) {
+			if err != nil {
+				return err
+			}
+			if err = write(headers, row); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("invalid input format '%s'", *from)
+	}
+	return flush()
+}
+
+// cmdSelect prints the given columns.
+func cmdSelect(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "select")
+	columns := fs.String("c", "", "comma separated column names (or 1-based numbers with -no-header)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := in.parser(env, fs)
+	if err != nil {
+		return err
+	}
+	var indices []int
+	for _, name := range strings.Split(*columns, ",") {
+		ix, err := columnIndex(p, name)
+		if err != nil {
+			return err
+		}
+		indices = append(indices, ix)
+	}
+	w := newWriter(env.stdout, p.Reader.Comma)
+	pick := func(row []string) []string {
+		out := make([]string, len(indices))
+		for i, ix := range indices {
+			if ix < len(row) {
+				out[i] = row[ix]
+			}
+		}
+		return out
+	}
+	if headers := p.Headers(); headers != nil {
+		w.Write(pick(headers))
+	}
+	for row, err := range p.Rows(context.Background()) {
+		if err != nil {
+			return err
+		}
+		w.Write(pick(row))
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// cmdFilter prints the header and the rows matching the expression.
+func cmdFilter(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "filter")
+	src := fs.String("e", "", `expression, e.g. "state == 'CA' && population > 10000", see bigcsv.Expr`)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	p, err := in.parser(env, fs)
+	if err != nil {
+		return err
+	}
+	expr, err := bigcsv.CompileExpr(*src, func(name string) (int, error) {
+		return columnIndex(p, name)
+	})
+	if err != nil {
+		return err
+	}
+	w := newWriter(env.stdout, p.Reader.Comma)
+	if headers := p.Headers(); headers != nil {
+		w.Write(headers)
+	}
+	for row, err := range p.Rows(context.Background()) {
+		if err != nil {
+			return err
+		}
+		match, err := expr.Bool(row)
+		if err != nil {
+			return err
+		}
+		if match {
+			w.Write(row)
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// cmdSplit writes the rows into numbered files of at most n records each, or
+// a file per value of a key column, repeating the header in every file.
+func cmdSplit(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "split")
+	n := fs.Int("n", 0, "maximum number of records per file (default 100000 without -key)")
+	key := fs.String("key", "", "column to split by, writing a file per value")
+	maxOpen := fs.Int("max-open", 0, "maximum number of files open at once with -key (default 64)")
+	prefix := fs.String("o", "split", "prefix of the output files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *n < 0 {
+		return fmt.Errorf("invalid number of records per file: %d", *n)
+	}
+	split := bigcsv.SplitOptions{Rows: *n, Key: *key, MaxOpen: *maxOpen}
+	switch {
+	case *key == "":
+		split.Rows = cmp.Or(split.Rows, 100000)
+		split.Template = *prefix + "-{seq}.csv"
+	case *n > 0:
+		split.Template = *prefix + "-{key}-{seq}.csv"
+	default:
+		split.Template = *prefix + "-{key}.csv"
+	}
+	stream, err := in.stream(env, fs)
+	if err != nil {
+		return err
+	}
+	opts, err := in.options()
+	if err != nil {
+		return err
+	}
+	if !in.noHeader {
+		opts = append(opts, bigcsv.WithHeaders())
+	}
+	files, err := bigcsv.Split(context.Background(), stream, split, opts...)
+	for _, name := range files {
+		fmt.Fprintln(env.stdout, name)
+	}
+	return err
+}
+
+// cmdTop prints the most frequent values of a column with their counts,
+// estimated in bounded memory.
+func cmdTop(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "top")
+	column := fs.String("c", "", "column to count")
+	k := fs.Int("k", 10, "number of values to print")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	counter, err := countValues(env, in, fs, *column, *k, 0)
+	if err != nil {
+		return err
+	}
+	w := newWriter(env.stdout, ',')
+	w.Write([]string{"value", "count"})
+	for _, vc := range counter.Top() {
+		w.Write([]string{vc.Value, strconv.FormatInt(vc.Count, 10)})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// cmdDistinct prints the distinct values of a column in sorted order, failing
+// with an estimate of their number if there are too many.
+func cmdDistinct(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "distinct")
+	column := fs.String("c", "", "column to list")
+	maxValues := fs.Int("max", 100000, "maximum number of distinct values")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	counter, err := countValues(env, in, fs, *column, 1, *maxValues)
+	if err != nil {
+		return err
+	}
+	values, ok := counter.Values()
+	if !ok {
+		return fmt.Errorf("more than %d distinct values, about %d", *maxValues, counter.Distinct())
+	}
+	w := newWriter(env.stdout, ',')
+	for _, v := range values {
+		w.Write([]string{v})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// cmdSample prints a uniform random sample of n rows, keeping their order.
+func cmdSample(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "sample")
+	n := fs.Int("n", 100000, "number of rows to sample")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	stream, err := in.stream(env, fs)
+	if err != nil {
+		return err
+	}
+	opts, err := in.options()
+	if err != nil {
+		return err
+	}
+	if !in.noHeader {
+		opts = append(opts, bigcsv.WithHeaders())
+	}
+	return bigcsv.Sample(context.Background(), stream, env.stdout, *n, opts...)
+}
+
+// countValues counts the values of the column of the source, see
+// bigcsv.CountValues.
+func countValues(env *env, in *input, fs *flag.FlagSet, column string, k, maxDistinct int) (*bigcsv.ValueCounter, error) {
+	if column == "" || in.noHeader {
+		return nil, fmt.Errorf("%s: a column of the header row is required, see -c", fs.Name())
+	}
+	stream, err := in.stream(env, fs)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := in.options()
+	if err != nil {
+		return nil, err
+	}
+	return bigcsv.CountValues(context.Background(), stream, column, k, maxDistinct, opts...)
+}
+
+// newWriter creates a CSV writer with the given delimiter.
+func newWriter(w io.Writer, comma rune) *csv.Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	return cw
+}
+
+// cmdPipeline runs the pipeline described by a JSON file, or a YAML file by
+// its .yaml or .yml extension, reporting rejected rows. It fails if any row was rejected.
+func cmdPipeline(env *env, args []string) error {
+	fs := flag.NewFlagSet("pipeline", flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("pipeline: expected exactly one spec, got %d", fs.NArg())
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	load := bigcsv.LoadPipeline
+	if ext := filepath.Ext(fs.Arg(0)); ext == ".yaml" || ext == ".yml" {
+		load = bigcsv.LoadPipelineYAML
+	}
+	pl, err := load(f)
+	if err != nil {
+		return err
+	}
+	pl.OnError = func(err error) {
+		fmt.Fprintln(env.stderr, err)
+	}
+	stats, err := pl.Run(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(env.stdout, "%d rows read, %d rows rejected\n", stats.Rows, stats.Errors())
+	if stats.Errors() > 0 {
+		return fmt.Errorf("rejected %d rows", stats.Errors())
+	}
+	return nil
+}
+
+// cmdGenerate writes Go code for the schema in a JSON file, see
+// bigcsv.Schema.Generate.
+func cmdGenerate(env *env, args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	pkg := fs.String("package", "main", "package of the generated code")
+	typeName := fs.String("type", "Row", "name of the generated struct")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("generate: expected exactly one schema, got %d", fs.NArg())
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	schema, err := bigcsv.LoadSchema(f)
+	if err != nil {
+		return err
+	}
+	return schema.Generate(env.stdout, *pkg, *typeName)
+}
+
+// cmdInfer profiles the source, writing the inferred schema in the given
+// format: a bigcsv schema for generate, a JSON Schema, an Avro schema or an
+// SQL CREATE TABLE statement.
+func cmdInfer(env *env, args []string) error {
+	in := &input{}
+	fs := in.flags(env, "infer")
+	format := fs.String("format", "json", "output format: json, jsonschema, avro or sql")
+	name := fs.String("name", "data", "name of the Avro record or SQL table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	switch *format {
+	case "json", "jsonschema", "avro", "sql":
+	default:
+		return fmt.Errorf("unknown format '%s'", *format)
+	}
+	in.extra = append(in.extra, bigcsv.WithProfile())
+	p, err := in.parser(env, fs)
+	if err != nil {
+		return err
+	}
+	p.OnData = func([]string) error { return nil }
+	if err = p.Run(context.Background(), 1); err != nil {
+		return err
+	}
+	schema := bigcsv.NewProfileReport(p.Stats().Profile).Schema()
+	switch *format {
+	case "jsonschema":
+		return schema.WriteJSONSchema(env.stdout)
+	case "avro":
+		return schema.WriteAvroSchema(env.stdout, *name)
+	case "sql":
+		return schema.WriteSQL(env.stdout, *name)
+	}
+	enc := json.NewEncoder(env.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+// columnIndex resolves a column name, or a 1-based column number if the
+// input has no header row.
+func columnIndex(p *bigcsv.RowParser, name string) (int, error) {
+	if p.Headers() == nil {
+		n, err := strconv.Atoi(name)
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid column number '%s'", name)
+		}
+		return n - 1, nil
+	}
+	ix, ok := p.ColumnIndex(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown column '%s'", name)
+	}
+	return ix, nil
+}
+
+// writeJSONLine writes the row as a JSON object, keeping the column order.
+// Without headers, the 1-based column numbers are the keys.
+func writeJSONLine(w *bufio.Writer, headers, row []string) error {
+	w.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		key := strconv.Itoa(i + 1)
+		if i < len(headers) {
+			key = headers[i]
+		}
+		k, _ := json.Marshal(key)
+		s, _ := json.Marshal(v)
+		w.Write(k)
+		w.WriteByte(':')
+		w.Write(s)
+	}
+	w.WriteByte('}')
+	return w.WriteByte('\n')
+}
@@ -0,0 +1,161 @@
+// Command bigcsv offers streaming inspection and conversion of large CSV files,
+// built on the bigcsv package.
+//
+// Usage:
+//
+//	bigcsv <command> [flags] <source>
+//
+// The source is a file path (*.gz is decompressed), an http(s) URL, or "-" for
+// standard input. Commands:
+//
+//	head      print the first rows
+//	count     count the records
+//	validate  report rows which cannot be read
+//	convert   convert between csv, tsv and jsonl
+//	select    print the given columns
+//	filter    print the rows matching an expression
+//	split     split into files of at most n records, or by a key column
+//	top       print the most frequent values of a column
+//	distinct  print the distinct values of a column
+//	sample    print a random sample of n rows
+//	pipeline  run a pipeline described by a JSON or YAML file, see bigcsv.PipelineSpec
+//	infer     write the schema inferred from the source as JSON, JSON Schema,
+//	          Avro or SQL
+//	generate  write Go code for a JSON schema, see bigcsv.Schema
+//
+// Run "bigcsv <command> -h" for the flags of a command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/typeduck/bigcsv"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "bigcsv:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// command runs a subcommand with its arguments.
+type command func(env *env, args []string) error
+
+var commands = map[string]command{
+	"head":     cmdHead,
+	"count":    cmdCount,
+	"validate": cmdValidate,
+	"convert":  cmdConvert,
+	"select":   cmdSelect,
+	"filter":   cmdFilter,
+	"split":    cmdSplit,
+	"top":      cmdTop,
+	"distinct": cmdDistinct,
+	"sample":   cmdSample,
+	"pipeline": cmdPipeline,
+	"infer":    cmdInfer,
+	"generate": cmdGenerate,
+}
+
+// env holds the standard streams, so commands can be tested.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// run dispatches to the subcommand named by the first argument.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command, one of: head, count, validate, convert, select, filter, split, top, distinct, sample, pipeline, infer, generate")
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command '%s'", args[0])
+	}
+	return cmd(&env{stdin, stdout, stderr}, args[1:])
+}
+
+// input holds the flags shared by all commands describing the source.
+type input struct {
+	delimiter string
+	skip      int
+	noHeader  bool
+
+	// extra are options added by the command.
+	extra []bigcsv.Option
+}
+
+// flags creates the flag set of a command, registering the input flags.
+func (in *input) flags(env *env, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.StringVar(&in.delimiter, "d", ",", `input field delimiter, "tab" for tabs`)
+	fs.IntVar(&in.skip, "skip", 0, "number of lines to skip before the header")
+	fs.BoolVar(&in.noHeader, "no-header", false, "the input has no header row")
+	return fs
+}
+
+// options translates the input flags to Parser options. The header row is
+// left to the commands.
+func (in *input) options() ([]bigcsv.Option, error) {
+	comma, err := parseDelimiter(in.delimiter)
+	if err != nil {
+		return nil, err
+	}
+	opts := []bigcsv.Option{bigcsv.WithComma(comma), bigcsv.WithSkipRows(in.skip)}
+	return append(opts, in.extra...), nil
+}
+
+// stream returns the stream for the single source argument of fs.
+func (in *input) stream(env *env, fs *flag.FlagSet) (bigcsv.Stream, error) {
+	if fs.NArg() != 1 {
+		return nil, fmt.Errorf("%s: expected exactly one source, got %d", fs.Name(), fs.NArg())
+	}
+	src := fs.Arg(0)
+	switch {
+	case src == "-":
+		return bigcsv.ReadStream(env.stdin), nil
+	case strings.HasPrefix(src, "http://") || strings.HasPrefix(src, "https://"):
+		return bigcsv.HTTPStream(src), nil
+	default:
+		return bigcsv.FileStream(src), nil
+	}
+}
+
+// parser creates a RowParser for the source, with the header row consumed
+// unless -no-header was given.
+func (in *input) parser(env *env, fs *flag.FlagSet) (*bigcsv.RowParser, error) {
+	stream, err := in.stream(env, fs)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := in.options()
+	if err != nil {
+		return nil, err
+	}
+	if !in.noHeader {
+		opts = append(opts, bigcsv.WithHeaders())
+	}
+	return bigcsv.NewRowParser(stream, opts...)
+}
+
+// parseDelimiter accepts a single character or "tab".
+func parseDelimiter(s string) (rune, error) {
+	if s == "tab" || s == `\t` {
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("invalid delimiter '%s'", s)
+	}
+	return r[0], nil
+}
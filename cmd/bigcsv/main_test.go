@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const places = "name,state,population\nAlpha,CA,12000\nBeta,NY,500\nGamma,CA,800\n"
+
+// runWith runs the command with the CSV on standard input and returns the
+// output.
+func runWith(t *testing.T, csv string, args ...string) string {
+	t.Helper()
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := run(args, strings.NewReader(csv), stdout, stderr); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, stderr)
+	}
+	return stdout.String()
+}
+
+// TestCommands checks the output of the commands.
+func TestCommands(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"head", "-n", "1", "-"}, "name,state,population\nAlpha,CA,12000\n"},
+		{[]string{"count", "-"}, "3\n"},
+		{[]string{"validate", "-"}, "3 valid rows, 0 invalid rows\n"},
+		{[]string{"select", "-c", "population,name", "-"}, "population,name\n12000,Alpha\n500,Beta\n800,Gamma\n"},
+		{[]string{"filter", "-e", "state == 'CA' && population > 1000", "-"}, "name,state,population\nAlpha,CA,12000\n"},
+		{[]string{"convert", "-to", "tsv", "-"}, strings.ReplaceAll(places, ",", "\t")},
+		{[]string{"convert", "-to", "csv", "-quote", "non-numeric", "-crlf", "-"}, "\"name\",\"state\",\"population\"\r\n" +
+			"\"Alpha\",\"CA\",12000\r\n\"Beta\",\"NY\",500\r\n\"Gamma\",\"CA\",800\r\n"},
+		{[]string{"convert", "-to", "jsonl", "-"}, `{"name":"Alpha","state":"CA","population":"12000"}` + "\n" +
+			`{"name":"Beta","state":"NY","population":"500"}` + "\n" +
+			`{"name":"Gamma","state":"CA","population":"800"}` + "\n"},
+	} {
+		if got := runWith(t, places, tc.args...); got != tc.want {
+			t.Errorf("%v: got %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+// TestConvertRoundTrip converts CSV to jsonl and back.
+func TestConvertRoundTrip(t *testing.T) {
+	jsonl := runWith(t, places, "convert", "-to", "jsonl", "-")
+	if got := runWith(t, jsonl, "convert", "-from", "jsonl", "-to", "csv", "-"); got != places {
+		t.Fatalf("Round trip changed the CSV: %q", got)
+	}
+}
+
+// TestSplit checks splitting into files with repeated headers.
+func TestSplit(t *testing.T) {
+	prefix := filepath.Join(t.TempDir(), "part")
+	runWith(t, places, "split", "-n", "2", "-o", prefix, "-")
+	b, err := os.ReadFile(prefix + "-0002.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "name,state,population\nGamma,CA,800\n" {
+		t.Fatalf("Unexpected second file: %q", b)
+	}
+
+	got := runWith(t, places, "split", "-key", "state", "-o", prefix, "-")
+	if got != prefix+"-CA.csv\n"+prefix+"-NY.csv\n" {
+		t.Fatalf("Unexpected files: %q", got)
+	}
+	if b, err = os.ReadFile(prefix + "-CA.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "name,state,population\nAlpha,CA,12000\nGamma,CA,800\n" {
+		t.Fatalf("Unexpected file of CA: %q", b)
+	}
+}
+
+// TestTopDistinct counts the values of a column.
+func TestTopDistinct(t *testing.T) {
+	if got := runWith(t, places, "top", "-c", "state", "-k", "1", "-"); got != "value,count\nCA,2\n" {
+		t.Errorf("Unexpected top values: %q", got)
+	}
+	if got := runWith(t, places, "distinct", "-c", "state", "-"); got != "CA\nNY\n" {
+		t.Errorf("Unexpected distinct values: %q", got)
+	}
+	err := run([]string{"distinct", "-c", "name", "-max", "2", "-"}, strings.NewReader(places), &bytes.Buffer{}, &bytes.Buffer{})
+	if err == nil || !strings.Contains(err.Error(), "more than 2 distinct values, about 3") {
+		t.Errorf("Expected too many distinct values, got: %v", err)
+	}
+}
+
+// TestSample samples rows, copying short inputs.
+func TestSample(t *testing.T) {
+	if got := runWith(t, places, "sample", "-n", "5", "-"); got != places {
+		t.Errorf("Expected all rows, got %q", got)
+	}
+	if got := runWith(t, places, "sample", "-n", "1", "-"); strings.Count(got, "\n") != 2 || !strings.HasPrefix(got, "name,state,population\n") {
+		t.Errorf("Expected the header and a row, got %q", got)
+	}
+}
+
+// TestValidateFails checks that invalid rows fail validation.
+func TestValidateFails(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := run([]string{"validate", "-"}, strings.NewReader("a,b\n1,2\n3\n"), stdout, stderr)
+	if err == nil || !strings.Contains(stderr.String(), "wrong number of fields") {
+		t.Fatalf("Expected validation failure, got %v: %s", err, stderr)
+	}
+	err = run([]string{"validate", "-strict", "-"}, strings.NewReader("a,b\n1,2,\n"), stdout, stderr)
+	if err == nil || !strings.Contains(err.Error(), "line 2, column 4: trailing delimiter") {
+		t.Fatalf("Expected a conformance error, got %v", err)
+	}
+}
+
+// TestPipeline runs a pipeline spec, converting places to JSON lines.
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	in, out, spec := filepath.Join(dir, "places.csv"), filepath.Join(dir, "out.jsonl"), filepath.Join(dir, "spec.json")
+	if err := os.WriteFile(in, []byte(places), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	json := `{"source": {"path": "` + in + `"}, "sink": {"path": "` + out + `", "format": "jsonl"},
+		"columns": [{"name": "name"}, {"name": "pop", "from": "population", "type": "integer", "min": 600}]}`
+	if err := os.WriteFile(spec, []byte(json), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	err := run([]string{"pipeline", spec}, nil, stdout, stderr)
+	if err == nil || stdout.String() != "3 rows read, 1 rows rejected\n" || !strings.Contains(stderr.String(), "out of range") {
+		t.Fatalf("Expected one rejected row, got %v: %s%s", err, stdout, stderr)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"Alpha","pop":12000}`+"\n"+`{"name":"Gamma","pop":800}`+"\n" {
+		t.Fatalf("Unexpected output: %s", b)
+	}
+}
+
+// TestGenerate writes Go code for a schema.
+func TestGenerate(t *testing.T) {
+	schema := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schema, []byte(`{"columns": [{"name": "name"}, {"name": "population", "type": "integer"}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got := runWith(t, "", "generate", "-package", "places", "-type", "Place", schema)
+	if !strings.HasPrefix(got, "// Code generated by bigcsv") || !strings.Contains(got, "func ParsePlace(row []string) (Place, error) {") {
+		t.Fatalf("Unexpected output:\n%s", got)
+	}
+}
+
+// TestInfer writes the schema inferred from the input as SQL.
+func TestInfer(t *testing.T) {
+	got := runWith(t, places, "infer", "-format", "sql", "-name", "places", "-")
+	want := "CREATE TABLE \"places\" (\n  \"name\" TEXT NOT NULL,\n  \"state\" TEXT NOT NULL,\n  \"population\" BIGINT NOT NULL\n);\n"
+	if got != want {
+		t.Fatalf("Got %q, want %q", got, want)
+	}
+}
+
+// lastRead serves a CSV, recording whether it is read past its end.
+type lastRead struct {
+	r      *strings.Reader
+	ranOut bool
+}
+
+func (l *lastRead) Read(p []byte) (int, error) {
+	if l.r.Len() == 0 {
+		l.ranOut = true
+	}
+	return l.r.Read(p)
+}
+
+// TestHeadStops checks that head reads no rows beyond the limit.
+func TestHeadStops(t *testing.T) {
+	for n, csv := range []string{"name\n", "name\nAlpha\n"} {
+		stdin := &lastRead{r: strings.NewReader(csv)}
+		stdout := &bytes.Buffer{}
+		if err := run([]string{"head", "-n", strconv.Itoa(n), "-"}, stdin, stdout, &bytes.Buffer{}); err != nil {
+			t.Fatal(err)
+		}
+		if stdout.String() != csv || stdin.ranOut {
+			t.Errorf("head -n %d printed %q, reading past the limit: %t", n, stdout, stdin.ranOut)
+		}
+	}
+}
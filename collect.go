@@ -0,0 +1,79 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Collect parses all records of the stream into a slice. It is meant for
+// small and medium files, or tests, where setting up a Parser is overkill.
+//
+// The first error stops collecting and is returned along with the records
+// parsed so far.
+func Collect[T any](ctx context.Context, stream Stream, parse func(row []string) (T, error), opts ...Option) ([]T, error) {
+	return CollectN(ctx, stream, parse, -1, opts...)
+}
+
+// CollectN is like Collect, but stops after n records. A negative n collects
+// all records.
+func CollectN[T any](ctx context.Context, stream Stream, parse func(row []string) (T, error), n int, opts ...Option) ([]T, error) {
+	if parse == nil {
+		return nil, fmt.Errorf("cannot collect without parse function")
+	}
+	p, err := New[T](stream, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer p.close()
+	p.Parse = parse
+	var records []T
+	if n == 0 {
+		return records, nil
+	}
+	for data, err := range p.Rows(ctx) {
+		if err != nil {
+			return records, err
+		}
+		records = append(records, data)
+		if len(records) == n {
+			break
+		}
+	}
+	return records, ctx.Err()
+}
+
+// Count returns the number of records in the stream, not counting rows
+// consumed or dropped by options such as WithHeaders, WithSkipBlankLines,
+// WithFooter or WithTrailer.
+func Count(ctx context.Context, stream Stream, opts ...Option) (int64, error) {
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer p.close()
+	if err = p.prepare(); err != nil {
+		return 0, err
+	}
+	if p.Reader != nil {
+		p.Reader.ReuseRecord = true
+	}
+	var n int64
+	for ; ctx.Err() == nil; n++ {
+		// Read like Run does, dropping blank lines, footers and trailers.
+		if rec := p.read(); errors.Is(rec.err, io.EOF) {
+			return n, nil
+		} else if rec.err != nil {
+			return n, fmt.Errorf("could not read line #%d: %w", rec.line, rec.err)
+		}
+	}
+	return n, ctx.Err()
+}
+
+// Head returns the first n raw records of the stream.
+func Head(ctx context.Context, stream Stream, n int, opts ...Option) ([][]string, error) {
+	return CollectN(ctx, stream, func(row []string) ([]string, error) {
+		return row, nil
+	}, n, opts...)
+}
@@ -0,0 +1,69 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestCollect checks collecting all records, the first n, and stopping at an
+// error.
+func TestCollect(t *testing.T) {
+	ctx := context.Background()
+	numbers, err := bigcsv.Collect(ctx, bigcsv.ReadStream(strings.NewReader("1,one\n2,two\n3,three\n")), ParseNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 || numbers[2].String != "three" {
+		t.Fatalf("Unexpected records: %+v", numbers)
+	}
+
+	numbers, err = bigcsv.CollectN(ctx, bigcsv.ReadStream(strings.NewReader("1,one\n2,two\n3,three\n")), ParseNumber, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 2 {
+		t.Fatalf("Collected %d records, expected 2", len(numbers))
+	}
+
+	numbers, err = bigcsv.Collect(ctx, bigcsv.ReadStream(strings.NewReader("1,one\nx,two\n3,three\n")), ParseNumber)
+	if !errors.Is(err, bigcsv.ErrParse) || len(numbers) != 1 {
+		t.Fatalf("Expected 1 record and a parse error, got %+v, %v", numbers, err)
+	}
+}
+
+// TestCountAndHead checks the quick inspection helpers.
+func TestCountAndHead(t *testing.T) {
+	ctx := context.Background()
+	csv := "int,name\n1,one\n2,two\n3,three\n"
+	n, err := bigcsv.Count(ctx, bigcsv.ReadStream(strings.NewReader(csv)), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("Counted %d records, expected 3", n)
+	}
+	rows, err := bigcsv.Head(ctx, bigcsv.ReadStream(strings.NewReader(csv)), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0][0] != "int" || rows[1][1] != "one" {
+		t.Fatalf("Unexpected rows: %v", rows)
+	}
+}
+
+// TestCountSkipped checks that Count drops blank lines and comments like Run.
+func TestCountSkipped(t *testing.T) {
+	csv := "id\n1\n\n# note\n2\n,\n"
+	n, err := bigcsv.Count(context.Background(), bigcsv.ReadStream(strings.NewReader(csv)),
+		bigcsv.WithHeaders(), bigcsv.WithSkipBlankLines(), bigcsv.WithCommentPrefix("#"), bigcsv.WithFieldsPerRecord(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Counted %d records, expected 2", n)
+	}
+}
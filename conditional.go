@@ -0,0 +1,121 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrNotModified is returned when opening a ConditionalHTTPStream whose file
+// has not changed since it was last read.
+var ErrNotModified = errors.New("not modified")
+
+// ConditionalHTTPStream provides a CSV via HTTP(s) like HTTPStream, but only if
+// it changed since it was last read, so scheduled jobs skip downloading
+// unchanged files:
+//
+//	stream := bigcsv.ConditionalHTTPStream{URL: url, Store: bigcsv.FileCheckpointStore("state")}
+//	parser, err := bigcsv.New[Place](stream, bigcsv.WithHeaders())
+//	if errors.Is(err, bigcsv.ErrNotModified) {
+//		return nil // nothing new
+//	}
+//
+// The ETag and Last-Modified validators of the response are saved as a
+// Checkpoint in Store when the stream is closed after reading it to the end.
+// A Run stopping early thus downloads the file again next time. Servers not
+// supporting conditional requests always send the file.
+type ConditionalHTTPStream struct {
+	URL   string
+	Store CheckpointStore
+
+	// Key of the validators in Store, the URL if empty. It must not be the
+	// key of WithCheckpoint.
+	Key string
+
+	// Context is used for the request and Store, context.Background if nil.
+	Context context.Context
+}
+
+func (s ConditionalHTTPStream) Open() (io.ReadCloser, error) {
+	ctx := s.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	key := s.Key
+	if key == "" {
+		key = s.URL
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", s.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	last, err := s.Store.Load(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("could not load validators: %w", err)
+	}
+	if last != nil && last.Stream == s.URL {
+		if last.ETag != "" {
+			req.Header.Set("If-None-Match", last.ETag)
+		}
+		if last.LastModified != "" {
+			req.Header.Set("If-Modified-Since", last.LastModified)
+		}
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not request: %w", err)
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		res.Body.Close()
+		return nil, fmt.Errorf("%s: %w", s.URL, ErrNotModified)
+	default:
+		res.Body.Close()
+		return nil, fmt.Errorf("could not request %s: %s", s.URL, res.Status)
+	}
+
+	body, err := responseBody(s.URL, res)
+	if err != nil {
+		return nil, err
+	}
+	cp := Checkpoint{
+		Stream:       s.URL,
+		ETag:         res.Header.Get("ETag"),
+		LastModified: res.Header.Get("Last-Modified"),
+	}
+	if cp.ETag == "" && cp.LastModified == "" {
+		return body, nil
+	}
+	return &validatedBody{ReadCloser: body, save: func() error {
+		return s.Store.Save(ctx, key, cp)
+	}}, nil
+}
+
+// validatedBody saves the validators of a response when closed after reading
+// it to the end.
+type validatedBody struct {
+	io.ReadCloser
+	save func() error
+	eof  bool
+}
+
+func (b *validatedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *validatedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.eof {
+		if serr := b.save(); serr != nil {
+			err = errors.Join(err, fmt.Errorf("could not save validators: %w", serr))
+		}
+	}
+	return err
+}
@@ -0,0 +1,53 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestConditionalHTTPStream checks that an unchanged file is only downloaded
+// once.
+func TestConditionalHTTPStream(t *testing.T) {
+	etag := `"v1"`
+	downloads := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", etag)
+		io.WriteString(w, "1,one\n2,two\n")
+	}))
+	defer server.Close()
+
+	stream := bigcsv.ConditionalHTTPStream{URL: server.URL, Store: bigcsv.FileCheckpointStore(t.TempDir())}
+	run := func() error {
+		parser, err := bigcsv.New[Number](stream)
+		if err != nil {
+			return err
+		}
+		parser.Parse = ParseNumber
+		parser.OnData = func(Number) error { return nil }
+		return parser.Run(context.Background(), 1)
+	}
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if err := run(); !errors.Is(err, bigcsv.ErrNotModified) {
+		t.Fatalf("Expected ErrNotModified, got: %v", err)
+	}
+	etag = `"v2"`
+	if err := run(); err != nil {
+		t.Fatal(err)
+	}
+	if downloads != 2 {
+		t.Fatalf("Downloaded %d times, expected 2", downloads)
+	}
+}
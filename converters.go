@@ -0,0 +1,73 @@
+package bigcsv
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Converter converts a field to a value of a Go type for the struct mapper,
+// see ConvertFunc.
+type Converter struct {
+	typ reflect.Type
+	fn  func(s string) (any, error)
+}
+
+// ConvertFunc creates a Converter to type V. Registered with RegisterConverter
+// or WithConverter, it is used for all struct fields of type V, taking
+// precedence over the built-in conversions.
+//
+//	bigcsv.RegisterConverter(bigcsv.ConvertFunc(netip.ParseAddr))
+func ConvertFunc[V any](fn func(s string) (V, error)) Converter {
+	return Converter{
+		typ: reflect.TypeFor[V](),
+		fn: func(s string) (any, error) {
+			return fn(s)
+		},
+	}
+}
+
+// registry maps types to their converters.
+type registry map[reflect.Type]Converter
+
+// converters is the package-level registry, see RegisterConverter.
+var converters = struct {
+	sync.RWMutex
+	registry
+}{registry: registry{}}
+
+// RegisterConverter registers a converter for all Parsers, replacing any
+// converter registered before for the same type. It is meant to be called
+// during initialization, e.g. from an init function.
+func RegisterConverter(c Converter) {
+	converters.Lock()
+	defer converters.Unlock()
+	converters.registry[c.typ] = c
+}
+
+// WithConverter registers a converter for the Parser only, taking precedence
+// over converters registered with RegisterConverter.
+func WithConverter(c Converter) Option {
+	return func(cfg *config) error {
+		if c.fn == nil {
+			return fmt.Errorf("invalid converter")
+		}
+		if cfg.converters == nil {
+			cfg.converters = registry{}
+		}
+		cfg.converters[c.typ] = c
+		return nil
+	}
+}
+
+// converter looks up the converter for a type, first in the Parser's
+// registry, then in the package-level one.
+func (cfg *config) converter(typ reflect.Type) (Converter, bool) {
+	if c, ok := cfg.converters[typ]; ok {
+		return c, true
+	}
+	converters.RLock()
+	defer converters.RUnlock()
+	c, ok := converters.registry[typ]
+	return c, ok
+}
@@ -0,0 +1,188 @@
+package bigcsv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Special layouts of a DateFormat for Unix timestamps.
+const (
+	LayoutEpochSeconds = "epoch"
+	LayoutEpochMillis  = "epochms"
+)
+
+// DateFormat parses dates written in any of several layouts, such as
+// "31/12/2024", "Dec 31, 2024" or Unix timestamps, normalizing them into a
+// time zone.
+type DateFormat struct {
+	// Layouts are tried in order, see time.Parse and LayoutEpochSeconds.
+	Layouts []string
+
+	// Months maps lower-case month names of a locale to the months, e.g.
+	// "dezember" and "dez." to time.December. Words found in it are replaced
+	// by the English month name, so layouts must use "January" for them.
+	Months map[string]time.Month
+
+	// Location is assumed for values without a time zone, UTC if nil.
+	Location *time.Location
+
+	// Zone is the time zone results are converted to. If nil, they are
+	// kept in the zone they were given in.
+	Zone *time.Location
+}
+
+// Common date formats. Use their names with the `csv` tag option "date" of
+// struct fields, e.g. `csv:"Datum,date=eu"`.
+var (
+	// DateFormatISO ("iso") accepts RFC 3339 timestamps, and dates with or
+	// without a time.
+	DateFormatISO = DateFormat{Layouts: []string{time.RFC3339Nano, time.DateTime, "2006-01-02T15:04:05", time.DateOnly}}
+
+	// DateFormatUS ("us") accepts month-first dates like "12/31/2024" and
+	// "Dec 31, 2024".
+	DateFormatUS = DateFormat{Layouts: []string{"1/2/2006 15:04:05", "1/2/2006 15:04", "1/2/2006", "Jan 2, 2006", "January 2, 2006", "2006-01-02"}}
+
+	// DateFormatEU ("eu") accepts day-first dates like "31/12/2024",
+	// "31.12.2024" and "31 Dec 2024".
+	DateFormatEU = DateFormat{Layouts: []string{"2/1/2006 15:04:05", "2/1/2006 15:04", "2/1/2006", "2.1.2006 15:04:05", "2.1.2006 15:04", "2.1.2006", "2 Jan 2006", "2 January 2006", "2006-01-02"}}
+
+	// DateFormatEpoch ("epoch") accepts Unix timestamps in seconds.
+	DateFormatEpoch = DateFormat{Layouts: []string{LayoutEpochSeconds}}
+)
+
+// dateFormats are the formats known by name.
+var dateFormats = map[string]DateFormat{
+	"iso":   DateFormatISO,
+	"us":    DateFormatUS,
+	"eu":    DateFormatEU,
+	"epoch": DateFormatEpoch,
+}
+
+// WithDateFormat names a date format for the `csv` tag option "date" of struct
+// fields, in addition to the common ones ("iso", "us", "eu", "epoch").
+func WithDateFormat(name string, f DateFormat) Option {
+	return func(cfg *config) error {
+		if name == "" || len(f.Layouts) == 0 {
+			return fmt.Errorf("invalid date format %q", name)
+		}
+		if cfg.dateFormats == nil {
+			cfg.dateFormats = map[string]DateFormat{}
+		}
+		cfg.dateFormats[name] = f
+		return nil
+	}
+}
+
+// dateFormat looks up a date format by name, first in the Parser's formats,
+// then in the common ones.
+func (cfg *config) dateFormat(name string) (DateFormat, bool) {
+	if f, ok := cfg.dateFormats[name]; ok {
+		return f, true
+	}
+	f, ok := dateFormats[name]
+	return f, ok
+}
+
+// ParseTime parses a date, returning the layout which matched for
+// diagnostics. The error lists the layouts tried.
+func (f DateFormat) ParseTime(s string) (time.Time, string, error) {
+	s = f.translate(strings.TrimSpace(s))
+	loc := f.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, layout := range f.Layouts {
+		var t time.Time
+		var err error
+		switch layout {
+		case LayoutEpochSeconds, LayoutEpochMillis:
+			var n int64
+			if n, err = strconv.ParseInt(s, 10, 64); err != nil {
+				continue
+			}
+			if layout == LayoutEpochSeconds {
+				t = time.Unix(n, 0)
+			} else {
+				t = time.UnixMilli(n)
+			}
+			t = t.In(loc)
+		default:
+			if t, err = time.ParseInLocation(layout, s, loc); err != nil {
+				continue
+			}
+		}
+		if f.Zone != nil {
+			t = t.In(f.Zone)
+		}
+		return t, layout, nil
+	}
+	return time.Time{}, "", fmt.Errorf("date %q matches none of the layouts %q", s, f.Layouts)
+}
+
+// FormatTime writes a time in the first layout of the format, converted to
+// Zone if set. Month names are written in English.
+func (f DateFormat) FormatTime(t time.Time) string {
+	if f.Zone != nil {
+		t = t.In(f.Zone)
+	}
+	switch layout := f.Layouts[0]; layout {
+	case LayoutEpochSeconds:
+		return strconv.FormatInt(t.Unix(), 10)
+	case LayoutEpochMillis:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	default:
+		return t.Format(layout)
+	}
+}
+
+// translate replaces the month names of the locale by English ones.
+func (f DateFormat) translate(s string) string {
+	if len(f.Months) == 0 {
+		return s
+	}
+	var sb strings.Builder
+	word := -1 // start of the current word
+	flush := func(end int) {
+		if word < 0 {
+			return
+		}
+		if m, ok := f.Months[strings.ToLower(s[word:end])]; ok {
+			sb.WriteString(m.String())
+		} else {
+			sb.WriteString(s[word:end])
+		}
+		word = -1
+	}
+	for i, r := range s {
+		if unicode.IsLetter(r) || r == '.' && word >= 0 {
+			if word < 0 {
+				word = i
+			}
+			continue
+		}
+		flush(i)
+		sb.WriteRune(r)
+	}
+	flush(len(s))
+	return sb.String()
+}
+
+// dateSetter returns the conversion of a time.Time field in the named format.
+func (cfg *config) dateSetter(typ reflect.Type, name string) (setter, error) {
+	f, ok := cfg.dateFormat(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown date format %q", name)
+	}
+	if typ != timeType {
+		return nil, fmt.Errorf("date format for type %s", typ)
+	}
+	return func(s string, v reflect.Value) error {
+		t, _, err := f.ParseTime(s)
+		v.Set(reflect.ValueOf(t))
+		return err
+	}, nil
+}
@@ -0,0 +1,68 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestDateFormat checks trying layouts in order, month names of a locale and
+// normalizing the time zone.
+func TestDateFormat(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+	format := bigcsv.DateFormat{
+		Layouts:  []string{"2/1/2006", "2. January 2006", "Jan 2, 2006", bigcsv.LayoutEpochMillis},
+		Months:   map[string]time.Month{"dezember": time.December, "dez.": time.December},
+		Location: berlin,
+		Zone:     time.UTC,
+	}
+	for _, tc := range []struct {
+		in     string
+		layout string
+		want   time.Time
+	}{
+		{"31/12/2024", "2/1/2006", time.Date(2024, 12, 30, 23, 0, 0, 0, time.UTC)},
+		{"31. Dezember 2024", "2. January 2006", time.Date(2024, 12, 30, 23, 0, 0, 0, time.UTC)},
+		{"Dec 31, 2024", "Jan 2, 2006", time.Date(2024, 12, 30, 23, 0, 0, 0, time.UTC)},
+		{"1735689600000", bigcsv.LayoutEpochMillis, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		got, layout, err := format.ParseTime(tc.in)
+		if err != nil || layout != tc.layout || !got.Equal(tc.want) || got.Location() != time.UTC {
+			t.Errorf("Parsing %q: got %v with %q, %v", tc.in, got, layout, err)
+		}
+	}
+	if _, _, err := format.ParseTime("2024-12-31"); err == nil {
+		t.Error("Expected an error for an unknown layout")
+	}
+}
+
+// TestDateFormatTag checks selecting the date format per column.
+func TestDateFormatTag(t *testing.T) {
+	type Event struct {
+		Day     time.Time  `csv:"day,date=eu"`
+		Created *time.Time `csv:"created,date=epoch"`
+	}
+	parser, err := bigcsv.NewFromString[Event]("day,created\n31.12.2024,1735689600\n1/2/2025,\n",
+		bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []Event
+	for e, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 || !events[0].Day.Equal(time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)) || events[0].Created.Unix() != 1735689600 {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+	if events[1].Day.Month() != time.February || events[1].Created != nil {
+		t.Errorf("Unexpected events: %+v", events)
+	}
+}
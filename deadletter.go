@@ -0,0 +1,188 @@
+package bigcsv
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// deadLetterColumns precede the fields of the failed row in a dead-letter
+// file.
+var deadLetterColumns = []string{"line", "offset", "stage", "error"}
+
+// WithDeadLetter writes the rows failing in Run, Chan or DryRun to w, so they
+// can be fixed and processed again with Replay. The rows are written as CSV
+// with a header row: the columns line, offset, stage and error of the
+// *RowError, followed by the fields of the row as read, before any
+// preprocessing or changes by OnRow. Rows which could not be read have the
+// fields read so far, if any.
+//
+// Writing to w is serialized. Failing to write stops the Parser, returning
+// the error.
+func WithDeadLetter(w io.Writer) Option {
+	return func(cfg *config) error {
+		if w == nil {
+			return fmt.Errorf("invalid dead letter writer")
+		}
+		cfg.deadLetter = &deadLetter{w: csv.NewWriter(w)}
+		return nil
+	}
+}
+
+// deadLetter writes failed rows.
+type deadLetter struct {
+	mu     sync.Mutex
+	w      *csv.Writer
+	header bool // the header row was written
+}
+
+// write writes a failed row, preceded by the header row the first time.
+func (d *deadLetter) write(headers, row []string, err error) error {
+	entry := []string{"", "", "", err.Error()}
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		entry = []string{
+			strconv.Itoa(rowErr.Line),
+			strconv.FormatInt(rowErr.Offset, 10),
+			rowErr.Stage.Error(),
+			rowErr.Err.Error(),
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.header {
+		d.header = true
+		if err := d.w.Write(slices.Concat(deadLetterColumns, headers)); err != nil {
+			return err
+		}
+	}
+	if err := d.w.Write(slices.Concat(entry, row)); err != nil {
+		return err
+	}
+	d.w.Flush()
+	return d.w.Error()
+}
+
+// Replay processes the rows of a dead-letter file written with WithDeadLetter
+// in place of the stream, typically once the rows or the code processing them
+// were fixed. The rows pass OnRow, Parse and OnData as in Run, and errors are
+// reported with the line numbers and offsets of the original stream, so
+// failing again they can be traced back.
+//
+// With headers, the columns are taken from the dead-letter file. Options
+// concerning the layout of the stream, such as WithSkipRows, WithTrailer or
+// the csv.Reader settings, do not apply, and checkpoints are not saved. The
+// stream of the Parser is closed without being read, see Reset to process it
+// again. The dead letter of the Parser receives the rows failing again, so
+// use a separate Parser writing to a new file for a replay.
+func (p *Parser[T]) Replay(ctx context.Context, deadLetter io.Reader, workers int) (err error) {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	// The stream is closed anyway, so unlike begin this does not fail
+	// with ErrClosed.
+	if _, err := p.acquire(); err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := p.end(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+	if workers == 0 {
+		workers = p.cfg.workers
+	}
+	if workers < 1 {
+		return fmt.Errorf("invalid number of workers: %d", workers)
+	}
+	if err := p.close(); err != nil {
+		return fmt.Errorf("could not close stream: %w", err)
+	}
+
+	r := csv.NewReader(deadLetter)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil // nothing failed
+	} else if err != nil {
+		return fmt.Errorf("could not read dead letter header: %w", err)
+	}
+	if len(header) < len(deadLetterColumns) || !slices.Equal(header[:len(deadLetterColumns)], deadLetterColumns) {
+		return fmt.Errorf("not a dead letter: header %q", header)
+	}
+
+	// Process the entries like a stream without the layout options.
+	reader, cfg := p.Reader, p.cfg
+	defer func() {
+		p.Reader, p.cfg = reader, cfg
+	}()
+	replayCfg := *cfg
+	replayCfg.skipBlank, replayCfg.trailer, replayCfg.footer, replayCfg.checkpoint = false, nil, nil, nil
+	p.cfg = &replayCfg
+	p.Reader, p.records = nil, &deadLetterReader{r: r}
+	p.consumed, p.offsetBase, p.bodyRows, p.peeked, p.peekedEOF = 0, 0, 0, nil, false
+	p.prepared, p.prepareErr, p.inBody = true, nil, true
+	if p.cfg.headers {
+		if err := p.bindHeaders(header[len(deadLetterColumns):]); err != nil {
+			return err
+		}
+	}
+	if p.bind != nil {
+		if err := p.bind(); err != nil {
+			return err
+		}
+	}
+	return p.run(ctx, workers, p.onData(ctx), p.active.onDataAsync, p.active.onError)
+}
+
+// deadLetterReader reads the rows of a dead-letter file, providing their
+// original line numbers and offsets.
+type deadLetterReader struct {
+	r      *csv.Reader
+	next   []string // the entry read ahead by InputOffset
+	err    error
+	line   int
+	offset int64
+}
+
+func (d *deadLetterReader) Read() ([]string, error) {
+	d.InputOffset()
+	d.line = 0
+	entry, err := d.next, d.err
+	d.next, d.err = nil, nil
+	if err != nil {
+		return nil, err
+	}
+	if len(entry) < len(deadLetterColumns) {
+		return nil, fmt.Errorf("%w: dead letter entry with %d fields", ErrMalformedRecord, len(entry))
+	}
+	if d.line, err = strconv.Atoi(entry[0]); err != nil {
+		return nil, fmt.Errorf("%w: dead letter line %q", ErrMalformedRecord, entry[0])
+	}
+	return entry[len(deadLetterColumns):], nil
+}
+
+// InputOffset returns the original offset of the next entry, reading it
+// ahead.
+func (d *deadLetterReader) InputOffset() int64 {
+	if d.next == nil && d.err == nil {
+		d.next, d.err = d.r.Read()
+		d.offset = -1
+		if d.err == nil && len(d.next) > 1 {
+			if offset, err := strconv.ParseInt(d.next[1], 10, 64); err == nil {
+				d.offset = offset
+			}
+		}
+	}
+	return d.offset
+}
+
+// Line returns the original line number of the entry read last.
+func (d *deadLetterReader) Line() int {
+	return d.line
+}
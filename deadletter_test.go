@@ -0,0 +1,70 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestDeadLetter writes failing rows to a dead letter, replaying them with a
+// fixed Parse function.
+func TestDeadLetter(t *testing.T) {
+	input := "name,age\nalice,30\nbob,thirty\ncarol,40\ndave,-\n"
+	newParser := func(deadLetter *bytes.Buffer, parse func(string) (int, error)) (*bigcsv.Parser[int], *[]int) {
+		parser, err := bigcsv.NewFromString[int](input, bigcsv.WithHeaders(), bigcsv.WithDeadLetter(deadLetter))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ages []int
+		parser.Parse = func(row []string) (int, error) {
+			return parse(row[1])
+		}
+		parser.OnData = func(age int) error {
+			ages = append(ages, age)
+			return nil
+		}
+		return parser, &ages
+	}
+
+	first := &bytes.Buffer{}
+	parser, ages := newParser(first, strconv.Atoi)
+	if err := parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*ages, []int{30, 40}) {
+		t.Fatalf("Unexpected ages %v", *ages)
+	}
+	if !strings.HasPrefix(first.String(), "line,offset,stage,error,name,age\n3,18,Parse error,") {
+		t.Fatalf("Unexpected dead letter:\n%s", first)
+	}
+
+	// Fix the first row, the second still fails.
+	fixed := strings.Replace(first.String(), "bob,thirty", "bob,30", 1)
+	second := &bytes.Buffer{}
+	parser, ages = newParser(second, strconv.Atoi)
+	var lines []int
+	parser.OnError = func(err error) {
+		var rowErr *bigcsv.RowError
+		if errors.As(err, &rowErr) {
+			lines = append(lines, rowErr.Line)
+		}
+	}
+	if err := parser.Replay(context.Background(), strings.NewReader(fixed), 1); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*ages, []int{30}) || !slices.Equal(lines, []int{5}) {
+		t.Fatalf("Got ages %v, error lines %v", *ages, lines)
+	}
+	if !strings.HasPrefix(second.String(), "line,offset,stage,error,name,age\n5,38,Parse error,") {
+		t.Fatalf("Unexpected dead letter of the replay:\n%s", second)
+	}
+	if err := parser.Replay(context.Background(), strings.NewReader("a,b\n"), 1); err == nil {
+		t.Error("Expected an error for a file which is not a dead letter")
+	}
+}
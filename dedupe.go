@@ -0,0 +1,150 @@
+package bigcsv
+
+import (
+	"fmt"
+	"hash/maphash"
+	"math"
+	"sync"
+)
+
+// DedupeOptions sizes the Bloom filter of a Deduper.
+type DedupeOptions struct {
+	// Expected is the number of distinct keys expected.
+	Expected int
+
+	// FalsePositiveRate is the acceptable rate of unique records wrongly
+	// dropped as duplicates once Expected keys were seen, 1% if 0.
+	FalsePositiveRate float64
+
+	// MaxBytes bounds the memory of the filter, 0 for no bound. If the bound
+	// is lower than needed for the rate, the rate is higher.
+	MaxBytes int
+}
+
+// DedupeStats reports the records dropped by a Deduper.
+type DedupeStats struct {
+	// Records and Dropped count the records seen and dropped as duplicates.
+	Records int64
+	Dropped int64
+
+	// FalsePositives estimates how many of the dropped records were unique,
+	// an upper bound given the filter's fill over time.
+	FalsePositives float64
+
+	// FalsePositiveRate is the current rate of false positives.
+	FalsePositiveRate float64
+}
+
+// Deduper drops records whose key was seen before, using a Bloom filter, for
+// streams too large for an exact set of keys. It never passes on a duplicate,
+// but may drop a unique record at the configured rate. Its OnData is safe for
+// use with multiple workers.
+type Deduper[T any] struct {
+	mu     sync.Mutex
+	key    func(T) string
+	next   func(T) error
+	filter *bloomFilter
+	stats  DedupeStats
+}
+
+// Dedupe returns a Deduper passing records with a new key to next.
+//
+//	parser.OnData = bigcsv.Dedupe(func(o Order) string { return o.ID }, store, bigcsv.DedupeOptions{Expected: 1e8}).OnData
+func Dedupe[T any](key func(T) string, next func(T) error, opts DedupeOptions) (*Deduper[T], error) {
+	filter, err := newBloomFilter(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Deduper[T]{key: key, next: next, filter: filter}, nil
+}
+
+// OnData passes the record to next unless its key was seen before.
+func (d *Deduper[T]) OnData(data T) error {
+	if d.add(d.key(data)) {
+		return d.next(data)
+	}
+	return nil
+}
+
+// add adds the key to the filter, telling whether it was new.
+func (d *Deduper[T]) add(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.stats.Records++
+	d.stats.FalsePositives += d.filter.rate()
+	if !d.filter.add(key) {
+		d.stats.Dropped++
+		return false
+	}
+	return true
+}
+
+// Stats returns the records seen and dropped so far.
+func (d *Deduper[T]) Stats() DedupeStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s := d.stats
+	s.FalsePositives = min(s.FalsePositives, float64(s.Dropped))
+	s.FalsePositiveRate = d.filter.rate()
+	return s
+}
+
+// bloomFilter is a set of keys which may wrongly report a key as present.
+// It is not safe for concurrent use.
+type bloomFilter struct {
+	seed  maphash.Seed
+	bits  []uint64
+	k     int
+	added int64
+}
+
+// newBloomFilter sizes a Bloom filter according to the options.
+func newBloomFilter(opts DedupeOptions) (*bloomFilter, error) {
+	if opts.Expected < 1 {
+		return nil, fmt.Errorf("invalid number of expected keys: %d", opts.Expected)
+	}
+	p := opts.FalsePositiveRate
+	if p == 0 {
+		p = 0.01
+	}
+	if p <= 0 || p >= 1 {
+		return nil, fmt.Errorf("invalid false positive rate: %v", p)
+	}
+	n := float64(opts.Expected)
+	m := math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2))
+	if opts.MaxBytes > 0 {
+		m = min(m, float64(opts.MaxBytes)*8)
+	}
+	m = max(m, 64)
+	k := max(int(math.Round(m/n*math.Ln2)), 1)
+	return &bloomFilter{
+		seed: maphash.MakeSeed(),
+		bits: make([]uint64, int(m+63)/64),
+		k:    k,
+	}, nil
+}
+
+// add adds the key, telling whether it was new.
+func (f *bloomFilter) add(key string) bool {
+	h := maphash.String(f.seed, key)
+	h1, h2 := uint64(uint32(h)), h>>32|1
+	m := uint64(len(f.bits) * 64)
+	added := false
+	for i := uint64(0); i < uint64(f.k); i++ {
+		bit := (h1 + i*h2) % m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			f.bits[bit/64] |= 1 << (bit % 64)
+			added = true
+		}
+	}
+	if added {
+		f.added++
+	}
+	return added
+}
+
+// rate returns the current false positive rate.
+func (f *bloomFilter) rate() float64 {
+	m := float64(len(f.bits) * 64)
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.added)/m), float64(f.k))
+}
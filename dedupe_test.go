@@ -0,0 +1,32 @@
+package bigcsv_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestDedupe checks that duplicates are dropped and unique keys pass.
+func TestDedupe(t *testing.T) {
+	passed := 0
+	d, err := bigcsv.Dedupe(func(n Number) string { return n.String }, func(Number) error {
+		passed++
+		return nil
+	}, bigcsv.DedupeOptions{Expected: 1000, FalsePositiveRate: 0.001})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2000 {
+		if err = d.OnData(Number{Integer: i, String: fmt.Sprint(i % 1000)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := d.Stats()
+	if passed < 995 || passed > 1000 || stats.Records != 2000 || stats.Dropped != int64(2000-passed) {
+		t.Fatalf("Passed %d records, stats %+v", passed, stats)
+	}
+	if stats.FalsePositiveRate > 0.002 {
+		t.Fatalf("False positive rate is %v", stats.FalsePositiveRate)
+	}
+}
@@ -0,0 +1,266 @@
+package bigcsv
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// sampleSize is the number of bytes read by AnalyzeStream.
+const sampleSize = 64 * 1024
+
+// sampleRows is the number of rows kept in Dialect.SampleRows.
+const sampleRows = 10
+
+// QuoteStyle describes how fields are quoted in a stream, see also
+// WithQuoting.
+type QuoteStyle string
+
+const (
+	// QuoteNone means no fields are quoted.
+	QuoteNone QuoteStyle = "none"
+
+	// QuoteMinimal means some fields are quoted, usually where needed.
+	QuoteMinimal QuoteStyle = "minimal"
+
+	// QuoteAll means all fields are quoted.
+	QuoteAll QuoteStyle = "all"
+
+	// QuoteNonNumeric means all fields but numbers are quoted. It is not
+	// detected by AnalyzeStream.
+	QuoteNonNumeric QuoteStyle = "non-numeric"
+)
+
+// Dialect describes the format of a CSV stream as detected by AnalyzeStream.
+type Dialect struct {
+	// Delimiter is the field delimiter.
+	Delimiter rune
+
+	// Quoting is how fields are quoted.
+	Quoting QuoteStyle
+
+	// HasHeader tells whether the first row looks like a header row.
+	HasHeader bool
+
+	// LineTerminator is "\n", "\r\n" or "\r".
+	LineTerminator string
+
+	// Encoding is a guess of the text encoding: "utf-8", "utf-8-bom",
+	// "utf-16le", "utf-16be" or "latin-1" if the sample is not valid UTF-8.
+	Encoding string
+
+	// Columns is the most common number of fields per row.
+	Columns int
+
+	// SampleRows are the first rows of the stream, including the header.
+	SampleRows [][]string
+}
+
+// Options returns the Parser options matching the dialect, including its
+// Encoding.
+func (d *Dialect) Options() []Option {
+	opts := []Option{WithComma(d.Delimiter)}
+	if d.Encoding != "" {
+		opts = append(opts, WithEncoding(d.Encoding))
+	}
+	if d.HasHeader {
+		opts = append(opts, WithHeaders())
+	}
+	return opts
+}
+
+// WriterOptions returns the Writer options matching the dialect, e.g. to
+// write output in the format of the input.
+func (d *Dialect) WriterOptions() []WriterOption {
+	return []WriterOption{WithWriterComma(d.Delimiter), WithQuoting(d.Quoting), WithLineTerminator(d.LineTerminator)}
+}
+
+// delimiters are the candidates tried by AnalyzeStream, by preference.
+var delimiters = []rune{',', ';', '\t', '|'}
+
+// AnalyzeStream reads the start of the stream and detects its dialect, e.g. to
+// configure a Parser for CSV files of unknown format. The stream is opened
+// and closed by AnalyzeStream.
+func AnalyzeStream(ctx context.Context, stream Stream) (*Dialect, error) {
+	r, err := stream.Open()
+	if err != nil {
+		return nil, fmt.Errorf("could not open stream: %w", err)
+	}
+	defer r.Close()
+
+	// Read the sample, dropping a trailing incomplete line.
+	sample := make([]byte, 0, sampleSize)
+	for len(sample) < sampleSize && ctx.Err() == nil {
+		n, err := r.Read(sample[len(sample):cap(sample)])
+		sample = sample[:len(sample)+n]
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("could not read sample: %w", err)
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, err
+	}
+	if len(sample) == sampleSize {
+		if ix := bytes.LastIndexAny(sample, "\r\n"); ix > 0 {
+			sample = sample[:ix+1]
+		}
+	}
+
+	d := &Dialect{}
+	d.Encoding, sample = detectEncoding(sample)
+	d.LineTerminator = detectTerminator(sample)
+
+	var rows [][]string
+	best := -1.0
+	for _, delim := range delimiters {
+		candidate, columns, score := scoreDelimiter(sample, delim)
+		if score > best {
+			best = score
+			rows, d.Delimiter, d.Columns = candidate, delim, columns
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("could not detect dialect: no rows")
+	}
+	d.Quoting = detectQuoting(sample, d.Delimiter, rows)
+	d.HasHeader = detectHeader(rows, d.Columns)
+	d.SampleRows = rows[:min(len(rows), sampleRows)]
+	return d, nil
+}
+
+// detectEncoding guesses the encoding from byte order marks and UTF-8
+// validity. UTF-16 samples are converted for further analysis.
+func detectEncoding(sample []byte) (string, []byte) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return "utf-8-bom", sample[3:]
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return "utf-16le", decodeUTF16(sample[2:], false)
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return "utf-16be", decodeUTF16(sample[2:], true)
+	case utf8.Valid(sample):
+		return "utf-8", sample
+	}
+	return "latin-1", sample
+}
+
+// decodeUTF16 converts UTF-16 to UTF-8, ignoring surrogate pairs which do not
+// matter for detecting the dialect.
+func decodeUTF16(b []byte, bigEndian bool) []byte {
+	out := make([]byte, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		r := rune(b[i]) | rune(b[i+1])<<8
+		if bigEndian {
+			r = rune(b[i])<<8 | rune(b[i+1])
+		}
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
+
+// detectTerminator returns the first line terminator found.
+func detectTerminator(sample []byte) string {
+	ix := bytes.IndexAny(sample, "\r\n")
+	switch {
+	case ix < 0 || sample[ix] == '\n':
+		return "\n"
+	case ix+1 < len(sample) && sample[ix+1] == '\n':
+		return "\r\n"
+	}
+	return "\r"
+}
+
+// scoreDelimiter parses the sample with the delimiter. The score is the
+// fraction of rows having the most common number of fields, which must be
+// more than one.
+func scoreDelimiter(sample []byte, delim rune) (rows [][]string, columns int, score float64) {
+	r := csv.NewReader(bytes.NewReader(sample))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	counts := map[int]int{}
+	for {
+		row, err := r.Read()
+		if err != nil {
+			break
+		}
+		rows = append(rows, row)
+		counts[len(row)]++
+	}
+	for n, c := range counts {
+		if c > counts[columns] || (c == counts[columns] && n > columns) {
+			columns = n
+		}
+	}
+	if columns < 2 || len(rows) == 0 {
+		return rows, columns, 0
+	}
+	return rows, columns, float64(counts[columns]) / float64(len(rows))
+}
+
+// detectQuoting compares the number of quoted fields in the sample with the
+// number of fields.
+func detectQuoting(sample []byte, delim rune, rows [][]string) QuoteStyle {
+	fields := 0
+	for _, row := range rows {
+		fields += len(row)
+	}
+	quoted, atStart := 0, true
+	for _, c := range string(sample) {
+		if atStart && c == '"' {
+			quoted++
+		}
+		atStart = c == delim || c == '\n' || c == '\r'
+	}
+	switch {
+	case quoted == 0:
+		return QuoteNone
+	case quoted >= fields:
+		return QuoteAll
+	}
+	return QuoteMinimal
+}
+
+// detectHeader votes per column whether the first row is a header: a number
+// in the first row votes against it, a non-number above a numeric column or a
+// value of a different length above a column of fixed length votes for it.
+func detectHeader(rows [][]string, columns int) bool {
+	if len(rows) < 2 {
+		return false
+	}
+	isNumber := func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	}
+	votes := 0
+	for col := 0; col < columns && col < len(rows[0]); col++ {
+		header := rows[0][col]
+		if isNumber(header) {
+			votes--
+			continue
+		}
+		numeric, length := true, -1
+		for _, row := range rows[1:] {
+			if col >= len(row) {
+				continue
+			}
+			numeric = numeric && isNumber(row[col])
+			if length == -1 {
+				length = len(row[col])
+			} else if length != len(row[col]) {
+				length = -2
+			}
+		}
+		if numeric || (length >= 0 && length != len(header)) {
+			votes++
+		}
+	}
+	return votes > 0
+}
@@ -0,0 +1,44 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestAnalyzeStream checks the detection of a semicolon separated file with
+// header and CRLF line endings.
+func TestAnalyzeStream(t *testing.T) {
+	csv := "\xEF\xBB\xBFname;population;area\r\n\"Alpha\";12000;1.5\r\n\"Beta; Inc\";500;0.25\r\n\"Gamma\";800;7\r\n"
+	d, err := bigcsv.AnalyzeStream(context.Background(), bigcsv.ReadStream(strings.NewReader(csv)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Delimiter != ';' || !d.HasHeader || d.Columns != 3 {
+		t.Fatalf("Unexpected dialect: %+v", d)
+	}
+	if d.LineTerminator != "\r\n" || d.Encoding != "utf-8-bom" || d.Quoting != bigcsv.QuoteMinimal {
+		t.Fatalf("Unexpected dialect: %+v", d)
+	}
+	if len(d.SampleRows) != 4 || d.SampleRows[2][0] != "Beta; Inc" {
+		t.Fatalf("Unexpected sample rows: %v", d.SampleRows)
+	}
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(csv)), d.Options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers := parser.Headers(); len(headers) != 3 || headers[0] != "name" {
+		t.Fatalf("Unexpected headers with the dialect options: %q", headers)
+	}
+	parser.Close()
+
+	d, err = bigcsv.AnalyzeStream(context.Background(), bigcsv.ReadStream(strings.NewReader("1\t2\n3\t4\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Delimiter != '\t' || d.HasHeader || d.Quoting != bigcsv.QuoteNone {
+		t.Fatalf("Unexpected dialect: %+v", d)
+	}
+}
@@ -0,0 +1,72 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnknownRecordType is returned by Discriminator.OnRow for rows without a
+// matching route.
+var ErrUnknownRecordType = errors.New("unknown record type")
+
+// RowHandler processes a raw row, see Discriminator.
+type RowHandler func(row []string) error
+
+// Handle combines a Parse and OnData pair for one record type into a
+// RowHandler. Their errors wrap ErrParse and ErrOnData respectively.
+func Handle[T any](parse func(row []string) (T, error), onData func(data T) error) RowHandler {
+	return func(row []string) error {
+		data, err := parse(row)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrParse, err)
+		}
+		if err = onData(data); err != nil {
+			return fmt.Errorf("%w: %w", ErrOnData, err)
+		}
+		return nil
+	}
+}
+
+// Discriminator routes rows of files interleaving several record types, e.g.
+// header, detail and trailer records, to a handler per type, distinguished by
+// the value of a column. Set its OnRow as the OnRow of a Parser; as the record
+// types usually differ in their number of fields, use WithFieldsPerRecord(-1).
+//
+//	d := &bigcsv.Discriminator{Routes: map[string]bigcsv.RowHandler{
+//		"H": bigcsv.Handle(parseHeader, onHeader),
+//		"D": bigcsv.Handle(parseDetail, onDetail),
+//	}}
+//	parser, err := bigcsv.New[any](stream, bigcsv.WithFieldsPerRecord(-1))
+//	parser.OnRow = d.OnRow
+//
+// Handler errors reach OnError wrapped in ErrOnRow, with the line number.
+type Discriminator struct {
+	// Column is the index of the column holding the record type.
+	Column int
+
+	// Routes maps record types to their handlers.
+	Routes map[string]RowHandler
+
+	// Default handles rows of unknown record types. If nil, these rows fail
+	// with ErrUnknownRecordType.
+	Default RowHandler
+}
+
+// OnRow routes the row to the handler of its record type.
+func (d *Discriminator) OnRow(row []string) error {
+	if d.Column >= len(row) {
+		return fmt.Errorf("%w: missing column %d", ErrUnknownRecordType, d.Column+1)
+	}
+	kind := row[d.Column]
+	handler, ok := d.Routes[kind]
+	if !ok {
+		if d.Default == nil {
+			return fmt.Errorf("%w: '%s'", ErrUnknownRecordType, kind)
+		}
+		handler = d.Default
+	}
+	if err := handler(row); err != nil {
+		return fmt.Errorf("record type '%s': %w", kind, err)
+	}
+	return nil
+}
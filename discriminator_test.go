@@ -0,0 +1,57 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestDiscriminator routes header, detail and trailer records of a banking
+// style file.
+func TestDiscriminator(t *testing.T) {
+	var batch string
+	var total, declared int
+	d := &bigcsv.Discriminator{Routes: map[string]bigcsv.RowHandler{
+		"H": func(row []string) error {
+			batch = row[1]
+			return nil
+		},
+		"D": bigcsv.Handle(func(row []string) (int, error) {
+			return strconv.Atoi(row[2])
+		}, func(amount int) error {
+			total += amount
+			return nil
+		}),
+		"T": bigcsv.Handle(func(row []string) (int, error) {
+			return strconv.Atoi(row[1])
+		}, func(sum int) error {
+			declared = sum
+			return nil
+		}),
+	}}
+	parser, err := bigcsv.New[any](
+		bigcsv.ReadStream(strings.NewReader("H,batch-7\nD,alice,10\nD,bob,x\nX,?\nD,carol,5\nT,15\n")),
+		bigcsv.WithFieldsPerRecord(-1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnRow = d.OnRow
+	var errs []error
+	parser.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if batch != "batch-7" || total != 15 || declared != 15 {
+		t.Fatalf("Unexpected results: %s, %d, %d", batch, total, declared)
+	}
+	if len(errs) != 2 || !errors.Is(errs[0], bigcsv.ErrParse) || !errors.Is(errs[1], bigcsv.ErrUnknownRecordType) {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+}
@@ -0,0 +1,66 @@
+package bigcsv
+
+import (
+	"context"
+	"sync"
+)
+
+// maxDryRunErrors is the number of errors kept by DryRun.
+const maxDryRunErrors = 100
+
+// DryRunReport is the result of a DryRun.
+type DryRunReport struct {
+	// Stats counts the rows and all errors by stage.
+	Stats Stats
+
+	// Errors holds the first row errors, at most 100, in no particular order
+	// when using multiple workers.
+	Errors []error
+}
+
+// OK tells whether the dry run found no errors.
+func (r *DryRunReport) OK() bool {
+	return r.Stats.Errors() == 0
+}
+
+// DryRun processes the stream like Run with the configured number of workers,
+// reading, validating and parsing all rows, but does not call OnData or
+// OnError, nor save checkpoints. This allows a pre-flight check before an
+// expensive load. OnRow is called, so it should not have side effects.
+//
+// The error is that of Run, e.g. for a failing stream; row errors are part of
+// the report. As with Run, the stream is closed afterwards unless using
+// WithKeepOpen, see Reset to process it again for real.
+func (p *Parser[T]) DryRun(ctx context.Context) (_ *DryRunReport, err error) {
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if rerr := p.end(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+	defer p.finish()
+	workers, err := p.setup(0)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := p.cfg
+	dry := *cfg
+	dry.checkpoint = nil
+	p.cfg = &dry
+	defer func() { p.cfg = cfg }()
+
+	report := &DryRunReport{}
+	var mu sync.Mutex
+	err = p.run(ctx, workers, nil, nil, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(report.Errors) < maxDryRunErrors {
+			report.Errors = append(report.Errors, err)
+		}
+	})
+	report.Stats = p.Stats()
+	return report, err
+}
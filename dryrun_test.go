@@ -0,0 +1,33 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestDryRun checks that rows are parsed without calling OnData.
+func TestDryRun(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("int,name\n1,one\nx,two\n3,three\n", bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error {
+		t.Error("OnData called in dry run")
+		return nil
+	}
+	report, err := parser.DryRun(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.OK() || report.Stats.Rows != 3 || report.Stats.ParseErrors != 1 || len(report.Errors) != 1 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	var rowErr *bigcsv.RowError
+	if !errors.As(report.Errors[0], &rowErr) || rowErr.Line != 3 {
+		t.Errorf("Unexpected error: %v", report.Errors[0])
+	}
+}
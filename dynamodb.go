@@ -0,0 +1,50 @@
+package bigcsv
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DynamoDBClient writes a batch of up to 25 items. Implement it by wrapping
+// BatchWriteItem of an AWS SDK client, converting records e.g. with
+// attributevalue.MarshalMap, and returning the UnprocessedItems.
+type DynamoDBClient[T any] interface {
+	BatchWriteItem(ctx context.Context, items []T) (unprocessed []T, err error)
+}
+
+// DynamoDBSink writes records to DynamoDB in batches of 25, the limit of
+// BatchWriteItem, retrying unprocessed items with exponential backoff. Flush
+// must be called to write the last batch.
+type DynamoDBSink[T any] struct {
+	*Batcher[T]
+}
+
+// NewDynamoDBSink creates a DynamoDBSink, which fails a batch if items remain
+// unprocessed after maxRetries retries.
+func NewDynamoDBSink[T any](ctx context.Context, client DynamoDBClient[T], maxRetries int) (*DynamoDBSink[T], error) {
+	if maxRetries < 0 {
+		return nil, fmt.Errorf("invalid number of retries: %d", maxRetries)
+	}
+	return &DynamoDBSink[T]{Batch(25, func(items []T) error {
+		backoff := 50 * time.Millisecond
+		for retry := 0; ; retry++ {
+			unprocessed, err := client.BatchWriteItem(ctx, items)
+			if err != nil {
+				return fmt.Errorf("could not write batch: %w", err)
+			}
+			if len(unprocessed) == 0 {
+				return nil
+			}
+			if retry == maxRetries {
+				return fmt.Errorf("%d items unprocessed after %d retries", len(unprocessed), retry)
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			items, backoff = unprocessed, min(2*backoff, 5*time.Second)
+		}
+	})}, nil
+}
@@ -0,0 +1,47 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// flakyTable leaves the first item of each first attempt unprocessed.
+type flakyTable struct {
+	attempts int
+	written  []Number
+}
+
+func (f *flakyTable) BatchWriteItem(ctx context.Context, items []Number) ([]Number, error) {
+	f.attempts++
+	if f.attempts%2 == 1 {
+		f.written = append(f.written, items[1:]...)
+		return items[:1], nil
+	}
+	f.written = append(f.written, items...)
+	return nil, nil
+}
+
+// TestDynamoDBSink checks that unprocessed items are retried.
+func TestDynamoDBSink(t *testing.T) {
+	table := &flakyTable{}
+	sink, err := bigcsv.NewDynamoDBSink[Number](context.Background(), table, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 30 {
+		if err := sink.OnData(Number{Integer: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(table.written) != 30 || table.attempts != 4 {
+		t.Fatalf("Wrote %d items in %d attempts", len(table.written), table.attempts)
+	}
+	if _, err = bigcsv.NewDynamoDBSink[Number](context.Background(), table, -1); err == nil {
+		t.Error("Expected an error for a negative number of retries")
+	}
+}
@@ -0,0 +1,196 @@
+package bigcsv
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EncodingWriter writes records in a binary encoding to a file or socket, for
+// compact intermediate storage between pipeline stages. Its OnData is safe for
+// use with multiple workers. Output is buffered, Flush must be called at the
+// end.
+type EncodingWriter[T any] struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	encode func(b []byte, data T) ([]byte, error)
+	buf    []byte
+}
+
+// NewProtobufWriter writes records converted to protobuf by marshal, e.g.
+// proto.Marshal of a message built from the record. Each message is prefixed
+// by its length as varint, the framing of protodelim and Java's
+// writeDelimitedTo.
+func NewProtobufWriter[T any](w io.Writer, marshal func(data T) ([]byte, error)) *EncodingWriter[T] {
+	return &EncodingWriter[T]{w: bufio.NewWriter(w), encode: func(b []byte, data T) ([]byte, error) {
+		msg, err := marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		return append(binary.AppendUvarint(b, uint64(len(msg))), msg...), nil
+	}}
+}
+
+// NewMsgpackWriter writes records as a stream of MessagePack values. Structs
+// are encoded as maps keyed by their `csv` tag names as for WithStructTags,
+// time.Time values as timestamp extension, types implementing
+// encoding.TextMarshaler as strings.
+func NewMsgpackWriter[T any](w io.Writer) *EncodingWriter[T] {
+	return &EncodingWriter[T]{w: bufio.NewWriter(w), encode: func(b []byte, data T) ([]byte, error) {
+		return appendMsgpack(b, reflect.ValueOf(&data).Elem())
+	}}
+}
+
+// OnData encodes and writes the record.
+func (e *EncodingWriter[T]) OnData(data T) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var err error
+	if e.buf, err = e.encode(e.buf[:0], data); err != nil {
+		return fmt.Errorf("could not encode record: %w", err)
+	}
+	if _, err = e.w.Write(e.buf); err != nil {
+		return fmt.Errorf("could not write record: %w", err)
+	}
+	return nil
+}
+
+// Flush writes any buffered output.
+func (e *EncodingWriter[T]) Flush() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.w.Flush()
+}
+
+var textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+
+// appendMsgpack appends the MessagePack encoding of v.
+func appendMsgpack(b []byte, v reflect.Value) ([]byte, error) {
+	if !v.IsValid() {
+		return append(b, 0xc0), nil
+	}
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		b = append(b, 0xc7, 12, 0xff) // ext 8, timestamp 96
+		b = binary.BigEndian.AppendUint32(b, uint32(t.Nanosecond()))
+		return binary.BigEndian.AppendUint64(b, uint64(t.Unix())), nil
+	}
+	if v.Type().Implements(textMarshalerType) && (v.Kind() != reflect.Pointer || !v.IsNil()) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return appendMsgpackString(b, string(text)), nil
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return append(b, 0xc0), nil
+		}
+		return appendMsgpack(b, v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return appendMsgpackInt(b, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := v.Uint(); u > math.MaxInt64 {
+			return binary.BigEndian.AppendUint64(append(b, 0xcf), u), nil
+		}
+		return appendMsgpackInt(b, int64(v.Uint())), nil
+	case reflect.Float32:
+		return binary.BigEndian.AppendUint32(append(b, 0xca), math.Float32bits(float32(v.Float()))), nil
+	case reflect.Float64:
+		return binary.BigEndian.AppendUint64(append(b, 0xcb), math.Float64bits(v.Float())), nil
+	case reflect.String:
+		return appendMsgpackString(b, v.String()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return append(b, 0xc0), nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(v.Len()))
+			return append(b, v.Bytes()...), nil
+		}
+		b = appendMsgpackHeader(b, v.Len(), 0x90, 0xdc)
+		var err error
+		for i := 0; i < v.Len() && err == nil; i++ {
+			b, err = appendMsgpack(b, v.Index(i))
+		}
+		return b, err
+	case reflect.Map:
+		if v.IsNil() {
+			return append(b, 0xc0), nil
+		}
+		b = appendMsgpackHeader(b, v.Len(), 0x80, 0xde)
+		var err error
+		for it := v.MapRange(); it.Next() && err == nil; {
+			if b, err = appendMsgpack(b, it.Key()); err == nil {
+				b, err = appendMsgpack(b, it.Value())
+			}
+		}
+		return b, err
+	case reflect.Struct:
+		fields := taggedFields(v.Type(), nil)
+		b = appendMsgpackHeader(b, len(fields), 0x80, 0xde)
+		var err error
+		for _, f := range fields {
+			b = appendMsgpackString(b, f.tag.name)
+			if b, err = appendMsgpack(b, v.FieldByIndex(f.index)); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("cannot encode %s as MessagePack", v.Type())
+}
+
+// appendMsgpackInt appends an integer in the smallest format.
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= -32 && i <= math.MaxInt8:
+		return append(b, byte(i)) // fixint
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(b, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(i))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(i))
+}
+
+// appendMsgpackHeader appends the header of an array or map of n elements,
+// given the fix and 16-bit formats; the 32-bit format follows the latter.
+func appendMsgpackHeader(b []byte, n int, fix, format16 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, format16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, format16+1), uint32(n))
+}
+
+// appendMsgpackString appends a string in the smallest format.
+func appendMsgpackString(b []byte, s string) []byte {
+	switch n := len(s); {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
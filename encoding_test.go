@@ -0,0 +1,43 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestMsgpackWriter checks the encoding of a struct.
+func TestMsgpackWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := bigcsv.NewMsgpackWriter[Number](&buf)
+	if err := w.OnData(Number{Integer: 1, String: "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := append([]byte{0x82, 0xa7}, "Integer\x01\xa6String\xa3one"...)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("Encoding is %q, expected %q", buf.Bytes(), want)
+	}
+}
+
+// TestProtobufWriter checks the length-delimited framing.
+func TestProtobufWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := bigcsv.NewProtobufWriter(&buf, func(n Number) ([]byte, error) {
+		return []byte(n.String), nil // stands in for proto.Marshal
+	})
+	for _, n := range []Number{{1, "one"}, {2, "two"}} {
+		if err := w.OnData(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "\x03one\x03two" {
+		t.Fatalf("Output is %q", buf.String())
+	}
+}
@@ -0,0 +1,245 @@
+package bigcsv
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EnrichOptions configures an Enricher.
+type EnrichOptions struct {
+	// CacheSize is the number of lookup results kept in an LRU cache, none
+	// if 0. Keys not found are cached, too, but failed lookups are not.
+	CacheSize int
+
+	// Concurrency bounds the number of lookups in progress, 1 if 0.
+	Concurrency int
+
+	// BatchSize is the maximum number of keys per lookup, 1 if 0. Batches are
+	// only filled by records arriving concurrently, i.e. with several workers.
+	BatchSize int
+
+	// BatchWait is how long a record waits for its batch to fill before the
+	// batch is looked up anyway, 10ms if 0.
+	BatchWait time.Duration
+}
+
+// Enricher merges data looked up by a key into each record before passing it
+// on, e.g. from a database or an HTTP API. Lookups are cached, batched and
+// bounded in concurrency; records waiting for the same key share one lookup.
+// Its OnData is safe for use with multiple workers.
+type Enricher[T any, K comparable, V any] struct {
+	ctx    context.Context
+	key    func(T) K
+	lookup func(ctx context.Context, keys []K) (map[K]V, error)
+	merge  func(data T, value V, found bool) (T, error)
+	next   func(T) error
+	opts   EnrichOptions
+	sem    chan struct{}
+	cache  *lru[K, lookupResult[V]]
+
+	mu      sync.Mutex
+	current *lookupBatch[K, V]       // the batch being filled
+	pending map[K]*lookupBatch[K, V] // keys being looked up
+}
+
+// lookupResult is a cached result of a lookup.
+type lookupResult[V any] struct {
+	value V
+	found bool
+}
+
+// lookupBatch is a batch of keys looked up together.
+type lookupBatch[K comparable, V any] struct {
+	keys       []K
+	dispatched bool
+	done       chan struct{}
+	results    map[K]V
+	err        error
+}
+
+// Enrich creates an Enricher looking up the key of each record with lookup,
+// which returns the values of the keys found, and passing the record merged
+// with its value to next. Lookups use ctx.
+//
+//	enricher := bigcsv.Enrich(ctx, func(o Order) string { return o.CustomerID },
+//		db.CustomersByID,
+//		func(o Order, c Customer, found bool) (Order, error) {
+//			o.Customer = c
+//			return o, nil
+//		},
+//		store, bigcsv.EnrichOptions{CacheSize: 10000, BatchSize: 100, Concurrency: 4})
+//	parser.OnData = enricher.OnData
+func Enrich[T any, K comparable, V any](
+	ctx context.Context,
+	key func(T) K,
+	lookup func(ctx context.Context, keys []K) (map[K]V, error),
+	merge func(data T, value V, found bool) (T, error),
+	next func(T) error,
+	opts EnrichOptions,
+) *Enricher[T, K, V] {
+	opts.Concurrency = max(opts.Concurrency, 1)
+	opts.BatchSize = max(opts.BatchSize, 1)
+	if opts.BatchWait <= 0 {
+		opts.BatchWait = 10 * time.Millisecond
+	}
+	e := &Enricher[T, K, V]{
+		ctx:     ctx,
+		key:     key,
+		lookup:  lookup,
+		merge:   merge,
+		next:    next,
+		opts:    opts,
+		sem:     make(chan struct{}, opts.Concurrency),
+		pending: map[K]*lookupBatch[K, V]{},
+	}
+	if opts.CacheSize > 0 {
+		e.cache = newLRU[K, lookupResult[V]](opts.CacheSize)
+	}
+	return e
+}
+
+// OnData looks up the key of the record, merges the result and passes the
+// record on. A failed lookup is returned as OnData error.
+func (e *Enricher[T, K, V]) OnData(data T) error {
+	k := e.key(data)
+	res, err := e.get(k)
+	if err != nil {
+		return err
+	}
+	if data, err = e.merge(data, res.value, res.found); err != nil {
+		return err
+	}
+	return e.next(data)
+}
+
+// get returns the result for a key from the cache or a lookup.
+func (e *Enricher[T, K, V]) get(k K) (lookupResult[V], error) {
+	if e.cache != nil {
+		if res, ok := e.cache.get(k); ok {
+			return res, nil
+		}
+	}
+	b, full := e.join(k)
+	if full {
+		e.dispatch(b)
+	} else {
+		timer := time.NewTimer(e.opts.BatchWait)
+		select {
+		case <-b.done:
+		case <-timer.C:
+			e.dispatch(b)
+		}
+		timer.Stop()
+	}
+	<-b.done
+	if b.err != nil {
+		return lookupResult[V]{}, fmt.Errorf("lookup failed: %w", b.err)
+	}
+	v, found := b.results[k]
+	return lookupResult[V]{v, found}, nil
+}
+
+// join adds the key to a batch, unless already being looked up, telling
+// whether the batch is full.
+func (e *Enricher[T, K, V]) join(k K) (*lookupBatch[K, V], bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if b, ok := e.pending[k]; ok {
+		return b, false
+	}
+	if e.current == nil {
+		e.current = &lookupBatch[K, V]{done: make(chan struct{})}
+	}
+	b := e.current
+	b.keys = append(b.keys, k)
+	e.pending[k] = b
+	if len(b.keys) < e.opts.BatchSize {
+		return b, false
+	}
+	e.current = nil
+	return b, true
+}
+
+// dispatch looks up the batch unless dispatched before.
+func (e *Enricher[T, K, V]) dispatch(b *lookupBatch[K, V]) {
+	e.mu.Lock()
+	if b.dispatched {
+		e.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if e.current == b {
+		e.current = nil
+	}
+	e.mu.Unlock()
+
+	select {
+	case e.sem <- struct{}{}:
+		b.results, b.err = e.lookup(e.ctx, b.keys)
+		<-e.sem
+	case <-e.ctx.Done():
+		b.err = e.ctx.Err()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, k := range b.keys {
+		delete(e.pending, k)
+		if e.cache != nil && b.err == nil {
+			v, found := b.results[k]
+			e.cache.add(k, lookupResult[V]{v, found})
+		}
+	}
+	close(b.done)
+}
+
+// lru is a cache evicting the least recently used entries. It is safe for
+// concurrent use.
+type lru[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // of *lruEntry, most recent first
+	items map[K]*list.Element
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// newLRU creates a cache of size entries.
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{size: size, order: list.New(), items: make(map[K]*list.Element, size)}
+}
+
+// get returns the value of a key, marking it as used.
+func (c *lru[K, V]) get(k K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// add sets the value of a key, evicting the least recently used entry if full.
+func (c *lru[K, V]) add(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[k]; ok {
+		el.Value.(*lruEntry[K, V]).value = v
+		c.order.MoveToFront(el)
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*lruEntry[K, V]).key)
+	}
+	c.items[k] = c.order.PushFront(&lruEntry[K, V]{k, v})
+}
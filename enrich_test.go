@@ -0,0 +1,66 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestEnrich checks merging looked up values with caching and batching.
+func TestEnrich(t *testing.T) {
+	var sb strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&sb, "%d,c%d\n", i, i%10)
+	}
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(sb.String())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lookups, keys atomic.Int64
+	lookup := func(ctx context.Context, ids []string) (map[string]string, error) {
+		lookups.Add(1)
+		keys.Add(int64(len(ids)))
+		names := map[string]string{}
+		for _, id := range ids {
+			if id != "c9" {
+				names[id] = strings.ToUpper(id)
+			}
+		}
+		return names, nil
+	}
+	var mu sync.Mutex
+	got := map[string]string{}
+	enricher := bigcsv.Enrich(context.Background(),
+		func(row []string) string { return row[1] },
+		lookup,
+		func(row []string, name string, found bool) ([]string, error) {
+			if !found {
+				return nil, errors.New("unknown customer")
+			}
+			return append(row, name), nil
+		},
+		func(row []string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			got[row[0]] = row[2]
+			return nil
+		},
+		bigcsv.EnrichOptions{CacheSize: 100, BatchSize: 4, Concurrency: 2},
+	)
+	parser.OnData = enricher.OnData
+	if err = parser.Run(context.Background(), 8); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 180 || got["13"] != "C3" || parser.Stats().OnDataErrors != 20 {
+		t.Errorf("Unexpected results %v with stats %+v", len(got), parser.Stats())
+	}
+	if keys.Load() != 10 || lookups.Load() > 10 {
+		t.Errorf("Expected 10 keys looked up once, got %d in %d lookups", keys.Load(), lookups.Load())
+	}
+}
@@ -0,0 +1,169 @@
+package bigcsv
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Schema returns the schema of the inferred column types, to export it for
+// provisioning downstream storage. Columns without nulls are required, empty
+// columns are strings and unnamed columns are named "column_" and their
+// number.
+func (r *ProfileReport) Schema() Schema {
+	s := Schema{Columns: make([]SchemaColumn, len(r.Columns))}
+	for i, c := range r.Columns {
+		col := SchemaColumn{
+			Name:     cmp.Or(c.Name, "column_"+strconv.Itoa(i+1)),
+			Type:     c.Type,
+			Required: c.NullPercent == 0 && r.Rows > 0,
+		}
+		if col.Type == TypeEmpty {
+			col.Type, col.Required = TypeString, false
+		}
+		s.Columns[i] = col
+	}
+	return s
+}
+
+// WriteJSONSchema writes a JSON Schema (draft 2020-12) of objects with a
+// property per column, such as the rows written as JSON lines by a Pipeline.
+// Optional columns may be null, dates are "date-time" strings.
+func (s Schema) WriteJSONSchema(w io.Writer) error {
+	type property struct {
+		Type    any      `json:"type"`
+		Format  string   `json:"format,omitempty"`
+		Pattern string   `json:"pattern,omitempty"`
+		Enum    []any    `json:"enum,omitempty"`
+		Minimum *float64 `json:"minimum,omitempty"`
+		Maximum *float64 `json:"maximum,omitempty"`
+	}
+	properties := make(map[string]property, len(s.Columns))
+	required := []string{}
+	for _, col := range s.Columns {
+		p := property{Type: "string", Minimum: col.Min, Maximum: col.Max}
+		for _, v := range col.Values {
+			p.Enum = append(p.Enum, v)
+		}
+		switch col.Type {
+		case TypeInteger:
+			p.Type = "integer"
+		case TypeNumber:
+			p.Type = "number"
+		case TypeBoolean:
+			p.Type = "boolean"
+		case TypeDate:
+			p.Format = "date-time"
+		}
+		if col.Required {
+			required = append(required, col.Name)
+		} else {
+			p.Type = []any{p.Type, "null"}
+			if p.Enum != nil {
+				p.Enum = append(p.Enum, nil)
+			}
+		}
+		if col.Pattern != "" {
+			p.Pattern = "^(?:" + col.Pattern + ")$" // anchored like WithSchema
+		}
+		properties[col.Name] = p
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Schema     string              `json:"$schema"`
+		Type       string              `json:"type"`
+		Properties map[string]property `json:"properties"`
+		Required   []string            `json:"required"`
+	}{"https://json-schema.org/draft/2020-12/schema", "object", properties, required})
+}
+
+// WriteAvroSchema writes an Avro record schema with the given name and a
+// field per column. Optional columns are unions with null, dates are
+// timestamp-micros like for GenerateAvroSchema. Names are changed to be valid
+// in Avro.
+func (s Schema) WriteAvroSchema(w io.Writer, name string) error {
+	fields := make([]avroField, len(s.Columns))
+	for i, col := range s.Columns {
+		var typ any = "string"
+		switch col.Type {
+		case TypeInteger:
+			typ = "long"
+		case TypeNumber:
+			typ = "double"
+		case TypeBoolean:
+			typ = "boolean"
+		case TypeDate:
+			typ = map[string]string{"type": "long", "logicalType": "timestamp-micros"}
+		}
+		fields[i] = avroField{Name: avroName(col.Name), Type: typ}
+		if !col.Required {
+			fields[i].Type = []any{"null", typ}
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Type   string      `json:"type"`
+		Name   string      `json:"name"`
+		Fields []avroField `json:"fields"`
+	}{"record", avroName(name), fields})
+}
+
+// WriteSQL writes a CREATE TABLE statement in standard SQL, with a column per
+// schema column. Required columns are NOT NULL, allowed values and bounds of
+// numbers become CHECK constraints. Patterns are left out, as databases
+// differ in their regular expressions.
+func (s Schema) WriteSQL(w io.Writer, table string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CREATE TABLE %s (", sqlName(table))
+	for i, col := range s.Columns {
+		typ := "TEXT"
+		switch col.Type {
+		case TypeInteger:
+			typ = "BIGINT"
+		case TypeNumber:
+			typ = "DOUBLE PRECISION"
+		case TypeBoolean:
+			typ = "BOOLEAN"
+		case TypeDate:
+			typ = "TIMESTAMP"
+		}
+		if i > 0 {
+			b.WriteString(",")
+		}
+		name := sqlName(col.Name)
+		fmt.Fprintf(&b, "\n  %s %s", name, typ)
+		if col.Required {
+			b.WriteString(" NOT NULL")
+		}
+		var checks []string
+		if len(col.Values) > 0 {
+			values := make([]string, len(col.Values))
+			for j, v := range col.Values {
+				values[j] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+			}
+			checks = append(checks, fmt.Sprintf("%s IN (%s)", name, strings.Join(values, ", ")))
+		}
+		if col.Min != nil {
+			checks = append(checks, fmt.Sprintf("%s >= %s", name, strconv.FormatFloat(*col.Min, 'g', -1, 64)))
+		}
+		if col.Max != nil {
+			checks = append(checks, fmt.Sprintf("%s <= %s", name, strconv.FormatFloat(*col.Max, 'g', -1, 64)))
+		}
+		if len(checks) > 0 {
+			fmt.Fprintf(&b, " CHECK (%s)", strings.Join(checks, " AND "))
+		}
+	}
+	b.WriteString("\n);\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// sqlName quotes an SQL identifier.
+func sqlName(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,83 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// inferSchema profiles the rows, returning the inferred schema.
+func inferSchema(t *testing.T, headers []string, rows ...[]string) bigcsv.Schema {
+	t.Helper()
+	p := bigcsv.NewProfiler(headers)
+	for _, row := range rows {
+		if err := p.OnRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return bigcsv.NewProfileReport(p.Columns()).Schema()
+}
+
+// TestSchemaExport exports an inferred schema as SQL, JSON Schema and Avro.
+func TestSchemaExport(t *testing.T) {
+	schema := inferSchema(t, []string{"id", "price", "active", "since", "note"},
+		[]string{"1", "9.5", "true", "2024-01-02", ""},
+		[]string{"2", "", "false", "2024-02-03T10:00:00Z", ""},
+	)
+	schema.Columns[1].Min = new(float64)
+
+	var b bytes.Buffer
+	if err := schema.WriteSQL(&b, "items"); err != nil {
+		t.Fatal(err)
+	}
+	expected := `CREATE TABLE "items" (
+  "id" BIGINT NOT NULL,
+  "price" DOUBLE PRECISION CHECK ("price" >= 0),
+  "active" BOOLEAN NOT NULL,
+  "since" TIMESTAMP NOT NULL,
+  "note" TEXT
+);
+`
+	if b.String() != expected {
+		t.Errorf("Got SQL:\n%s\nexpected:\n%s", &b, expected)
+	}
+
+	b.Reset()
+	if err := schema.WriteJSONSchema(&b); err != nil {
+		t.Fatal(err)
+	}
+	var jsonSchema struct {
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal(b.Bytes(), &jsonSchema); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(jsonSchema.Required, []string{"id", "active", "since"}) ||
+		!reflect.DeepEqual(jsonSchema.Properties["price"], map[string]any{"type": []any{"number", "null"}, "minimum": 0.0}) ||
+		jsonSchema.Properties["since"]["format"] != "date-time" {
+		t.Errorf("Unexpected JSON Schema:\n%s", &b)
+	}
+
+	b.Reset()
+	if err := schema.WriteAvroSchema(&b, "item"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `"logicalType": "timestamp-micros"`) {
+		t.Errorf("Unexpected Avro schema:\n%s", &b)
+	}
+	if _, err := bigcsv.ParseAvroSchema[struct {
+		ID     int64     `csv:"id"`
+		Price  *float64  `csv:"price"`
+		Active bool      `csv:"active"`
+		Since  time.Time `csv:"since"`
+		Note   *string   `csv:"note"`
+	}](b.String()); err != nil {
+		t.Errorf("Could not bind Avro schema: %v\n%s", err, &b)
+	}
+}
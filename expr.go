@@ -0,0 +1,712 @@
+package bigcsv
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Expr is a compiled expression over the fields of a row, for filters and
+// computed columns defined at run time, e.g. on the command line or in a
+// PipelineSpec:
+//
+//	population > 10000 && state == 'CA'
+//	lower(trim(name)) + ' (' + state + ')'
+//
+// Identifiers name columns; names which are not identifiers, e.g. containing
+// spaces, are quoted with backticks. Strings are quoted with ' or ", and true
+// and false are booleans. The operators are, by increasing precedence:
+//
+//	||
+//	&&
+//	== != < <= > >= ~ (regular expression match, the pattern must be a string)
+//	+ - (+ concatenates strings which are not numbers)
+//	* / %
+//	! - (unary)
+//
+// Fields are strings, which are used as numbers where both sides of an
+// operator are decimal numbers, not NaN or Inf. Comparisons with a quoted
+// string compare strings, so zip == '01234' does not match 1234. The
+// functions are lower, upper, trim, len, contains, startsWith, endsWith,
+// number, abs, round, min, max, coalesce (the first non-empty value) and
+// if(condition, then, else).
+type Expr struct {
+	src  string
+	root exprNode
+}
+
+// exprNode is a node of the syntax tree of an Expr.
+type exprNode func(row []string) (any, error)
+
+// CompileExpr compiles an expression. The resolve function returns the index
+// of a column, see Parser.ColumnIndex, and fails for unknown columns.
+//
+//	expr, err := bigcsv.CompileExpr(src, func(name string) (int, error) {
+//		if ix, ok := parser.ColumnIndex(name); ok {
+//			return ix, nil
+//		}
+//		return 0, fmt.Errorf("unknown column '%s'", name)
+//	})
+func CompileExpr(src string, resolve func(name string) (int, error)) (*Expr, error) {
+	tokens, err := lexExpr(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	c := &exprCompiler{tokens: tokens, resolve: resolve}
+	root, err := c.or()
+	if err == nil && c.peek().kind != tokenEnd {
+		err = fmt.Errorf("unexpected %s", c.peek())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", src, err)
+	}
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression for a row. The result is a string, float64 or
+// bool.
+func (e *Expr) Eval(row []string) (any, error) {
+	return e.root(row)
+}
+
+// EvalString evaluates the expression for a row, formatting the result as a
+// field value.
+func (e *Expr) EvalString(row []string) (string, error) {
+	v, err := e.root(row)
+	if err != nil {
+		return "", err
+	}
+	return formatExprValue(v), nil
+}
+
+// Bool evaluates the expression for a row, which must result in a boolean.
+func (e *Expr) Bool(row []string) (bool, error) {
+	v, err := e.root(row)
+	if err != nil {
+		return false, err
+	}
+	return exprBool(v)
+}
+
+// Match tells whether the expression is true for a row, treating errors as
+// false. It can be used with Filter:
+//
+//	parser.OnData = bigcsv.Filter(expr.Match, next)
+func (e *Expr) Match(row []string) bool {
+	ok, err := e.Bool(row)
+	return ok && err == nil
+}
+
+// ComputeColumns returns an OnData function appending the values of the
+// expressions to a copy of each row and passing it to next. Errors of the
+// expressions are returned as OnData errors.
+func ComputeColumns(exprs []*Expr, next func(row []string) error) func(row []string) error {
+	return func(row []string) error {
+		out := make([]string, len(row), len(row)+len(exprs))
+		copy(out, row)
+		for _, e := range exprs {
+			v, err := e.EvalString(row)
+			if err != nil {
+				return fmt.Errorf("computing %s: %w", e, err)
+			}
+			out = append(out, v)
+		}
+		return next(out)
+	}
+}
+
+// formatExprValue formats a value as a field.
+func formatExprValue(v any) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return v.(string)
+	}
+}
+
+// exprNumber converts a value to a number.
+func exprNumber(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case string:
+		s := strings.TrimSpace(v)
+		if !numeric.MatchString(s) {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// exprBool converts a value to a boolean.
+func exprBool(v any) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return false, fmt.Errorf("%s is not a boolean", quoteExprValue(v))
+}
+
+// quoteExprValue formats a value for error messages.
+func quoteExprValue(v any) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return formatExprValue(v)
+}
+
+// tokenKind is the kind of a token of an expression.
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOp
+)
+
+// token is a token of an expression.
+type token struct {
+	kind tokenKind
+	text string
+	num  float64
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return "'" + t.text + "'"
+	}
+}
+
+// exprOperators are the operators, longer ones first.
+var exprOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "~", "+", "-", "*", "/", "%", "!", "(", ")", ","}
+
+// lexExpr splits an expression into tokens.
+func lexExpr(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(src) && src[i+1] >= '0' && src[i+1] <= '9':
+			j := i
+			for j < len(src) && (src[j] >= '0' && src[j] <= '9' || src[j] == '.' || src[j] == 'e' || src[j] == 'E' ||
+				(src[j] == '+' || src[j] == '-') && (src[j-1] == 'e' || src[j-1] == 'E')) {
+				j++
+			}
+			f, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number '%s'", src[i:j])
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: src[i:j], num: f})
+			i = j
+		case c == '\'' || c == '"' || c == '`':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(src) && src[j] != c; j++ {
+				if src[j] == '\\' && j+1 < len(src) {
+					j++
+				}
+				sb.WriteByte(src[j])
+			}
+			if j == len(src) {
+				return nil, fmt.Errorf("unterminated %c", c)
+			}
+			kind := tokenString
+			if c == '`' {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind: kind, text: sb.String()})
+			i = j + 1
+		case c == '_' || unicode.IsLetter(rune(c)) || c >= 0x80:
+			j := i
+			for j < len(src) && (src[j] == '_' || src[j] == '.' || src[j] >= 0x80 ||
+				unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j]))) {
+				j++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[i:j]})
+			i = j
+		default:
+			op := ""
+			for _, o := range exprOperators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q", c)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEnd}), nil
+}
+
+// exprCompiler compiles tokens into nodes by recursive descent.
+type exprCompiler struct {
+	tokens  []token
+	pos     int
+	resolve func(name string) (int, error)
+}
+
+func (c *exprCompiler) peek() token {
+	return c.tokens[c.pos]
+}
+
+// accept consumes the next token if it is one of the operators.
+func (c *exprCompiler) accept(ops ...string) (string, bool) {
+	t := c.peek()
+	if t.kind != tokenOp {
+		return "", false
+	}
+	for _, op := range ops {
+		if t.text == op {
+			c.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+// expect consumes the operator or fails.
+func (c *exprCompiler) expect(op string) error {
+	if _, ok := c.accept(op); !ok {
+		return fmt.Errorf("expected '%s', got %s", op, c.peek())
+	}
+	return nil
+}
+
+func (c *exprCompiler) or() (exprNode, error) {
+	left, err := c.and()
+	for err == nil {
+		if _, ok := c.accept("||"); !ok {
+			break
+		}
+		var right exprNode
+		if right, err = c.and(); err == nil {
+			left = logical(left, right, true)
+		}
+	}
+	return left, err
+}
+
+func (c *exprCompiler) and() (exprNode, error) {
+	left, err := c.comparison()
+	for err == nil {
+		if _, ok := c.accept("&&"); !ok {
+			break
+		}
+		var right exprNode
+		if right, err = c.comparison(); err == nil {
+			left = logical(left, right, false)
+		}
+	}
+	return left, err
+}
+
+// logical evaluates || (or) and && with short-circuiting.
+func logical(left, right exprNode, or bool) exprNode {
+	return func(row []string) (any, error) {
+		l, err := left(row)
+		if err != nil {
+			return nil, err
+		}
+		b, err := exprBool(l)
+		if err != nil || b == or {
+			return b, err
+		}
+		r, err := right(row)
+		if err != nil {
+			return nil, err
+		}
+		return exprBool(r)
+	}
+}
+
+func (c *exprCompiler) comparison() (exprNode, error) {
+	start := c.pos
+	left, err := c.additive()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.accept("~"); ok {
+		t := c.peek()
+		if t.kind != tokenString {
+			return nil, fmt.Errorf("expected a pattern string after '~', got %s", t)
+		}
+		c.pos++
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []string) (any, error) {
+			l, err := left(row)
+			if err != nil {
+				return nil, err
+			}
+			return re.MatchString(formatExprValue(l)), nil
+		}, nil
+	}
+	op, ok := c.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	textual := c.isString(start)
+	start = c.pos
+	right, err := c.additive()
+	if err != nil {
+		return nil, err
+	}
+	textual = textual || c.isString(start)
+	return func(row []string) (any, error) {
+		l, err := left(row)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(row)
+		if err != nil {
+			return nil, err
+		}
+		n := compareExprValues(l, r, textual)
+		switch op {
+		case "==":
+			return n == 0, nil
+		case "!=":
+			return n != 0, nil
+		case "<":
+			return n < 0, nil
+		case "<=":
+			return n <= 0, nil
+		case ">":
+			return n > 0, nil
+		default:
+			return n >= 0, nil
+		}
+	}, nil
+}
+
+// isString tells whether the operand compiled from the token at start on is
+// a single string literal.
+func (c *exprCompiler) isString(start int) bool {
+	return c.pos == start+1 && c.tokens[start].kind == tokenString
+}
+
+// compareExprValues compares values as numbers if both are numbers, as
+// strings otherwise or if textual.
+func compareExprValues(l, r any, textual bool) int {
+	if x, ok := exprNumber(l); ok && !textual {
+		if y, ok := exprNumber(r); ok {
+			return cmp.Compare(x, y)
+		}
+	}
+	return strings.Compare(formatExprValue(l), formatExprValue(r))
+}
+
+func (c *exprCompiler) additive() (exprNode, error) {
+	left, err := c.multiplicative()
+	for err == nil {
+		op, ok := c.accept("+", "-")
+		if !ok {
+			break
+		}
+		var right exprNode
+		if right, err = c.multiplicative(); err == nil {
+			left = arithmetic(op, left, right)
+		}
+	}
+	return left, err
+}
+
+func (c *exprCompiler) multiplicative() (exprNode, error) {
+	left, err := c.unary()
+	for err == nil {
+		op, ok := c.accept("*", "/", "%")
+		if !ok {
+			break
+		}
+		var right exprNode
+		if right, err = c.unary(); err == nil {
+			left = arithmetic(op, left, right)
+		}
+	}
+	return left, err
+}
+
+// arithmetic applies a binary arithmetic operator. Adding values which are not
+// both numbers concatenates them.
+func arithmetic(op string, left, right exprNode) exprNode {
+	return func(row []string) (any, error) {
+		l, err := left(row)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right(row)
+		if err != nil {
+			return nil, err
+		}
+		x, okl := exprNumber(l)
+		y, okr := exprNumber(r)
+		if !okl || !okr {
+			if op == "+" {
+				return formatExprValue(l) + formatExprValue(r), nil
+			}
+			bad := l
+			if okl {
+				bad = r
+			}
+			return nil, fmt.Errorf("%s is not a number", quoteExprValue(bad))
+		}
+		switch op {
+		case "+":
+			return x + y, nil
+		case "-":
+			return x - y, nil
+		case "*":
+			return x * y, nil
+		case "/":
+			if y == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return x / y, nil
+		default:
+			if y == 0 {
+				return nil, errors.New("division by zero")
+			}
+			return math.Mod(x, y), nil
+		}
+	}
+}
+
+func (c *exprCompiler) unary() (exprNode, error) {
+	op, ok := c.accept("!", "-")
+	if !ok {
+		return c.primary()
+	}
+	operand, err := c.unary()
+	if err != nil {
+		return nil, err
+	}
+	return func(row []string) (any, error) {
+		v, err := operand(row)
+		if err != nil {
+			return nil, err
+		}
+		if op == "!" {
+			b, err := exprBool(v)
+			return !b, err
+		}
+		x, ok := exprNumber(v)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", quoteExprValue(v))
+		}
+		return -x, nil
+	}, nil
+}
+
+func (c *exprCompiler) primary() (exprNode, error) {
+	t := c.peek()
+	c.pos++
+	switch t.kind {
+	case tokenNumber:
+		return constant(t.num), nil
+	case tokenString:
+		return constant(t.text), nil
+	case tokenIdent:
+		if _, ok := c.accept("("); ok {
+			return c.call(t.text)
+		}
+		switch t.text {
+		case "true":
+			return constant(true), nil
+		case "false":
+			return constant(false), nil
+		}
+		ix, err := c.resolve(t.text)
+		if err != nil {
+			return nil, err
+		}
+		return func(row []string) (any, error) {
+			if ix < len(row) {
+				return row[ix], nil
+			}
+			return "", nil
+		}, nil
+	case tokenOp:
+		if t.text == "(" {
+			node, err := c.or()
+			if err != nil {
+				return nil, err
+			}
+			return node, c.expect(")")
+		}
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+// constant returns a node of a constant value.
+func constant(v any) exprNode {
+	return func([]string) (any, error) {
+		return v, nil
+	}
+}
+
+// exprFunc is a function callable in expressions, with its number of
+// arguments, -1 for any number of at least one.
+type exprFunc struct {
+	args int
+	fn   func(args []any) (any, error)
+}
+
+// stringFunc adapts a string function.
+func stringFunc(fn func(string) string) exprFunc {
+	return exprFunc{1, func(args []any) (any, error) {
+		return fn(formatExprValue(args[0])), nil
+	}}
+}
+
+// predicateFunc adapts a string predicate of two arguments.
+func predicateFunc(fn func(s, sub string) bool) exprFunc {
+	return exprFunc{2, func(args []any) (any, error) {
+		return fn(formatExprValue(args[0]), formatExprValue(args[1])), nil
+	}}
+}
+
+// numberFunc adapts a numeric function of one argument.
+func numberFunc(fn func(float64) float64) exprFunc {
+	return exprFunc{1, func(args []any) (any, error) {
+		x, ok := exprNumber(args[0])
+		if !ok {
+			return nil, fmt.Errorf("%s is not a number", quoteExprValue(args[0]))
+		}
+		return fn(x), nil
+	}}
+}
+
+// extremeFunc returns the minimum or maximum of numbers.
+func extremeFunc(better func(x, y float64) bool) exprFunc {
+	return exprFunc{-1, func(args []any) (any, error) {
+		var best float64
+		for i, arg := range args {
+			x, ok := exprNumber(arg)
+			if !ok {
+				return nil, fmt.Errorf("%s is not a number", quoteExprValue(arg))
+			}
+			if i == 0 || better(x, best) {
+				best = x
+			}
+		}
+		return best, nil
+	}}
+}
+
+// exprFuncs are the functions callable in expressions, besides if.
+var exprFuncs = map[string]exprFunc{
+	"lower":      stringFunc(strings.ToLower),
+	"upper":      stringFunc(strings.ToUpper),
+	"trim":       stringFunc(strings.TrimSpace),
+	"contains":   predicateFunc(strings.Contains),
+	"startsWith": predicateFunc(strings.HasPrefix),
+	"endsWith":   predicateFunc(strings.HasSuffix),
+	"abs":        numberFunc(math.Abs),
+	"round":      numberFunc(math.Round),
+	"number":     numberFunc(func(x float64) float64 { return x }),
+	"min":        extremeFunc(func(x, y float64) bool { return x < y }),
+	"max":        extremeFunc(func(x, y float64) bool { return x > y }),
+	"len": {1, func(args []any) (any, error) {
+		return float64(len([]rune(formatExprValue(args[0])))), nil
+	}},
+	"coalesce": {-1, func(args []any) (any, error) {
+		for _, arg := range args {
+			if s, ok := arg.(string); !ok || s != "" {
+				return arg, nil
+			}
+		}
+		return "", nil
+	}},
+}
+
+// call compiles a function call, after its opening parenthesis.
+func (c *exprCompiler) call(name string) (exprNode, error) {
+	var args []exprNode
+	if _, ok := c.accept(")"); !ok {
+		for {
+			arg, err := c.or()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := c.accept(","); !ok {
+				break
+			}
+		}
+		if err := c.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	if name == "if" {
+		if len(args) != 3 {
+			return nil, fmt.Errorf("if takes 3 arguments, got %d", len(args))
+		}
+		return func(row []string) (any, error) {
+			v, err := args[0](row)
+			if err != nil {
+				return nil, err
+			}
+			b, err := exprBool(v)
+			if err != nil {
+				return nil, err
+			}
+			if b {
+				return args[1](row)
+			}
+			return args[2](row)
+		}, nil
+	}
+	f, ok := exprFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown function '%s'", name)
+	}
+	if f.args >= 0 && len(args) != f.args || f.args < 0 && len(args) == 0 {
+		return nil, fmt.Errorf("wrong number of arguments for %s: %d", name, len(args))
+	}
+	return func(row []string) (any, error) {
+		values := make([]any, len(args))
+		for i, arg := range args {
+			v, err := arg(row)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = v
+		}
+		v, err := f.fn(values)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return v, nil
+	}, nil
+}
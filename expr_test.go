@@ -0,0 +1,109 @@
+package bigcsv_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// resolveColumns resolves the columns of a header row.
+func resolveColumns(headers ...string) func(string) (int, error) {
+	return func(name string) (int, error) {
+		if ix := slices.Index(headers, name); ix >= 0 {
+			return ix, nil
+		}
+		return 0, fmt.Errorf("unknown column '%s'", name)
+	}
+}
+
+// TestExpr evaluates expressions over a row.
+func TestExpr(t *testing.T) {
+	resolve := resolveColumns("name", "state", "population", "area code")
+	row := []string{" Alpha ", "CA", "12000", "0415"}
+	for src, want := range map[string]string{
+		"population > 10000 && state == 'CA'":            "true",
+		"population > 9 && population < 100000":          "true",
+		`state == "NY" || !(population <= 5000)`:         "true",
+		"state ~ '^C'":                                   "true",
+		"population / 1000 + 1":                          "13",
+		"-population % 7":                                "-2",
+		"lower(trim(name)) + ' (' + state + ')'":         "alpha (CA)",
+		"`area code` == 415":                             "true",
+		"`area code` + ''":                               "0415",
+		"len(trim(name)) * 2":                            "10",
+		"if(population > 100, 'big', 'small')":           "big",
+		"coalesce('', state)":                            "CA",
+		"max(1, population, 3)":                          "12000",
+		"startsWith(name, ' A') && endsWith(state, 'A')": "true",
+		"round(2.5) + abs(-1.5)":                         "4.5",
+		"`area code` == '415'":                           "false",
+		"'0415' == `area code`":                          "true",
+		"`area code` > '1'":                              "false",
+	} {
+		expr, err := bigcsv.CompileExpr(src, resolve)
+		if err != nil {
+			t.Errorf("%s: %v", src, err)
+			continue
+		}
+		if got, err := expr.EvalString(row); err != nil || got != want {
+			t.Errorf("%s: got %q (%v), expected %q", src, got, err, want)
+		}
+	}
+}
+
+// TestExprNumbers checks which strings are used as numbers.
+func TestExprNumbers(t *testing.T) {
+	resolve := resolveColumns("zip", "value")
+	for _, test := range []struct {
+		src  string
+		row  []string
+		want string
+	}{
+		{"zip == '01234'", []string{"1234"}, "false"},
+		{"zip == 1234", []string{"01234"}, "true"},
+		{"zip != '01234'", []string{"01234"}, "false"},
+		{"value == value", []string{"", "NaN"}, "true"},
+		{"value > 1", []string{"", "Inf"}, "true"}, // "Inf" > "1" as strings
+		{"value < 1", []string{"", "Inf"}, "false"},
+		{"value + 1", []string{"", "NaN"}, "NaN1"},
+		{"value + 1", []string{"", "0x10"}, "0x101"},
+		{"value + 1", []string{"", " 1e3 "}, "1001"},
+	} {
+		expr, err := bigcsv.CompileExpr(test.src, resolve)
+		if err != nil {
+			t.Fatalf("%s: %v", test.src, err)
+		}
+		if got, err := expr.EvalString(test.row); err != nil || got != test.want {
+			t.Errorf("%s for %q: got %q (%v), expected %q", test.src, test.row, got, err, test.want)
+		}
+	}
+}
+
+// TestExprErrors checks compile and evaluation errors.
+func TestExprErrors(t *testing.T) {
+	resolve := resolveColumns("a", "b")
+	for _, src := range []string{"", "a ==", "c > 1", "a ~ b", "(a", "foo(a)", "if(a, b)", "'open", "a # b"} {
+		if _, err := bigcsv.CompileExpr(src, resolve); err == nil {
+			t.Errorf("Expected a compile error for %q", src)
+		}
+	}
+	row := []string{"x", "0"}
+	for _, src := range []string{"a * 2", "1 / b", "a && true", "-a"} {
+		expr, err := bigcsv.CompileExpr(src, resolve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v, err := expr.Eval(row); err == nil {
+			t.Errorf("Expected an evaluation error for %q, got %v", src, v)
+		}
+	}
+	expr, err := bigcsv.CompileExpr("a", resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = expr.Bool(row); err == nil || expr.Match(row) {
+		t.Errorf("Expected a string not to be a boolean")
+	}
+}
@@ -0,0 +1,180 @@
+package bigcsv
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// FileSet runs a Parser on each of several files, e.g. the daily exports in a
+// directory. Many medium-sized files are often processed faster across files
+// than within one, so Concurrency files are processed at once, each with its
+// own reader and Workers.
+type FileSet struct {
+	// Parser creates the Parser for a file, see ParserFor.
+	Parser func(stream Stream) (Runner, error)
+
+	// Concurrency is the number of files processed at once, 1 if zero.
+	Concurrency int
+
+	// Workers is passed to each Run.
+	Workers int
+
+	// ShareWorkers pools Workers for all files instead of giving each file
+	// its own: workers done with a small file help with the larger ones.
+	// Files are then started from the largest, so that cores are not left
+	// idle waiting for a large file started last. Parsers not created by
+	// ParserFor keep their own workers.
+	ShareWorkers bool
+
+	// ErrorPolicy decides whether to carry on with the other files once a
+	// file fails, the default, or to stop all files with StopOnError.
+	ErrorPolicy ErrorPolicy
+}
+
+// Run processes the files at the paths, which are glob patterns as for
+// filepath.Glob or directories, standing for the regular files directly in
+// them. Files are started in lexical order unless sharing workers. The Stats
+// are keyed by path, and the errors of all files are returned together.
+func (fs *FileSet) Run(ctx context.Context, paths ...string) (ArchiveStats, error) {
+	stats := ArchiveStats{Entries: map[string]Stats{}}
+	files, err := expandPaths(paths)
+	if err != nil {
+		return stats, err
+	}
+	concurrency := fs.Concurrency
+	if concurrency == 0 {
+		concurrency = 1
+	}
+	if concurrency < 0 {
+		return stats, fmt.Errorf("invalid concurrency: %d", concurrency)
+	}
+	var pool chan struct{}
+	if fs.ShareWorkers {
+		if fs.Workers < 1 {
+			return stats, fmt.Errorf("invalid number of shared workers: %d", fs.Workers)
+		}
+		pool = make(chan struct{}, fs.Workers)
+		if files, err = largestFirst(files); err != nil {
+			return stats, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, name := range files {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s, err := fs.file(ctx, name, pool)
+			mu.Lock()
+			defer mu.Unlock()
+			if s != nil {
+				stats.Entries[name] = *s
+				stats.Total = stats.Total.Add(*s)
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("file %s: %w", name, err))
+				if fs.ErrorPolicy == StopOnError {
+					cancel()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return stats, errors.Join(errs...)
+}
+
+// file runs the Parser on a single file with the shared pool of workers, if
+// any, returning nil Stats if it did not run.
+func (fs *FileSet) file(ctx context.Context, name string, pool chan struct{}) (*Stats, error) {
+	p, err := fs.Parser(FileStream(name))
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := p.(interface{ share(chan struct{}) }); ok && pool != nil {
+		s.share(pool)
+	}
+	err = p.Run(ctx, fs.Workers)
+	s := p.Stats()
+	return &s, err
+}
+
+// expandPaths resolves glob patterns and directories to sorted, distinct file
+// names.
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, pattern := range paths {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		if matches == nil {
+			return nil, fmt.Errorf("no files match '%s'", pattern)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				files = append(files, match)
+				continue
+			}
+			entries, err := os.ReadDir(match)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if e.Type().IsRegular() {
+					files = append(files, filepath.Join(match, e.Name()))
+				}
+			}
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// largestFirst sorts files by descending size.
+func largestFirst(files []string) ([]string, error) {
+	sizes := make(map[string]int64, len(files))
+	for _, name := range files {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		sizes[name] = info.Size()
+	}
+	slices.SortStableFunc(files, func(a, b string) int {
+		return cmp.Compare(sizes[b], sizes[a])
+	})
+	return files, nil
+}
+
+// share makes the Parser take its worker slots from a pool shared with other
+// Parsers.
+func (p *Parser[T]) share(pool chan struct{}) {
+	p.pool = pool
+}
@@ -0,0 +1,110 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestFileSet processes the files of a directory concurrently, combining
+// their Stats and errors.
+func TestFileSet(t *testing.T) {
+	dir := t.TempDir()
+	for i := range 4 {
+		content := fmt.Sprintf("n\n%d\n%d\n", i, i*10)
+		if i == 2 {
+			content += "x\n"
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("part-%d.csv", i)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var sum atomic.Int64
+	fs := &bigcsv.FileSet{
+		Parser: bigcsv.ParserFor(func(p *bigcsv.Parser[int]) error {
+			p.Parse = func(row []string) (int, error) {
+				return strconv.Atoi(row[0])
+			}
+			p.OnData = func(n int) error {
+				sum.Add(int64(n))
+				return nil
+			}
+			return nil
+		}, bigcsv.WithHeaders()),
+		Concurrency: 2,
+		Workers:     1,
+	}
+	stats, err := fs.Run(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Load() != 66 || len(stats.Entries) != 4 || stats.Total.Rows != 9 || stats.Total.ParseErrors != 1 {
+		t.Fatalf("Got sum %d, stats %+v", sum.Load(), stats)
+	}
+
+	if _, err = fs.Run(context.Background(), filepath.Join(dir, "*.tsv")); err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("Expected an error for a pattern without matches, got: %v", err)
+	}
+}
+
+// TestFileSetShareWorkers checks that files sharing workers never exceed them
+// together, and that the largest file is started first.
+func TestFileSetShareWorkers(t *testing.T) {
+	dir := t.TempDir()
+	for i, rows := range []int{5, 200, 10} {
+		content := "n\n" + strings.Repeat("1\n", rows)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("part-%d.csv", i)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var busy, peak, total atomic.Int64
+	var mu sync.Mutex
+	var started []string
+	fs := &bigcsv.FileSet{
+		Parser: func(stream bigcsv.Stream) (bigcsv.Runner, error) {
+			mu.Lock()
+			started = append(started, filepath.Base(string(stream.(bigcsv.FileStream))))
+			mu.Unlock()
+			return bigcsv.ParserFor(func(p *bigcsv.Parser[int]) error {
+				p.Parse = func(row []string) (int, error) {
+					return strconv.Atoi(row[0])
+				}
+				p.OnData = func(n int) error {
+					cur := busy.Add(1)
+					for old := peak.Load(); cur > old && !peak.CompareAndSwap(old, cur); old = peak.Load() {
+					}
+					total.Add(int64(n))
+					busy.Add(-1)
+					return nil
+				}
+				return nil
+			}, bigcsv.WithHeaders())(stream)
+		},
+		Concurrency:  3,
+		Workers:      4,
+		ShareWorkers: true,
+	}
+	if _, err := fs.Run(context.Background(), filepath.Join(dir, "*.csv")); err != nil {
+		t.Fatal(err)
+	}
+	if total.Load() != 215 || peak.Load() > 4 {
+		t.Errorf("Got %d rows with up to %d busy workers", total.Load(), peak.Load())
+	}
+
+	fs.Concurrency, started = 1, nil
+	if _, err := fs.Run(context.Background(), dir); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(started, []string{"part-1.csv", "part-2.csv", "part-0.csv"}) {
+		t.Errorf("Expected the largest files first, got %q", started)
+	}
+}
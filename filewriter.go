@@ -0,0 +1,170 @@
+package bigcsv
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// FileWriter is a Writer to a file which may be appended to or resumed, see
+// AppendFile and ResumeFile. Close must be called at the end.
+type FileWriter[T any] struct {
+	*Writer[T]
+	file *os.File
+}
+
+// AppendFile opens a Writer appending to the file, creating it along with its
+// directory if missing, e.g. for daily loads into one output. The header row
+// is only written to an empty file, and otherwise must match the first row of
+// the file. A last record without a line terminator, e.g. edited by hand, is
+// ended before appending. Rows counts the records appended.
+func AppendFile[T any](name string, opts ...WriterOption) (*FileWriter[T], error) {
+	return openFileWriter[T](name, -1, opts)
+}
+
+// ResumeFile opens a Writer continuing the file after its first rows
+// records, as counted by Rows of an interrupted run and saved with its
+// checkpoint, see WithCheckpointOutput. Records written after them, including
+// one written partially, are removed. The file is created along with its
+// directory if missing, which is the same as resuming it at 0 records. Rows
+// counts the records in the file, starting at rows.
+//
+// It fails if the file has fewer records, or a different header row.
+func ResumeFile[T any](name string, rows int64, opts ...WriterOption) (*FileWriter[T], error) {
+	if rows < 0 {
+		return nil, fmt.Errorf("invalid number of records to resume at: %d", rows)
+	}
+	return openFileWriter[T](name, rows, opts)
+}
+
+// openFileWriter opens a Writer to the file after its first rows records, or
+// at its end if rows is negative.
+func openFileWriter[T any](name string, rows int64, opts []WriterOption) (*FileWriter[T], error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter[T](f, opts...)
+	if err == nil {
+		err = w.resume(f, rows)
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("could not open %s: %w", name, err)
+	}
+	return &FileWriter[T]{Writer: w, file: f}, nil
+}
+
+// resume reads the header row and the first rows records of the file, or all
+// if rows is negative, and moves to the end of them.
+func (w *Writer[T]) resume(f *os.File, rows int64) error {
+	var in io.Reader = bufio.NewReader(f)
+	if w.cfg.terminator == "\r" {
+		in = &crLines{r: in}
+	}
+	r := csv.NewReader(in)
+	r.Comma = w.cfg.comma
+	r.FieldsPerRecord = -1
+	var offset int64
+	if w.pending {
+		header, err := r.Read()
+		switch {
+		case errors.Is(err, io.EOF):
+		case err != nil:
+			return fmt.Errorf("could not read header: %w", err)
+		case !slices.Equal(header, w.header):
+			return fmt.Errorf("header %q does not match %q", header, w.header)
+		default:
+			w.pending = false
+			offset = r.InputOffset()
+		}
+	}
+	if rows < 0 {
+		return w.seekEnd(f)
+	}
+	for n := int64(0); n < rows; n++ {
+		if _, err := r.Read(); errors.Is(err, io.EOF) {
+			return fmt.Errorf("got %d records to resume at %d", n, rows)
+		} else if err != nil {
+			return fmt.Errorf("could not read record %d: %w", n+1, err)
+		}
+		offset = r.InputOffset()
+	}
+	if err := f.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	w.rows = rows
+	return nil
+}
+
+// seekEnd moves to the end of the file, ending its last record first if the
+// file does not end with a line terminator.
+func (w *Writer[T]) seekEnd(f *os.File) error {
+	end, err := f.Seek(0, io.SeekEnd)
+	if err != nil || end == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err = f.ReadAt(last, end-1); err != nil {
+		return err
+	}
+	term := w.cfg.terminator
+	switch {
+	case last[0] == '\n' || last[0] == '\r' && term == "\r":
+		return nil
+	case last[0] == '\r' && term == "\r\n":
+		term = "\n"
+	}
+	_, err = f.WriteString(term)
+	return err
+}
+
+// crLines reads a file with carriage returns as line terminators, see
+// WithLineTerminator, turning those outside of quoted fields into line feeds
+// for csv.Reader. Offsets are unchanged.
+type crLines struct {
+	r       io.Reader
+	inQuote bool
+}
+
+func (c *crLines) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	for i, ch := range b[:n] {
+		switch {
+		case ch == '"':
+			c.inQuote = !c.inQuote
+		case ch == '\r' && !c.inQuote:
+			b[i] = '\n'
+		}
+	}
+	return n, err
+}
+
+// Sync flushes the records written so far and commits the file to stable
+// storage, returning the number of records, see Rows. It can be passed to
+// WithCheckpointOutput.
+func (w *FileWriter[T]) Sync() (int64, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return 0, err
+	}
+	return w.rows, w.file.Sync()
+}
+
+// Close flushes and closes the file.
+func (w *FileWriter[T]) Close() error {
+	return errors.Join(w.Flush(), w.file.Close())
+}
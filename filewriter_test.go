@@ -0,0 +1,149 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestAppendFile appends to a file, writing the header row once.
+func TestAppendFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "out", "ids.csv")
+	for _, id := range []string{"1", "2"} {
+		w, err := bigcsv.AppendFile[[]string](name, bigcsv.WithHeader("id"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = w.OnData([]string{id}); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if w.Rows() != 1 {
+			t.Errorf("Got %d rows appended, expected 1", w.Rows())
+		}
+	}
+	if b, _ := os.ReadFile(name); string(b) != "id\n1\n2\n" {
+		t.Errorf("Got file %q", b)
+	}
+	if _, err := bigcsv.AppendFile[[]string](name, bigcsv.WithHeader("key")); err == nil {
+		t.Error("Expected an error appending with another header")
+	}
+}
+
+// TestAppendFileTerminators appends to files without a final line terminator
+// and with carriage returns as line terminators.
+func TestAppendFileTerminators(t *testing.T) {
+	for _, test := range []struct {
+		file, terminator, want string
+	}{
+		{"a,b\n1,2", "\n", "a,b\n1,2\n3,4\n"},
+		{"a,b", "\n", "a,b\n3,4\n"},
+		{"a,b\r\n1,2\r", "\r\n", "a,b\r\n1,2\r\n3,4\r\n"},
+		{"a,b\r1,\"x\ry\"\r", "\r", "a,b\r1,\"x\ry\"\r3,4\r"},
+		{"a,b\r1,2", "\r", "a,b\r1,2\r3,4\r"},
+	} {
+		name := filepath.Join(t.TempDir(), "out.csv")
+		if err := os.WriteFile(name, []byte(test.file), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		w, err := bigcsv.AppendFile[[]string](name, bigcsv.WithHeader("a", "b"), bigcsv.WithLineTerminator(test.terminator))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = errors.Join(w.OnData([]string{"3", "4"}), w.Close()); err != nil {
+			t.Fatal(err)
+		}
+		if b, _ := os.ReadFile(name); string(b) != test.want {
+			t.Errorf("Appending to %q got %q, expected %q", test.file, b, test.want)
+		}
+	}
+
+	// Resuming reads records ended by carriage returns too.
+	name := filepath.Join(t.TempDir(), "out.csv")
+	if err := os.WriteFile(name, []byte("a,b\r1,2\r3,4\r"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w, err := bigcsv.ResumeFile[[]string](name, 1, bigcsv.WithHeader("a", "b"), bigcsv.WithLineTerminator("\r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(name); string(b) != "a,b\r1,2\r" {
+		t.Errorf("Resuming got %q", b)
+	}
+}
+
+// TestResumeFile resumes an interrupted transform from its checkpoint,
+// dropping the output written after it.
+func TestResumeFile(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.csv")
+	output := filepath.Join(dir, "out.csv")
+	if err := os.WriteFile(input, []byte("id\n1\n2\n3\n4\n5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := bigcsv.FileCheckpointStore(filepath.Join(dir, "checkpoints"))
+	ctx := context.Background()
+	run := func(fail string) error {
+		var written int64
+		if cp, err := store.Load(ctx, "ids"); err != nil {
+			t.Fatal(err)
+		} else if cp != nil {
+			written = cp.Output
+		}
+		out, err := bigcsv.ResumeFile[[]string](output, written, bigcsv.WithHeader("id"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser, err := bigcsv.NewRowParser(bigcsv.FileStream(input),
+			bigcsv.WithHeaders(),
+			bigcsv.WithErrorPolicy(bigcsv.StopOnError),
+			bigcsv.WithCheckpoint(store, "ids", 2),
+			bigcsv.WithCheckpointOutput(out),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.OnData = func(row []string) error {
+			if row[0] == fail {
+				return errors.New("disk full")
+			}
+			return out.OnData(row)
+		}
+		return errors.Join(parser.Run(ctx, 1), out.Close())
+	}
+
+	if err := run("4"); err == nil {
+		t.Fatal("Expected the first run to fail")
+	}
+	// A record written partially by a crash.
+	f, err := os.OpenFile(output, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("9\n\"unfinished")
+	f.Close()
+	if err = run(""); err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := os.ReadFile(output); string(b) != "id\n1\n2\n3\n4\n5\n" {
+		t.Errorf("Got output %q", b)
+	}
+	if cp, _ := store.Load(ctx, "ids"); cp == nil || cp.Rows != 5 || cp.Output != 5 {
+		t.Errorf("Unexpected final checkpoint %+v", cp)
+	}
+	if _, err = bigcsv.ResumeFile[[]string](output, 6, bigcsv.WithHeader("id")); err == nil {
+		t.Error("Expected an error resuming after the end")
+	}
+	if _, err = bigcsv.NewFromString[[]string]("a\n", bigcsv.WithCheckpointOutput(&bigcsv.FileWriter[[]string]{})); err == nil {
+		t.Error("Expected an error for a checkpoint output without a checkpoint")
+	}
+}
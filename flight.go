@@ -0,0 +1,124 @@
+package bigcsv
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// flightSender streams batches as FlightData messages of a DoPut call.
+type flightSender struct {
+	pw         *io.PipeWriter
+	enc        arrowEncoder
+	descriptor []byte
+	done       chan error
+}
+
+// FlightSender streams record batches to the DoPut method of the Arrow Flight
+// service at url, e.g. "https://flight.example.com:8815", uploading them to
+// the flight with the descriptor path. The batches are sent as Arrow IPC
+// messages in a single gRPC call, which needs HTTP/2: it is negotiated for
+// https, while a plaintext service needs a client whose transport speaks
+// unencrypted HTTP/2, see http.Protocols. Cancelling ctx aborts the call.
+// CloseSend returns an error if the call does not end with gRPC status OK.
+func FlightSender(ctx context.Context, client *http.Client, url string, path ...string) (BatchSender, error) {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimSuffix(url, "/")+"/arrow.flight.protocol.FlightService/DoPut", pr)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("Te", "trailers")
+	descriptor := protoVarint(nil, 1, 1) // type PATH
+	for _, p := range path {
+		descriptor = protoBytes(descriptor, 3, []byte(p))
+	}
+	s := &flightSender{pw: pw, descriptor: descriptor, done: make(chan error, 1)}
+	go func() {
+		res, err := client.Do(req)
+		if err != nil {
+			err = fmt.Errorf("could not request: %w", err)
+		} else {
+			err = grpcStatus(res)
+		}
+		pr.CloseWithError(err) // unblock Send if the call failed early
+		s.done <- err
+	}()
+	return s, nil
+}
+
+func (s *flightSender) Send(batch *RecordBatch) error {
+	return s.enc.encode(batch, s.message)
+}
+
+func (s *flightSender) CloseSend() error {
+	s.pw.Close()
+	return <-s.done
+}
+
+// message writes a FlightData message as a gRPC frame, the first one carrying
+// the flight descriptor.
+func (s *flightSender) message(meta, body []byte) error {
+	var data []byte
+	if s.descriptor != nil {
+		data = protoBytes(data, 1, s.descriptor)
+		s.descriptor = nil
+	}
+	data = protoBytes(data, 2, meta)
+	if len(body) > 0 {
+		data = protoBytes(data, 1000, body)
+	}
+	frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(data)))
+	for _, b := range [][]byte{frame, data} {
+		if _, err := s.pw.Write(b); err != nil {
+			return fmt.Errorf("could not send batch: %w", err)
+		}
+	}
+	return nil
+}
+
+// grpcStatus reads the response of a gRPC call, returning an error unless the
+// call ended with status OK.
+func grpcStatus(res *http.Response) error {
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response status: %s", res.Status)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		return fmt.Errorf("could not read response: %w", err)
+	}
+	// Calls failing right away send the status with the headers.
+	status, msg := res.Header.Get("Grpc-Status"), res.Header.Get("Grpc-Message")
+	if status == "" {
+		status, msg = res.Trailer.Get("Grpc-Status"), res.Trailer.Get("Grpc-Message")
+	}
+	switch status {
+	case "0":
+		return nil
+	case "":
+		return errors.New("response without gRPC status")
+	}
+	if m, err := url.PathUnescape(msg); err == nil {
+		msg = m
+	}
+	return fmt.Errorf("gRPC status %s: %s", status, msg)
+}
+
+// protoVarint appends a varint field of a protocol buffer message.
+func protoVarint(b []byte, field int, v uint64) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3)
+	return binary.AppendUvarint(b, v)
+}
+
+// protoBytes appends a length delimited field of a protocol buffer message.
+func protoBytes(b []byte, field int, v []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|2)
+	b = binary.AppendUvarint(b, uint64(len(v)))
+	return append(b, v...)
+}
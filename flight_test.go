@@ -0,0 +1,126 @@
+package bigcsv_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestFlightSender uploads batches to a test Flight service.
+func TestFlightSender(t *testing.T) {
+	var messages, bodies int
+	var path []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+		defer func() {
+			if strings.Contains(strings.Join(path, "/"), "denied") {
+				w.Header().Set("Grpc-Status", "7")
+				w.Header().Set("Grpc-Message", "no%20access")
+			} else {
+				w.Header().Set("Grpc-Status", "0")
+			}
+		}()
+		if r.ProtoMajor != 2 || r.URL.Path != "/arrow.flight.protocol.FlightService/DoPut" {
+			t.Errorf("Request %s %s", r.Proto, r.URL.Path)
+			return
+		}
+		br := bufio.NewReader(r.Body)
+		for {
+			frame := make([]byte, 5)
+			if _, err := io.ReadFull(br, frame); err != nil {
+				return
+			}
+			data := make([]byte, binary.BigEndian.Uint32(frame[1:]))
+			if _, err := io.ReadFull(br, data); err != nil {
+				t.Error(err)
+				return
+			}
+			fields := protoFields(t, data)
+			if descriptor, ok := fields[1]; ok {
+				path = nil
+				for field, v := range protoPairs(t, descriptor) {
+					if field == 3 {
+						path = append(path, string(v))
+					}
+				}
+			}
+			if _, ok := fields[2]; !ok {
+				t.Error("FlightData without header")
+			}
+			if _, ok := fields[1000]; ok {
+				bodies++
+			}
+			messages++
+		}
+	}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	upload := func(path ...string) error {
+		parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n3,three\n")
+		if err != nil {
+			t.Fatal(err)
+		}
+		sender, err := bigcsv.FlightSender(context.Background(), srv.Client(), srv.URL, path...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sink := bigcsv.NewBatchSink[Number](sender, 2)
+		parser.Parse = ParseNumber
+		parser.OnData = sink.OnData
+		return errors.Join(parser.Run(context.Background(), 1), sink.Close())
+	}
+	if err := upload("imports", "numbers"); err != nil {
+		t.Fatal(err)
+	}
+	if messages != 3 || bodies != 2 || strings.Join(path, "/") != "imports/numbers" {
+		t.Fatalf("Service received %d messages, %d with a body, for path %v", messages, bodies, path)
+	}
+	if err := upload("denied"); err == nil || !strings.Contains(err.Error(), "no access") {
+		t.Fatalf("Expected the gRPC status as error, got %v", err)
+	}
+}
+
+// protoFields returns the length delimited fields of a protocol buffer
+// message by number.
+func protoFields(t *testing.T, b []byte) map[uint64][]byte {
+	fields := map[uint64][]byte{}
+	for field, v := range protoPairs(t, b) {
+		fields[field] = v
+	}
+	return fields
+}
+
+// protoPairs iterates over the fields of a protocol buffer message, skipping
+// varints.
+func protoPairs(t *testing.T, b []byte) func(yield func(uint64, []byte) bool) {
+	return func(yield func(uint64, []byte) bool) {
+		for len(b) > 0 {
+			key, n := binary.Uvarint(b)
+			b = b[n:]
+			v, n := binary.Uvarint(b)
+			b = b[n:]
+			if key&7 == 0 {
+				continue
+			}
+			if key&7 != 2 || n <= 0 || v > uint64(len(b)) {
+				t.Errorf("Unexpected field %x", key)
+				return
+			}
+			if !yield(key>>3, b[:v]) {
+				return
+			}
+			b = b[v:]
+		}
+	}
+}
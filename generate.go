@@ -0,0 +1,174 @@
+package bigcsv
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/format"
+	gotoken "go/token"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// generatedFormats are the exported variables of the known number and date
+// formats, used by generated code.
+var generatedFormats = map[string]map[string]string{
+	TypeInteger: {"en": "NumberFormatEN", "de": "NumberFormatDE", "fr": "NumberFormatFR", "ch": "NumberFormatCH"},
+	TypeNumber:  {"en": "NumberFormatEN", "de": "NumberFormatDE", "fr": "NumberFormatFR", "ch": "NumberFormatCH"},
+	TypeDate:    {"iso": "DateFormatISO", "us": "DateFormatUS", "eu": "DateFormatEU", "epoch": "DateFormatEpoch"},
+}
+
+// Generate writes Go source declaring a struct named typeName in package pkg,
+// with a field per column, and a Parse function named "Parse" + typeName
+// converting rows with the columns in the order of the schema, for feeds
+// stable enough not to look up columns by name:
+//
+//	parser.Parse = orders.ParseOrder
+//
+// Empty values of optional columns are left zero, other values are converted
+// according to the type and format of their column, failing with a
+// *ColumnError. Constraints such as Pattern are not checked, use WithSchema.
+// The struct fields also have `csv` tags for WithStructTags.
+func (s Schema) Generate(w io.Writer, pkg, typeName string) error {
+	if err := s.validate(); err != nil {
+		return fmt.Errorf("invalid schema: %w", err)
+	}
+	if !gotoken.IsIdentifier(pkg) || !gotoken.IsExported(typeName) || !gotoken.IsIdentifier(typeName) {
+		return fmt.Errorf("invalid package %q or type name %q", pkg, typeName)
+	}
+	fields := make([]string, len(s.Columns))
+	seen := map[string]string{}
+	for i, col := range s.Columns {
+		fields[i] = goName(col.Name)
+		if other, ok := seen[fields[i]]; ok {
+			return fmt.Errorf("columns '%s' and '%s' both map to the field %s", other, col.Name, fields[i])
+		}
+		seen[fields[i]] = col.Name
+		if col.Format != "" && generatedFormats[col.Type][col.Format] == "" {
+			return fmt.Errorf("column '%s': format %q is not known to generated code", col.Name, col.Format)
+		}
+	}
+
+	var b bytes.Buffer
+	imports := map[string]bool{"fmt": true}
+	fmt.Fprintf(&b, "// %s is a row of the columns %s.\ntype %s struct {\n", typeName, s.names(), typeName)
+	for i, col := range s.Columns {
+		goType := "string"
+		switch col.Type {
+		case TypeInteger:
+			goType = "int64"
+		case TypeNumber:
+			goType = "float64"
+		case TypeBoolean:
+			goType, imports["strconv"] = "bool", true
+		case TypeDate:
+			goType, imports["time"] = "time.Time", true
+		}
+		tag := col.Name
+		if col.Format != "" {
+			option := "number"
+			if col.Type == TypeDate {
+				option = "date"
+			}
+			tag += "," + option + "=" + col.Format
+		}
+		fmt.Fprintf(&b, "\t%s %s `csv:%q`\n", fields[i], goType, tag)
+	}
+	fmt.Fprintf(&b, "}\n\n")
+
+	fmt.Fprintf(&b, "// Parse%s converts a row with the columns in the order of the schema.\n", typeName)
+	fmt.Fprintf(&b, "func Parse%s(row []string) (%s, error) {\n\tvar v %s\n", typeName, typeName, typeName)
+	fmt.Fprintf(&b, "\tif len(row) < %d {\n\t\treturn v, fmt.Errorf(\"got %%d columns, need %d\", len(row))\n\t}\n", len(s.Columns), len(s.Columns))
+	if s.converts() {
+		fmt.Fprintf(&b, "\tvar err error\n")
+	}
+	for i, col := range s.Columns {
+		fail := fmt.Sprintf("return v, &bigcsv.ColumnError{Column: %q, Index: %d, Err: err}", col.Name, i)
+		if col.Required {
+			imports["errors"] = true
+			fmt.Fprintf(&b, "\tif row[%d] == \"\" {\n\t\treturn v, &bigcsv.ColumnError{Column: %q, Index: %d, Err: errors.New(\"value required\")}\n\t}\n", i, col.Name, i)
+		}
+		var convert string
+		switch col.Type {
+		case TypeInteger:
+			convert = fmt.Sprintf("v.%s, err = bigcsv.%s.ParseInt(row[%d])", fields[i], generatedFormats[col.Type][cmp.Or(col.Format, "en")], i)
+		case TypeNumber:
+			convert = fmt.Sprintf("v.%s, err = bigcsv.%s.ParseFloat(row[%d])", fields[i], generatedFormats[col.Type][cmp.Or(col.Format, "en")], i)
+		case TypeBoolean:
+			convert = fmt.Sprintf("v.%s, err = strconv.ParseBool(row[%d])", fields[i], i)
+		case TypeDate:
+			convert = fmt.Sprintf("v.%s, _, err = bigcsv.%s.ParseTime(row[%d])", fields[i], generatedFormats[col.Type][cmp.Or(col.Format, "iso")], i)
+		default:
+			fmt.Fprintf(&b, "\tv.%s = row[%d]\n", fields[i], i)
+			continue
+		}
+		if col.Required {
+			fmt.Fprintf(&b, "\tif %s; err != nil {\n\t\t%s\n\t}\n", convert, fail)
+		} else {
+			fmt.Fprintf(&b, "\tif row[%d] != \"\" {\n\t\tif %s; err != nil {\n\t\t\t%s\n\t\t}\n\t}\n", i, convert, fail)
+		}
+	}
+	fmt.Fprintf(&b, "\treturn v, nil\n}\n")
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by bigcsv from a schema. DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	for _, path := range []string{"errors", "fmt", "strconv", "time"} {
+		if imports[path] {
+			fmt.Fprintf(&src, "\t%q\n", path)
+		}
+	}
+	fmt.Fprintf(&src, "\n\t\"github.com/typeduck/bigcsv\"\n)\n\n")
+	src.Write(b.Bytes())
+	formatted, err := format.Source(src.Bytes())
+	if err != nil {
+		return fmt.Errorf("could not format generated code: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+// names lists the names of the columns for a doc comment.
+func (s Schema) names() string {
+	names := make([]string, len(s.Columns))
+	for i, col := range s.Columns {
+		names[i] = col.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// converts tells whether any column is not a string.
+func (s Schema) converts() bool {
+	for _, col := range s.Columns {
+		if cmp.Or(col.Type, TypeString) != TypeString {
+			return true
+		}
+	}
+	return false
+}
+
+// goInitialisms are written in upper case in field names.
+var goInitialisms = map[string]bool{"ID": true, "URL": true, "URI": true, "API": true, "HTTP": true, "IP": true, "UUID": true, "SKU": true}
+
+// goName converts a column name to an exported Go identifier, e.g.
+// "customer_id" to "CustomerID".
+func goName(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	id := b.String()
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "Column" + id
+	}
+	return id
+}
@@ -0,0 +1,62 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestGenerate generates a struct and Parse function from a JSON schema.
+func TestGenerate(t *testing.T) {
+	schema, err := bigcsv.LoadSchema(strings.NewReader(`{"columns": [
+		{"name": "order_id", "type": "integer", "required": true},
+		{"name": "Betrag", "type": "number", "format": "de", "min": 0},
+		{"name": "shipped", "type": "date"},
+		{"name": "note"}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err = schema.Generate(&b, "orders", "Order"); err != nil {
+		t.Fatal(err)
+	}
+	src := b.String()
+	if _, err = parser.ParseFile(token.NewFileSet(), "order.go", src, 0); err != nil {
+		t.Fatalf("Generated invalid code: %v\n%s", err, src)
+	}
+	for _, line := range []string{
+		"\tOrderID int64     `csv:\"order_id\"`\n",
+		"\tBetrag  float64   `csv:\"Betrag,number=de\"`\n",
+		"\tShipped time.Time `csv:\"shipped\"`\n",
+		"func ParseOrder(row []string) (Order, error) {\n",
+		"\t\tif v.Betrag, err = bigcsv.NumberFormatDE.ParseFloat(row[1]); err != nil {\n",
+		"\tv.Note = row[3]\n",
+	} {
+		if !strings.Contains(src, line) {
+			t.Errorf("Generated code lacks %q:\n%s", line, src)
+		}
+	}
+}
+
+// TestLoadSchemaInvalid checks that invalid schemas are rejected.
+func TestLoadSchemaInvalid(t *testing.T) {
+	for _, spec := range []string{
+		`{"columns": [{"name": "a", "type": "float"}]}`,
+		`{"columns": [{"name": "a"}, {"name": "a"}]}`,
+		`{"columns": [{"name": "a", "pattern": "("}]}`,
+		`{"colums": [{"name": "a"}]}`,
+	} {
+		if _, err := bigcsv.LoadSchema(strings.NewReader(spec)); err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+	schema := bigcsv.Schema{Columns: []bigcsv.SchemaColumn{{Name: "a-b"}, {Name: "a b"}}}
+	if err := schema.Generate(&bytes.Buffer{}, "x", "Row"); err == nil {
+		t.Error("Expected an error for columns mapping to the same field")
+	}
+}
@@ -0,0 +1,215 @@
+package bigcsv
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Point is a geographic position in degrees. As a struct field it accepts WKT
+// points like "POINT (13.4 52.5)", with the longitude first, and pairs like
+// "52.5,13.4" or "52.5 13.4", with the latitude first. It is written as WKT.
+type Point struct {
+	Lon, Lat float64
+}
+
+// ParsePoint parses a WKT point or a "lat,lon" pair, see Point.
+func ParsePoint(s string) (Point, error) {
+	s = strings.TrimSpace(s)
+	if body, ok := wktBody(s, "POINT"); ok {
+		coords, err := parseCoords(body)
+		if err != nil {
+			return Point{}, err
+		}
+		if len(coords) != 1 {
+			return Point{}, fmt.Errorf("invalid WKT point %q", s)
+		}
+		return coords[0], nil
+	}
+	lat, lon, ok := strings.Cut(s, ",")
+	if !ok {
+		lat, lon, ok = strings.Cut(s, " ")
+	}
+	if !ok {
+		return Point{}, fmt.Errorf("invalid point %q", s)
+	}
+	p, err := newPoint(lon, lat)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid point %q: %w", s, err)
+	}
+	return p, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Point) UnmarshalText(text []byte) error {
+	var err error
+	*p, err = ParsePoint(string(text))
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, writing WKT.
+func (p Point) MarshalText() ([]byte, error) {
+	return []byte("POINT (" + p.coords() + ")"), nil
+}
+
+// coords formats the point as WKT coordinates.
+func (p Point) coords() string {
+	return strconv.FormatFloat(p.Lon, 'f', -1, 64) + " " + strconv.FormatFloat(p.Lat, 'f', -1, 64)
+}
+
+// earthRadius is the mean radius of the earth in meters.
+const earthRadius = 6371008.8
+
+// Distance returns the great-circle distance to q in meters.
+func (p Point) Distance(q Point) float64 {
+	lat1, lat2 := p.Lat*math.Pi/180, q.Lat*math.Pi/180
+	dLat, dLon := lat2-lat1, (q.Lon-p.Lon)*math.Pi/180
+	a := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// Polygon is a WKT polygon, the outer ring followed by any holes. Rings are
+// closed, their last point repeating the first. As a struct field it accepts
+// WKT like "POLYGON ((0 0, 4 0, 4 4, 0 4, 0 0))".
+type Polygon [][]Point
+
+// ParsePolygon parses a WKT polygon.
+func ParsePolygon(s string) (Polygon, error) {
+	body, ok := wktBody(strings.TrimSpace(s), "POLYGON")
+	if !ok {
+		return nil, fmt.Errorf("invalid WKT polygon %q", s)
+	}
+	var poly Polygon
+	for body = strings.TrimSpace(body); body != ""; {
+		rest, ok := strings.CutPrefix(body, "(")
+		end := strings.IndexByte(rest, ')')
+		if !ok || end < 0 {
+			return nil, fmt.Errorf("invalid WKT polygon %q", s)
+		}
+		ring, err := parseCoords(rest[:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+			return nil, fmt.Errorf("invalid WKT polygon %q: ring not closed", s)
+		}
+		poly = append(poly, ring)
+		body = strings.TrimSpace(rest[end+1:])
+		if body, ok = strings.CutPrefix(body, ","); ok {
+			body = strings.TrimSpace(body)
+		}
+	}
+	if len(poly) == 0 {
+		return nil, fmt.Errorf("invalid WKT polygon %q: no rings", s)
+	}
+	return poly, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (p *Polygon) UnmarshalText(text []byte) error {
+	var err error
+	*p, err = ParsePolygon(string(text))
+	return err
+}
+
+// MarshalText implements encoding.TextMarshaler, writing WKT.
+func (p Polygon) MarshalText() ([]byte, error) {
+	var sb strings.Builder
+	sb.WriteString("POLYGON (")
+	for i, ring := range p {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('(')
+		for j, pt := range ring {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(pt.coords())
+		}
+		sb.WriteByte(')')
+	}
+	sb.WriteByte(')')
+	return []byte(sb.String()), nil
+}
+
+// Contains tells whether the point lies within the polygon and outside its
+// holes, treating coordinates as planar.
+func (p Polygon) Contains(pt Point) bool {
+	if len(p) == 0 || !inRing(p[0], pt) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if inRing(hole, pt) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRing tells whether the point lies within the ring, by ray casting.
+func inRing(ring []Point, pt Point) bool {
+	in := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > pt.Lat) != (b.Lat > pt.Lat) &&
+			pt.Lon < (b.Lon-a.Lon)*(pt.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			in = !in
+		}
+	}
+	return in
+}
+
+// wktBody returns what is within the outer parentheses of a WKT geometry of
+// the given type, ignoring case and a "Z" or "M" dimension.
+func wktBody(s, typ string) (string, bool) {
+	if len(s) < len(typ) || !strings.EqualFold(s[:len(typ)], typ) {
+		return "", false
+	}
+	s = strings.TrimSpace(s[len(typ):])
+	for _, dim := range []string{"ZM", "Z", "M"} {
+		if len(s) > len(dim) && strings.EqualFold(s[:len(dim)], dim) && !strings.HasPrefix(s, "(") {
+			s = strings.TrimSpace(s[len(dim):])
+			break
+		}
+	}
+	if !strings.HasPrefix(s, "(") || !strings.HasSuffix(s, ")") {
+		return "", false
+	}
+	return s[1 : len(s)-1], true
+}
+
+// parseCoords parses comma-separated WKT coordinates, ignoring any beyond
+// longitude and latitude.
+func parseCoords(s string) ([]Point, error) {
+	var points []Point
+	for _, c := range strings.Split(s, ",") {
+		f := strings.Fields(c)
+		if len(f) < 2 || len(f) > 4 {
+			return nil, fmt.Errorf("invalid WKT coordinates %q", c)
+		}
+		p, err := newPoint(f[0], f[1])
+		if err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, nil
+}
+
+// newPoint parses and checks the coordinates of a point.
+func newPoint(lon, lat string) (Point, error) {
+	x, err := strconv.ParseFloat(strings.TrimSpace(lon), 64)
+	if err != nil {
+		return Point{}, err
+	}
+	y, err := strconv.ParseFloat(strings.TrimSpace(lat), 64)
+	if err != nil {
+		return Point{}, err
+	}
+	if math.Abs(y) > 90 || math.Abs(x) > 180 {
+		return Point{}, fmt.Errorf("coordinates out of range: %g, %g", y, x)
+	}
+	return Point{Lon: x, Lat: y}, nil
+}
@@ -0,0 +1,63 @@
+package bigcsv_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestGeoColumns checks parsing points and polygons into struct fields and
+// writing them as WKT.
+func TestGeoColumns(t *testing.T) {
+	type Area struct {
+		Name   string         `csv:"name"`
+		Center bigcsv.Point   `csv:"center"`
+		Shape  bigcsv.Polygon `csv:"shape"`
+	}
+	input := "name,center,shape\n" +
+		"park,\"52.5,13.4\",\"POLYGON ((13 52, 14 52, 14 53, 13 53, 13 52), (13.3 52.4, 13.5 52.4, 13.5 52.6, 13.3 52.4))\"\n" +
+		"lake,POINT (13.2 52.4),\"polygon((13 52,14 52,14 53,13 52))\"\n" +
+		"bad,\"95,13\",\n"
+	parser, err := bigcsv.NewFromString[Area](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var areas []Area
+	var errs int
+	for a, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			errs++
+			continue
+		}
+		areas = append(areas, a)
+	}
+	if len(areas) != 2 || errs != 1 {
+		t.Fatalf("Unexpected areas %+v with %d errors", areas, errs)
+	}
+	park, lake := areas[0], areas[1]
+	if park.Center != (bigcsv.Point{Lon: 13.4, Lat: 52.5}) || lake.Center != (bigcsv.Point{Lon: 13.2, Lat: 52.4}) {
+		t.Errorf("Unexpected centers: %+v, %+v", park.Center, lake.Center)
+	}
+	if len(park.Shape) != 2 || park.Shape.Contains(park.Center) || !park.Shape.Contains(lake.Center) {
+		t.Errorf("Unexpected shape: %+v", park.Shape)
+	}
+	if d := park.Center.Distance(lake.Center); math.Abs(d-17531) > 10 {
+		t.Errorf("Unexpected distance: %f", d)
+	}
+
+	var sb strings.Builder
+	w, err := bigcsv.NewWriter[Area](&sb, bigcsv.WithoutHeader())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.OnData(lake); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	if want := "lake,POINT (13.2 52.4),\"POLYGON ((13 52, 14 52, 14 53, 13 52))\"\n"; sb.String() != want {
+		t.Errorf("Expected %q, got %q", want, sb.String())
+	}
+}
@@ -0,0 +1,166 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// gunzip decompresses a gzip stream, reading all of its members as written
+// by concatenating .gz files. BGZF streams, which consist of small members
+// recording their size, are decompressed in parallel. Closing the result
+// closes rc, which is left open on error.
+func gunzip(rc io.ReadCloser) (io.ReadCloser, error) {
+	r := bufio.NewReader(rc)
+	if isBGZF(r) {
+		return newBGZFReader(r, rc, runtime.GOMAXPROCS(0)), nil
+	}
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return readCloser{gz, rc}, nil
+}
+
+// bgzfHeader is the size of the fixed part of a gzip header, followed by the
+// extra field holding the BGZF block size.
+const bgzfHeader = 12
+
+// isBGZF tells whether the stream starts with a BGZF block.
+func isBGZF(r *bufio.Reader) bool {
+	_, ok := bgzfBlockSize(r)
+	return ok
+}
+
+// bgzfBlockSize peeks at the header of the next block, returning its total
+// size if it is a BGZF block.
+func bgzfBlockSize(r *bufio.Reader) (int, bool) {
+	h, err := r.Peek(bgzfHeader)
+	if err != nil || h[0] != 0x1f || h[1] != 0x8b || h[2] != 8 || h[3]&4 == 0 {
+		return 0, false
+	}
+	xlen := int(binary.LittleEndian.Uint16(h[10:]))
+	h, err = r.Peek(bgzfHeader + xlen)
+	if err != nil {
+		return 0, false
+	}
+	for extra := h[bgzfHeader:]; len(extra) >= 4; {
+		n := int(binary.LittleEndian.Uint16(extra[2:]))
+		if extra[0] == 'B' && extra[1] == 'C' && n == 2 && len(extra) >= 6 {
+			return int(binary.LittleEndian.Uint16(extra[4:])) + 1, true
+		}
+		extra = extra[min(4+n, len(extra)):]
+	}
+	return 0, false
+}
+
+// bgzfBlock is the decompressed content of a block, or its error.
+type bgzfBlock struct {
+	data []byte
+	err  error
+}
+
+// bgzfReader decompresses BGZF blocks in parallel, a read-ahead of blocks
+// being decompressed while the previous ones are read.
+type bgzfReader struct {
+	closer io.Closer
+	queue  chan chan bgzfBlock // blocks in stream order
+	stop   chan struct{}
+	once   sync.Once
+
+	cur []byte
+	err error
+}
+
+func newBGZFReader(r *bufio.Reader, closer io.Closer, workers int) *bgzfReader {
+	b := &bgzfReader{
+		closer: closer,
+		queue:  make(chan chan bgzfBlock, 2*workers),
+		stop:   make(chan struct{}),
+	}
+	go b.split(r)
+	return b
+}
+
+// split reads the blocks, queueing their decompression until the stream ends.
+func (b *bgzfReader) split(r *bufio.Reader) {
+	defer close(b.queue)
+	for {
+		result := make(chan bgzfBlock, 1)
+		block, err := readBGZFBlock(r)
+		if err != nil {
+			result <- bgzfBlock{err: err}
+		} else {
+			go func() {
+				data, err := inflate(block)
+				result <- bgzfBlock{data, err}
+			}()
+		}
+		select {
+		case b.queue <- result:
+		case <-b.stop:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// readBGZFBlock reads the next compressed block, returning io.EOF at the end
+// of the stream.
+func readBGZFBlock(r *bufio.Reader) ([]byte, error) {
+	if _, err := r.Peek(1); err != nil {
+		return nil, err
+	}
+	size, ok := bgzfBlockSize(r)
+	if !ok {
+		return nil, errors.New("gzip: BGZF stream continues with a member without block size")
+	}
+	block := make([]byte, size)
+	if _, err := io.ReadFull(r, block); err != nil {
+		return nil, fmt.Errorf("gzip: truncated BGZF block: %w", io.ErrUnexpectedEOF)
+	}
+	return block, nil
+}
+
+// inflate decompresses a single gzip member, verifying its checksum.
+func inflate(block []byte) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(block))
+	if err != nil {
+		return nil, err
+	}
+	gz.Multistream(false)
+	return io.ReadAll(gz)
+}
+
+func (b *bgzfReader) Read(p []byte) (int, error) {
+	for len(b.cur) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		result, ok := <-b.queue
+		if !ok {
+			b.err = io.EOF
+			continue
+		}
+		block := <-result
+		b.cur, b.err = block.data, block.err
+	}
+	n := copy(p, b.cur)
+	b.cur = b.cur[n:]
+	return n, nil
+}
+
+func (b *bgzfReader) Close() error {
+	b.once.Do(func() {
+		close(b.stop)
+	})
+	return b.closer.Close()
+}
@@ -0,0 +1,71 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// gzipMember compresses s as a single gzip member, as a BGZF block if bgzf.
+func gzipMember(t *testing.T, s string, bgzf bool) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if bgzf {
+		gz.Header.Extra = []byte{'B', 'C', 2, 0, 0, 0}
+	}
+	if _, err := gz.Write([]byte(s)); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if bgzf {
+		binary.LittleEndian.PutUint16(b[16:], uint16(len(b)-1))
+	}
+	return b
+}
+
+// TestGzipMembers reads files of several gzip members, plain and BGZF.
+func TestGzipMembers(t *testing.T) {
+	dir := t.TempDir()
+	for _, bgzf := range []bool{false, true} {
+		var data []byte
+		var expected strings.Builder
+		for i := range 50 {
+			rows := fmt.Sprintf("%d,a\n%d,b\n", 2*i, 2*i+1)
+			expected.WriteString(rows)
+			data = append(data, gzipMember(t, rows, bgzf)...)
+		}
+		if bgzf {
+			data = append(data, gzipMember(t, "", true)...) // end of file marker
+		}
+		name := filepath.Join(dir, fmt.Sprintf("bgzf-%t.csv.gz", bgzf))
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		parser, err := bigcsv.New[[]string](bigcsv.FileStream(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got strings.Builder
+		parser.OnRow = func(row []string) error {
+			got.WriteString(strings.Join(row, ",") + "\n")
+			return nil
+		}
+		if err = parser.Run(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+		if got.String() != expected.String() {
+			t.Errorf("BGZF %t: got rows:\n%s", bgzf, got.String())
+		}
+	}
+}
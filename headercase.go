@@ -0,0 +1,167 @@
+package bigcsv
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// HeaderCase is a naming convention of header names, see WithHeaderCase.
+type HeaderCase int
+
+const (
+	// KeepCase leaves header names as they are. This is the default.
+	KeepCase HeaderCase = iota
+
+	// SnakeCase writes "customer_id".
+	SnakeCase
+
+	// UpperSnakeCase writes "CUSTOMER_ID".
+	UpperSnakeCase
+
+	// KebabCase writes "customer-id".
+	KebabCase
+
+	// CamelCase writes "customerId".
+	CamelCase
+
+	// PascalCase writes "CustomerId".
+	PascalCase
+
+	// TitleCase writes "Customer Id".
+	TitleCase
+)
+
+// WithHeaderCase converts the header names to a naming convention, e.g. for a
+// warehouse expecting snake_case columns while another consumer of the same
+// data expects camelCase. Names are split into words at characters other than
+// letters and digits and at changes to upper case, so "CustomerID",
+// "customer_id" and "Customer ID" all convert alike. Names set by
+// WithHeaderName are not converted.
+func WithHeaderCase(c HeaderCase) WriterOption {
+	return func(cfg *writerConfig) error {
+		if c < KeepCase || c > TitleCase {
+			return fmt.Errorf("invalid header case: %d", c)
+		}
+		cfg.headerCase = c
+		return nil
+	}
+}
+
+// WithHeaderName writes the column as name, the column being given by its
+// name from struct tags or WithHeader. It may be used for several columns.
+func WithHeaderName(column, name string) WriterOption {
+	return func(cfg *writerConfig) error {
+		if cfg.headerNames == nil {
+			cfg.headerNames = map[string]string{}
+		}
+		cfg.headerNames[column] = name
+		return nil
+	}
+}
+
+// WithHeaderTag names the columns of struct fields by another struct tag if
+// present, such as the `json` or `db` tag, rather than the `csv` tag. Only
+// the name of the tag is used, tags without a name or "-" are ignored.
+func WithHeaderTag(key string) WriterOption {
+	return func(cfg *writerConfig) error {
+		if key == "" || key == "csv" {
+			return fmt.Errorf("invalid header tag %q", key)
+		}
+		cfg.headerTag = key
+		return nil
+	}
+}
+
+// headerName returns the header name of a struct field, from the tag set by
+// WithHeaderTag if any.
+func (cfg *writerConfig) headerName(f taggedField) string {
+	if cfg.headerTag != "" {
+		name, _, _ := strings.Cut(f.tags.Get(cfg.headerTag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.tag.name
+}
+
+// renameHeader applies WithHeaderName and WithHeaderCase to the header.
+func (cfg *writerConfig) renameHeader(header []string) ([]string, error) {
+	renamed := make([]string, len(header))
+	for i, name := range header {
+		renamed[i] = cfg.headerCase.convert(name)
+	}
+	for column, name := range cfg.headerNames {
+		i := slices.Index(header, column)
+		if i < 0 {
+			return nil, fmt.Errorf("no column '%s' to rename", column)
+		}
+		renamed[i] = name
+	}
+	return renamed, nil
+}
+
+// convert converts a header name to the naming convention.
+func (c HeaderCase) convert(name string) string {
+	if c == KeepCase {
+		return name
+	}
+	words := headerWords(name)
+	if words == nil {
+		return name
+	}
+	for i, word := range words {
+		switch {
+		case c == UpperSnakeCase:
+			words[i] = strings.ToUpper(word)
+		case c == CamelCase && i == 0, c == SnakeCase, c == KebabCase:
+			words[i] = strings.ToLower(word)
+		default:
+			runes := []rune(strings.ToLower(word))
+			runes[0] = unicode.ToUpper(runes[0])
+			words[i] = string(runes)
+		}
+	}
+	switch c {
+	case SnakeCase, UpperSnakeCase:
+		return strings.Join(words, "_")
+	case KebabCase:
+		return strings.Join(words, "-")
+	case TitleCase:
+		return strings.Join(words, " ")
+	}
+	return strings.Join(words, "")
+}
+
+// headerWords splits a name into words at characters other than letters and
+// digits, before an upper case letter following a lower case one or digit,
+// and before the last of several upper case letters followed by a lower case
+// one, e.g. "HTTPServer" into "HTTP" and "Server".
+func headerWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+			continue
+		}
+		if len(word) > 0 && unicode.IsUpper(r) {
+			prev := word[len(word)-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && next {
+				words = append(words, string(word))
+				word = word[:0]
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
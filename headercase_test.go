@@ -0,0 +1,53 @@
+package bigcsv_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+type account struct {
+	CustomerID string `csv:"customer_id" db:"cust_id"`
+	HTTPServer string
+	Balance    int `csv:"Balance" json:"-"`
+}
+
+// TestHeaderCase writes the same header for different consumers.
+func TestHeaderCase(t *testing.T) {
+	for _, test := range []struct {
+		opts []bigcsv.WriterOption
+		want string
+	}{
+		{nil, "customer_id,HTTPServer,Balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.SnakeCase)}, "customer_id,http_server,balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.UpperSnakeCase)}, "CUSTOMER_ID,HTTP_SERVER,BALANCE"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.CamelCase)}, "customerId,httpServer,balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.PascalCase)}, "CustomerId,HttpServer,Balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.KebabCase)}, "customer-id,http-server,balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.TitleCase)}, `Customer Id,Http Server,Balance`},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderTag("db")}, "cust_id,HTTPServer,Balance"},
+		{[]bigcsv.WriterOption{bigcsv.WithHeaderCase(bigcsv.SnakeCase), bigcsv.WithHeaderName("Balance", "Amount in EUR")}, "customer_id,http_server,Amount in EUR"},
+	} {
+		var out strings.Builder
+		w, err := bigcsv.NewWriter[account](&out, test.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = w.OnData(account{"c1", "web", 5}); err != nil {
+			t.Fatal(err)
+		}
+		if err = w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if header, _, _ := strings.Cut(out.String(), "\n"); header != test.want {
+			t.Errorf("Got header %q, expected %q", header, test.want)
+		}
+	}
+	if _, err := bigcsv.NewWriter[account](&strings.Builder{}, bigcsv.WithHeaderName("balance", "amount")); err == nil {
+		t.Error("Expected an error renaming an unknown column")
+	}
+	if _, err := bigcsv.NewWriter[[]string](&strings.Builder{}, bigcsv.WithHeaderName("a", "b")); err == nil {
+		t.Error("Expected an error renaming columns without a header")
+	}
+}
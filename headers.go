@@ -0,0 +1,38 @@
+package bigcsv
+
+import "slices"
+
+// Headers returns a copy of the header row consumed by the Parser, or nil if
+// the Parser was not created with WithHeaders.
+//
+// If called before processing, the header row is read from the stream. A
+// failure to read it is returned by Run.
+func (p *Parser[T]) Headers() []string {
+	if !p.closed {
+		p.prepare()
+	}
+	return slices.Clone(p.headers)
+}
+
+// ColumnIndex returns the index of the column with the given header name. If
+// several columns share the name, the first is returned.
+//
+// Like Headers, it reads the header row if called before processing.
+func (p *Parser[T]) ColumnIndex(name string) (int, bool) {
+	if !p.closed {
+		p.prepare()
+	}
+	ix, ok := p.columns[name]
+	return ix, ok
+}
+
+// indexColumns maps the header names to their column index.
+func indexColumns(headers []string) map[string]int {
+	columns := make(map[string]int, len(headers))
+	for i, name := range headers {
+		if _, ok := columns[name]; !ok {
+			columns[name] = i
+		}
+	}
+	return columns
+}
@@ -0,0 +1,47 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestColumnIndex checks parsing by column names rather than indices.
+func TestColumnIndex(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("name,int\none,1\ntwo,2\n", bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers := parser.Headers(); len(headers) != 2 || headers[1] != "int" {
+		t.Fatalf("Unexpected headers: %v", headers)
+	}
+	ixInt, ok := parser.ColumnIndex("int")
+	if !ok {
+		t.Fatal("Column 'int' not found")
+	}
+	ixName, _ := parser.ColumnIndex("name")
+	if _, ok = parser.ColumnIndex("missing"); ok {
+		t.Fatal("Found missing column")
+	}
+	parser.Parse = func(row []string) (n Number, err error) {
+		n.String = row[ixName]
+		n.Integer, err = strconv.Atoi(row[ixInt])
+		return n, err
+	}
+	sum := 0
+	parser.OnData = func(n Number) error {
+		sum += n.Integer
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 3 {
+		t.Fatalf("Sum of processed rows is %d, expected 3", sum)
+	}
+}
@@ -0,0 +1,125 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ParseHelper converts the fields of a row for a Parse function, keeping the
+// first error so that fields can be converted without checking each one:
+//
+//	parser.Parse = func(row []string) (Place, error) {
+//		h := parser.Helper(row)
+//		return Place{Name: h.String("name"), Walkability: h.Float("Walkability")}, h.Err()
+//	}
+//
+// Errors are *ColumnError values naming the column and the invalid value, so
+// that the RowError reads e.g. `Parse error: line 2041: column 'Walkability'
+// (115): invalid float "n/a"`.
+type ParseHelper struct {
+	row Row
+	err error
+}
+
+// Helper returns a ParseHelper for the fields of a row, using the Parser's
+// headers to look up columns by name.
+func (p *Parser[T]) Helper(fields []string) *ParseHelper {
+	return &ParseHelper{row: p.Row(fields)}
+}
+
+// Err returns the first error converting a field, if any.
+func (h *ParseHelper) Err() error {
+	return h.err
+}
+
+// String returns the value of the named column.
+func (h *ParseHelper) String(name string) string {
+	s, _ := h.field(name)
+	return s
+}
+
+// Int converts the value of the named column to an int.
+func (h *ParseHelper) Int(name string) int {
+	s, ix := h.field(name)
+	n, err := strconv.Atoi(s)
+	h.check(ix, "integer", s, err)
+	return n
+}
+
+// Float converts the value of the named column to a float64.
+func (h *ParseHelper) Float(name string) float64 {
+	s, ix := h.field(name)
+	f, err := strconv.ParseFloat(s, 64)
+	h.check(ix, "float", s, err)
+	return f
+}
+
+// Bool converts the value of the named column to a bool, see
+// strconv.ParseBool.
+func (h *ParseHelper) Bool(name string) bool {
+	s, ix := h.field(name)
+	b, err := strconv.ParseBool(s)
+	h.check(ix, "boolean", s, err)
+	return b
+}
+
+// Time parses the value of the named column using the layout, see
+// time.Parse.
+func (h *ParseHelper) Time(name, layout string) time.Time {
+	s, ix := h.field(name)
+	t, err := time.Parse(layout, s)
+	h.check(ix, "time", s, err)
+	return t
+}
+
+// field returns the value and index of the named column, or -1 after
+// recording an error.
+func (h *ParseHelper) field(name string) (string, int) {
+	ix, ok := h.row.columns[name]
+	if !ok {
+		h.fail(&ColumnError{Column: name, Index: -1, Err: ErrNoColumn})
+		return "", -1
+	}
+	s, err := h.row.GetStringAt(ix)
+	if err != nil {
+		h.fail(err)
+		return "", -1
+	}
+	return s, ix
+}
+
+// check records a conversion error of the value at the index, unless the
+// column is missing.
+func (h *ParseHelper) check(ix int, kind, value string, err error) {
+	if err != nil && ix >= 0 {
+		h.fail(h.row.errAt(ix, &conversionError{kind, value, err}))
+	}
+}
+
+// fail records the error if it is the first.
+func (h *ParseHelper) fail(err error) {
+	if h.err == nil {
+		h.err = err
+	}
+}
+
+// conversionError reports a value which is not of the expected kind, more
+// briefly than strconv.
+type conversionError struct {
+	kind  string
+	value string
+	err   error
+}
+
+func (e *conversionError) Error() string {
+	if errors.Is(e.err, strconv.ErrRange) {
+		return fmt.Sprintf("%s %q out of range", e.kind, e.value)
+	}
+	return fmt.Sprintf("invalid %s %q", e.kind, e.value)
+}
+
+func (e *conversionError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,169 @@
+package bigcsv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// LineIndex records the byte offsets of every Nth row of a CSV file, for
+// random access with IndexedStream. Rows are counted from 1 as in RowError,
+// including any header row.
+type LineIndex struct {
+	// Every is the distance between indexed rows.
+	Every int
+
+	// Comma is the field delimiter of the file.
+	Comma rune
+
+	// Rows is the number of rows in the file.
+	Rows int
+
+	// Offsets holds the offset of row i*Every+1 at index i.
+	Offsets []int64
+}
+
+// BuildIndex reads the stream once, recording the offset of every Nth row.
+// Options such as WithComma apply, while skipped and header rows are indexed
+// as well. Offsets refer to the stream as read, so the index is of use for
+// uncompressed files only.
+func BuildIndex(stream Stream, every int, opts ...Option) (*LineIndex, error) {
+	if every < 1 {
+		return nil, fmt.Errorf("invalid index distance: %d", every)
+	}
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer p.close()
+	if p.Reader == nil {
+		return nil, fmt.Errorf("cannot index without CSV reader")
+	}
+	p.Reader.FieldsPerRecord = -1
+	p.Reader.ReuseRecord = true
+	ix := &LineIndex{Every: every, Comma: p.Reader.Comma}
+	for {
+		rec := p.read()
+		if errors.Is(rec.err, io.EOF) {
+			return ix, nil
+		}
+		if rec.err != nil && !isParseError(rec.err) {
+			return nil, rec.error(ErrRead, rec.err)
+		}
+		if (rec.line-1)%every == 0 {
+			ix.Offsets = append(ix.Offsets, rec.offset)
+		}
+		ix.Rows = rec.line
+	}
+}
+
+// indexMagic starts an index file.
+const indexMagic = "BCIX1"
+
+// WriteTo writes the index in a compact binary format.
+func (ix *LineIndex) WriteTo(w io.Writer) (int64, error) {
+	b := []byte(indexMagic)
+	b = binary.AppendUvarint(b, uint64(ix.Every))
+	b = binary.AppendUvarint(b, uint64(ix.Comma))
+	b = binary.AppendUvarint(b, uint64(ix.Rows))
+	b = binary.AppendUvarint(b, uint64(len(ix.Offsets)))
+	prev := int64(0)
+	for _, off := range ix.Offsets {
+		b = binary.AppendUvarint(b, uint64(off-prev))
+		prev = off
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadIndex reads an index written by LineIndex.WriteTo.
+func ReadIndex(r io.Reader) (*LineIndex, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != indexMagic {
+		return nil, fmt.Errorf("not an index file")
+	}
+	var fields [4]uint64
+	for i := range fields {
+		var err error
+		if fields[i], err = binary.ReadUvarint(br); err != nil {
+			return nil, fmt.Errorf("invalid index file: %w", err)
+		}
+	}
+	ix := &LineIndex{Every: int(fields[0]), Comma: rune(fields[1]), Rows: int(fields[2])}
+	ix.Offsets = make([]int64, 0, min(fields[3], 1<<20))
+	prev := int64(0)
+	for i := uint64(0); i < fields[3]; i++ {
+		delta, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index file: %w", err)
+		}
+		prev += int64(delta)
+		ix.Offsets = append(ix.Offsets, prev)
+	}
+	return ix, nil
+}
+
+// IndexedStream provides the rows of a CSV file starting at Row, seeking to the
+// nearest indexed row instead of reading all rows before it. Line numbers of
+// the Parser count from Row then, as 1.
+//
+//	page := bigcsv.IndexedStream{Path: "huge.csv", Index: ix, Row: 40_000_000}
+//	rows, err := bigcsv.CollectN(ctx, page, parse, 100)
+type IndexedStream struct {
+	Path  string
+	Index *LineIndex
+	Row   int
+}
+
+func (s IndexedStream) Open() (io.ReadCloser, error) {
+	if s.Row < 1 || s.Index.Every < 1 {
+		return nil, fmt.Errorf("invalid row %d", s.Row)
+	}
+	f, err := os.Open(s.Path)
+	if err != nil {
+		return nil, fmt.Errorf("could open file '%s': %w", s.Path, err)
+	}
+	i := (s.Row - 1) / s.Index.Every
+	if i >= len(s.Index.Offsets) { // beyond the end
+		i = len(s.Index.Offsets) - 1
+	}
+	offset := int64(0)
+	skip := s.Row - 1
+	if i >= 0 {
+		offset, skip = s.Index.Offsets[i], s.Row-1-i*s.Index.Every
+	}
+	if skip > 0 { // find the offset of the row after the indexed one
+		if _, err = f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		r := csv.NewReader(f)
+		r.Comma = s.Index.Comma
+		r.FieldsPerRecord = -1
+		r.ReuseRecord = true
+		for ; skip > 0; skip-- {
+			if _, err = r.Read(); err != nil && !isParseError(err) {
+				break
+			}
+		}
+		switch {
+		case errors.Is(err, io.EOF):
+			offset, err = f.Seek(0, io.SeekEnd)
+		case err != nil && !isParseError(err):
+			f.Close()
+			return nil, fmt.Errorf("could not skip to row %d: %w", s.Row, err)
+		default:
+			offset += r.InputOffset()
+		}
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,48 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestIndexedStream builds an index, round-trips it and seeks to rows.
+func TestIndexedStream(t *testing.T) {
+	var sb strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&sb, "%d,\"line\nbreak %d\"\n", i, i)
+	}
+	path := filepath.Join(t.TempDir(), "numbers.csv")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := bigcsv.BuildIndex(bigcsv.FileStream(path), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err = ix.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if ix, err = bigcsv.ReadIndex(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if ix.Rows != 10 || len(ix.Offsets) != 4 {
+		t.Fatalf("Unexpected index: %+v", ix)
+	}
+	for _, row := range []int{1, 4, 5, 10} {
+		numbers, err := bigcsv.CollectN(context.Background(), bigcsv.IndexedStream{Path: path, Index: ix, Row: row}, ParseNumber, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(numbers) != 1 || numbers[0].Integer != row {
+			t.Fatalf("Row %d: got %v", row, numbers)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Rows returns an iterator over the parsed records, as an alternative to
+// setting OnData and calling Run.
+//
+// Rows are read and parsed sequentially. OnRow is applied, but OnData and
+// OnError are not used: row errors are yielded with the zero value instead, so
+// the loop body decides whether to continue. With the StopOnError policy, the
+// iteration ends after the first error. A failing stream always ends it.
+//
+// The stream is closed when the iteration ends unless using WithKeepOpen, so
+// Rows may only be used once.
+// Cancelling ctx ends the iteration.
+func (p *Parser[T]) Rows(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if err := p.begin(); err != nil {
+			yield(zero, err)
+			return
+		}
+		// Callbacks run on the goroutine of the loop, so replacing them
+		// in its body is not a race.
+		defer p.end()
+		defer p.finish()
+		if !p.parses() {
+			yield(zero, fmt.Errorf("cannot iterate without Parse"))
+			return
+		}
+		if err := p.prepare(); err != nil {
+			yield(zero, err)
+			return
+		}
+		unique, err := p.cfg.uniqueness(p.columns)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		for ctx.Err() == nil {
+			rec := p.read()
+			if errors.Is(rec.err, io.EOF) {
+				if err := p.checkTrailer(); err != nil {
+					yield(zero, err)
+				}
+				return
+			}
+			data, err, fatal := zero, error(nil), false
+			if rec.err != nil {
+				fatal = !isParseError(rec.err) // the stream itself failed
+				err = rec.error(ErrRead, rec.err)
+			} else if err = unique.check(rec); err != nil {
+				err = rec.error(ErrOnRow, err)
+			} else if data, err = p.parseRow(ctx, rec, nil, nil); err != nil {
+				data = zero
+			}
+			if !yield(data, err) || fatal {
+				return
+			}
+			if err != nil && p.cfg.errorPolicy == StopOnError {
+				return
+			}
+		}
+	}
+}
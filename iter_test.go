@@ -0,0 +1,34 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRows checks iterating over records, including a row error.
+func TestRows(t *testing.T) {
+	parser, err := bigcsv.New[Number](bigcsv.ReadStream(strings.NewReader("1,one\nx,two\n3,three\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	var numbers []int
+	var errs []error
+	for n, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		numbers = append(numbers, n.Integer)
+	}
+	if len(numbers) != 2 || numbers[0] != 1 || numbers[1] != 3 {
+		t.Fatalf("Unexpected numbers: %v", numbers)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], bigcsv.ErrParse) {
+		t.Fatalf("Unexpected errors: %v", errs)
+	}
+}
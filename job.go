@@ -0,0 +1,72 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+)
+
+// errStopped is the cause of a Job cancelled by Stop.
+var errStopped = errors.New("stopped")
+
+// Job is a Run in the background, see Start.
+type Job[T any] struct {
+	p      *Parser[T]
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+	err    error
+}
+
+// Start runs the Parser in the background like Run, e.g. for a server
+// starting an import on request and answering status queries about it. The
+// callbacks are validated before starting, and ErrRunning is returned if the
+// Parser is running already; any other error is returned by Wait. The Parser
+// must not be used otherwise until the Job is done.
+//
+//	job, err := parser.Start(context.Background(), 8)
+//	...
+//	http.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(job.Stats())
+//	})
+func (p *Parser[T]) Start(ctx context.Context, workers int) (*Job[T], error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	j := &Job[T]{p: p, cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(j.done)
+		defer cancel(nil)
+		j.err = p.runBegun(ctx, workers)
+		if errors.Is(j.err, context.Canceled) && errors.Is(context.Cause(ctx), errStopped) {
+			j.err = nil
+		}
+	}()
+	return j, nil
+}
+
+// Wait waits for the Job to finish, returning the error of Run. It may be
+// called any number of times.
+func (j *Job[T]) Wait() error {
+	<-j.done
+	return j.err
+}
+
+// Done is closed once the Job finished.
+func (j *Job[T]) Done() <-chan struct{} {
+	return j.done
+}
+
+// Stats returns the Stats of the Job so far, see Parser.Stats.
+func (j *Job[T]) Stats() Stats {
+	return j.p.Stats()
+}
+
+// Stop stops reading rows, waits for the rows in progress to complete and
+// returns the result of Wait. Being stopped is not an error.
+func (j *Job[T]) Stop() error {
+	j.cancel(errStopped)
+	return j.Wait()
+}
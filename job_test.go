@@ -0,0 +1,58 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestJob starts a Parser in the background, querying its Stats and stopping
+// it.
+func TestJob(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string](strings.Repeat("a,b\n", 1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := make(chan struct{})
+	parser.OnRow = func([]string) error {
+		rows <- struct{}{}
+		return nil
+	}
+	job, err := parser.Start(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range 10 {
+		<-rows
+	}
+	if stats := job.Stats(); stats.Rows < 10 {
+		t.Errorf("Got %d rows, expected at least 10", stats.Rows)
+	}
+	go func() { // unblock the row in progress
+		for range rows {
+		}
+	}()
+	if err = job.Stop(); err != nil {
+		t.Fatalf("Expected no error when stopped, got: %v", err)
+	}
+	close(rows)
+	select {
+	case <-job.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Job not done after Stop")
+	}
+	if stats := job.Stats(); stats.Rows >= 1000 {
+		t.Errorf("Got %d rows, expected the Job to stop early", stats.Rows)
+	}
+
+	parser, err = bigcsv.NewFromString[[]string]("a\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = parser.Start(context.Background(), 1); err == nil {
+		t.Error("Expected an error starting without callbacks")
+	}
+}
@@ -0,0 +1,241 @@
+package bigcsv
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// keyIndexMagic starts a key index file.
+const keyIndexMagic = "BCKX1"
+
+// keyIndexBlock is the number of entries per block of a key index file.
+const keyIndexBlock = 128
+
+// keyEntry is the offset of a row with the key.
+type keyEntry struct {
+	key    string
+	offset int64
+}
+
+// BuildKeyIndex reads the stream once and writes an index file mapping the
+// values of a column to the byte offsets of their rows, for point lookups with
+// OpenKeyIndex. The column is named as in the header row, so the Parser needs
+// WithHeaders. As for BuildIndex, the stream must not be compressed.
+//
+// The keys are sorted in memory, which takes about the size of the key column
+// plus 24 bytes per row.
+func BuildKeyIndex(stream Stream, column, path string, opts ...Option) error {
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+	if p.Reader == nil {
+		return fmt.Errorf("cannot index without CSV reader")
+	}
+	col, ok := p.ColumnIndex(column)
+	if !ok {
+		return errors.Join(p.prepareErr, fmt.Errorf("no column '%s'", column))
+	}
+	p.Reader.FieldsPerRecord = -1
+	var entries []keyEntry
+	for {
+		rec := p.read()
+		if errors.Is(rec.err, io.EOF) {
+			break
+		}
+		if rec.err != nil {
+			if !isParseError(rec.err) {
+				return rec.error(ErrRead, rec.err)
+			}
+			continue
+		}
+		if col < len(rec.row) {
+			entries = append(entries, keyEntry{strings.Clone(rec.row[col]), rec.offset})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b keyEntry) int {
+		return strings.Compare(a.key, b.key)
+	})
+	return writeKeyIndex(path, p.Reader.Comma, entries)
+}
+
+// writeKeyIndex writes the sorted entries in blocks, followed by a directory
+// of the first key and offset of each block, and the offset of the directory.
+func writeKeyIndex(path string, comma rune, entries []keyEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create index: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	b := binary.AppendUvarint([]byte(keyIndexMagic), uint64(comma))
+	var dir []byte
+	pos := int64(0)
+	for i, e := range entries {
+		if i%keyIndexBlock == 0 {
+			dir = binary.AppendUvarint(dir, uint64(len(e.key)))
+			dir = append(dir, e.key...)
+			dir = binary.AppendUvarint(dir, uint64(pos+int64(len(b))))
+			pos += int64(len(b))
+			w.Write(b)
+			b = b[:0]
+		}
+		b = binary.AppendUvarint(b, uint64(len(e.key)))
+		b = append(b, e.key...)
+		b = binary.AppendUvarint(b, uint64(e.offset))
+	}
+	pos += int64(len(b))
+	w.Write(b)
+	w.Write(dir)
+	w.Write(binary.BigEndian.AppendUint64(nil, uint64(pos)))
+	return errors.Join(w.Flush(), f.Close())
+}
+
+// KeyIndex looks up rows by key in an index file built by BuildKeyIndex. Only
+// the directory of the index is kept in memory, a lookup reads a block of the
+// index and the rows of the CSV file.
+type KeyIndex struct {
+	f      *os.File
+	comma  rune
+	end    int64 // end of the entries
+	keys   []string
+	blocks []int64
+}
+
+// OpenKeyIndex opens an index file.
+func OpenKeyIndex(path string) (*KeyIndex, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open index: %w", err)
+	}
+	ix, err := readKeyIndex(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("invalid index %s: %w", path, err)
+	}
+	return ix, nil
+}
+
+// readKeyIndex reads the header and directory.
+func readKeyIndex(f *os.File) (*KeyIndex, error) {
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	var footer [8]byte
+	if _, err = f.ReadAt(footer[:], info.Size()-8); err != nil {
+		return nil, err
+	}
+	ix := &KeyIndex{f: f, end: int64(binary.BigEndian.Uint64(footer[:]))}
+	if ix.end < 0 || ix.end > info.Size()-8 {
+		return nil, fmt.Errorf("invalid directory offset %d", ix.end)
+	}
+	header := bufio.NewReader(io.NewSectionReader(f, 0, ix.end))
+	magic := make([]byte, len(keyIndexMagic))
+	if _, err = io.ReadFull(header, magic); err != nil || string(magic) != keyIndexMagic {
+		return nil, fmt.Errorf("not a key index")
+	}
+	comma, err := binary.ReadUvarint(header)
+	if err != nil {
+		return nil, err
+	}
+	ix.comma = rune(comma)
+	dir := bufio.NewReader(io.NewSectionReader(f, ix.end, info.Size()-8-ix.end))
+	for {
+		key, err := readKeyString(dir)
+		if errors.Is(err, io.EOF) {
+			return ix, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		offset, err := binary.ReadUvarint(dir)
+		if err != nil {
+			return nil, err
+		}
+		ix.keys = append(ix.keys, key)
+		ix.blocks = append(ix.blocks, int64(offset))
+	}
+}
+
+// readKeyString reads a length-prefixed key.
+func readKeyString(r *bufio.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	b := make([]byte, n)
+	if _, err = io.ReadFull(r, b); err != nil {
+		return "", io.ErrUnexpectedEOF
+	}
+	return string(b), nil
+}
+
+// Offsets returns the byte offsets of the rows with the key, in file order.
+func (ix *KeyIndex) Offsets(key string) ([]int64, error) {
+	// The first block which may contain the key is the one before the first
+	// block starting with a greater or equal key.
+	i := sort.SearchStrings(ix.keys, key)
+	if i > 0 {
+		i--
+	}
+	if i >= len(ix.blocks) {
+		return nil, nil
+	}
+	r := bufio.NewReader(io.NewSectionReader(ix.f, ix.blocks[i], ix.end-ix.blocks[i]))
+	var offsets []int64
+	for {
+		k, err := readKeyString(r)
+		if errors.Is(err, io.EOF) || k > key {
+			return offsets, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid index: %w", err)
+		}
+		offset, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index: %w", err)
+		}
+		if k == key {
+			offsets = append(offsets, int64(offset))
+		}
+	}
+}
+
+// Rows returns the rows of the CSV file at path with the key.
+func (ix *KeyIndex) Rows(path, key string) ([][]string, error) {
+	offsets, err := ix.Offsets(key)
+	if err != nil || len(offsets) == 0 {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could open file '%s': %w", path, err)
+	}
+	defer f.Close()
+	rows := make([][]string, 0, len(offsets))
+	for _, offset := range offsets {
+		r := csv.NewReader(io.NewSectionReader(f, offset, 1<<62))
+		r.Comma = ix.comma
+		r.FieldsPerRecord = -1
+		row, err := r.Read()
+		if err != nil {
+			return rows, fmt.Errorf("could not read row at offset %d: %w", offset, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Close closes the index file.
+func (ix *KeyIndex) Close() error {
+	return ix.f.Close()
+}
@@ -0,0 +1,44 @@
+package bigcsv_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestKeyIndex looks up rows by key, including keys spanning index blocks.
+func TestKeyIndex(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,customer\n")
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintf(&sb, "%d,c%d\n", i, i%3)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	indexPath := filepath.Join(dir, "orders.customer.idx")
+	if err := bigcsv.BuildKeyIndex(bigcsv.FileStream(path), "customer", indexPath, bigcsv.WithHeaders()); err != nil {
+		t.Fatal(err)
+	}
+	ix, err := bigcsv.OpenKeyIndex(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	rows, err := ix.Rows(path, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 333 || rows[0][0] != "1" || rows[332][0] != "997" {
+		t.Fatalf("Found %d rows, first %v", len(rows), rows[0])
+	}
+	if rows, err = ix.Rows(path, "c9"); err != nil || len(rows) != 0 {
+		t.Fatalf("Expected no rows for unknown key, got %v, %v", rows, err)
+	}
+}
@@ -0,0 +1,142 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// ErrRunning is returned when using a Parser which is running already, e.g.
+// calling Run twice at the same time, or Reset during a Run.
+var ErrRunning = errors.New("parser is running")
+
+// ErrReconfigured is returned in builds with the race detector by a run
+// during which callbacks such as OnData were replaced, stopping at the next
+// row to point out the mutation. Runs use the callbacks set when they began,
+// so replacing them only takes effect with the next run. Other builds do not
+// check for it: the callbacks are plain fields, so comparing them while a
+// caller may be setting them is itself a data race, which only race builds
+// are meant to surface.
+var ErrReconfigured = errors.New("parser callbacks changed while running")
+
+// State is the lifecycle state of a Parser, see Parser.State.
+type State int32
+
+const (
+	// StateConfigured is the state of a new or Reset Parser.
+	StateConfigured State = iota
+
+	// StateRunning is the state while processing the stream with Run,
+	// Start, Chan, Rows, DryRun, Replay or ReplayTrace. Only Stats,
+	// Position and State may be used meanwhile.
+	StateRunning
+
+	// StateFinished is the state after processing a stream kept open, see
+	// WithKeepOpen. Processing it again continues with the remaining rows.
+	StateFinished
+
+	// StateClosed is the state once the stream is closed, see Reset.
+	StateClosed
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConfigured:
+		return "configured"
+	case StateRunning:
+		return "running"
+	case StateFinished:
+		return "finished"
+	case StateClosed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// State returns the lifecycle state of the Parser. It may be called from any
+// goroutine, e.g. to monitor a Job.
+func (p *Parser[T]) State() State {
+	return State(p.state.Load())
+}
+
+// callbacks are the callbacks of a Parser, copied when it begins running so
+// that workers do not race with the caller setting them.
+type callbacks[T any] struct {
+	onRow         func(row []string) error
+	parse         func(row []string) (T, error)
+	parseContext  func(ctx context.Context, row []string) (T, error)
+	onData        func(data T) error
+	onDataContext func(ctx context.Context, data T) error
+	onDataAsync   func(data T) (*Pending, error)
+	onError       func(error)
+	onWarning     func(*Warning)
+}
+
+// current returns a copy of the callbacks as currently set.
+func (p *Parser[T]) current() callbacks[T] {
+	return callbacks[T]{p.OnRow, p.Parse, p.ParseContext, p.OnData, p.OnDataContext, p.OnDataAsync, p.OnError, p.OnWarning}
+}
+
+// code identifies the functions of the callbacks. Closures of the same
+// function literal are not told apart.
+func (c callbacks[T]) code() [8]uintptr {
+	var code [8]uintptr
+	for i, f := range [...]any{c.onRow, c.parse, c.parseContext, c.onData, c.onDataContext, c.onDataAsync, c.onError, c.onWarning} {
+		code[i] = reflect.ValueOf(f).Pointer()
+	}
+	return code
+}
+
+// reconfigured tells whether callbacks were replaced since acquire.
+func (p *Parser[T]) reconfigured() bool {
+	return p.current().code() != p.active.code()
+}
+
+// acquire moves the Parser to StateRunning and copies the callbacks to use,
+// returning the previous state, or ErrRunning if it is running already.
+func (p *Parser[T]) acquire() (State, error) {
+	for {
+		s := p.state.Load()
+		if State(s) == StateRunning {
+			return StateRunning, ErrRunning
+		}
+		if p.state.CompareAndSwap(s, int32(StateRunning)) {
+			p.active = p.current()
+			return State(s), nil
+		}
+	}
+}
+
+// release leaves StateRunning after acquire.
+func (p *Parser[T]) release(s State) {
+	p.state.Store(int32(s))
+}
+
+// begin starts a run, failing if the Parser is running already or its stream
+// is closed.
+func (p *Parser[T]) begin() error {
+	prev, err := p.acquire()
+	if err != nil {
+		return err
+	}
+	if p.closed {
+		p.release(prev)
+		return ErrClosed
+	}
+	return nil
+}
+
+// end ends a run. In race builds, it returns ErrReconfigured if callbacks
+// were replaced meanwhile.
+func (p *Parser[T]) end() error {
+	changed := raceEnabled && p.reconfigured()
+	if p.closed {
+		p.release(StateClosed)
+	} else {
+		p.release(StateFinished)
+	}
+	if changed {
+		return ErrReconfigured
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestLifecycle checks the states of a Parser and that it cannot be used
+// while running.
+func TestLifecycle(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string]("a\nb\nc\n", bigcsv.WithKeepOpen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := parser.State(); s != bigcsv.StateConfigured {
+		t.Errorf("Got state %v, expected configured", s)
+	}
+	rows := make(chan struct{})
+	parser.OnRow = func([]string) error {
+		rows <- struct{}{}
+		return nil
+	}
+	job, err := parser.Start(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-rows
+	if s := parser.State(); s != bigcsv.StateRunning {
+		t.Errorf("Got state %v, expected running", s)
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrRunning) {
+		t.Errorf("Expected ErrRunning from Run, got: %v", err)
+	}
+	if _, err = parser.Start(context.Background(), 1); !errors.Is(err, bigcsv.ErrRunning) {
+		t.Errorf("Expected ErrRunning from Start, got: %v", err)
+	}
+	if err = parser.Reset(nil); !errors.Is(err, bigcsv.ErrRunning) {
+		t.Errorf("Expected ErrRunning from Reset, got: %v", err)
+	}
+	<-rows
+	<-rows
+	if err = job.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if s := parser.State(); s != bigcsv.StateFinished {
+		t.Errorf("Got state %v, expected finished", s)
+	}
+	if err = parser.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if s := parser.State(); s != bigcsv.StateClosed {
+		t.Errorf("Got state %v, expected closed", s)
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrClosed) {
+		t.Errorf("Expected ErrClosed, got: %v", err)
+	}
+}
+
+// TestReconfigured checks that replacing a callback while running fails the
+// run in race builds, and is not checked otherwise.
+func TestReconfigured(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string](strings.Repeat("a\n", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) ([]string, error) { return row, nil }
+	parser.OnData = func([]string) error {
+		parser.OnError = func(error) {}
+		return nil
+	}
+	err = parser.Run(context.Background(), 1)
+	if raceEnabled && !errors.Is(err, bigcsv.ErrReconfigured) {
+		t.Errorf("Expected ErrReconfigured, got: %v", err)
+	} else if !raceEnabled && err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if s := parser.State(); s != bigcsv.StateClosed {
+		t.Errorf("Got state %v, expected closed", s)
+	}
+}
+
+// TestCallbackSnapshot checks that a run keeps the callbacks set when it
+// began, and the next run uses those replacing them.
+func TestCallbackSnapshot(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string](strings.Repeat("a\n", 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var first, second int
+	parser.Parse = func(row []string) ([]string, error) { return row, nil }
+	parser.OnData = func([]string) error {
+		first++
+		parser.OnData = func([]string) error {
+			second++
+			return nil
+		}
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); raceEnabled != errors.Is(err, bigcsv.ErrReconfigured) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if first == 0 || second != 0 {
+		t.Errorf("Got %d rows for the first and %d for the second OnData, expected the first only", first, second)
+	}
+	if err = parser.Reset(bigcsv.ReadStream(strings.NewReader("a\nb\n"))); err != nil {
+		t.Fatal(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if second != 2 {
+		t.Errorf("Got %d rows for the second OnData, expected 2", second)
+	}
+}
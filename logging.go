@@ -0,0 +1,111 @@
+package bigcsv
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// WithLogger logs lifecycle events of the Parser at the given level: opening
+// and closing streams, the start and end of each run with a summary of the
+// errors, and progress if enabled by WithProgress. By default nothing is
+// logged.
+func WithLogger(logger *slog.Logger, level slog.Level) Option {
+	return func(cfg *config) error {
+		cfg.logger = logger
+		cfg.logLevel = level
+		return nil
+	}
+}
+
+// WithProgress logs the progress every n rows read. It requires WithLogger.
+func WithProgress(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid progress interval: %d", n)
+		}
+		cfg.progress = n
+		return nil
+	}
+}
+
+// log writes a message to the configured logger, if any.
+func (cfg *config) log(ctx context.Context, msg string, args ...any) {
+	if cfg.logger != nil {
+		cfg.logger.Log(ctx, cfg.logLevel, msg, args...)
+	}
+}
+
+// tally counts the rows and errors of a run.
+type tally struct {
+	started    time.Time
+	rows       atomic.Int64
+	readErrs   atomic.Int64
+	onRowErrs  atomic.Int64
+	parseErrs  atomic.Int64
+	onDataErrs atomic.Int64
+	warnings   atomic.Int64
+	elapsed    atomic.Int64 // set when the run ended
+	readTime   atomic.Int64 // the time spent by stage, see spent
+	onRowTime  atomic.Int64
+	parseTime  atomic.Int64
+	onDataTime atomic.Int64
+	waitTime   atomic.Int64
+	profiler   *Profiler // see WithProfile
+}
+
+// countError counts the error by the stage it arose in.
+func (t *tally) countError(err error) {
+	switch stageOf(err) {
+	case ErrOnRow:
+		t.onRowErrs.Add(1)
+	case ErrParse:
+		t.parseErrs.Add(1)
+	case ErrOnData:
+		t.onDataErrs.Add(1)
+	default:
+		t.readErrs.Add(1)
+	}
+}
+
+// errors returns the total number of errors.
+func (t *tally) errors() int64 {
+	return t.readErrs.Load() + t.onRowErrs.Load() + t.parseErrs.Load() + t.onDataErrs.Load()
+}
+
+// logStart logs the start of a run.
+func (r *run[T]) logStart(ctx context.Context, workers int) {
+	r.p.cfg.log(ctx, "bigcsv: run started", "workers", workers)
+}
+
+// logRow logs the progress if the row count reached the interval.
+func (r *run[T]) logRow(ctx context.Context) {
+	n := r.tally.rows.Add(1)
+	if every := int64(r.p.cfg.progress); every > 0 && n%every == 0 {
+		r.p.cfg.log(ctx, "bigcsv: progress",
+			"rows", n,
+			"errors", r.tally.errors(),
+			"elapsed", time.Since(r.tally.started),
+		)
+	}
+}
+
+// logEnd records the end of a run and logs its summary.
+func (r *run[T]) logEnd(ctx context.Context) {
+	t := &r.tally
+	t.elapsed.Store(int64(max(time.Since(t.started), 1)))
+	r.p.cfg.log(ctx, "bigcsv: run finished",
+		"rows", t.rows.Load(),
+		"errors", t.errors(),
+		slog.Group("errors_by_stage",
+			"read", t.readErrs.Load(),
+			"on_row", t.onRowErrs.Load(),
+			"parse", t.parseErrs.Load(),
+			"on_data", t.onDataErrs.Load(),
+		),
+		"elapsed", time.Duration(t.elapsed.Load()),
+		"stopped", r.stopErr != nil || ctx.Err() != nil,
+	)
+}
@@ -0,0 +1,43 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestLogging checks the lifecycle and progress logs.
+func TestLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(buf, nil))
+	parser, err := bigcsv.NewFromString[Number](
+		"1,one\nx,two\n3,three\n4,four\n",
+		bigcsv.WithLogger(logger, slog.LevelInfo),
+		bigcsv.WithProgress(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	logs := buf.String()
+	for _, want := range []string{
+		"stream opened",
+		"run started",
+		`progress" rows=2`,
+		`progress" rows=4`,
+		`run finished" rows=4 errors=1 errors_by_stage.read=0 errors_by_stage.on_row=0 errors_by_stage.parse=1`,
+		"stream closed",
+	} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("Missing log %q in:\n%s", want, logs)
+		}
+	}
+}
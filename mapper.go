@@ -0,0 +1,317 @@
+package bigcsv
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithStructTags sets the Parse function to fill the fields of a struct type
+// T from the columns, so no hand-written Parse is needed. Fields are matched to
+// headers by their `csv` tag or else their name; without WithHeaders they are
+// matched to columns in declaration order.
+//
+//	type Place struct {
+//		Name        string  `csv:"NAME"`
+//		Population  int     `csv:"POP"`
+//		Walkability float64 `csv:"NatWalkInd,optional"`
+//		Internal    string  `csv:"-"`
+//	}
+//
+// The tag option "optional" allows the column to be missing, "json" decodes
+// values holding JSON into the field with json.Unmarshal, "number" parses
+// numbers in a locale format (see NumberFormat), "date" dates in one of
+// several layouts (see DateFormat) and "layout=l" dates in the layout l.
+// []byte fields may be tagged "base64", "base64url" or "hex", with "max=n"
+// limiting their size to n bytes. Fields tagged "rowhash" are not read from a
+// column but set to the hash of the row, see Parser.RowHash.
+//
+// Other conversions use the converters registered for the field type (see
+// ConvertFunc), otherwise encoding.TextUnmarshaler, or the built-in
+// conversions of strings, bools, numbers, time.Duration and time.Time
+// (RFC 3339). Pointer fields are nil for empty values. Conversion failures are *ColumnError values.
+func WithStructTags() Option {
+	return func(cfg *config) error {
+		cfg.structTags = true
+		return nil
+	}
+}
+
+// tag is a parsed `csv` struct tag.
+type tag struct {
+	name    string
+	options map[string]string
+}
+
+// parseTag splits a tag into the name and options, which are either flags or
+// key=value pairs.
+func parseTag(s string) tag {
+	parts := strings.Split(s, ",")
+	t := tag{name: parts[0], options: map[string]string{}}
+	for _, opt := range parts[1:] {
+		key, value, _ := strings.Cut(opt, "=")
+		t.options[strings.TrimSpace(key)] = value
+	}
+	return t
+}
+
+// has tells whether the tag has the option.
+func (t tag) has(option string) bool {
+	_, ok := t.options[option]
+	return ok
+}
+
+// setter converts a field value and sets it on a struct field.
+type setter func(s string, v reflect.Value) error
+
+// mappedField is a struct field bound to a column.
+type mappedField struct {
+	name   string
+	index  []int // see reflect.Value.FieldByIndex
+	column int
+	tag    tag
+	set    setter
+}
+
+// hashedField is a struct field set to the hash of the row.
+type hashedField struct {
+	index []int // see reflect.Value.FieldByIndex
+	set   func(hash uint64, v reflect.Value)
+}
+
+// mapper fills structs of type T from rows.
+type mapper[T any] struct {
+	p      *Parser[T]
+	fields []mappedField
+	hashes []hashedField
+}
+
+// newMapper creates a mapper for T, which must be a struct.
+func newMapper[T any](p *Parser[T]) (*mapper[T], error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("struct tags need a struct type, got %s", typ)
+	}
+	m := &mapper[T]{p: p}
+	if err := m.collect(typ); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// collect gathers the mapped fields of a struct type.
+func (m *mapper[T]) collect(typ reflect.Type) error {
+	for _, f := range taggedFields(typ, nil) {
+		if f.tag.has("rowhash") {
+			set, err := hashSetter(f.typ)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", f.field, err)
+			}
+			m.hashes = append(m.hashes, hashedField{index: f.index, set: set})
+			continue
+		}
+		set, err := m.setter(f.typ, f.tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.field, err)
+		}
+		m.fields = append(m.fields, mappedField{name: f.tag.name, index: f.index, column: -1, tag: f.tag, set: set})
+	}
+	return nil
+}
+
+// taggedField is a struct field with its `csv` tag, named after the field if
+// the tag has no name.
+type taggedField struct {
+	field string
+	index []int // see reflect.Value.FieldByIndex
+	typ   reflect.Type
+	tag   tag
+	tags  reflect.StructTag // all tags of the field
+}
+
+// taggedFields returns the exported fields of a struct type which are not
+// tagged "-", including those of untagged embedded structs.
+func taggedFields(typ reflect.Type, index []int) []taggedField {
+	var fields []taggedField
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		raw, tagged := f.Tag.Lookup("csv")
+		if !f.IsExported() || raw == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int(nil), index...), i)
+		if f.Anonymous && !tagged && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, taggedFields(f.Type, fieldIndex)...)
+			continue
+		}
+		t := parseTag(raw)
+		if t.name == "" {
+			t.name = f.Name
+		}
+		fields = append(fields, taggedField{field: f.Name, index: fieldIndex, typ: f.Type, tag: t, tags: f.Tag})
+	}
+	return fields
+}
+
+// bind matches the fields to the columns, by header name if the Parser has
+// headers, else by position.
+func (m *mapper[T]) bind() error {
+	for i := range m.fields {
+		f := &m.fields[i]
+		if m.p.headers == nil {
+			f.column = i
+			continue
+		}
+		ix, ok := m.p.columns[f.name]
+		if !ok && !f.tag.has("optional") {
+			return fmt.Errorf("no column '%s' for struct field", f.name)
+		}
+		f.column = -1
+		if ok {
+			f.column = ix
+		}
+	}
+	return nil
+}
+
+// parse fills a struct from the row.
+func (m *mapper[T]) parse(row []string) (T, error) {
+	var data T
+	v := reflect.ValueOf(&data).Elem()
+	for _, f := range m.fields {
+		if f.column < 0 || f.column >= len(row) {
+			continue
+		}
+		if err := f.set(row[f.column], v.FieldByIndex(f.index)); err != nil {
+			return data, &ColumnError{Column: f.name, Index: f.column, Err: err}
+		}
+	}
+	if m.hashes != nil {
+		hash := m.p.RowHash(row)
+		for _, f := range m.hashes {
+			f.set(hash, v.FieldByIndex(f.index))
+		}
+	}
+	return data, nil
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+	timeType            = reflect.TypeFor[time.Time]()
+)
+
+// setter returns the conversion for a field type.
+func (m *mapper[T]) setter(typ reflect.Type, t tag) (setter, error) {
+	if t.has("json") {
+		return func(s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			return json.Unmarshal([]byte(s), v.Addr().Interface())
+		}, nil
+	}
+	if t.binaryEncoding() != "" {
+		return binarySetter(typ, t)
+	}
+	if name, ok := t.options["number"]; ok && typ.Kind() != reflect.Pointer {
+		return m.p.cfg.numberSetter(typ, name)
+	}
+	if name, ok := t.options["date"]; ok && typ.Kind() != reflect.Pointer {
+		return m.p.cfg.dateSetter(typ, name)
+	}
+	if layout, ok := t.options["layout"]; ok && typ.Kind() != reflect.Pointer {
+		if typ != timeType {
+			return nil, fmt.Errorf("date layout for type %s", typ)
+		}
+		return func(s string, v reflect.Value) error {
+			ts, err := time.Parse(layout, s)
+			v.Set(reflect.ValueOf(ts))
+			return err
+		}, nil
+	}
+	if c, ok := m.p.cfg.converter(typ); ok {
+		return func(s string, v reflect.Value) error {
+			x, err := c.fn(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(x))
+			return nil
+		}, nil
+	}
+	if reflect.PointerTo(typ).Implements(textUnmarshalerType) {
+		return func(s string, v reflect.Value) error {
+			return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		}, nil
+	}
+	switch typ {
+	case durationType:
+		return func(s string, v reflect.Value) error {
+			d, err := time.ParseDuration(s)
+			v.SetInt(int64(d))
+			return err
+		}, nil
+	case timeType:
+		return func(s string, v reflect.Value) error {
+			ts, err := time.Parse(time.RFC3339, s)
+			v.Set(reflect.ValueOf(ts))
+			return err
+		}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Pointer:
+		elem, err := m.setter(typ.Elem(), t)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string, v reflect.Value) error {
+			if s == "" {
+				v.SetZero()
+				return nil
+			}
+			ptr := reflect.New(typ.Elem())
+			if err := elem(s, ptr.Elem()); err != nil {
+				return err
+			}
+			v.Set(ptr)
+			return nil
+		}, nil
+	case reflect.String:
+		return func(s string, v reflect.Value) error {
+			v.SetString(s)
+			return nil
+		}, nil
+	case reflect.Bool:
+		return func(s string, v reflect.Value) error {
+			b, err := strconv.ParseBool(s)
+			v.SetBool(b)
+			return err
+		}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(s string, v reflect.Value) error {
+			i, err := strconv.ParseInt(s, 10, typ.Bits())
+			v.SetInt(i)
+			return err
+		}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(s string, v reflect.Value) error {
+			u, err := strconv.ParseUint(s, 10, typ.Bits())
+			v.SetUint(u)
+			return err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(s string, v reflect.Value) error {
+			f, err := strconv.ParseFloat(s, typ.Bits())
+			v.SetFloat(f)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("no conversion for type %s", typ)
+}
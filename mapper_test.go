@@ -0,0 +1,112 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+type Server struct {
+	Name    string        `csv:"name"`
+	Addr    netip.Addr    `csv:"address"`
+	Port    uint16        `csv:"port"`
+	Timeout time.Duration `csv:"timeout"`
+	Weight  *float64      `csv:"weight"`
+	Comment string        `csv:"comment,optional"`
+	Ignored string        `csv:"-"`
+}
+
+// TestStructTags checks mapping columns to struct fields by header, with a
+// registered converter.
+func TestStructTags(t *testing.T) {
+	bigcsv.RegisterConverter(bigcsv.ConvertFunc(netip.ParseAddr))
+	parser, err := bigcsv.NewFromString[Server](
+		"port,name,address,timeout,weight\n80,web,10.0.0.1,1s,\n5432,db,10.0.0.2,1m,0.5\n22,ssh,invalid,1s,1\n",
+		bigcsv.WithHeaders(),
+		bigcsv.WithStructTags(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var servers []Server
+	for s, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			var colErr *bigcsv.ColumnError
+			if !errors.As(err, &colErr) || colErr.Column != "address" || !strings.Contains(err.Error(), "line 4") {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			continue
+		}
+		servers = append(servers, s)
+	}
+	if len(servers) != 2 {
+		t.Fatalf("Got %d servers, expected 2", len(servers))
+	}
+	db := servers[1]
+	if db.Name != "db" || db.Addr != netip.MustParseAddr("10.0.0.2") || db.Port != 5432 || db.Timeout != time.Minute {
+		t.Fatalf("Unexpected server: %+v", db)
+	}
+	if servers[0].Weight != nil || db.Weight == nil || *db.Weight != 0.5 {
+		t.Fatalf("Unexpected weights: %v, %v", servers[0].Weight, db.Weight)
+	}
+}
+
+// TestStructTagsMissingColumn checks that a missing required column fails
+// before any rows are processed.
+func TestStructTagsMissingColumn(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Server]("name\nweb\n", bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnData = func(Server) error { return nil }
+	if err = parser.Run(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "address") {
+		t.Fatalf("Expected error for missing column, got: %v", err)
+	}
+}
+
+// TestStructTagsJSON checks decoding and writing columns holding JSON.
+func TestStructTagsJSON(t *testing.T) {
+	type Account struct {
+		ID    int               `csv:"id"`
+		Attrs map[string]string `csv:"attrs,json"`
+		Tags  []string          `csv:"tags,json"`
+	}
+	input := "id,attrs,tags\n1,\"{\"\"plan\"\":\"\"pro\"\"}\",\"[\"\"a\"\",\"\"b\"\"]\"\n2,,\n3,{oops,\n"
+	parser, err := bigcsv.NewFromString[Account](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	w, err := bigcsv.NewWriter[Account](&sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var accounts []Account
+	for a, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			var colErr *bigcsv.ColumnError
+			if !errors.As(err, &colErr) || colErr.Column != "attrs" {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			continue
+		}
+		accounts = append(accounts, a)
+		if err = w.OnData(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(accounts) != 2 || accounts[0].Attrs["plan"] != "pro" || len(accounts[0].Tags) != 2 || accounts[1].Attrs != nil {
+		t.Fatalf("Unexpected accounts: %+v", accounts)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.TrimSuffix(input, "3,{oops,\n"); sb.String() != want {
+		t.Errorf("Expected output:\n%s\ngot:\n%s", want, sb.String())
+	}
+}
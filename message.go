@@ -0,0 +1,224 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrStreamEnd ends a MessageStream when returned by its Dial function or a
+// MessageConn, so the Parser sees the end of the CSV instead of reconnecting.
+var ErrStreamEnd = errors.New("end of message stream")
+
+// MessageConn is a connection delivering CSV lines in messages, e.g. over a
+// WebSocket.
+type MessageConn interface {
+	// Next returns the data of the next message, one or more CSV lines, and
+	// the resume token after it, which may be empty.
+	Next() (data []byte, token string, err error)
+
+	// Close closes the connection.
+	Close() error
+}
+
+// MessageStream is a Stream of CSV lines pushed by a server, reconnecting with
+// exponential backoff when the connection fails. On reconnect, Dial receives
+// the last resume token, so the server can continue after the last message.
+//
+// For WebSockets, implement Dial with the client library of your choice; see
+// SSEStream for Server-Sent Events.
+type MessageStream struct {
+	// Dial connects to the server, resuming after token unless it is empty.
+	Dial func(ctx context.Context, token string) (MessageConn, error)
+
+	// MinBackoff and MaxBackoff limit the delay before reconnecting, which
+	// doubles with each failed attempt. They default to 1s and 1m.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// MaxRetries is the number of consecutive failed attempts after which
+	// the stream fails, 0 retrying forever.
+	MaxRetries int
+
+	mu    sync.Mutex
+	token string
+}
+
+// Resume sets the token to resume from when opening the stream, e.g. one
+// persisted from Token by an earlier process.
+func (s *MessageStream) Resume(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+}
+
+// Token returns the resume token of the last message passed to the Parser.
+func (s *MessageStream) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// Open starts receiving messages. Closing the reader disconnects.
+func (s *MessageStream) Open() (io.ReadCloser, error) {
+	if s.Dial == nil {
+		return nil, fmt.Errorf("message stream without Dial")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(s.receive(ctx, pw))
+	}()
+	return &messageReader{PipeReader: pr, cancel: cancel}, nil
+}
+
+// receive copies the messages to w until the stream ends, reconnecting as
+// needed. It returns nil at the end of the stream.
+func (s *MessageStream) receive(ctx context.Context, w io.Writer) error {
+	minBackoff, maxBackoff := s.MinBackoff, s.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = time.Second
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = time.Minute
+	}
+	backoff, failures := minBackoff, 0
+	for {
+		received, err := s.connection(ctx, w)
+		if errors.Is(err, ErrStreamEnd) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if received {
+			backoff, failures = minBackoff, 0
+		}
+		failures++
+		if s.MaxRetries > 0 && failures > s.MaxRetries {
+			return fmt.Errorf("giving up after %d attempts: %w", failures, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// connection copies the messages of a single connection to w, telling whether
+// any message was received.
+func (s *MessageStream) connection(ctx context.Context, w io.Writer) (bool, error) {
+	conn, err := s.Dial(ctx, s.Token())
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	for received := false; ; received = true {
+		data, token, err := conn.Next()
+		if err != nil {
+			return received, err
+		}
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			data = append(data, '\n')
+		}
+		if _, err = w.Write(data); err != nil {
+			return received, err
+		}
+		if token != "" {
+			s.Resume(token)
+		}
+	}
+}
+
+// messageReader stops receiving when closed.
+type messageReader struct {
+	*io.PipeReader
+	cancel context.CancelFunc
+}
+
+func (r *messageReader) Close() error {
+	r.cancel()
+	return r.PipeReader.Close()
+}
+
+// SSEStream creates a MessageStream of Server-Sent Events from url, each event
+// carrying CSV lines as data. The event ID is the resume token, sent as the
+// Last-Event-ID header when reconnecting. A 204 No Content response ends the
+// stream. If client is nil, http.DefaultClient is used.
+func SSEStream(client *http.Client, url string) *MessageStream {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &MessageStream{
+		Dial: func(ctx context.Context, token string) (MessageConn, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, fmt.Errorf("could not create request: %w", err)
+			}
+			req.Header.Set("Accept", "text/event-stream")
+			if token != "" {
+				req.Header.Set("Last-Event-ID", token)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("could not request: %w", err)
+			}
+			if res.StatusCode == http.StatusNoContent {
+				res.Body.Close()
+				return nil, ErrStreamEnd
+			}
+			if res.StatusCode != http.StatusOK {
+				res.Body.Close()
+				return nil, fmt.Errorf("unexpected response status: %s", res.Status)
+			}
+			return &sseConn{body: res.Body, scanner: bufio.NewScanner(res.Body), id: token}, nil
+		},
+	}
+}
+
+// sseConn reads events from an event stream.
+type sseConn struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+	id      string
+}
+
+// Next returns the data of the next event with data.
+func (c *sseConn) Next() ([]byte, string, error) {
+	var data bytes.Buffer
+	for c.scanner.Scan() {
+		line := c.scanner.Text()
+		if line == "" { // end of event
+			if data.Len() > 0 {
+				return data.Bytes(), c.id, nil
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			c.id = value
+		}
+	}
+	if err := c.scanner.Err(); err != nil {
+		return nil, "", err
+	}
+	return nil, "", io.ErrUnexpectedEOF // the server closed the connection
+}
+
+func (c *sseConn) Close() error {
+	return c.body.Close()
+}
@@ -0,0 +1,41 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSSEStream checks that the stream resumes after the last event when the
+// connection drops.
+func TestSSEStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Header.Get("Last-Event-ID") {
+		case "":
+			fmt.Fprint(w, "id: 1\ndata: 1,one\n\nid: 2\ndata: 2,two\n\n") // then drop
+		case "2":
+			fmt.Fprint(w, ": comment\nid: 3\ndata: 3,three\ndata: 4,four\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	stream := bigcsv.SSEStream(srv.Client(), srv.URL)
+	stream.MinBackoff = time.Millisecond
+	numbers, err := bigcsv.Collect(context.Background(), stream, ParseNumber)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 4 || numbers[3].String != "four" {
+		t.Fatalf("Unexpected numbers: %v", numbers)
+	}
+	if stream.Token() != "3" {
+		t.Fatalf("Resume token is %q, expected 3", stream.Token())
+	}
+}
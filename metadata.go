@@ -0,0 +1,120 @@
+package bigcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Metadata holds the lines wrapping the CSV body of many BI exports, such as a
+// report title and generation date before the header row, or totals after the
+// data, see WithHeaderMetadata and WithFooter.
+type Metadata struct {
+	// Header are the lines before the header row, Footer the lines from the
+	// start of the footer. Their fields are joined by the delimiter.
+	Header []string
+	Footer []string
+
+	// Values are the key-value pairs found in the lines, in the form
+	// "key: value", "key=value" or a row of two fields. Later lines
+	// replace the values of earlier ones.
+	Values map[string]string
+}
+
+// WithHeaderMetadata keeps the first n lines as Metadata rather than parsing
+// them, like WithSkipRows, which it replaces. Quotes in these lines are read
+// leniently and malformed lines are kept as far as they could be read, so
+// free-form text does not fail the Parser.
+func WithHeaderMetadata(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return fmt.Errorf("invalid number of metadata lines: %d", n)
+		}
+		cfg.skipRows = n
+		cfg.headerMetadata = true
+		return nil
+	}
+}
+
+// WithFooter takes the first row after the header matching the regular
+// expression as the start of a footer: it and all following lines are kept as
+// Metadata rather than processed, read as leniently as with
+// WithHeaderMetadata. The pattern is matched against the fields of the row
+// joined by the delimiter, e.g. `^(Total|Exported by)`.
+func WithFooter(pattern string) Option {
+	return func(cfg *config) error {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid footer pattern: %w", err)
+		}
+		cfg.footer = re
+		return nil
+	}
+}
+
+// Metadata returns the header and footer lines read so far.
+func (p *Parser[T]) Metadata() Metadata {
+	m := Metadata{
+		Header: slices.Clone(p.metaHeader),
+		Footer: slices.Clone(p.metaFooter),
+		Values: map[string]string{},
+	}
+	for _, line := range slices.Concat(m.Header, m.Footer) {
+		if key, value, ok := p.metadataValue(line); ok {
+			m.Values[key] = value
+		}
+	}
+	return m
+}
+
+// metadataValue splits a line into a key and a value.
+func (p *Parser[T]) metadataValue(line string) (string, string, bool) {
+	for _, sep := range []string{":", "=", string(p.cfg.comma)} {
+		if key, value, ok := strings.Cut(line, sep); ok && strings.TrimSpace(key) != "" {
+			return strings.TrimSpace(key), strings.TrimSpace(strings.TrimRight(value, string(p.cfg.comma))), true
+		}
+	}
+	return "", "", false
+}
+
+// joinRow joins the fields of a metadata line.
+func (p *Parser[T]) joinRow(row []string) string {
+	return strings.Join(row, string(p.cfg.comma))
+}
+
+// lenient makes the Reader tolerate free-form metadata lines, returning a
+// function restoring its settings.
+func (p *Parser[T]) lenient() func() {
+	if p.Reader == nil {
+		return func() {}
+	}
+	fields, lazy := p.Reader.FieldsPerRecord, p.Reader.LazyQuotes
+	p.Reader.FieldsPerRecord, p.Reader.LazyQuotes = -1, true
+	return func() {
+		p.Reader.FieldsPerRecord, p.Reader.LazyQuotes = fields, lazy
+	}
+}
+
+// footer tells whether a row read by next belongs to the footer, keeping it.
+func (p *Parser[T]) footer(row []string, err error) bool {
+	if !p.inFooter {
+		if p.cfg.footer == nil || !p.inBody || row == nil || err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return false
+		}
+		if !p.cfg.footer.MatchString(p.joinRow(row)) {
+			return false
+		}
+		p.inFooter = true
+		p.lenient() // for the rest of the stream
+	}
+	if err != nil && !isParseError(err) {
+		return false // the stream failed
+	}
+	if row != nil {
+		p.metaFooter = append(p.metaFooter, p.joinRow(row))
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package bigcsv_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestMetadata keeps the title lines and the totals of a BI export.
+func TestMetadata(t *testing.T) {
+	input := "Sales \"Q3\" report\nGenerated: 2024-10-01\n" +
+		"region,amount\nnorth,10\nsouth,20\n" +
+		"Total,30,EUR\nExported by,BI Suite\n"
+	parser, err := bigcsv.NewFromString[[]string](input, bigcsv.WithHeaders(),
+		bigcsv.WithHeaderMetadata(2), bigcsv.WithFooter(`^Total,`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	parser.OnRow = func(row []string) error {
+		rows = append(rows, slices.Clone(row))
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"north", "10"}, {"south", "20"}}
+	if !slices.EqualFunc(rows, expected, slices.Equal) {
+		t.Fatalf("Got rows %q, expected %q", rows, expected)
+	}
+	m := parser.Metadata()
+	if !slices.Equal(m.Header, []string{`Sales "Q3" report`, "Generated: 2024-10-01"}) {
+		t.Errorf("Unexpected header %q", m.Header)
+	}
+	if !slices.Equal(m.Footer, []string{"Total,30,EUR", "Exported by,BI Suite"}) {
+		t.Errorf("Unexpected footer %q", m.Footer)
+	}
+	if m.Values["Generated"] != "2024-10-01" || m.Values["Exported by"] != "BI Suite" {
+		t.Errorf("Unexpected values %q", m.Values)
+	}
+}
+
+// TestCountFooter checks that Count leaves out the footer like Run.
+func TestCountFooter(t *testing.T) {
+	input := "region,amount\nnorth,10\nsouth,20\nTotal,30,EUR\nExported by,BI Suite\n"
+	n, err := bigcsv.Count(context.Background(), bigcsv.ReadStream(strings.NewReader(input)),
+		bigcsv.WithHeaders(), bigcsv.WithFooter(`^Total,`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Counted %d records, expected 2", n)
+	}
+}
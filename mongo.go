@@ -0,0 +1,114 @@
+package bigcsv
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// MongoCollection writes documents to a MongoDB collection. Implement it by
+// wrapping a *mongo.Collection: InsertMany with options.InsertMany().
+// SetOrdered, and BulkWrite with a mongo.ReplaceOneModel per write.
+type MongoCollection[T any] interface {
+	InsertMany(ctx context.Context, docs []T, ordered bool) error
+	BulkWrite(ctx context.Context, writes []MongoWrite[T], ordered bool) error
+}
+
+// MongoWrite replaces the document matching Filter, inserting it if none
+// matches.
+type MongoWrite[T any] struct {
+	Filter   map[string]any
+	Document T
+}
+
+// MongoOptions configures a MongoDB sink.
+type MongoOptions struct {
+	// BatchSize is the number of documents per call, 1000 if 0.
+	BatchSize int
+
+	// Ordered stops a batch at the first failed write, else the other writes
+	// of the batch are attempted.
+	Ordered bool
+
+	// Key names the fields identifying a document, by their `csv` tags as
+	// for WithStructTags. If set, documents are upserted with BulkWrite
+	// instead of inserted, filtering by the names of the fields in the
+	// document: their `bson` tags, else their lower case names, as the
+	// driver stores them.
+	Key []string
+}
+
+// NewMongoSink creates a Batcher writing batches of records to the collection,
+// see Batch. Flush must be called to write the last batch.
+//
+//	sink, err := bigcsv.NewMongoSink[Customer](ctx, customers, bigcsv.MongoOptions{Key: []string{"customer_id"}})
+//	parser.OnData = sink.OnData
+//	err = errors.Join(parser.Run(ctx, 4), sink.Flush())
+func NewMongoSink[T any](ctx context.Context, coll MongoCollection[T], opts MongoOptions) (*Batcher[T], error) {
+	size := opts.BatchSize
+	if size == 0 {
+		size = 1000
+	}
+	if len(opts.Key) == 0 {
+		return Batch(size, func(docs []T) error {
+			return coll.InsertMany(ctx, docs, opts.Ordered)
+		}), nil
+	}
+
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("upsert by key needs a struct type, got %s", typ)
+	}
+	byName := map[string][]int{}
+	for _, f := range taggedFields(typ, nil) {
+		byName[f.tag.name] = f.index
+	}
+	keys := make([][]int, len(opts.Key))
+	paths := make([]string, len(opts.Key))
+	for i, name := range opts.Key {
+		var ok bool
+		if keys[i], ok = byName[name]; !ok {
+			return nil, fmt.Errorf("no field for key %s", name)
+		}
+		if paths[i], ok = bsonPath(typ, keys[i]); !ok {
+			return nil, fmt.Errorf("key %s is not stored in the document", name)
+		}
+	}
+	return Batch(size, func(docs []T) error {
+		writes := make([]MongoWrite[T], len(docs))
+		for i, doc := range docs {
+			v := reflect.ValueOf(doc)
+			filter := make(map[string]any, len(keys))
+			for j, index := range keys {
+				filter[paths[j]] = v.FieldByIndex(index).Interface()
+			}
+			writes[i] = MongoWrite[T]{Filter: filter, Document: doc}
+		}
+		return coll.BulkWrite(ctx, writes, opts.Ordered)
+	}), nil
+}
+
+// bsonPath returns the dotted path of a field in the document encoded by the
+// bson package, which nests embedded structs unless tagged inline. It returns
+// false for fields tagged "-".
+func bsonPath(typ reflect.Type, index []int) (string, bool) {
+	var path []string
+	for _, i := range index {
+		f := typ.Field(i)
+		typ = f.Type
+		name, opts, _ := strings.Cut(f.Tag.Get("bson"), ",")
+		if name == "-" && opts == "" {
+			return "", false
+		}
+		if slices.Contains(strings.Split(opts, ","), "inline") {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		path = append(path, name)
+	}
+	return strings.Join(path, "."), true
+}
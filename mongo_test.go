@@ -0,0 +1,98 @@
+package bigcsv_test
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// memoryCollection stores documents by the filter of upserts.
+type memoryCollection struct {
+	docs map[any]Server
+}
+
+func (m *memoryCollection) InsertMany(ctx context.Context, docs []Server, ordered bool) error {
+	for _, doc := range docs {
+		m.docs[len(m.docs)] = doc
+	}
+	return nil
+}
+
+func (m *memoryCollection) BulkWrite(ctx context.Context, writes []bigcsv.MongoWrite[Server], ordered bool) error {
+	for _, w := range writes {
+		m.docs[w.Filter["name"]] = w.Document
+	}
+	return nil
+}
+
+// TestMongoSink checks that documents are upserted by key.
+func TestMongoSink(t *testing.T) {
+	coll := &memoryCollection{docs: map[any]Server{}}
+	sink, err := bigcsv.NewMongoSink[Server](context.Background(), coll, bigcsv.MongoOptions{BatchSize: 2, Key: []string{"name"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []Server{{Name: "a", Port: 1}, {Name: "b", Port: 2}, {Name: "a", Port: 3}} {
+		if err = sink.OnData(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if len(coll.docs) != 2 || coll.docs["a"].Port != 3 {
+		t.Fatalf("Unexpected documents: %v", coll.docs)
+	}
+	if _, err = bigcsv.NewMongoSink[Server](context.Background(), coll, bigcsv.MongoOptions{Key: []string{"id"}}); err == nil {
+		t.Fatal("Expected error for unknown key")
+	}
+}
+
+// filterCollection records the filters of upserts.
+type filterCollection[T any] struct {
+	filters []map[string]any
+}
+
+func (f *filterCollection[T]) InsertMany(ctx context.Context, docs []T, ordered bool) error {
+	return nil
+}
+
+func (f *filterCollection[T]) BulkWrite(ctx context.Context, writes []bigcsv.MongoWrite[T], ordered bool) error {
+	for _, w := range writes {
+		f.filters = append(f.filters, w.Filter)
+	}
+	return nil
+}
+
+// TestMongoSinkBSON checks that upserts filter by the names in the document.
+func TestMongoSinkBSON(t *testing.T) {
+	type Account struct {
+		Region string `csv:"region"`
+	}
+	type Customer struct {
+		Account
+		ID    int    `csv:"customer_id" bson:"_id"`
+		Email string `csv:"email"`
+		Note  string `csv:"note" bson:"-"`
+	}
+	coll := &filterCollection[Customer]{}
+	sink, err := bigcsv.NewMongoSink[Customer](context.Background(), coll, bigcsv.MongoOptions{Key: []string{"customer_id", "email", "region"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.OnData(Customer{Account{"eu"}, 7, "a@example.com", ""}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{"_id": 7, "email": "a@example.com", "account.region": "eu"}
+	if len(coll.filters) != 1 || !maps.Equal(coll.filters[0], want) {
+		t.Fatalf("Got filters %v, expected %v", coll.filters, want)
+	}
+	if _, err = bigcsv.NewMongoSink[Customer](context.Background(), coll, bigcsv.MongoOptions{Key: []string{"note"}}); err == nil {
+		t.Fatal("Expected error for a key not stored in the document")
+	}
+}
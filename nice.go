@@ -0,0 +1,76 @@
+package bigcsv
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// minYield is the shortest sleep of a nice Parser, shorter ones are summed
+// up.
+const minYield = time.Millisecond
+
+// WithNice limits the processing of rows to about share of the time of each
+// worker, between 0 and 1, e.g. for a backfill running in the same process
+// as a latency-sensitive API. Workers sleep after each row in proportion to
+// the time it took: with a share of 0.25, three times as long. It can be
+// changed during a Run with SetNice.
+func WithNice(share float64) Option {
+	return func(cfg *config) error {
+		if err := checkShare(share); err != nil {
+			return err
+		}
+		cfg.nice = share
+		return nil
+	}
+}
+
+// SetNice changes the share of time spent processing rows, see WithNice. A
+// share of 1 turns it off. It is safe to call during processing, e.g. to
+// slow down an import while the API is busy.
+func (p *Parser[T]) SetNice(share float64) error {
+	if err := checkShare(share); err != nil {
+		return err
+	}
+	p.nice.set(share)
+	return nil
+}
+
+func checkShare(share float64) error {
+	if !(share > 0 && share <= 1) {
+		return fmt.Errorf("invalid share of time: %v", share)
+	}
+	return nil
+}
+
+// niceness holds the share of time and the sleep owed by the workers.
+type niceness struct {
+	share atomic.Uint64 // float64 bits, 0 meaning 1
+	owed  atomic.Int64
+}
+
+// set sets the share of time.
+func (n *niceness) set(share float64) {
+	n.share.Store(math.Float64bits(share))
+}
+
+// yield sleeps for the time owed by a row processed since start, once the
+// debt of all workers reaches minYield.
+func (n *niceness) yield(start time.Time) {
+	bits := n.share.Load()
+	if bits == 0 {
+		return
+	}
+	share := math.Float64frombits(bits)
+	if share >= 1 {
+		return
+	}
+	owed := n.owed.Add(int64(float64(time.Since(start)) * (1/share - 1)))
+	if owed < int64(minYield) {
+		return
+	}
+	if n.owed.CompareAndSwap(owed, 0) {
+		time.Sleep(time.Duration(owed))
+	}
+}
@@ -0,0 +1,32 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestNice checks that a nice Parser takes about the expected time longer.
+func TestNice(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string](strings.Repeat("x\n", 20), bigcsv.WithNice(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnRow = func([]string) error {
+		time.Sleep(time.Millisecond)
+		return nil
+	}
+	start := time.Now()
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 38*time.Millisecond {
+		t.Errorf("Took %v for 20ms of work at half the time, expected about 40ms", elapsed)
+	}
+	if err = parser.SetNice(0); err == nil {
+		t.Error("Expected an error for a share of 0")
+	}
+}
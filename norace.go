@@ -0,0 +1,6 @@
+//go:build !race
+
+package bigcsv
+
+// raceEnabled tells whether the race detector is enabled, see race.go.
+const raceEnabled = false
@@ -0,0 +1,6 @@
+//go:build !race
+
+package bigcsv_test
+
+// raceEnabled tells whether the race detector is enabled, see race_test.go.
+const raceEnabled = false
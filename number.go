@@ -0,0 +1,221 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NumberFormat describes how numbers are written in a locale, for parsing
+// values such as "1.234,56", "(1,234.56)", "€ 12,50" or "12,5 %".
+//
+// Besides the separators, a NumberFormat accepts currency symbols and spaces
+// around the number, a leading or trailing sign, parentheses for negative
+// numbers and a percent sign, which divides the value by 100.
+type NumberFormat struct {
+	// Decimal is the decimal separator.
+	Decimal rune
+
+	// Group is the thousands separator, or 0 if there is none. If it is a
+	// space, any space character is accepted, as is common in French.
+	Group rune
+}
+
+// Common number formats. Use their names with the `csv` tag option "number"
+// of struct fields, e.g. `csv:"Betrag,number=de"`.
+var (
+	NumberFormatEN = NumberFormat{Decimal: '.', Group: ','}  // "en", 1,234.56
+	NumberFormatDE = NumberFormat{Decimal: ',', Group: '.'}  // "de", 1.234,56
+	NumberFormatFR = NumberFormat{Decimal: ',', Group: ' '}  // "fr", 1 234,56
+	NumberFormatCH = NumberFormat{Decimal: '.', Group: '\''} // "ch", 1'234.56
+)
+
+// numberFormats are the formats known by name.
+var numberFormats = map[string]NumberFormat{
+	"en": NumberFormatEN,
+	"de": NumberFormatDE,
+	"fr": NumberFormatFR,
+	"ch": NumberFormatCH,
+}
+
+// WithNumberFormat names a number format for the `csv` tag option "number"
+// of struct fields, in addition to the common ones ("en", "de", "fr", "ch").
+func WithNumberFormat(name string, f NumberFormat) Option {
+	return func(cfg *config) error {
+		if name == "" || f.Decimal == 0 || f.Decimal == f.Group || unicode.IsDigit(f.Decimal) || unicode.IsDigit(f.Group) {
+			return fmt.Errorf("invalid number format %q", name)
+		}
+		if cfg.numberFormats == nil {
+			cfg.numberFormats = map[string]NumberFormat{}
+		}
+		cfg.numberFormats[name] = f
+		return nil
+	}
+}
+
+// numberFormat looks up a number format by name, first in the Parser's
+// formats, then in the common ones.
+func (cfg *config) numberFormat(name string) (NumberFormat, bool) {
+	if f, ok := cfg.numberFormats[name]; ok {
+		return f, true
+	}
+	f, ok := numberFormats[name]
+	return f, ok
+}
+
+// errNumber is returned for values which are not numbers in the format.
+var errNumber = errors.New("invalid number")
+
+// ParseFloat parses a number in the format.
+func (f NumberFormat) ParseFloat(s string) (float64, error) {
+	n, percent, err := f.normalize(s)
+	if err != nil {
+		return 0, err
+	}
+	x, err := strconv.ParseFloat(n, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", errNumber, s)
+	}
+	if percent {
+		x /= 100
+	}
+	return x, nil
+}
+
+// ParseInt parses an integer in the format. Values with decimals or percent
+// signs are rejected.
+func (f NumberFormat) ParseInt(s string) (int64, error) {
+	n, percent, err := f.normalize(s)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseInt(n, 10, 64)
+	if err != nil || percent {
+		return 0, fmt.Errorf("%w: %q is not an integer", errNumber, s)
+	}
+	return i, nil
+}
+
+// FormatFloat writes a number in the format, with the given number of decimal
+// places, or as few as needed if negative, never in scientific notation.
+// Groups are separated unless Group is 0. Infinities and NaN are written as
+// "+Inf", "-Inf" and "NaN".
+func (f NumberFormat) FormatFloat(x float64, decimals int) string {
+	return f.format(strconv.FormatFloat(x, 'f', max(decimals, -1), 64))
+}
+
+// FormatInt writes an integer in the format.
+func (f NumberFormat) FormatInt(i int64) string {
+	return f.format(strconv.FormatInt(i, 10))
+}
+
+// format converts a number in the syntax of strconv to the format. Infinities
+// and NaN are kept as strconv writes them.
+func (f NumberFormat) format(n string) string {
+	sign, n := "", n
+	if n != "" && (n[0] == '-' || n[0] == '+') {
+		sign, n = n[:1], n[1:]
+	}
+	if n == "Inf" || n == "NaN" {
+		return sign + n
+	}
+	whole, fraction, ok := strings.Cut(n, ".")
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, r := range whole {
+		if f.Group != 0 && i > 0 && (len(whole)-i)%3 == 0 {
+			sb.WriteRune(f.Group)
+		}
+		sb.WriteRune(r)
+	}
+	if ok {
+		sb.WriteRune(f.Decimal)
+		sb.WriteString(fraction)
+	}
+	return sb.String()
+}
+
+// normalize converts a number to the syntax of strconv, telling whether it
+// had a percent sign.
+func (f NumberFormat) normalize(s string) (string, bool, error) {
+	s = strings.TrimSpace(s)
+	var neg, percent, digits, decimal, signed, grouped bool
+	run := 0 // digits since the last group separator
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		neg, signed = true, true
+		s = s[1 : len(s)-1]
+	}
+	var sb strings.Builder
+	runes := []rune(s)
+	for i, r := range runes {
+		// A space only separates groups if followed by a digit.
+		spaceGroup := f.Group == ' ' && unicode.IsSpace(r) && digits && i+1 < len(runes) && unicode.IsDigit(runes[i+1])
+		switch {
+		case r >= '0' && r <= '9':
+			if percent {
+				return "", false, fmt.Errorf("%w: %q", errNumber, s)
+			}
+			sb.WriteRune(r)
+			digits = true
+			run++
+		case r == f.Decimal:
+			if decimal || grouped && run != 3 {
+				return "", false, fmt.Errorf("%w: %q", errNumber, s)
+			}
+			sb.WriteByte('.')
+			decimal = true
+		case r == f.Group && f.Group != ' ' || spaceGroup:
+			if !digits || decimal || grouped && run != 3 {
+				return "", false, fmt.Errorf("%w: %q", errNumber, s)
+			}
+			grouped, run = true, 0
+		case r == '-' || r == '+' || r == '−':
+			if signed {
+				return "", false, fmt.Errorf("%w: %q", errNumber, s)
+			}
+			neg, signed = r != '+', true
+		case r == '%':
+			percent = true
+		case unicode.Is(unicode.Sc, r) || unicode.IsSpace(r):
+		default:
+			return "", false, fmt.Errorf("%w: %q", errNumber, s)
+		}
+	}
+	if !digits || grouped && !decimal && run != 3 {
+		return "", false, fmt.Errorf("%w: %q", errNumber, s)
+	}
+	n := sb.String()
+	if neg {
+		n = "-" + n
+	}
+	return n, percent, nil
+}
+
+// numberSetter returns the conversion of a numeric field in the named format.
+func (cfg *config) numberSetter(typ reflect.Type, name string) (setter, error) {
+	f, ok := cfg.numberFormat(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown number format %q", name)
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(s string, v reflect.Value) error {
+			i, err := f.ParseInt(s)
+			if err == nil && v.OverflowInt(i) {
+				err = fmt.Errorf("%w: %q is out of range", errNumber, s)
+			}
+			v.SetInt(i)
+			return err
+		}, nil
+	case reflect.Float32, reflect.Float64:
+		return func(s string, v reflect.Value) error {
+			x, err := f.ParseFloat(s)
+			v.SetFloat(x)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("number format for non-numeric type %s", typ)
+}
@@ -0,0 +1,69 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestNumberFormat checks parsing numbers in locale formats.
+func TestNumberFormat(t *testing.T) {
+	for _, tc := range []struct {
+		format bigcsv.NumberFormat
+		in     string
+		want   float64
+	}{
+		{bigcsv.NumberFormatDE, "1.234,56", 1234.56},
+		{bigcsv.NumberFormatDE, "-1.234.567", -1234567},
+		{bigcsv.NumberFormatEN, "(1,234.50)", -1234.5},
+		{bigcsv.NumberFormatEN, "$ 1,000", 1000},
+		{bigcsv.NumberFormatDE, "12,50 €", 12.5},
+		{bigcsv.NumberFormatDE, "12,5 %", 0.125},
+		{bigcsv.NumberFormatFR, "1 234 567,8", 1234567.8},
+		{bigcsv.NumberFormatFR, "1 234,5", 1234.5},
+		{bigcsv.NumberFormatCH, "1'234.5-", -1234.5},
+	} {
+		got, err := tc.format.ParseFloat(tc.in)
+		if err != nil || got != tc.want {
+			t.Errorf("Parsing %q: got %v, %v, expected %v", tc.in, got, err, tc.want)
+		}
+	}
+	for _, in := range []string{"", "€", "1,2,3", "1.2,3", "abc", "--1", "1 % 2"} {
+		if got, err := bigcsv.NumberFormatDE.ParseFloat(in); err == nil {
+			t.Errorf("Expected an error for %q, got %v", in, got)
+		}
+	}
+}
+
+// TestNumberFormatTag checks selecting the number format per column.
+func TestNumberFormatTag(t *testing.T) {
+	type Item struct {
+		Price    float64  `csv:"price,number=de"`
+		Quantity int      `csv:"qty,number=en"`
+		Discount *float64 `csv:"discount,number=pct"`
+	}
+	parser, err := bigcsv.NewFromString[Item](
+		"price;qty;discount\n1.299,99;\"1,200\";5%\n0,5;3;\n",
+		bigcsv.WithComma(';'),
+		bigcsv.WithHeaders(),
+		bigcsv.WithStructTags(),
+		bigcsv.WithNumberFormat("pct", bigcsv.NumberFormat{Decimal: '.'}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []Item
+	for item, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, item)
+	}
+	if len(items) != 2 || items[0].Price != 1299.99 || items[0].Quantity != 1200 || *items[0].Discount != 0.05 {
+		t.Errorf("Unexpected items: %+v", items)
+	}
+	if items[1].Price != 0.5 || items[1].Discount != nil {
+		t.Errorf("Unexpected items: %+v", items)
+	}
+}
@@ -0,0 +1,219 @@
+package bigcsv
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ODS returns a RecordReader constructor for a table of an OpenDocument
+// spreadsheet (LibreOffice), the first table if name is empty. The rows are
+// decoded as they are read.
+//
+//	parser, err := bigcsv.New[Place](bigcsv.FileStream("places.ods"), bigcsv.WithRecordReader(bigcsv.ODS("")))
+//
+// As with XLSX, streams other than files are read into memory. Numbers are
+// returned as stored, dates in ISO 8601, booleans as TRUE or FALSE and text
+// with paragraphs joined by newlines. Trailing empty rows and cells, which
+// spreadsheets tend to repeat up to their size limits, are dropped.
+func ODS(name string) func(io.Reader) RecordReader {
+	return func(r io.Reader) RecordReader {
+		o, err := openODS(r, name)
+		if err != nil {
+			return failedReader{fmt.Errorf("could not open spreadsheet: %w", err)}
+		}
+		return o
+	}
+}
+
+// odsReader reads the rows of a table.
+type odsReader struct {
+	content io.ReadCloser
+	dec     *xml.Decoder
+	empty   int        // empty rows, returned only if a row follows
+	queue   [][]string // rows ready to be returned
+}
+
+// openODS positions the decoder at the start of the table.
+func openODS(r io.Reader, name string) (*odsReader, error) {
+	z, err := openZip(r)
+	if err != nil {
+		return nil, err
+	}
+	f, err := openXML(z, "content.xml")
+	if err != nil || f == nil {
+		return nil, fmt.Errorf("no content.xml: %w", err)
+	}
+	o := &odsReader{content: f, dec: xml.NewDecoder(f)}
+	for {
+		tok, err := o.dec.Token()
+		if err != nil {
+			f.Close()
+			if err == io.EOF {
+				return nil, fmt.Errorf("no table %q", name)
+			}
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if ok && start.Name.Local == "table" && (name == "" || attr(start, "name") == name) {
+			return o, nil
+		}
+	}
+}
+
+// attr returns the value of an attribute by local name.
+func attr(start xml.StartElement, local string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// repeated returns the value of a repetition attribute, at least 1.
+func repeated(start xml.StartElement, local string) int {
+	n, err := strconv.Atoi(attr(start, local))
+	if err != nil || n < 1 {
+		return 1
+	}
+	return n
+}
+
+func (o *odsReader) Read() ([]string, error) {
+	for len(o.queue) == 0 {
+		tok, err := o.dec.Token()
+		if err != nil {
+			o.content.Close()
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement:
+			if tok.Name.Local == "table" {
+				o.content.Close()
+				return nil, io.EOF
+			}
+		case xml.StartElement:
+			if tok.Name.Local != "table-row" {
+				continue
+			}
+			row, err := o.row()
+			if err != nil {
+				return nil, err
+			}
+			n := repeated(tok, "number-rows-repeated")
+			if len(row) == 0 {
+				o.empty += n
+				continue
+			}
+			for ; o.empty > 0; o.empty-- {
+				o.queue = append(o.queue, []string{})
+			}
+			for i := 0; i < n; i++ {
+				o.queue = append(o.queue, slices.Clone(row))
+			}
+		}
+	}
+	row := o.queue[0]
+	o.queue = o.queue[1:]
+	return row, nil
+}
+
+// row decodes the cells of a row, without trailing empty cells.
+func (o *odsReader) row() ([]string, error) {
+	row := []string{}
+	empty := 0 // empty cells, added only if a value follows
+	for {
+		tok, err := o.dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.EndElement: // of the row
+			return row, nil
+		case xml.StartElement:
+			value, err := o.cell(tok)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrMalformedRecord, err)
+			}
+			n := repeated(tok, "number-columns-repeated")
+			if value == "" {
+				empty += n
+				continue
+			}
+			for ; empty > 0; empty-- {
+				row = append(row, "")
+			}
+			for i := 0; i < n; i++ {
+				row = append(row, value)
+			}
+		}
+	}
+}
+
+// cell decodes the value of a cell.
+func (o *odsReader) cell(start xml.StartElement) (string, error) {
+	var text []string
+	depth := 0
+	for {
+		tok, err := o.dec.Token()
+		if err != nil {
+			return "", err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch tok.Name.Local {
+			case "p":
+				if depth == 1 {
+					text = append(text, "")
+				}
+			case "s": // spaces
+				text = appendText(text, strings.Repeat(" ", repeated(tok, "c")))
+			case "tab":
+				text = appendText(text, "\t")
+			case "line-break":
+				text = appendText(text, "\n")
+			}
+		case xml.CharData:
+			if depth > 0 {
+				text = appendText(text, string(tok))
+			}
+		case xml.EndElement:
+			if depth == 0 {
+				return cellValue(start, strings.Join(text, "\n")), nil
+			}
+			depth--
+		}
+	}
+}
+
+// appendText appends to the last paragraph.
+func appendText(text []string, s string) []string {
+	if len(text) == 0 {
+		return []string{s}
+	}
+	text[len(text)-1] += s
+	return text
+}
+
+// cellValue returns the value of a cell by its type, or else its text.
+func cellValue(start xml.StartElement, text string) string {
+	switch attr(start, "value-type") {
+	case "float", "percentage", "currency":
+		return attr(start, "value")
+	case "date":
+		return attr(start, "date-value")
+	case "time":
+		return attr(start, "time-value")
+	case "boolean":
+		if attr(start, "boolean-value") == "true" {
+			return "TRUE"
+		}
+		return "FALSE"
+	}
+	return text
+}
@@ -0,0 +1,45 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestODS reads a table with typed cells and repeated rows and cells.
+func TestODS(t *testing.T) {
+	spreadsheet := zipFiles(t, map[string]string{
+		"content.xml": `<office:document-content
+xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet>
+<table:table table:name="Notes"><table:table-row><table:table-cell><text:p>skip</text:p></table:table-cell></table:table-row></table:table>
+<table:table table:name="Data">
+<table:table-column table:number-columns-repeated="3"/>
+<table:table-row><table:table-cell office:value-type="string"><text:p>name</text:p></table:table-cell><table:table-cell><text:p>a<text:s text:c="2"/>b</text:p><text:p>c</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell office:value-type="float" office:value="1.5"><text:p>1,5</text:p></table:table-cell><table:table-cell office:value-type="boolean" office:boolean-value="true"/><table:table-cell office:value-type="date" office:date-value="2024-01-31"/><table:table-cell table:number-columns-repeated="1020"/></table:table-row>
+<table:table-row table:number-rows-repeated="1048570"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table>
+</office:spreadsheet></office:body></office:document-content>`,
+	})
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(bytes.NewReader(spreadsheet)), bigcsv.WithRecordReader(bigcsv.ODS("Data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for row, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	want := [][]string{{"name", "a  b\nc"}, {}, {}, {"1.5", "TRUE", "2024-01-31"}, {"1.5", "TRUE", "2024-01-31"}}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Fatalf("Rows are %q, expected %q", rows, want)
+	}
+}
@@ -0,0 +1,231 @@
+package bigcsv
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// Option configures a Parser. Options are applied and validated by New, so an
+// invalid configuration is reported before any rows are processed.
+type Option func(*config) error
+
+// ErrorPolicy decides how the Parser reacts to row errors.
+type ErrorPolicy int
+
+const (
+	// ContinueOnError passes row errors to OnError and carries on with the
+	// next row. This is the default.
+	ContinueOnError ErrorPolicy = iota
+
+	// StopOnError passes the first row error to OnError and stops reading
+	// further rows. Run returns that error.
+	StopOnError
+)
+
+// config holds the settings made by options.
+type config struct {
+	comma          rune
+	headers        bool
+	skipRows       int
+	workers        int
+	errorPolicy    ErrorPolicy
+	logger         *slog.Logger
+	logLevel       slog.Level
+	progress       int
+	keepOpen       bool
+	fields         *int
+	skipBlank      bool
+	commentPrefix  string
+	encoding       string
+	structTags     bool
+	converters     registry
+	newReader      func(io.Reader) RecordReader
+	stallAfter     time.Duration
+	onStall        func(Stall) error
+	profile        bool
+	numberFormats  map[string]NumberFormat
+	dateFormats    map[string]DateFormat
+	unique         *uniqueConfig
+	references     []reference
+	checkpoint     *checkpointConfig
+	signals        []os.Signal
+	maxLines       int
+	ignoreFatal    bool
+	preprocess     map[string]func(string) string
+	trailer        *regexp.Regexp
+	footer         *regexp.Regexp
+	headerMetadata bool
+	strict         bool
+	deadLetter     *deadLetter
+	maxPending     int
+	readAhead      int
+	bufferSize     int
+	trace          *tracer
+	maxInFlight    int
+	nice           float64
+	padRows        bool
+	repairQuotes   bool
+	maxFieldLength int
+	defaults       map[string]string
+	schema         *Schema
+	sampleEvery    int
+	sampleHook     func(*RowSample)
+	rowHash        []string
+	checkpointOut  CheckpointOutput
+}
+
+// newConfig applies the options on top of the defaults.
+func newConfig(opts []Option) (*config, error) {
+	cfg := &config{
+		comma:   ',',
+		workers: 1,
+	}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	if cfg.progress > 0 && cfg.logger == nil {
+		return nil, fmt.Errorf("invalid option: progress requires a logger")
+	}
+	if cfg.unique != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: unique columns require headers")
+	}
+	if cfg.references != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: references require headers")
+	}
+	if cfg.preprocess != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: preprocessing requires headers")
+	}
+	if cfg.strict && (cfg.headerMetadata || cfg.footer != nil) {
+		return nil, fmt.Errorf("invalid option: strict mode cannot be combined with metadata")
+	}
+	if cfg.strict && cfg.repairQuotes {
+		return nil, fmt.Errorf("invalid option: strict mode cannot be combined with repairing quotes")
+	}
+	if cfg.defaults != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: defaults require headers")
+	}
+	if cfg.schema != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: a schema requires headers")
+	}
+	if cfg.checkpointOut != nil {
+		if cfg.checkpoint == nil {
+			return nil, fmt.Errorf("invalid option: checkpoint output requires a checkpoint")
+		}
+		cfg.checkpoint.out = cfg.checkpointOut
+	}
+	if cfg.rowHash != nil && !cfg.headers {
+		return nil, fmt.Errorf("invalid option: row hash columns require headers")
+	}
+	return cfg, nil
+}
+
+// WithComma sets the field delimiter, see csv.Reader.Comma.
+func WithComma(r rune) Option {
+	return func(cfg *config) error {
+		if r == 0 || r == '"' || r == '\r' || r == '\n' || !utf8.ValidRune(r) || r == utf8.RuneError {
+			return fmt.Errorf("invalid comma %q", r)
+		}
+		cfg.comma = r
+		return nil
+	}
+}
+
+// WithHeaders treats the first row (after any skipped rows) as the header row.
+// It is consumed by the Parser and not passed to OnRow or Parse.
+func WithHeaders() Option {
+	return func(cfg *config) error {
+		cfg.headers = true
+		return nil
+	}
+}
+
+// WithSkipRows discards the first n rows of the stream, for example a title
+// line preceding the header.
+func WithSkipRows(n int) Option {
+	return func(cfg *config) error {
+		if n < 0 {
+			return fmt.Errorf("invalid number of rows to skip: %d", n)
+		}
+		cfg.skipRows = n
+		return nil
+	}
+}
+
+// WithFieldsPerRecord sets the number of fields each row must have, see
+// csv.Reader.FieldsPerRecord. Use -1 for rows of varying length.
+func WithFieldsPerRecord(n int) Option {
+	return func(cfg *config) error {
+		if n < -1 {
+			return fmt.Errorf("invalid number of fields per record: %d", n)
+		}
+		cfg.fields = &n
+		return nil
+	}
+}
+
+// WithMaxInFlight allows up to n rows to be read ahead and queued for the
+// workers, rather than reading only as many rows as there are workers. A
+// burst of slow OnData calls then does not stall reading right away, while
+// the rows held in memory remain bounded. It has no effect if n does not
+// exceed the number of workers. Queued rows are started in the order read, but
+// not processed when the Parser is stopped.
+func WithMaxInFlight(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of rows in flight: %d", n)
+		}
+		cfg.maxInFlight = n
+		return nil
+	}
+}
+
+// WithWorkers sets the number of workers used when Run is called with 0
+// workers. The default is 1.
+func WithWorkers(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of workers: %d", n)
+		}
+		cfg.workers = n
+		return nil
+	}
+}
+
+// WithErrorPolicy sets how row errors affect the Run, see ErrorPolicy.
+func WithErrorPolicy(policy ErrorPolicy) Option {
+	return func(cfg *config) error {
+		if policy != ContinueOnError && policy != StopOnError {
+			return fmt.Errorf("invalid error policy: %d", policy)
+		}
+		cfg.errorPolicy = policy
+		return nil
+	}
+}
+
+// WithKeepOpen prevents Run, Rows and Chan from closing the stream when they
+// end, so that trailing content can be read afterwards, e.g. with Reader or
+// another Run continuing where the last one stopped. The stream must then be
+// closed with Close.
+func WithKeepOpen() Option {
+	return func(cfg *config) error {
+		cfg.keepOpen = true
+		return nil
+	}
+}
+
+// WithIgnoreFatal makes Run return nil when the stream fails, ctx is cancelled
+// or the stream cannot be closed, as it did before returning fatal errors.
+// Errors of the stream are still passed to OnError.
+func WithIgnoreFatal() Option {
+	return func(cfg *config) error {
+		cfg.ignoreFatal = true
+		return nil
+	}
+}
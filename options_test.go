@@ -0,0 +1,115 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestOptions checks that the comma, skipped rows and headers are applied.
+func TestOptions(t *testing.T) {
+	parser, err := bigcsv.New[Number](
+		bigcsv.ReadStream(strings.NewReader("Numbers export\nint;name\n1;one\n2;two\n")),
+		bigcsv.WithComma(';'),
+		bigcsv.WithSkipRows(1),
+		bigcsv.WithHeaders(),
+		bigcsv.WithWorkers(2),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := &atomic.Int32{}
+	parser.Parse = ParseNumber
+	parser.OnData = func(n Number) error {
+		sum.Add(int32(n.Integer))
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err = parser.Run(context.Background(), 0); err != nil {
+		t.Fatal(err)
+	}
+	if sum.Load() != 3 {
+		t.Fatalf("Sum of processed rows is %d, expected 3", sum.Load())
+	}
+}
+
+// TestInvalidOptions ensures New rejects invalid options up front.
+func TestInvalidOptions(t *testing.T) {
+	for name, opt := range map[string]bigcsv.Option{
+		"comma":   bigcsv.WithComma('"'),
+		"skip":    bigcsv.WithSkipRows(-1),
+		"workers": bigcsv.WithWorkers(0),
+		"policy":  bigcsv.WithErrorPolicy(bigcsv.ErrorPolicy(42)),
+	} {
+		if _, err := bigcsv.New[Number](bigcsv.ReadStream(strings.NewReader("")), opt); err == nil {
+			t.Errorf("Option %s: expected error", name)
+		}
+	}
+}
+
+// TestStopOnError checks that Run returns the first row error when using the
+// StopOnError policy.
+func TestStopOnError(t *testing.T) {
+	parser, err := bigcsv.New[Number](
+		bigcsv.ReadStream(strings.NewReader("1,one\nx,two\n3,three\n")),
+		bigcsv.WithErrorPolicy(bigcsv.StopOnError),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed := 0
+	parser.Parse = ParseNumber
+	parser.OnData = func(n Number) error {
+		processed++
+		return nil
+	}
+	err = parser.Run(context.Background(), 1)
+	if !errors.Is(err, bigcsv.ErrParse) {
+		t.Fatalf("Expected parse error, got: %v", err)
+	}
+	if processed != 1 {
+		t.Fatalf("Processed %d rows, expected 1", processed)
+	}
+}
+
+// TestMaxInFlight checks that rows are read ahead of blocked workers up to
+// the limit.
+func TestMaxInFlight(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string](strings.Repeat("x\n", 100), bigcsv.WithMaxInFlight(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	var processed atomic.Int64
+	parser.OnRow = func([]string) error {
+		<-release
+		processed.Add(1)
+		return nil
+	}
+	done := make(chan error)
+	go func() {
+		done <- parser.Run(context.Background(), 2)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for line, _ := parser.Position(); line < 10 && time.Now().Before(deadline); line, _ = parser.Position() {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond) // would read on beyond the limit
+	if line, _ := parser.Position(); line != 10 {
+		t.Errorf("Read %d rows with 2 blocked workers, expected 10", line)
+	}
+	close(release)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if processed.Load() != 100 {
+		t.Errorf("Processed %d rows, expected 100", processed.Load())
+	}
+}
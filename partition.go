@@ -0,0 +1,76 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+)
+
+// PartitionWriter routes each record to one of n Writers by a hash of a key
+// column, so parallel loaders downstream receive pre-partitioned inputs from a
+// single pass. Records with the same key always end up in the same partition.
+// Its OnData is safe for use with multiple workers. Close must be called at the
+// end.
+type PartitionWriter[T any] struct {
+	writers []*Writer[T]
+	closers []io.Closer
+	column  int
+}
+
+// NewPartitionWriter creates n partitions, calling create for the output of
+// each, e.g. a file named after the partition. The key column is named as in
+// the header, see Writer.
+//
+//	w, err := bigcsv.NewPartitionWriter[Order](8, "customer_id", func(i int) (io.WriteCloser, error) {
+//		return os.Create(fmt.Sprintf("orders-%02d.csv", i))
+//	})
+func NewPartitionWriter[T any](n int, column string, create func(partition int) (io.WriteCloser, error), opts ...WriterOption) (*PartitionWriter[T], error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of partitions: %d", n)
+	}
+	p := &PartitionWriter[T]{}
+	for i := 0; i < n; i++ {
+		out, err := create(i)
+		if err != nil {
+			return nil, errors.Join(fmt.Errorf("could not create partition %d: %w", i, err), p.Close())
+		}
+		p.closers = append(p.closers, out)
+		w, err := NewWriter[T](out, opts...)
+		if err != nil {
+			return nil, errors.Join(err, p.Close())
+		}
+		p.writers = append(p.writers, w)
+	}
+	if p.column = slices.Index(p.writers[0].Header(), column); p.column < 0 {
+		return nil, errors.Join(fmt.Errorf("no key column '%s'", column), p.Close())
+	}
+	return p, nil
+}
+
+// OnData writes the record to its partition.
+func (p *PartitionWriter[T]) OnData(data T) error {
+	row, err := p.writers[0].format(data)
+	if err != nil {
+		return err
+	}
+	if p.column >= len(row) {
+		return &ColumnError{Column: p.writers[0].header[p.column], Index: p.column, Err: ErrNoColumn}
+	}
+	h := fnv.New32a()
+	h.Write([]byte(row[p.column]))
+	return p.writers[h.Sum32()%uint32(len(p.writers))].Write(row)
+}
+
+// Close flushes and closes all partitions.
+func (p *PartitionWriter[T]) Close() error {
+	var errs []error
+	for _, w := range p.writers {
+		errs = append(errs, w.Flush())
+	}
+	for _, c := range p.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
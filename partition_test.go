@@ -0,0 +1,53 @@
+package bigcsv_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// nopWriteCloser adds a Close method to a strings.Builder.
+type nopWriteCloser struct {
+	*strings.Builder
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// TestPartitionWriter checks that rows with the same key share a partition.
+func TestPartitionWriter(t *testing.T) {
+	outputs := make([]*strings.Builder, 4)
+	w, err := bigcsv.NewPartitionWriter[[]string](4, "customer", func(i int) (io.WriteCloser, error) {
+		outputs[i] = &strings.Builder{}
+		return nopWriteCloser{outputs[i]}, nil
+	}, bigcsv.WithHeader("customer", "amount"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		if err = w.OnData([]string{fmt.Sprintf("c%d", i%10), fmt.Sprint(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rows := 0
+	for _, out := range outputs {
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		rows += len(lines) - 1
+		for _, line := range lines[1:] {
+			customer, _, _ := strings.Cut(line, ",")
+			for _, other := range outputs {
+				if other != out && strings.Contains(other.String(), customer+",") {
+					t.Fatalf("Customer %s is in several partitions", customer)
+				}
+			}
+		}
+	}
+	if rows != 100 {
+		t.Fatalf("Wrote %d rows, expected 100", rows)
+	}
+}
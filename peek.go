@@ -0,0 +1,83 @@
+package bigcsv
+
+import (
+	"errors"
+	"io"
+	"slices"
+)
+
+// peeked is a row read ahead by Peek, along with the number of rows read for
+// it.
+type peeked struct {
+	record
+	n int
+}
+
+// Peek returns the first n rows without consuming them: a subsequent Run,
+// Rows or Chan processes all rows including the peeked ones. This allows e.g.
+// showing a preview before processing. Rows consumed by options such as
+// WithHeaders are not included.
+//
+// Fewer rows are returned if the stream ends. A read error is returned along
+// with the rows before it, and is reported again when processing.
+func (p *Parser[T]) Peek(n int) ([][]string, error) {
+	prev, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer p.release(prev)
+	if p.closed {
+		return nil, ErrClosed
+	}
+	if err := p.prepare(); err != nil {
+		return nil, err
+	}
+	for len(p.peeked) < n && !p.peekedEOF {
+		rec, n := p.next()
+		if errors.Is(rec.err, io.EOF) {
+			p.peekedEOF = true
+			break
+		}
+		rec.row = slices.Clone(rec.row)
+		p.peeked = append(p.peeked, peeked{rec, n})
+		if rec.err != nil && !isParseError(rec.err) {
+			break
+		}
+	}
+
+	rows := make([][]string, 0, min(n, len(p.peeked)))
+	for _, pk := range p.peeked[:min(n, len(p.peeked))] {
+		if pk.err != nil {
+			return rows, pk.err
+		}
+		rows = append(rows, slices.Clone(pk.row))
+	}
+	return rows, nil
+}
+
+// read returns the next row, taking rows buffered by Peek first, and counts
+// the rows read.
+func (p *Parser[T]) read() record {
+	var rec record
+	var n int
+	switch {
+	case len(p.peeked) > 0:
+		rec, n = p.peeked[0].record, p.peeked[0].n
+		p.peeked = p.peeked[1:]
+	case p.peekedEOF:
+		return record{err: io.EOF}
+	default:
+		rec, n = p.next()
+	}
+	p.consumed += n
+	if rec.line == 0 { // not set by the RecordReader
+		rec.line = p.consumed
+	}
+	if !errors.Is(rec.err, io.EOF) {
+		p.repair(&rec)
+		p.bodyRows++
+		p.lastLine.Store(int64(rec.line))
+		p.lastOffset.Store(rec.offset)
+	}
+	return rec
+}
@@ -0,0 +1,41 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestPeek checks that peeked rows are still processed by Run.
+func TestPeek(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("int,name\n1,one\n2,two\n3,three\n", bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	preview, err := parser.Peek(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview) != 2 || preview[1][1] != "two" {
+		t.Fatalf("Unexpected preview: %v", preview)
+	}
+	if preview, _ = parser.Peek(10); len(preview) != 3 {
+		t.Fatalf("Expected all 3 rows when peeking beyond the end, got: %v", preview)
+	}
+	parser.Parse = ParseNumber
+	sum := 0
+	parser.OnData = func(n Number) error {
+		sum += n.Integer
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Fatalf("Sum of processed rows is %d, expected 6", sum)
+	}
+}
@@ -0,0 +1,529 @@
+package bigcsv
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PipelineSpec describes a complete pipeline from a source to a sink, so a new
+// feed can be onboarded by writing a configuration instead of a program. It is
+// usually decoded from JSON by LoadPipeline, or from YAML by LoadPipelineYAML
+// using the same field names:
+//
+//	{
+//		"source":  {"path": "https://partner.example/feed.csv.gz"},
+//		"dialect": {"delimiter": ";", "skip_rows": 1},
+//		"preprocessing": {"amount": {"strip_currency": true, "trim": true}},
+//		"columns": [
+//			{"name": "id", "from": "Kundennummer", "type": "integer", "required": true},
+//			{"name": "amount", "from": "Betrag", "type": "number", "format": "de", "min": 0},
+//			{"name": "country", "from": "Land", "values": ["DE", "AT", "CH"]},
+//			{"name": "key", "expr": "Land + '-' + Kundennummer"}
+//		],
+//		"filter": "Land != 'XX'",
+//		"unique": ["id"],
+//		"sink": {"path": "out/feed.jsonl", "format": "jsonl"}
+//	}
+type PipelineSpec struct {
+	Source        SourceSpec    `json:"source" yaml:"source"`
+	Dialect       DialectSpec   `json:"dialect,omitempty" yaml:"dialect,omitempty"`
+	Preprocessing Preprocessing `json:"preprocessing,omitempty" yaml:"preprocessing,omitempty"`
+
+	// Columns map the input columns to the output, in output order.
+	Columns []ColumnSpec `json:"columns" yaml:"columns"`
+
+	// Filter is an Expr over the input columns; rows for which it is false
+	// are left out, without counting as errors.
+	Filter string `json:"filter,omitempty" yaml:"filter,omitempty"`
+
+	// Unique are input columns whose combined values must be unique, see
+	// WithUnique.
+	Unique []string `json:"unique,omitempty" yaml:"unique,omitempty"`
+
+	Sink SinkSpec `json:"sink" yaml:"sink"`
+
+	// Workers is the number of workers, 1 if not set.
+	Workers int `json:"workers,omitempty" yaml:"workers,omitempty"`
+
+	// StopOnError stops at the first row error, see StopOnError.
+	StopOnError bool `json:"stop_on_error,omitempty" yaml:"stop_on_error,omitempty"`
+}
+
+// SourceSpec describes the input of a pipeline.
+type SourceSpec struct {
+	// Path is a file, decompressed if ending in .gz, or an http(s) URL.
+	Path string `json:"path" yaml:"path"`
+}
+
+// DialectSpec describes the format of the input. The input always has a
+// header row, after the skipped rows.
+type DialectSpec struct {
+	// Delimiter is the field delimiter, "," if empty, "tab" for tabs.
+	Delimiter string `json:"delimiter,omitempty" yaml:"delimiter,omitempty"`
+
+	SkipRows        int    `json:"skip_rows,omitempty" yaml:"skip_rows,omitempty"`
+	CommentPrefix   string `json:"comment_prefix,omitempty" yaml:"comment_prefix,omitempty"`
+	SkipBlankLines  bool   `json:"skip_blank_lines,omitempty" yaml:"skip_blank_lines,omitempty"`
+	FieldsPerRecord int    `json:"fields_per_record,omitempty" yaml:"fields_per_record,omitempty"`
+}
+
+// ColumnSpec maps an input column to an output column, converting and
+// validating its values. Empty values are null in the output unless Required.
+type ColumnSpec struct {
+	// Name is the output name, From the input header, Name if empty.
+	Name string `json:"name" yaml:"name"`
+	From string `json:"from,omitempty" yaml:"from,omitempty"`
+
+	// Expr computes the value from the input columns instead, see Expr.
+	Expr string `json:"expr,omitempty" yaml:"expr,omitempty"`
+
+	// Type is "string" (the default), "integer", "number", "boolean" or
+	// "date". Format names the number format, "en" by default, or the date
+	// format, "iso" by default, see NumberFormat and DateFormat.
+	Type   string `json:"type,omitempty" yaml:"type,omitempty"`
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Required rejects empty values.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty"`
+
+	// Pattern is a regular expression the whole value must match.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+
+	// Values are the allowed values, if not empty.
+	Values []string `json:"values,omitempty" yaml:"values,omitempty"`
+
+	// Min and Max bound numbers.
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+}
+
+// SinkSpec describes the output of a pipeline.
+type SinkSpec struct {
+	// Path is the output file, replaced if it exists.
+	Path string `json:"path" yaml:"path"`
+
+	// Format is "csv" (the default) or "jsonl", a JSON object per line.
+	Format string `json:"format,omitempty" yaml:"format,omitempty"`
+
+	// Delimiter is the field delimiter of CSV, "," if empty.
+	Delimiter string `json:"delimiter,omitempty" yaml:"delimiter,omitempty"`
+}
+
+// Pipeline runs a PipelineSpec.
+type Pipeline struct {
+	// OnError receives the row errors, e.g. to log rejected rows. Errors are
+	// counted in the Stats in any case.
+	OnError func(error)
+
+	spec    PipelineSpec
+	opts    []Option
+	columns []pipelineColumn
+	comma   rune
+}
+
+// pipelineColumn is a compiled ColumnSpec.
+type pipelineColumn struct {
+	name    string
+	from    string
+	expr    string
+	convert func(string) (any, error)
+}
+
+// LoadPipeline decodes a PipelineSpec from JSON and creates its Pipeline.
+// Unknown fields are rejected, to catch misspelled settings.
+func LoadPipeline(r io.Reader) (*Pipeline, error) {
+	var spec PipelineSpec
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	return NewPipeline(spec)
+}
+
+// NewPipeline validates the spec and creates its Pipeline. Missing input
+// columns are only detected by Run.
+func NewPipeline(spec PipelineSpec) (*Pipeline, error) {
+	pl := &Pipeline{spec: spec}
+	if err := pl.compile(); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	return pl, nil
+}
+
+// compile checks the spec, translating it to options and column converters.
+func (pl *Pipeline) compile() error {
+	spec := pl.spec
+	if spec.Source.Path == "" {
+		return errors.New("no source path")
+	}
+	if spec.Sink.Path == "" {
+		return errors.New("no sink path")
+	}
+	if spec.Sink.Format != "" && spec.Sink.Format != "csv" && spec.Sink.Format != "jsonl" {
+		return fmt.Errorf("invalid sink format %q", spec.Sink.Format)
+	}
+	if len(spec.Columns) == 0 {
+		return errors.New("no columns")
+	}
+
+	comma, err := pipelineDelimiter(spec.Dialect.Delimiter)
+	if err != nil {
+		return err
+	}
+	if pl.comma, err = pipelineDelimiter(spec.Sink.Delimiter); err != nil {
+		return err
+	}
+	pl.opts = []Option{WithHeaders(), WithComma(comma), WithSkipRows(spec.Dialect.SkipRows)}
+	if spec.Dialect.CommentPrefix != "" {
+		pl.opts = append(pl.opts, WithCommentPrefix(spec.Dialect.CommentPrefix))
+	}
+	if spec.Dialect.SkipBlankLines {
+		pl.opts = append(pl.opts, WithSkipBlankLines())
+	}
+	if spec.Dialect.FieldsPerRecord != 0 {
+		pl.opts = append(pl.opts, WithFieldsPerRecord(spec.Dialect.FieldsPerRecord))
+	}
+	if spec.Preprocessing != nil {
+		pl.opts = append(pl.opts, WithPreprocessing(spec.Preprocessing))
+	}
+	if len(spec.Unique) > 0 {
+		pl.opts = append(pl.opts, WithUnique(spec.Unique...))
+	}
+	if spec.Workers != 0 {
+		pl.opts = append(pl.opts, WithWorkers(spec.Workers))
+	}
+	if spec.StopOnError {
+		pl.opts = append(pl.opts, WithErrorPolicy(StopOnError))
+	}
+	// Check the options now rather than when running.
+	if _, err = newConfig(pl.opts); err != nil {
+		return err
+	}
+
+	if err = checkExpr(spec.Filter); err != nil {
+		return fmt.Errorf("filter: %w", err)
+	}
+	names := map[string]bool{}
+	for _, col := range spec.Columns {
+		if col.Name == "" {
+			return errors.New("column without name")
+		}
+		if names[col.Name] {
+			return fmt.Errorf("duplicate column '%s'", col.Name)
+		}
+		names[col.Name] = true
+		if col.Expr != "" && col.From != "" {
+			return fmt.Errorf("column '%s': from and expr are exclusive", col.Name)
+		}
+		if err = checkExpr(col.Expr); err != nil {
+			return fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+		convert, err := col.compile()
+		if err != nil {
+			return fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+		from := col.From
+		if from == "" {
+			from = col.Name
+		}
+		pl.columns = append(pl.columns, pipelineColumn{name: col.Name, from: from, expr: col.Expr, convert: convert})
+	}
+	return nil
+}
+
+// checkExpr checks the syntax of an expression, if not empty. Its columns are
+// only resolved by Run.
+func checkExpr(src string) error {
+	if src == "" {
+		return nil
+	}
+	_, err := CompileExpr(src, func(string) (int, error) { return 0, nil })
+	return err
+}
+
+// pipelineDelimiter parses a delimiter of a spec.
+func pipelineDelimiter(s string) (rune, error) {
+	switch s {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 {
+		return 0, fmt.Errorf("invalid delimiter %q", s)
+	}
+	return r[0], nil
+}
+
+// compile returns a function converting and validating a value of the column.
+func (col ColumnSpec) compile() (func(string) (any, error), error) {
+	var convert func(string) (any, error)
+	switch col.Type {
+	case "", "string":
+		convert = func(s string) (any, error) { return s, nil }
+	case "integer", "number":
+		name := cmp.Or(col.Format, "en")
+		f, ok := numberFormats[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown number format %q", name)
+		}
+		if col.Type == "integer" {
+			convert = func(s string) (any, error) { return f.ParseInt(s) }
+		} else {
+			convert = func(s string) (any, error) { return f.ParseFloat(s) }
+		}
+	case "boolean":
+		convert = func(s string) (any, error) { return strconv.ParseBool(s) }
+	case "date":
+		name := cmp.Or(col.Format, "iso")
+		f, ok := dateFormats[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown date format %q", name)
+		}
+		convert = func(s string) (any, error) {
+			t, _, err := f.ParseTime(s)
+			return t, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid type %q", col.Type)
+	}
+	if (col.Min != nil || col.Max != nil) && col.Type != "integer" && col.Type != "number" {
+		return nil, errors.New("min and max require a numeric type")
+	}
+
+	var pattern *regexp.Regexp
+	if col.Pattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(`^(?:` + col.Pattern + `)$`); err != nil {
+			return nil, err
+		}
+	}
+	return func(s string) (any, error) {
+		if s == "" {
+			if col.Required {
+				return nil, errors.New("value required")
+			}
+			return nil, nil
+		}
+		if pattern != nil && !pattern.MatchString(s) {
+			return nil, fmt.Errorf("value %q does not match %s", s, col.Pattern)
+		}
+		if len(col.Values) > 0 && !slices.Contains(col.Values, s) {
+			return nil, fmt.Errorf("value %q not allowed", s)
+		}
+		v, err := convert(s)
+		if err != nil {
+			return nil, err
+		}
+		var x float64
+		switch n := v.(type) {
+		case int64:
+			x = float64(n)
+		case float64:
+			x = n
+		default:
+			return v, nil
+		}
+		if col.Min != nil && x < *col.Min || col.Max != nil && x > *col.Max {
+			return nil, fmt.Errorf("value %s out of range", s)
+		}
+		return v, nil
+	}, nil
+}
+
+// Run processes the source, writing the valid rows to the sink. Rows failing
+// validation are passed to OnError and left out. The error is that of
+// Parser.Run, or of writing the sink.
+func (pl *Pipeline) Run(ctx context.Context) (Stats, error) {
+	var stream Stream = FileStream(pl.spec.Source.Path)
+	if strings.HasPrefix(pl.spec.Source.Path, "http://") || strings.HasPrefix(pl.spec.Source.Path, "https://") {
+		stream = HTTPStream(pl.spec.Source.Path)
+	}
+	p, err := New[[]any](stream, pl.opts...)
+	if err != nil {
+		return Stats{}, err
+	}
+	defer p.Close()
+
+	// Read the headers to bind the columns.
+	if _, err = p.Peek(0); err != nil {
+		return Stats{}, err
+	}
+	resolve := func(name string) (int, error) {
+		ix, ok := p.ColumnIndex(name)
+		if !ok {
+			return 0, &ColumnError{Column: name, Index: -1, Err: ErrNoColumn}
+		}
+		return ix, nil
+	}
+	// Computed columns are named by their output name in errors.
+	fields := make([]func(row []string) (string, error), len(pl.columns))
+	columns := make([]ColumnError, len(pl.columns))
+	for i, col := range pl.columns {
+		if col.expr != "" {
+			expr, err := CompileExpr(col.expr, resolve)
+			if err != nil {
+				return Stats{}, err
+			}
+			fields[i] = expr.EvalString
+			columns[i] = ColumnError{Column: col.name, Index: -1}
+			continue
+		}
+		ix, err := resolve(col.from)
+		if err != nil {
+			return Stats{}, err
+		}
+		fields[i] = func(row []string) (string, error) {
+			if ix < len(row) {
+				return row[ix], nil
+			}
+			return "", nil
+		}
+		columns[i] = ColumnError{Column: col.from, Index: ix}
+	}
+	keep := func([]string) (bool, error) { return true, nil }
+	if pl.spec.Filter != "" {
+		filter, err := CompileExpr(pl.spec.Filter, resolve)
+		if err != nil {
+			return Stats{}, err
+		}
+		keep = filter.Bool
+	}
+	p.Parse = func(row []string) ([]any, error) {
+		if ok, err := keep(row); !ok || err != nil {
+			return nil, err
+		}
+		values := make([]any, len(pl.columns))
+		for i, col := range pl.columns {
+			field, err := fields[i](row)
+			if err == nil {
+				values[i], err = col.convert(field)
+			}
+			if err != nil {
+				colErr := columns[i]
+				colErr.Err = err
+				return nil, &colErr
+			}
+		}
+		return values, nil
+	}
+	p.OnError = pl.OnError
+	if p.OnError == nil {
+		p.OnError = func(error) {}
+	}
+
+	f, err := os.Create(pl.spec.Sink.Path)
+	if err != nil {
+		return Stats{}, fmt.Errorf("could not create sink: %w", err)
+	}
+	sink := pl.sink(f)
+	p.OnData = sink.write
+	err = p.Run(ctx, 0)
+	return p.Stats(), errors.Join(err, sink.close(), f.Close())
+}
+
+// pipelineSink writes the output of a Pipeline.
+type pipelineSink struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	format func(values []any) error
+	err    error
+}
+
+// sink creates the sink writing to w, starting with the header row of CSV.
+func (pl *Pipeline) sink(w io.Writer) *pipelineSink {
+	s := &pipelineSink{w: bufio.NewWriter(w)}
+	names := make([]string, len(pl.columns))
+	for i, col := range pl.columns {
+		names[i] = col.name
+	}
+	if pl.spec.Sink.Format == "jsonl" {
+		s.format = func(values []any) error {
+			s.w.WriteByte('{')
+			for i, v := range values {
+				if i > 0 {
+					s.w.WriteByte(',')
+				}
+				key, _ := json.Marshal(names[i])
+				value, err := json.Marshal(v)
+				if err != nil {
+					return err
+				}
+				s.w.Write(key)
+				s.w.WriteByte(':')
+				s.w.Write(value)
+			}
+			_, err := s.w.WriteString("}\n")
+			return err
+		}
+		return s
+	}
+
+	cw := csv.NewWriter(s.w)
+	cw.Comma = pl.comma
+	s.err = cw.Write(names)
+	s.format = func(values []any) error {
+		row := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				row[i] = v
+			case float64:
+				row[i] = strconv.FormatFloat(v, 'f', -1, 64)
+			case time.Time:
+				row[i] = v.Format(time.RFC3339)
+			default:
+				row[i] = fmt.Sprint(v)
+			}
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return s
+}
+
+// write writes a row, failing once writing failed before. Rows left out by
+// the filter are nil.
+func (s *pipelineSink) write(values []any) error {
+	if values == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = s.format(values)
+	}
+	return s.err
+}
+
+// close flushes the output.
+func (s *pipelineSink) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return fmt.Errorf("could not write sink: %w", s.err)
+	}
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("could not write sink: %w", err)
+	}
+	return nil
+}
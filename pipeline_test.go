@@ -0,0 +1,161 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestPipeline runs a pipeline loaded from JSON, checking the output and the
+// rejected rows.
+func TestPipeline(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "feed.csv"), filepath.Join(dir, "out.jsonl")
+	input := "Partner feed\nKundennummer;Betrag;Land;Datum\n" +
+		"1;€ 1.234,50;de;2024-03-01\n" +
+		"2;-5,00;DE;2024-03-02\n" + // below min
+		"3;7,25;FR;2024-03-03\n" + // not allowed
+		";1,00;AT;2024-03-04\n" + // id required
+		"4;12;ch;\n" +
+		"5;1;XX;\n" // filtered
+	if err := os.WriteFile(in, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := fmt.Sprintf(`{
+		"source": {"path": %q},
+		"dialect": {"delimiter": ";", "skip_rows": 1},
+		"preprocessing": {"Betrag": {"strip_currency": true, "trim": true}, "Land": {"case": "upper"}},
+		"columns": [
+			{"name": "id", "from": "Kundennummer", "type": "integer", "required": true},
+			{"name": "amount", "from": "Betrag", "type": "number", "format": "de", "min": 0},
+			{"name": "country", "from": "Land", "values": ["DE", "AT", "CH"]},
+			{"name": "date", "from": "Datum", "type": "date"},
+			{"name": "label", "expr": "Kundennummer + '-' + lower(Land)"}
+		],
+		"filter": "Land != 'XX'",
+		"sink": {"path": %q, "format": "jsonl"}
+	}`, in, out)
+	pl, err := bigcsv.LoadPipeline(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected []string
+	pl.OnError = func(err error) {
+		rejected = append(rejected, err.Error())
+	}
+	stats, err := pl.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != 6 || stats.ParseErrors != 3 || len(rejected) != 3 {
+		t.Fatalf("Unexpected stats %+v, rejected: %q", stats, rejected)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"id":1,"amount":1234.5,"country":"DE","date":"2024-03-01T00:00:00Z","label":"1-de"}` + "\n" +
+		`{"id":4,"amount":12,"country":"CH","date":null,"label":"4-ch"}` + "\n"
+	if string(b) != expected {
+		t.Fatalf("Got output:\n%s\nexpected:\n%s", b, expected)
+	}
+}
+
+// TestPipelineInvalid checks that invalid specs are rejected when loading.
+func TestPipelineInvalid(t *testing.T) {
+	specs := []string{
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv"}}`,
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv"}, "columns": [{"name": "a", "type": "float"}]}`,
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv"}, "columns": [{"name": "a", "min": 1}]}`,
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv", "format": "xml"}, "columns": [{"name": "a"}]}`,
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv"}, "columns": [{"name": "a"}], "workers": -1}`,
+		`{"source": {"path": "in.csv"}, "sink": {"path": "out.csv"}, "colums": [{"name": "a"}]}`,
+	}
+	for _, spec := range specs {
+		if _, err := bigcsv.LoadPipeline(strings.NewReader(spec)); err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+}
+
+// TestPipelineYAML runs a pipeline loaded from YAML.
+func TestPipelineYAML(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "feed.csv"), filepath.Join(dir, "out.csv")
+	input := "Kundennummer;Betrag;Land\n1;€ 1.234,50;de\n2;-5,00;DE\n3;7,25;at # 1\n"
+	if err := os.WriteFile(in, []byte(input), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	spec := fmt.Sprintf(`---
+# Partner feed
+source:
+  path: %q
+dialect: {delimiter: ";"}
+preprocessing:
+  Betrag:
+    strip_currency: true
+    trim: true
+  Land:
+    case: upper
+    replace:
+    - pattern: ' # \d+$'
+      with: ""
+columns:
+- name: id
+  from: Kundennummer
+  type: integer
+  required: true
+- {name: amount, from: Betrag, type: number, format: de, min: 0}
+- name: country
+  from: Land
+  values: [DE, AT, "CH"]
+sink:
+  path: %s # CSV by default
+workers: 1
+`, in, out)
+	pl, err := bigcsv.LoadPipelineYAML(strings.NewReader(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rejected []string
+	pl.OnError = func(err error) {
+		rejected = append(rejected, err.Error())
+	}
+	stats, err := pl.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Rows != 3 || len(rejected) != 1 {
+		t.Fatalf("Unexpected stats %+v, rejected: %q", stats, rejected)
+	}
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "id,amount,country\n1,1234.5,DE\n3,7.25,AT\n"; string(b) != expected {
+		t.Fatalf("Got output:\n%s\nexpected:\n%s", b, expected)
+	}
+}
+
+// TestPipelineYAMLInvalid checks that invalid YAML specs are rejected.
+func TestPipelineYAMLInvalid(t *testing.T) {
+	specs := []string{
+		"source: {path: in.csv}\nsink: {path: out.csv}\ncolums: [{name: a}]\n",
+		"source: {path: in.csv}\nsink: {path: out.csv}\ncolumns: [{name: a}]\nworkers: many\n",
+		"source: {path: in.csv}\nsink: {path: out.csv}\ncolumns: [{name: a}]\nsource: {path: x.csv}\n",
+		"source: {path: in.csv\nsink: {path: out.csv}\ncolumns: [{name: a}]\n",
+		"source: &in {path: in.csv}\nsink: {path: out.csv}\ncolumns: [{name: a}]\n",
+		"source:\n  path: in.csv\n   x: 1\nsink: {path: out.csv}\ncolumns: [{name: a}]\n",
+		"source: {path: in.csv}\nsink: {path: out.csv}\ncolumns:\n- name: a\n  filter: |\n    a > 1\n",
+	}
+	for _, spec := range specs {
+		if _, err := bigcsv.LoadPipelineYAML(strings.NewReader(spec)); err == nil {
+			t.Errorf("Expected an error for %s", spec)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package bigcsv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// RowError reports an error concerning a single row, passed to OnError or
+// yielded by Rows. It wraps both the stage, e.g. ErrParse, and the underlying
+// error, so both can be checked with errors.Is.
+type RowError struct {
+	// Stage is ErrRead, ErrOnRow, ErrParse or ErrOnData.
+	Stage error
+
+	// Line is the number of the row in the stream, counting from 1 and
+	// including rows consumed by options such as WithHeaders.
+	Line int
+
+	// StartLine and EndLine are the physical lines the row spans, which
+	// differ from Line if fields contain line breaks. They are 0 if the
+	// RecordReader is not a csv.Reader. Lines dropped by WithCommentPrefix
+	// are not counted.
+	StartLine int
+	EndLine   int
+
+	// Offset is the byte offset of the start of the row in the (decompressed)
+	// stream, or -1 if the RecordReader does not provide offsets. It can be
+	// used to inspect or resume a failed import at the exact location, e.g.
+	// with dd or tail -c.
+	Offset int64
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *RowError) Error() string {
+	var pos []string
+	if e.Offset >= 0 {
+		pos = append(pos, fmt.Sprintf("offset %d", e.Offset))
+	}
+	if e.EndLine > e.StartLine {
+		pos = append(pos, fmt.Sprintf("lines %d-%d", e.StartLine, e.EndLine))
+	}
+	if len(pos) == 0 {
+		return fmt.Sprintf("%v: line %d: %v", e.Stage, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%v: line %d (%s): %v", e.Stage, e.Line, strings.Join(pos, ", "), e.Err)
+}
+
+func (e *RowError) Unwrap() []error {
+	return []error{e.Stage, e.Err}
+}
+
+// stageOf returns the stage of a *RowError, defaulting to ErrRead.
+func stageOf(err error) error {
+	var rowErr *RowError
+	if errors.As(err, &rowErr) {
+		return rowErr.Stage
+	}
+	return ErrRead
+}
+
+// record is a row read from the stream, along with its position and read
+// error.
+type record struct {
+	row    []string
+	line   int
+	offset int64
+	err    error
+
+	// startLine and endLine are the physical lines of the row, if known.
+	startLine, endLine int
+
+	// sample records the timings of the row if sampled, see WithSampling.
+	sample *RowSample
+}
+
+// error wraps err as a *RowError at the position of the record.
+func (rec record) error(stage, err error) error {
+	return &RowError{
+		Stage:     stage,
+		Line:      rec.line,
+		Offset:    rec.offset,
+		StartLine: rec.startLine,
+		EndLine:   rec.endLine,
+		Err:       err,
+	}
+}
+
+// ErrRecordLines is wrapped by the read error of a row spanning more lines
+// than allowed by WithMaxRecordLines.
+var ErrRecordLines = errors.New("record spans too many lines")
+
+// WithMaxRecordLines limits the number of physical lines a row may span due to
+// line breaks within quoted fields. A quoted field still open after n lines is
+// ended while reading, so a stray quote cannot turn the rest of the stream into
+// a single field held in memory: the row is a read error wrapping
+// ErrRecordLines, whose EndLine is the line it was cut at, and reading
+// continues with that line. The n lines of the row are lost. It has no effect
+// with WithRecordReader.
+func WithMaxRecordLines(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of lines per record: %d", n)
+		}
+		cfg.maxLines = n
+		return nil
+	}
+}
+
+// physicalLines sets the physical lines spanned by the record read last by the
+// csv.Reader, checking them against WithMaxRecordLines.
+func (p *Parser[T]) physicalLines(rec *record) {
+	if p.Reader == nil {
+		return
+	}
+	var parseErr *csv.ParseError
+	switch {
+	case errors.As(rec.err, &parseErr):
+		rec.startLine, rec.endLine = parseErr.StartLine, parseErr.Line
+		return
+	case rec.err != nil || len(rec.row) == 0:
+		return
+	}
+	last := len(rec.row) - 1
+	rec.startLine, _ = p.Reader.FieldPos(0)
+	rec.endLine, _ = p.Reader.FieldPos(last)
+	rec.endLine += strings.Count(rec.row[last], "\n")
+	if p.limiter != nil && p.limiter.cut(rec.startLine) {
+		rec.endLine = rec.startLine + p.cfg.maxLines
+		rec.err = fmt.Errorf("%w: %w: more than %d lines", ErrMalformedRecord, ErrRecordLines, p.cfg.maxLines)
+	}
+}
+
+// lineLimiter passes the stream on to a csv.Reader, following its quoting to
+// end quoted fields which would make a record span more than max lines. It
+// inserts the closing quote before the line break, which the Parser removes
+// from the offsets.
+type lineLimiter struct {
+	r      io.Reader
+	reader *csv.Reader // for its settings, which may change after New
+	max    int
+
+	comma, comment []byte
+
+	line, start, lines int  // physical line, first line and lines of the record
+	lineStart          bool // the next byte starts a line
+	fieldStart         bool // the next byte starts a field
+	inQuote            bool // within a quoted field
+	closing            bool // a quote within a quoted field was read
+	ignore             bool // the rest of the line is skipped by the csv.Reader
+	matched            int  // bytes of Comment matched at the start of the line
+	recent             []byte
+
+	cuts     []int   // first lines of records cut, not yet read
+	inserted []int64 // positions of the inserted quotes, not yet passed
+	passed   int64   // number of inserted quotes before the last offset
+	written  int64
+
+	buf, out, pending []byte
+	err               error
+}
+
+func newLineLimiter(r io.Reader, max int) *lineLimiter {
+	return &lineLimiter{
+		r:          r,
+		max:        max,
+		line:       1,
+		start:      1,
+		lines:      1,
+		lineStart:  true,
+		fieldStart: true,
+		buf:        make([]byte, 4096),
+	}
+}
+
+func (l *lineLimiter) Read(b []byte) (int, error) {
+	for len(l.pending) == 0 {
+		if l.err != nil {
+			return 0, l.err
+		}
+		n, err := l.r.Read(l.buf)
+		l.err = err
+		if l.reader != nil {
+			l.comma = utf8.AppendRune(l.comma[:0], l.reader.Comma)
+			l.comment = l.comment[:0]
+			if l.reader.Comment != 0 {
+				l.comment = utf8.AppendRune(l.comment, l.reader.Comment)
+			}
+		}
+		l.out = l.out[:0]
+		for _, c := range l.buf[:n] {
+			l.step(c)
+		}
+		l.pending = l.out
+	}
+	n := copy(b, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+// step passes on one byte, advancing the state like the csv.Reader.
+func (l *lineLimiter) step(c byte) {
+	if l.inQuote {
+		if !l.closing {
+			switch c {
+			case '"':
+				l.closing = true
+			case '\n':
+				if l.lines++; l.lines > l.max {
+					l.inserted = append(l.inserted, l.written)
+					l.emit('"')
+					l.cuts = append(l.cuts, l.start)
+					l.inQuote = false
+					l.emit(c)
+					l.newLine()
+					return
+				}
+				l.line++
+			}
+			l.emit(c)
+			return
+		}
+		l.closing = false
+		switch {
+		case c == '"': // escaped quote
+			l.emit(c)
+			return
+		case l.reader != nil && l.reader.LazyQuotes && c != '\r' && c != '\n' && !l.startsComma(c):
+			l.emit(c) // the quote was kept as part of the field
+			return
+		case c != '\r' && c != '\n' && !l.startsComma(c):
+			l.ignore = true // the csv.Reader fails the rest of the line
+		}
+		l.inQuote = false
+	}
+	l.emit(c)
+	l.unquoted(c)
+}
+
+// unquoted advances the state by a byte outside of quoted fields.
+func (l *lineLimiter) unquoted(c byte) {
+	if c == '\n' {
+		l.newLine()
+		return
+	}
+	if l.ignore {
+		return
+	}
+	if l.lineStart && len(l.comment) > 0 {
+		if c == l.comment[l.matched] {
+			if l.matched++; l.matched == len(l.comment) {
+				l.ignore = true
+			}
+			return
+		}
+		if l.matched > 0 {
+			l.fieldStart = false
+		}
+	}
+	l.lineStart = false
+	l.recent = append(l.recent, c)
+	if len(l.recent) > len(l.comma) {
+		l.recent = l.recent[1:]
+	}
+	switch {
+	case c == '"' && l.fieldStart:
+		l.inQuote = true
+	case bytes.Equal(l.recent, l.comma):
+		l.fieldStart = true
+	case c == '\r':
+	case (c == ' ' || c == '\t') && l.fieldStart && l.reader != nil && l.reader.TrimLeadingSpace:
+	default:
+		l.fieldStart = false
+	}
+}
+
+// startsComma tells whether a byte may start the delimiter.
+func (l *lineLimiter) startsComma(c byte) bool {
+	return len(l.comma) > 0 && c == l.comma[0]
+}
+
+// newLine starts a record at the next line.
+func (l *lineLimiter) newLine() {
+	l.line++
+	l.start, l.lines = l.line, 1
+	l.lineStart, l.fieldStart, l.ignore = true, true, false
+	l.matched = 0
+	l.recent = l.recent[:0]
+}
+
+func (l *lineLimiter) emit(c byte) {
+	l.out = append(l.out, c)
+	l.written++
+}
+
+// cut tells whether the record starting at line was cut, forgetting cuts of
+// records before it.
+func (l *lineLimiter) cut(line int) bool {
+	for len(l.cuts) > 0 && l.cuts[0] < line {
+		l.cuts = l.cuts[1:]
+	}
+	if len(l.cuts) > 0 && l.cuts[0] == line {
+		l.cuts = l.cuts[1:]
+		return true
+	}
+	return false
+}
+
+// offset removes the inserted quotes from an offset of the csv.Reader.
+// Offsets must not decrease.
+func (l *lineLimiter) offset(o int64) int64 {
+	for len(l.inserted) > 0 && l.inserted[0] < o {
+		l.inserted = l.inserted[1:]
+		l.passed++
+	}
+	return o - l.passed
+}
+
+// Position returns the line number and byte offset of the row read last, see
+// RowError for their meaning. When using multiple workers, rows read last may
+// still be in progress. It is safe to call during processing.
+func (p *Parser[T]) Position() (line int, offset int64) {
+	return int(p.lastLine.Load()), p.lastOffset.Load()
+}
+
+// inputOffset returns the current byte offset of the RecordReader, or -1 if it
+// does not provide one like csv.Reader.InputOffset.
+func (p *Parser[T]) inputOffset() int64 {
+	if o, ok := p.records.(interface{ InputOffset() int64 }); ok {
+		if p.limiter != nil {
+			return p.offsetBase + p.limiter.offset(o.InputOffset())
+		}
+		return p.offsetBase + o.InputOffset()
+	}
+	return -1
+}
@@ -0,0 +1,79 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRowErrorOffset checks that row errors carry the line and byte offset of
+// the failing row.
+func TestRowErrorOffset(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("int,name\n1,one\nx,two\n", bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	var rowErr *bigcsv.RowError
+	parser.OnError = func(err error) {
+		if !errors.As(err, &rowErr) {
+			t.Errorf("Expected RowError, got: %v", err)
+		}
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if rowErr == nil || rowErr.Line != 3 || rowErr.Offset != 15 || !errors.Is(rowErr, bigcsv.ErrParse) {
+		t.Fatalf("Unexpected row error: %v", rowErr)
+	}
+}
+
+// TestPosition checks the position of the row read last.
+func TestPosition(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n22,two\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	for range parser.Rows(context.Background()) {
+	}
+	if line, offset := parser.Position(); line != 2 || offset != 6 {
+		t.Fatalf("Position is line %d, offset %d, expected line 2, offset 6", line, offset)
+	}
+}
+
+// TestMaxRecordLines checks the physical lines of rows and limiting them while
+// reading.
+func TestMaxRecordLines(t *testing.T) {
+	input := "id,note\n1,\"two\nlines\"\n2,\"stray quote\n3,x\n4,y\n5,z\n6,ok\n"
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(input)), bigcsv.WithMaxRecordLines(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	var errs []*bigcsv.RowError
+	for row, err := range parser.Rows(context.Background()) {
+		var rowErr *bigcsv.RowError
+		if errors.As(err, &rowErr) {
+			errs = append(errs, rowErr)
+			continue
+		}
+		ids = append(ids, row[0])
+	}
+	if strings.Join(ids, ",") != "id,1,5,6" || len(errs) != 1 {
+		t.Fatalf("Unexpected rows %q and errors %v", ids, errs)
+	}
+	if e := errs[0]; e.Line != 3 || e.StartLine != 4 || e.EndLine != 7 || !errors.Is(e, bigcsv.ErrRecordLines) {
+		t.Errorf("Unexpected error: %+v", e)
+	}
+	if want := "read error: line 3 (offset 22, lines 4-7): malformed record: record spans too many lines: more than 3 lines"; errs[0].Error() != want {
+		t.Errorf("Expected %q, got %q", want, errs[0].Error())
+	}
+	if line, offset := parser.Position(); line != 5 || offset != int64(strings.Index(input, "6,ok")) {
+		t.Errorf("Position is line %d, offset %d after the cut row", line, offset)
+	}
+}
@@ -0,0 +1,162 @@
+package bigcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Preprocessing holds cleanup rules by column name, see WithPreprocessing. It
+// can be decoded from JSON with ParsePreprocessing, so the rules can be
+// adjusted without code changes, and from YAML by a YAML package using the
+// same field names:
+//
+//	{
+//		"country": {"trim": true, "case": "upper"},
+//		"price":   {"strip_currency": true, "trim": true},
+//		"phone":   {"replace": [{"pattern": "[^0-9+]", "with": ""}]}
+//	}
+type Preprocessing map[string]ColumnRules
+
+// ColumnRules are the cleanup rules of a column. They are applied in the order
+// of the fields, so that trimming also removes the spaces left by the other
+// rules.
+type ColumnRules struct {
+	// StripCurrency removes currency symbols such as $ and €.
+	StripCurrency bool `json:"strip_currency,omitempty" yaml:"strip_currency,omitempty"`
+
+	// Replace replaces all matches of regular expressions, in order.
+	Replace []Replacement `json:"replace,omitempty" yaml:"replace,omitempty"`
+
+	// Case converts the value to "upper" or "lower" case.
+	Case string `json:"case,omitempty" yaml:"case,omitempty"`
+
+	// Trim removes leading and trailing whitespace.
+	Trim bool `json:"trim,omitempty" yaml:"trim,omitempty"`
+}
+
+// Replacement replaces the matches of Pattern, in the syntax of package
+// regexp, with With, which may refer to groups as in Regexp.ReplaceAllString.
+type Replacement struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	With    string `json:"with" yaml:"with"`
+}
+
+// ParsePreprocessing decodes Preprocessing from JSON. Unknown fields are
+// rejected, to catch misspelled rules.
+func ParsePreprocessing(r io.Reader) (Preprocessing, error) {
+	var spec Preprocessing
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("invalid preprocessing: %w", err)
+	}
+	return spec, nil
+}
+
+// WithPreprocessing cleans the values of the columns according to the rules
+// before references are checked and OnRow and Parse are called. It requires
+// WithHeaders. Options for the same column replace each other.
+func WithPreprocessing(spec Preprocessing) Option {
+	return func(cfg *config) error {
+		for column, rules := range spec {
+			clean, err := rules.compile()
+			if err != nil {
+				return fmt.Errorf("invalid preprocessing of column '%s': %w", column, err)
+			}
+			if cfg.preprocess == nil {
+				cfg.preprocess = map[string]func(string) string{}
+			}
+			cfg.preprocess[column] = clean
+		}
+		return nil
+	}
+}
+
+// compile returns a function applying the rules.
+func (rules ColumnRules) compile() (func(string) string, error) {
+	var steps []func(string) string
+	if rules.StripCurrency {
+		steps = append(steps, stripCurrency)
+	}
+	for _, rep := range rules.Replace {
+		re, err := regexp.Compile(rep.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		with := rep.With
+		steps = append(steps, func(s string) string {
+			return re.ReplaceAllString(s, with)
+		})
+	}
+	switch rules.Case {
+	case "":
+	case "upper":
+		steps = append(steps, strings.ToUpper)
+	case "lower":
+		steps = append(steps, strings.ToLower)
+	default:
+		return nil, fmt.Errorf("invalid case %q", rules.Case)
+	}
+	if rules.Trim {
+		steps = append(steps, strings.TrimSpace)
+	}
+	return func(s string) string {
+		for _, step := range steps {
+			s = step(s)
+		}
+		return s
+	}, nil
+}
+
+// stripCurrency removes currency symbols.
+func stripCurrency(s string) string {
+	if !strings.ContainsFunc(s, isCurrency) {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		if isCurrency(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// isCurrency tells whether r is a currency symbol.
+func isCurrency(r rune) bool {
+	return unicode.Is(unicode.Sc, r)
+}
+
+// cleanup is the preprocessing of a column, bound to its index.
+type cleanup struct {
+	index int
+	clean func(string) string
+}
+
+// bindPreprocessing resolves the columns of the preprocessing rules, in column
+// order.
+func (cfg *config) bindPreprocessing(columns map[string]int) ([]cleanup, error) {
+	var cleanups []cleanup
+	for column, clean := range cfg.preprocess {
+		ix, ok := columns[column]
+		if !ok {
+			return nil, fmt.Errorf("no column '%s' to preprocess", column)
+		}
+		cleanups = append(cleanups, cleanup{index: ix, clean: clean})
+	}
+	slices.SortFunc(cleanups, func(a, b cleanup) int { return a.index - b.index })
+	return cleanups, nil
+}
+
+// preprocess cleans the values of a row in place.
+func preprocess(cleanups []cleanup, row []string) {
+	for _, c := range cleanups {
+		if c.index < len(row) {
+			row[c.index] = c.clean(row[c.index])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package bigcsv_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestPreprocessing applies rules decoded from JSON before OnRow.
+func TestPreprocessing(t *testing.T) {
+	spec, err := bigcsv.ParsePreprocessing(strings.NewReader(`{
+		"country": {"trim": true, "case": "upper"},
+		"price": {"strip_currency": true, "trim": true},
+		"phone": {"replace": [{"pattern": "[^0-9+]", "with": ""}]}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := "country,price,phone\n de ,€ 12.50,+49 (30) 1234-5\nfr,$3,\n"
+	parser, err := bigcsv.NewFromString[[]string](input, bigcsv.WithHeaders(), bigcsv.WithPreprocessing(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	parser.OnRow = func(row []string) error {
+		rows = append(rows, slices.Clone(row))
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]string{{"DE", "12.50", "+493012345"}, {"FR", "3", ""}}
+	if !slices.EqualFunc(rows, expected, slices.Equal) {
+		t.Fatalf("Got rows %q, expected %q", rows, expected)
+	}
+}
+
+// TestPreprocessingInvalid checks that invalid rules are rejected.
+func TestPreprocessingInvalid(t *testing.T) {
+	if _, err := bigcsv.ParsePreprocessing(strings.NewReader(`{"a": {"trimm": true}}`)); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+	specs := []bigcsv.Preprocessing{
+		{"a": {Case: "title"}},
+		{"a": {Replace: []bigcsv.Replacement{{Pattern: "("}}}},
+	}
+	for _, spec := range specs {
+		if _, err := bigcsv.NewFromString[[]string]("a\n1\n", bigcsv.WithHeaders(), bigcsv.WithPreprocessing(spec)); err == nil {
+			t.Errorf("Expected an error for %+v", spec)
+		}
+	}
+	parser, err := bigcsv.NewFromString[[]string]("a\n1\n", bigcsv.WithHeaders(), bigcsv.WithPreprocessing(bigcsv.Preprocessing{"b": {Trim: true}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnRow = func([]string) error { return nil }
+	if err = parser.Run(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "no column 'b'") {
+		t.Errorf("Expected an error for the missing column, got: %v", err)
+	}
+}
@@ -0,0 +1,326 @@
+package bigcsv
+
+import (
+	"cmp"
+	"hash/maphash"
+	"math"
+	"math/bits"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// WithProfile profiles the columns of all rows read by Run, see Profiler. The
+// profile is part of the Stats.
+func WithProfile() Option {
+	return func(cfg *config) error {
+		cfg.profile = true
+		return nil
+	}
+}
+
+// ColumnProfile summarizes the values of a column.
+type ColumnProfile struct {
+	// Name is the header name of the column, if known.
+	Name string
+
+	// Count is the number of rows seen, Nulls those with an empty or missing
+	// value.
+	Count int64
+	Nulls int64
+
+	// Numeric is the number of values parsing as numbers, which Min, Max,
+	// Mean and StdDev describe.
+	Numeric int64
+	Min     float64
+	Max     float64
+	Mean    float64
+	StdDev  float64
+
+	// Integers, Booleans and Dates count the values parsing as such, for
+	// type inference. Integers are also Numeric.
+	Integers int64
+	Booleans int64
+	Dates    int64
+
+	// Timestamps counts the Dates with a time of day.
+	Timestamps int64
+
+	// NonNumeric holds the first non-empty values not parsing as numbers,
+	// with their lines, to find the bad values of mostly numeric columns.
+	NonNumeric []LineValue
+
+	// Distinct estimates the number of distinct values, within about 2%.
+	Distinct uint64
+
+	// Top lists the most frequent values, most frequent first. The counts
+	// are upper bounds if the column has many distinct values.
+	Top []ValueCount
+
+	// MinLength and MaxLength are the shortest and longest value in
+	// characters. Lengths is a histogram of the lengths: index 0 counts
+	// empty values, index i > 0 lengths from 2^(i-1) to 2^i - 1.
+	MinLength int
+	MaxLength int
+	Lengths   []int64
+}
+
+// ValueCount is the number of occurrences of a value.
+type ValueCount struct {
+	Value string
+	Count int64
+}
+
+// LineValue is a value and the line it was found on.
+type LineValue struct {
+	Line  int    `json:"line"`
+	Value string `json:"value"`
+}
+
+// NullRate returns the share of null values.
+func (c ColumnProfile) NullRate() float64 {
+	if c.Count == 0 {
+		return 0
+	}
+	return float64(c.Nulls) / float64(c.Count)
+}
+
+// Profiler computes per-column statistics in a single pass. Its OnRow is safe
+// for use with multiple workers and can be used as OnRow of a Parser.
+type Profiler struct {
+	mu      sync.Mutex
+	seed    maphash.Seed
+	names   []string
+	columns []*columnStats
+	rows    int
+}
+
+// NewProfiler creates a Profiler, naming the columns by the header row if
+// given.
+func NewProfiler(headers []string) *Profiler {
+	return &Profiler{seed: maphash.MakeSeed(), names: headers}
+}
+
+// columnStats accumulates the profile of a column.
+type columnStats struct {
+	ColumnProfile
+	m2  float64 // sum of squared differences from the mean
+	hll hyperLogLog
+	top topK
+}
+
+// OnRow adds the values of a row. Lines of values in the profile count the
+// rows added, use OnRowAt to give the lines.
+func (p *Profiler) OnRow(row []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.add(p.rows+1, row)
+}
+
+// OnRowAt adds the values of a row found on the given line.
+func (p *Profiler) OnRowAt(line int, row []string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.add(line, row)
+}
+
+// add adds the values of a row with the lock held.
+func (p *Profiler) add(line int, row []string) error {
+	p.rows++
+	for len(p.columns) < len(row) {
+		c := &columnStats{}
+		// Rows seen before had no value in this column.
+		if len(p.columns) > 0 {
+			c.Count = p.columns[0].Count
+			c.Nulls = c.Count
+		}
+		p.columns = append(p.columns, c)
+	}
+	for i, c := range p.columns {
+		value := ""
+		if i < len(row) {
+			value = row[i]
+		}
+		c.add(line, value, p.seed)
+	}
+	return nil
+}
+
+// profileSamples is the number of non-numeric values kept by a profile.
+const profileSamples = 10
+
+// add adds a value found on the line to the column.
+func (c *columnStats) add(line int, value string, seed maphash.Seed) {
+	c.Count++
+	n := utf8.RuneCountInString(value)
+	bucket := bits.Len(uint(n))
+	for len(c.Lengths) <= bucket {
+		c.Lengths = append(c.Lengths, 0)
+	}
+	c.Lengths[bucket]++
+	if c.Count == 1 || n < c.MinLength {
+		c.MinLength = n
+	}
+	c.MaxLength = max(c.MaxLength, n)
+	if value == "" {
+		c.Nulls++
+		return
+	}
+	c.hll.add(maphash.String(seed, value))
+	c.top.add(value)
+	if _, err := strconv.ParseBool(value); err == nil {
+		c.Booleans++
+	}
+	if isDate(value) {
+		c.Dates++
+		if len(value) > len(time.DateOnly) {
+			c.Timestamps++
+		}
+	}
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		c.Integers++
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		if len(c.NonNumeric) < profileSamples {
+			c.NonNumeric = append(c.NonNumeric, LineValue{line, strings.Clone(value)})
+		}
+		return
+	}
+	// Welford's online algorithm for the mean and variance.
+	c.Numeric++
+	if c.Numeric == 1 {
+		c.Min, c.Max = f, f
+	}
+	c.Min, c.Max = min(c.Min, f), max(c.Max, f)
+	delta := f - c.Mean
+	c.Mean += delta / float64(c.Numeric)
+	c.m2 += delta * (f - c.Mean)
+}
+
+// Columns returns the profiles of the columns seen so far.
+func (p *Profiler) Columns() []ColumnProfile {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	profiles := make([]ColumnProfile, len(p.columns))
+	for i, c := range p.columns {
+		profiles[i] = c.ColumnProfile
+		profiles[i].Lengths = append([]int64(nil), c.Lengths...)
+		profiles[i].NonNumeric = slices.Clone(c.NonNumeric)
+		if i < len(p.names) {
+			profiles[i].Name = p.names[i]
+		}
+		if c.Numeric > 1 {
+			profiles[i].StdDev = math.Sqrt(c.m2 / float64(c.Numeric-1))
+		}
+		profiles[i].Distinct = c.hll.estimate()
+		profiles[i].Top = c.top.top(profileTopK)
+	}
+	return profiles
+}
+
+// hllPrecision is the number of index bits of the HyperLogLog registers.
+const hllPrecision = 12
+
+// hyperLogLog estimates the number of distinct hashes.
+type hyperLogLog struct {
+	registers []uint8
+}
+
+// add adds a hash.
+func (h *hyperLogLog) add(x uint64) {
+	if h.registers == nil {
+		h.registers = make([]uint8, 1<<hllPrecision)
+	}
+	i := x >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	h.registers[i] = max(h.registers[i], rank)
+}
+
+// estimate returns the estimated number of distinct hashes.
+func (h *hyperLogLog) estimate() uint64 {
+	if h.registers == nil {
+		return 0
+	}
+	m := float64(len(h.registers))
+	sum, zeros := 0.0, 0
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+	e := 0.7213 / (1 + 1.079/m) * m * m / sum
+	if e <= 2.5*m && zeros > 0 { // small range correction: linear counting
+		e = m * math.Log(m/float64(zeros))
+	}
+	return uint64(math.Round(e))
+}
+
+// profileTopK is the number of most frequent values in a ColumnProfile.
+const profileTopK = 10
+
+// dateLayouts are the layouts recognized as dates by profiles.
+var dateLayouts = []string{time.RFC3339, time.DateTime, time.DateOnly}
+
+// isDate tells whether the value is a date in a common layout.
+func isDate(value string) bool {
+	if len(value) < len(time.DateOnly) || value[0] < '0' || value[0] > '9' {
+		return false
+	}
+	for _, layout := range dateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// topKCapacity is the number of values tracked by a topK by default.
+const topKCapacity = 100
+
+// topK tracks the most frequent values in bounded memory, using the
+// Space-Saving algorithm: when full, a new value replaces the least frequent
+// one, inheriting its count. It tracks capacity values, or topKCapacity if 0.
+type topK struct {
+	counts   map[string]int64
+	capacity int
+}
+
+// add counts a value.
+func (t *topK) add(value string) {
+	capacity := cmp.Or(t.capacity, topKCapacity)
+	if t.counts == nil {
+		t.counts = make(map[string]int64, capacity)
+	}
+	if _, ok := t.counts[value]; ok || len(t.counts) < capacity {
+		t.counts[value]++
+		return
+	}
+	minValue, minCount := "", int64(math.MaxInt64)
+	for v, n := range t.counts {
+		if n < minCount {
+			minValue, minCount = v, n
+		}
+	}
+	delete(t.counts, minValue)
+	t.counts[strings.Clone(value)] = minCount + 1
+}
+
+// top returns the k most frequent values.
+func (t *topK) top(k int) []ValueCount {
+	values := make([]ValueCount, 0, len(t.counts))
+	for v, n := range t.counts {
+		values = append(values, ValueCount{v, n})
+	}
+	slices.SortFunc(values, func(a, b ValueCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	return values[:min(k, len(values))]
+}
@@ -0,0 +1,47 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestProfile checks the column profiles in the Stats of a Run.
+func TestProfile(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("id,score,comment\n")
+	for i := 1; i <= 1000; i++ {
+		comment := ""
+		if i%4 == 0 {
+			comment = "note"
+		}
+		fmt.Fprintf(&sb, "%d,%d,%s\n", i, i%2*10, comment)
+	}
+	parser, err := bigcsv.NewFromString[[]string](sb.String(), bigcsv.WithHeaders(), bigcsv.WithProfile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnRow = func([]string) error { return nil }
+	if err = parser.Run(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	profile := parser.Stats().Profile
+	if len(profile) != 3 {
+		t.Fatalf("Expected 3 columns, got %+v", profile)
+	}
+	id, score, comment := profile[0], profile[1], profile[2]
+	// The distinct count is an estimate with a random seed, allow for its error.
+	if id.Name != "id" || id.Min != 1 || id.Max != 1000 || id.Mean != 500.5 || id.Distinct < 940 || id.Distinct > 1060 {
+		t.Errorf("Unexpected id profile: %+v", id)
+	}
+	if score.Distinct != 2 || score.Mean != 5 || math.Abs(score.StdDev-5) > 0.01 {
+		t.Errorf("Unexpected score profile: %+v", score)
+	}
+	if comment.NullRate() != 0.75 || comment.Numeric != 0 || comment.MaxLength != 4 || comment.Lengths[3] != 250 {
+		t.Errorf("Unexpected comment profile: %+v", comment)
+	}
+}
@@ -0,0 +1,55 @@
+package bigcsv
+
+import (
+	"os"
+	"time"
+)
+
+// Provenance describes where the rows of a Parser come from, so that loaded
+// rows can record it.
+type Provenance struct {
+	// Name is the file name or URL of the stream, or the path of an entry
+	// within an archive. Other streams are named by their type.
+	Name string
+
+	// Archive names the archive of an entry processed by an ArchiveRouter,
+	// and is empty for other streams.
+	Archive string
+
+	// ModTime is the modification time of the file or archive entry, zero if
+	// unknown.
+	ModTime time.Time
+}
+
+// Provenance returns the Provenance of the current stream. It is safe to call
+// from Parse and OnData, e.g. in the configure function of ParserFor:
+//
+//	bigcsv.ParserFor(func(p *bigcsv.Parser[Order]) error {
+//		source := p.Provenance()
+//		p.OnData = func(o Order) error {
+//			o.SourceFile, o.SourceTime = source.Name, source.ModTime
+//			return store.AddOrder(o)
+//		}
+//		return nil
+//	})
+func (p *Parser[T]) Provenance() Provenance {
+	return p.provenance
+}
+
+// provenanceOf describes a stream which was opened.
+func provenanceOf(stream Stream) Provenance {
+	switch s := stream.(type) {
+	case FileStream:
+		prov := Provenance{Name: string(s)}
+		if info, err := os.Stat(string(s)); err == nil {
+			prov.ModTime = info.ModTime()
+		}
+		return prov
+	case entryStream:
+		return Provenance{Name: s.name, Archive: s.archive, ModTime: s.modTime}
+	case teeStream:
+		return provenanceOf(s.inner)
+	default:
+		return Provenance{Name: describe(stream)}
+	}
+}
@@ -0,0 +1,61 @@
+package bigcsv_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestProvenance checks the provenance of files and archive entries.
+func TestProvenance(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "numbers.csv")
+	if err := os.WriteFile(name, []byte("1,one\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(name, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+	parser, err := bigcsv.New[Number](bigcsv.FileStream(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Close()
+	if prov := parser.Provenance(); prov.Name != name || prov.Archive != "" || !prov.ModTime.Equal(modified) {
+		t.Errorf("Unexpected provenance of file: %+v", prov)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	tw.WriteHeader(&tar.Header{Name: "export/numbers.csv", Mode: 0o644, Size: 6, ModTime: modified, Typeflag: tar.TypeReg})
+	tw.Write([]byte("1,one\n"))
+	tw.Close()
+	archive := filepath.Join(t.TempDir(), "export.tar")
+	if err = os.WriteFile(archive, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sources []bigcsv.Provenance
+	router := &bigcsv.ArchiveRouter{Routes: []bigcsv.Route{
+		{Pattern: "*.csv", Parser: bigcsv.ParserFor(func(p *bigcsv.Parser[Number]) error {
+			source := p.Provenance()
+			p.Parse = ParseNumber
+			p.OnData = func(Number) error {
+				sources = append(sources, source)
+				return nil
+			}
+			return nil
+		})},
+	}}
+	if _, err = router.Run(context.Background(), bigcsv.FileStream(archive)); err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].Name != "export/numbers.csv" || sources[0].Archive != archive || !sources[0].ModTime.Equal(modified) {
+		t.Errorf("Unexpected provenance of entry: %+v", sources)
+	}
+}
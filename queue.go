@@ -0,0 +1,175 @@
+package bigcsv
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message is a queue message carrying a CSV chunk, or a pointer to CSV
+// objects such as an S3 event.
+type Message struct {
+	ID   string
+	Body []byte
+
+	// Ack acknowledges the message, e.g. deleting it from an SQS queue. It
+	// may be nil if the queue needs no acknowledgement.
+	Ack func(ctx context.Context) error
+
+	// Nack requests redelivery of the message. It may be nil if the queue
+	// redelivers unacknowledged messages by itself, like SQS after the
+	// visibility timeout.
+	Nack func(ctx context.Context) error
+}
+
+// Queue receives messages. There are no adapters for SQS or Google Pub/Sub in
+// this package, which does not depend on their SDKs: the caller supplies one
+// wrapping its client, e.g. for SQS:
+//
+//	type sqsQueue struct {
+//		client *sqs.Client
+//		url    string
+//	}
+//
+//	func (q sqsQueue) Receive(ctx context.Context) ([]bigcsv.Message, error) {
+//		out, err := q.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{QueueUrl: &q.url, WaitTimeSeconds: 20})
+//		if err != nil {
+//			return nil, err
+//		}
+//		msgs := make([]bigcsv.Message, len(out.Messages))
+//		for i, m := range out.Messages {
+//			handle := m.ReceiptHandle
+//			msgs[i] = bigcsv.Message{ID: *m.MessageId, Body: []byte(*m.Body), Ack: func(ctx context.Context) error {
+//				_, err := q.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: &q.url, ReceiptHandle: handle})
+//				return err
+//			}}
+//		}
+//		return msgs, nil
+//	}
+//
+// A Pub/Sub subscription delivers messages to a callback instead, which can
+// pass them on through a channel read by Receive, with Ack and Nack calling
+// those of the pubsub.Message.
+type Queue interface {
+	// Receive blocks until messages are available or ctx is done.
+	Receive(ctx context.Context) ([]Message, error)
+}
+
+// QueueConsumer parses the CSV referenced by queue messages, acknowledging a
+// message only once all of its rows passed OnData without error. Otherwise it
+// is not acknowledged and will be redelivered, so ingestion is at least once.
+type QueueConsumer[T any] struct {
+	Queue Queue
+
+	// Streams returns the streams of a message. By default, the body is a CSV
+	// chunk, see also S3EventStreams.
+	Streams func(msg Message) ([]Stream, error)
+
+	// Options are passed to New for each stream.
+	Options []Option
+
+	// Configure sets up the Parser of each stream, e.g. its Parse and OnData.
+	Configure func(p *Parser[T]) error
+
+	// Workers is passed to Parser.Run.
+	Workers int
+
+	// OnError receives errors of messages which are not acknowledged.
+	OnError func(msg Message, err error)
+}
+
+// Run consumes messages until ctx is done or receiving fails.
+func (c *QueueConsumer[T]) Run(ctx context.Context) error {
+	for {
+		msgs, err := c.Queue.Receive(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return fmt.Errorf("could not receive messages: %w", err)
+		}
+		for _, msg := range msgs {
+			if err = c.process(ctx, msg); err == nil {
+				if msg.Ack != nil {
+					err = msg.Ack(ctx)
+				}
+			} else if msg.Nack != nil {
+				err = errors.Join(err, msg.Nack(ctx))
+			}
+			if err != nil && c.OnError != nil {
+				c.OnError(msg, err)
+			}
+		}
+	}
+}
+
+// process parses all streams of a message, returning the first error.
+func (c *QueueConsumer[T]) process(ctx context.Context, msg Message) error {
+	streams := []Stream{ReadStream(bytes.NewReader(msg.Body))}
+	if c.Streams != nil {
+		var err error
+		if streams, err = c.Streams(msg); err != nil {
+			return err
+		}
+	}
+	for _, stream := range streams {
+		if err := c.parse(ctx, stream); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parse runs a Parser over a stream, failing on any row error.
+func (c *QueueConsumer[T]) parse(ctx context.Context, stream Stream) error {
+	p, err := New[T](stream, c.Options...)
+	if err != nil {
+		return err
+	}
+	if c.Configure != nil {
+		if err = c.Configure(p); err != nil {
+			p.Close()
+			return err
+		}
+	}
+	var mu sync.Mutex
+	var rowErr error
+	onError := p.OnError
+	p.OnError = func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if rowErr == nil {
+			rowErr = err
+		}
+		if onError != nil {
+			onError(err)
+		}
+	}
+	if err = p.Run(ctx, c.Workers); err != nil {
+		return err
+	}
+	return rowErr
+}
+
+// S3EventStreams returns a QueueConsumer.Streams function for messages holding
+// S3 event notifications, as sent to SQS by S3.
+func S3EventStreams(ctx context.Context, client S3Client) func(msg Message) ([]Stream, error) {
+	return func(msg Message) ([]Stream, error) {
+		var event S3Event
+		if err := json.Unmarshal(msg.Body, &event); err != nil {
+			return nil, fmt.Errorf("invalid S3 event: %w", err)
+		}
+		streams := make([]Stream, 0, len(event.Records))
+		for _, rec := range event.Records {
+			stream, err := rec.Stream(ctx, client)
+			if err != nil {
+				return nil, err
+			}
+			streams = append(streams, stream)
+		}
+		return streams, nil
+	}
+}
@@ -0,0 +1,66 @@
+package bigcsv_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// memoryQueue delivers its messages once, then cancels the consumer.
+type memoryQueue struct {
+	msgs   []bigcsv.Message
+	cancel context.CancelFunc
+}
+
+func (q *memoryQueue) Receive(ctx context.Context) ([]bigcsv.Message, error) {
+	msgs := q.msgs
+	q.msgs = nil
+	if msgs == nil {
+		q.cancel()
+	}
+	return msgs, nil
+}
+
+// TestQueueConsumer checks that only messages without row errors are
+// acknowledged.
+func TestQueueConsumer(t *testing.T) {
+	var mu sync.Mutex
+	acked := map[string]bool{}
+	message := func(id, body string) bigcsv.Message {
+		return bigcsv.Message{ID: id, Body: []byte(body), Ack: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			acked[id] = true
+			return nil
+		}}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sum := 0
+	consumer := &bigcsv.QueueConsumer[Number]{
+		Queue: &memoryQueue{cancel: cancel, msgs: []bigcsv.Message{
+			message("good", "1,one\n2,two\n"),
+			message("bad", "3,three\nx,four\n"),
+			{ID: "unacknowledged", Body: []byte("4,four\n")},
+		}},
+		Configure: func(p *bigcsv.Parser[Number]) error {
+			p.Parse = ParseNumber
+			p.OnData = func(n Number) error {
+				sum += n.Integer
+				return nil
+			}
+			return nil
+		},
+		Workers: 1,
+	}
+	var failed []string
+	consumer.OnError = func(msg bigcsv.Message, err error) {
+		failed = append(failed, msg.ID)
+	}
+	consumer.Run(ctx)
+	if !acked["good"] || acked["bad"] || len(failed) != 1 || failed[0] != "bad" || sum != 10 {
+		t.Fatalf("Acked %v, failed %v, sum %d", acked, failed, sum)
+	}
+}
@@ -0,0 +1,147 @@
+package bigcsv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNeedsQuotes is returned by a Writer quoting with QuoteNone for a field
+// holding the delimiter, a quote or a line break.
+var ErrNeedsQuotes = errors.New("field needs quotes")
+
+// WithQuoting sets which fields of the output are quoted, for loaders
+// requiring e.g. every field quoted:
+//
+//	QuoteMinimal     only fields holding the delimiter, a quote or a line
+//	                 break, starting with a space, or being `\.`, like
+//	                 csv.Writer; the default
+//	QuoteAll         every field, including empty ones
+//	QuoteNonNumeric  every field but empty ones and decimal numbers such as
+//	                 "42", "-1.5" or "2e10", like Python's QUOTE_NONNUMERIC
+//	QuoteNone        no field, failing with ErrNeedsQuotes for fields which
+//	                 cannot be written unquoted
+func WithQuoting(style QuoteStyle) WriterOption {
+	return func(cfg *writerConfig) error {
+		switch style {
+		case QuoteMinimal, QuoteAll, QuoteNonNumeric, QuoteNone:
+		default:
+			return fmt.Errorf("invalid quote style %q", style)
+		}
+		cfg.quoting = style
+		return nil
+	}
+}
+
+// WithLineTerminator sets the line terminator of the output: "\n", the
+// default, "\r\n" as expected by many Windows and mainframe loaders, or "\r".
+// Line breaks within quoted fields are written with the terminator, too.
+func WithLineTerminator(term string) WriterOption {
+	return func(cfg *writerConfig) error {
+		if term != "\n" && term != "\r\n" && term != "\r" {
+			return fmt.Errorf("invalid line terminator %q", term)
+		}
+		cfg.terminator = term
+		return nil
+	}
+}
+
+// recordWriter writes records like csv.Writer, quoting as configured.
+type recordWriter struct {
+	w       *bufio.Writer
+	comma   rune
+	eol     string
+	quoting QuoteStyle
+}
+
+// newRecordWriter creates a recordWriter with the settings of cfg.
+func newRecordWriter(w io.Writer, cfg *writerConfig) *recordWriter {
+	return &recordWriter{
+		w:       bufio.NewWriter(w),
+		comma:   cfg.comma,
+		eol:     cfg.terminator,
+		quoting: cfg.quoting,
+	}
+}
+
+// Write writes a record. A record with a field which cannot be written is
+// rejected as a whole.
+func (w *recordWriter) Write(record []string) error {
+	quoted := make([]bool, len(record))
+	for i, field := range record {
+		quoted[i] = w.quoted(field)
+		if !quoted[i] && w.quoting == QuoteNone && strings.ContainsFunc(field, w.special) {
+			return &ColumnError{Index: i, Err: ErrNeedsQuotes}
+		}
+	}
+	for i, field := range record {
+		if i > 0 {
+			w.w.WriteRune(w.comma)
+		}
+		if !quoted[i] {
+			w.w.WriteString(field)
+			continue
+		}
+		w.w.WriteByte('"')
+		for j := 0; j < len(field); j++ {
+			switch c := field[j]; {
+			case c == '"':
+				w.w.WriteString(`""`)
+			case w.eol != "\n" && (c == '\r' || c == '\n'):
+				if c == '\r' && j+1 < len(field) && field[j+1] == '\n' {
+					j++
+				}
+				w.w.WriteString(w.eol)
+			default:
+				w.w.WriteByte(c)
+			}
+		}
+		w.w.WriteByte('"')
+	}
+	_, err := w.w.WriteString(w.eol)
+	return err
+}
+
+// special tells whether a rune needs quoting.
+func (w *recordWriter) special(r rune) bool {
+	return r == w.comma || r == '"' || r == '\r' || r == '\n'
+}
+
+// numeric matches the decimal numbers left unquoted by QuoteNonNumeric.
+var numeric = regexp.MustCompile(`^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)([eE][-+]?[0-9]+)?$`)
+
+// quoted tells whether to quote a field.
+func (w *recordWriter) quoted(field string) bool {
+	switch w.quoting {
+	case QuoteAll:
+		return true
+	case QuoteNonNumeric:
+		return field != "" && !numeric.MatchString(field)
+	case QuoteNone:
+		return false
+	}
+	if field == "" {
+		return false
+	}
+	if field == `\.` || strings.ContainsFunc(field, w.special) {
+		return true
+	}
+	r, _ := utf8.DecodeRuneInString(field)
+	return unicode.IsSpace(r)
+}
+
+// Flush writes any buffered output.
+func (w *recordWriter) Flush() {
+	w.w.Flush()
+}
+
+// Error returns any error of writing or flushing.
+func (w *recordWriter) Error() error {
+	_, err := w.w.Write(nil)
+	return err
+}
@@ -0,0 +1,76 @@
+package bigcsv_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestQuoting writes the same rows with each quote style.
+func TestQuoting(t *testing.T) {
+	rows := [][]string{{"id", "name", "amount"}, {"7", "Smith, J", "-1.5"}, {"8", " ", ""}}
+	for _, test := range []struct {
+		style bigcsv.QuoteStyle
+		want  string
+	}{
+		{bigcsv.QuoteMinimal, "id,name,amount\n7,\"Smith, J\",-1.5\n8,\" \",\n"},
+		{bigcsv.QuoteAll, "\"id\",\"name\",\"amount\"\n\"7\",\"Smith, J\",\"-1.5\"\n\"8\",\" \",\"\"\n"},
+		{bigcsv.QuoteNonNumeric, "\"id\",\"name\",\"amount\"\n7,\"Smith, J\",-1.5\n8,\" \",\n"},
+	} {
+		var out strings.Builder
+		w, err := bigcsv.NewWriter[[]string](&out, bigcsv.WithQuoting(test.style), bigcsv.WithoutHeader())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range rows {
+			if err = w.OnData(row); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.want {
+			t.Errorf("Quoting %s, got %q, expected %q", test.style, out.String(), test.want)
+		}
+	}
+
+	var out strings.Builder
+	w, err := bigcsv.NewWriter[[]string](&out, bigcsv.WithQuoting(bigcsv.QuoteNone))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.OnData([]string{"a b", "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.OnData([]string{"d", "e,f"}); !errors.Is(err, bigcsv.ErrNeedsQuotes) {
+		t.Errorf("Expected ErrNeedsQuotes, got: %v", err)
+	}
+	w.Flush()
+	if out.String() != "a b,c\n" {
+		t.Errorf("Got %q without quotes", out.String())
+	}
+}
+
+// TestLineTerminator writes CRLF line breaks, also within fields.
+func TestLineTerminator(t *testing.T) {
+	var out strings.Builder
+	w, err := bigcsv.NewWriter[[]string](&out, bigcsv.WithHeader("id", "note"), bigcsv.WithLineTerminator("\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = w.OnData([]string{"1", "line\nbreak\r\nhere"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "id,note\r\n1,\"line\r\nbreak\r\nhere\"\r\n"; out.String() != want {
+		t.Errorf("Got %q, expected %q", out.String(), want)
+	}
+	if _, err = bigcsv.NewWriter[[]string](&out, bigcsv.WithLineTerminator("\n\r")); err == nil {
+		t.Error("Expected an error for an invalid line terminator")
+	}
+}
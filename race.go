@@ -0,0 +1,7 @@
+//go:build race
+
+package bigcsv
+
+// raceEnabled tells whether the race detector is enabled, checking each row
+// that the callbacks were not replaced, see ErrReconfigured.
+const raceEnabled = true
@@ -0,0 +1,7 @@
+//go:build race
+
+package bigcsv_test
+
+// raceEnabled tells whether the race detector is enabled, failing runs which
+// replace callbacks, see ErrReconfigured.
+const raceEnabled = true
@@ -0,0 +1,121 @@
+package bigcsv
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// readAheadBlock is the size of the blocks read ahead unless set by
+// WithBufferSize.
+const readAheadBlock = 1 << 20
+
+// WithReadAhead reads the stream in its own goroutine, up to blocks ahead of
+// the csv.Reader, e.g. to keep a high-latency network stream busy while rows
+// are processed. Blocks are 1 MiB unless set by WithBufferSize. The gzip
+// decompression of a FileStream or HTTPStream then runs in parallel to
+// parsing, though on a single core: only BGZF streams, which record the size
+// of their blocks, are decompressed by several goroutines, which they always
+// are. Other gzip streams cannot be split without decompressing them, so
+// there is no option to decompress them in parallel, nor support for zstd.
+func WithReadAhead(blocks int) Option {
+	return func(cfg *config) error {
+		if blocks < 1 {
+			return fmt.Errorf("invalid number of blocks to read ahead: %d", blocks)
+		}
+		cfg.readAhead = blocks
+		return nil
+	}
+}
+
+// WithBufferSize sets the size of the buffer between the stream and the
+// csv.Reader, 4 KiB by default, and of the blocks of WithReadAhead. Larger
+// buffers mean fewer, larger reads, which suits network streams.
+func WithBufferSize(size int) Option {
+	return func(cfg *config) error {
+		if size < 16 {
+			return fmt.Errorf("invalid buffer size: %d", size)
+		}
+		cfg.bufferSize = size
+		return nil
+	}
+}
+
+// readAhead reads blocks of a stream in the background.
+type readAhead struct {
+	r    io.ReadCloser
+	full chan []byte // blocks read, closed at the end of the stream
+	free chan []byte // blocks consumed, for reuse
+	stop chan struct{}
+	once sync.Once
+	err  error // the error ending the stream, set before full is closed
+
+	block []byte // the block being consumed
+	cur   []byte // its unread part
+}
+
+func newReadAhead(r io.ReadCloser, blocks, size int) *readAhead {
+	ra := &readAhead{
+		r:    r,
+		full: make(chan []byte, blocks),
+		free: make(chan []byte, blocks+1),
+		stop: make(chan struct{}),
+	}
+	for range blocks + 1 {
+		ra.free <- make([]byte, size)
+	}
+	go ra.fill()
+	return ra
+}
+
+// fill reads blocks until the stream ends or the reader is closed.
+func (ra *readAhead) fill() {
+	defer close(ra.full)
+	for {
+		var block []byte
+		select {
+		case block = <-ra.free:
+		case <-ra.stop:
+			return
+		}
+		n, err := ra.r.Read(block[:cap(block)])
+		for n == 0 && err == nil {
+			n, err = ra.r.Read(block[:cap(block)])
+		}
+		if n > 0 {
+			select {
+			case ra.full <- block[:n]:
+			case <-ra.stop:
+				return
+			}
+		}
+		if err != nil {
+			ra.err = err
+			return
+		}
+	}
+}
+
+func (ra *readAhead) Read(p []byte) (int, error) {
+	for len(ra.cur) == 0 {
+		if ra.block != nil {
+			ra.free <- ra.block
+			ra.block = nil
+		}
+		block, ok := <-ra.full
+		if !ok {
+			return 0, ra.err
+		}
+		ra.block, ra.cur = block, block
+	}
+	n := copy(p, ra.cur)
+	ra.cur = ra.cur[n:]
+	return n, nil
+}
+
+func (ra *readAhead) Close() error {
+	ra.once.Do(func() {
+		close(ra.stop)
+	})
+	return ra.r.Close()
+}
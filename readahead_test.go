@@ -0,0 +1,86 @@
+package bigcsv_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestReadAheadGzip reads a gzip file of several blocks ahead.
+func TestReadAheadGzip(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "rows.csv.gz")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	const rows = 100_000
+	for i := range rows {
+		fmt.Fprintf(gz, "%d,row number %d\n", i, i)
+	}
+	if err = gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	parser, err := bigcsv.New[int](bigcsv.FileStream(name), bigcsv.WithReadAhead(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) (int, error) {
+		return strconv.Atoi(row[0])
+	}
+	next := 0
+	parser.OnData = func(n int) error {
+		if n != next {
+			return fmt.Errorf("got row %d, expected %d", n, next)
+		}
+		next++
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Fatal(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if next != rows {
+		t.Fatalf("Got %d rows, expected %d", next, rows)
+	}
+	if _, err = bigcsv.New[int](bigcsv.FileStream(name), bigcsv.WithReadAhead(0)); err == nil {
+		t.Error("Expected an error for no blocks")
+	}
+}
+
+// TestBufferSize reads with a large buffer and small blocks read ahead,
+// checking that offsets are unaffected.
+func TestBufferSize(t *testing.T) {
+	input := "a,b\n1,2\n3,4\n"
+	parser, err := bigcsv.NewFromString[[]string](input, bigcsv.WithBufferSize(1<<16), bigcsv.WithReadAhead(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var offsets []int64
+	parser.OnRow = func([]string) error {
+		_, offset := parser.Position()
+		offsets = append(offsets, offset)
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(offsets) != "[0 4 8]" {
+		t.Errorf("Unexpected offsets %v", offsets)
+	}
+	if _, err = bigcsv.NewFromString[[]string](input, bigcsv.WithBufferSize(0)); err == nil {
+		t.Error("Expected an error for an empty buffer")
+	}
+}
@@ -0,0 +1,193 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrMalformedRecord should be wrapped by a RecordReader for errors concerning
+// a single record, so the Parser continues with the next one. Any other error
+// is treated as a failure of the stream and ends processing.
+var ErrMalformedRecord = errors.New("malformed record")
+
+// RecordReader provides the rows of a stream. A *csv.Reader is the default,
+// other formats can be processed by the same Parser machinery by providing a
+// RecordReader with WithRecordReader.
+//
+// Read returns io.EOF when there are no more records.
+type RecordReader interface {
+	Read() (record []string, err error)
+}
+
+// WithRecordReader replaces the CSV reader by a RecordReader created by
+// newReader for each opened stream. Options specific to CSV, such as WithComma,
+// have no effect and Parser.Reader is nil.
+func WithRecordReader(newReader func(r io.Reader) RecordReader) Option {
+	return func(cfg *config) error {
+		if newReader == nil {
+			return fmt.Errorf("invalid record reader")
+		}
+		cfg.newReader = newReader
+		return nil
+	}
+}
+
+// attach sets the opened stream as the source of rows.
+func (p *Parser[T]) attach(r io.ReadCloser) {
+	if p.cfg.readAhead > 0 {
+		r = newReadAhead(r, p.cfg.readAhead, cmp.Or(p.cfg.bufferSize, readAheadBlock))
+	}
+	p.closer = r
+	in := p.cfg.input(r)
+	p.repairedLines = nil
+	if p.cfg.repairQuotes && p.cfg.newReader == nil {
+		s := newStrictReader(in, p.cfg.comma)
+		s.repaired = func(line int) {
+			p.repairedLines = append(p.repairedLines, line)
+		}
+		in = s
+	}
+	p.limiter = nil
+	if p.cfg.maxLines > 0 && p.cfg.newReader == nil {
+		p.limiter = newLineLimiter(in, p.cfg.maxLines)
+		in = p.limiter
+	}
+	if p.cfg.bufferSize > 0 { // used by csv.NewReader as is
+		in = bufio.NewReaderSize(in, p.cfg.bufferSize)
+	}
+	if p.cfg.newReader != nil {
+		p.Reader = nil
+		p.records = p.cfg.newReader(in)
+		return
+	}
+	p.Reader = csv.NewReader(in)
+	p.records = p.Reader
+	if p.limiter != nil {
+		p.limiter.reader = p.Reader
+	}
+}
+
+// FixedWidth returns a RecordReader constructor for fixed-width files, where
+// each field has the given width in characters. Fields are trimmed of
+// surrounding spaces, short lines have empty trailing fields.
+//
+//	parser, err := bigcsv.New[Account](stream, bigcsv.WithRecordReader(bigcsv.FixedWidth(10, 30, 12)))
+func FixedWidth(widths ...int) func(io.Reader) RecordReader {
+	return func(r io.Reader) RecordReader {
+		return &fixedWidthReader{s: bufio.NewScanner(r), widths: widths}
+	}
+}
+
+type fixedWidthReader struct {
+	s      *bufio.Scanner
+	widths []int
+}
+
+func (f *fixedWidthReader) Read() ([]string, error) {
+	if !f.s.Scan() {
+		if err := f.s.Err(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	line := []rune(strings.TrimSuffix(f.s.Text(), "\r"))
+	row := make([]string, len(f.widths))
+	pos := 0
+	for i, w := range f.widths {
+		start, end := min(pos, len(line)), min(pos+w, len(line))
+		row[i] = strings.TrimSpace(string(line[start:end]))
+		pos += w
+	}
+	return row, nil
+}
+
+// JSONLines returns a RecordReader for JSON Lines, one JSON object per line.
+// The keys of the first object are returned as the first record, so it can be
+// used as header row with WithHeaders; later objects are returned in the same
+// column order. Strings are returned as is, other values in their JSON
+// representation and null as an empty string. Objects with unknown keys are
+// malformed records.
+func JSONLines(r io.Reader) RecordReader {
+	return &jsonLinesReader{r: bufio.NewReader(r)}
+}
+
+type jsonLinesReader struct {
+	r       *bufio.Reader
+	columns map[string]int
+	pending []string // first record, returned after the keys
+}
+
+func (j *jsonLinesReader) Read() ([]string, error) {
+	if j.pending != nil {
+		row := j.pending
+		j.pending = nil
+		return row, nil
+	}
+	for {
+		b, err := j.r.ReadBytes('\n')
+		if len(bytes.TrimSpace(b)) == 0 {
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		keys, values, err := decodeObject(b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrMalformedRecord, err)
+		}
+		if j.columns == nil {
+			j.columns = indexColumns(keys)
+			j.pending = values
+			return keys, nil
+		}
+		row := make([]string, len(j.columns))
+		for i, k := range keys {
+			ix, ok := j.columns[k]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown key '%s'", ErrMalformedRecord, k)
+			}
+			row[ix] = values[i]
+		}
+		return row, nil
+	}
+}
+
+// decodeObject decodes a JSON object into its keys and values, in order.
+func decodeObject(b []byte) (keys, values []string, err error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, nil, fmt.Errorf("expected JSON object")
+	}
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var raw json.RawMessage
+		if err = dec.Decode(&raw); err != nil {
+			return nil, nil, err
+		}
+		v := string(raw)
+		switch {
+		case v == "null":
+			v = ""
+		case strings.HasPrefix(v, `"`):
+			if err = json.Unmarshal(raw, &v); err != nil {
+				return nil, nil, err
+			}
+		}
+		keys = append(keys, t.(string))
+		values = append(values, v)
+	}
+	return keys, values, nil
+}
@@ -0,0 +1,65 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestFixedWidth checks reading a fixed-width file through the Parser.
+func TestFixedWidth(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number](
+		"1    one  \n22   two\n333  three\n",
+		bigcsv.WithRecordReader(bigcsv.FixedWidth(5, 5)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	numbers, err := collect(parser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 || numbers[2].Integer != 333 || numbers[2].String != "three" {
+		t.Fatalf("Unexpected records: %+v", numbers)
+	}
+}
+
+// TestJSONLines checks reading JSON Lines with the keys as headers.
+func TestJSONLines(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number](
+		`{"name": "one", "int": 1}`+"\n"+`{"int": 2, "name": "two"}`+"\n"+`{"bad": 3}`+"\n",
+		bigcsv.WithRecordReader(bigcsv.JSONLines),
+		bigcsv.WithHeaders(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if headers := parser.Headers(); strings.Join(headers, ",") != "name,int" {
+		t.Fatalf("Unexpected headers: %v", headers)
+	}
+	parser.Parse = func(row []string) (Number, error) {
+		return ParseNumber([]string{row[1], row[0]})
+	}
+	numbers, err := collect(parser)
+	if err == nil || !strings.Contains(err.Error(), "unknown key 'bad'") {
+		t.Fatalf("Expected error for unknown key, got: %v", err)
+	}
+	if len(numbers) != 2 || numbers[1].String != "two" {
+		t.Fatalf("Unexpected records: %+v", numbers)
+	}
+}
+
+// collect gathers the records of the parser until the first error.
+func collect[T any](parser *bigcsv.Parser[T]) ([]T, error) {
+	var records []T
+	for data, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			return records, err
+		}
+		records = append(records, data)
+	}
+	return records, nil
+}
@@ -0,0 +1,69 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRedactor checks the policies applied when writing a scrubbed copy.
+func TestRedactor(t *testing.T) {
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(
+		"name,email,phone,notes\nann,ann@example.com,555-1234,vip\nbob,,555-9876,\ncat,ann@example.com,12,x\n",
+	)), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	redactor, err := bigcsv.NewRedactor(parser.Headers(), map[string]bigcsv.RedactPolicy{
+		"name":  bigcsv.Tokenize(),
+		"email": bigcsv.HashWith([]byte("secret")),
+		"phone": bigcsv.Mask(4),
+		"notes": bigcsv.Drop(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	w, err := bigcsv.NewWriter[[]string](&sb, bigcsv.WithHeader(redactor.Headers()...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnData = redactor.Stage(w.OnData)
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if len(lines) != 4 || lines[0] != "name,email,phone" {
+		t.Fatalf("Unexpected output:\n%s", sb.String())
+	}
+	ann, bob, cat := strings.Split(lines[1], ","), strings.Split(lines[2], ","), strings.Split(lines[3], ",")
+	if ann[0] != "tok-1" || bob[0] != "tok-2" || cat[0] != "tok-3" {
+		t.Errorf("Unexpected tokens: %q", lines)
+	}
+	if len(ann[1]) != 64 || ann[1] != cat[1] || bob[1] != "" {
+		t.Errorf("Unexpected hashes: %q", lines)
+	}
+	if ann[2] != "****1234" || cat[2] != "12" {
+		t.Errorf("Unexpected masks: %q", lines)
+	}
+}
+
+// TestRedactorOnRow checks redacting in place before parsing.
+func TestRedactorOnRow(t *testing.T) {
+	redactor, err := bigcsv.NewRedactor([]string{"a", "b"}, map[string]bigcsv.RedactPolicy{"b": bigcsv.Drop()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := []string{"x", "y"}
+	if err = redactor.OnRow(row); err != nil || row[0] != "x" || row[1] != "" {
+		t.Errorf("Unexpected row %q: %v", row, err)
+	}
+	if _, err = bigcsv.NewRedactor([]string{"a"}, map[string]bigcsv.RedactPolicy{"c": bigcsv.Drop()}); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,225 @@
+package bigcsv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// RedisSink writes records to Redis, sending commands in pipelines of a fixed
+// size over a connection, e.g. a net.Conn to the server. Its OnData is safe for
+// use with multiple workers. Flush must be called to send the last pipeline.
+//
+// Error replies are reported for the command they answer, passed to
+// OnReplyError along with its record if set, and otherwise returned joined by
+// the OnData or Flush call sending the pipeline, naming the key of each failed
+// command. The connection stays usable. Once sending commands or reading
+// replies fails, however, the replies cannot be matched to the commands
+// anymore: the connection is broken, and all further calls fail with that
+// error.
+type RedisSink[T any] struct {
+	// OnReplyError, if set, is called with the record of each command
+	// getting an error reply, which then does not fail OnData or Flush. It
+	// is called while the sink is locked, so it must not use the sink.
+	OnReplyError func(data T, err error)
+
+	mu       sync.Mutex
+	w        *bufio.Writer
+	r        *bufio.Reader
+	key      *template.Template
+	command  func(key string, data T) ([]string, error)
+	pipeline int
+	queued   []redisCommand[T]
+	broken   error
+}
+
+// redisCommand is a command sent for a record, awaiting its reply.
+type redisCommand[T any] struct {
+	key  string
+	data T
+}
+
+// NewRedisHashSink writes each record as hash with HSET, its fields named by
+// their `csv` tags as for WithStructTags. The key is a text/template executed
+// with the record, e.g. "customer:{{.ID}}".
+func NewRedisHashSink[T any](conn io.ReadWriter, key string, pipeline int) (*RedisSink[T], error) {
+	typ := reflect.TypeFor[T]()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Redis hashes need a struct type, got %s", typ)
+	}
+	fields := taggedFields(typ, nil)
+	return newRedisSink(conn, key, pipeline, func(key string, data T) ([]string, error) {
+		v := reflect.ValueOf(data)
+		args := []string{"HSET", key}
+		for _, f := range fields {
+			fv := v.FieldByIndex(f.index)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			args = append(args, f.tag.name, formatValue(fv))
+		}
+		return args, nil
+	})
+}
+
+// NewRedisSetSink writes each record as string with SET, its value encoded by
+// value, e.g. with json.Marshal. The key is a template as for
+// NewRedisHashSink.
+func NewRedisSetSink[T any](conn io.ReadWriter, key string, value func(data T) ([]byte, error), pipeline int) (*RedisSink[T], error) {
+	return newRedisSink(conn, key, pipeline, func(key string, data T) ([]string, error) {
+		b, err := value(data)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"SET", key, string(b)}, nil
+	})
+}
+
+func newRedisSink[T any](conn io.ReadWriter, key string, pipeline int, command func(string, T) ([]string, error)) (*RedisSink[T], error) {
+	tmpl, err := template.New("key").Option("missingkey=error").Parse(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key template: %w", err)
+	}
+	return &RedisSink[T]{
+		w:        bufio.NewWriter(conn),
+		r:        bufio.NewReader(conn),
+		key:      tmpl,
+		command:  command,
+		pipeline: max(pipeline, 1),
+	}, nil
+}
+
+// OnData queues the command for the record, sending the pipeline once full.
+func (s *RedisSink[T]) OnData(data T) error {
+	var key strings.Builder
+	if err := s.key.Execute(&key, data); err != nil {
+		return fmt.Errorf("could not create key: %w", err)
+	}
+	args, err := s.command(key.String(), data)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.broken != nil {
+		return s.broken
+	}
+	writeRedisCommand(s.w, args...)
+	if s.queued = append(s.queued, redisCommand[T]{key.String(), data}); len(s.queued) < s.pipeline {
+		return nil
+	}
+	return s.flush()
+}
+
+// Flush sends the queued commands and checks their replies.
+func (s *RedisSink[T]) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flush()
+}
+
+// flush sends the pipeline, reporting the error replies. The caller holds the
+// lock.
+func (s *RedisSink[T]) flush() error {
+	if s.broken != nil {
+		return s.broken
+	}
+	if len(s.queued) == 0 {
+		return nil
+	}
+	if err := s.w.Flush(); err != nil {
+		s.broken = fmt.Errorf("could not send commands: %w", err)
+		return s.broken
+	}
+	queued := s.queued
+	defer func() {
+		clear(queued) // not to keep the records
+		s.queued = queued[:0]
+	}()
+	var errs []error
+	for _, c := range queued {
+		err := readRedisReply(s.r)
+		if _, ok := err.(redisError); !ok && err != nil {
+			s.broken = fmt.Errorf("could not read reply: %w", err)
+			return s.broken
+		}
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("command for key %s: %w", c.key, err)
+		if s.OnReplyError != nil {
+			s.OnReplyError(c.data, err)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeRedisCommand writes a command in RESP.
+func writeRedisCommand(w *bufio.Writer, args ...string) {
+	fmt.Fprintf(w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+// redisError is an error reply.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// readRedisReply reads and discards a reply, returning error replies as
+// redisError. Other errors leave the reply partially read.
+func readRedisReply(r *bufio.Reader) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return fmt.Errorf("empty reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisError(line[1:])
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return err
+		}
+		_, err = r.Discard(n + 2)
+		return err
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return err
+		}
+		var first error
+		for i := 0; i < n; i++ {
+			err = readRedisReply(r)
+			if _, ok := err.(redisError); !ok && err != nil {
+				return err
+			}
+			if first == nil {
+				first = err
+			}
+		}
+		return first
+	}
+	return fmt.Errorf("invalid reply %q", line)
+}
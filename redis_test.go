@@ -0,0 +1,103 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// fakeRedis records the commands sent and replies with canned replies.
+type fakeRedis struct {
+	sent    bytes.Buffer
+	replies io.Reader
+}
+
+func (f *fakeRedis) Write(p []byte) (int, error) { return f.sent.Write(p) }
+func (f *fakeRedis) Read(p []byte) (int, error)  { return f.replies.Read(p) }
+
+// TestRedisHashSink checks the pipelined HSET commands.
+func TestRedisHashSink(t *testing.T) {
+	conn := &fakeRedis{replies: strings.NewReader(":2\r\n:2\r\n-ERR wrong type\r\n")}
+	sink, err := bigcsv.NewRedisHashSink[Number](conn, "number:{{.Integer}}", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []Number{{1, "one"}, {2, "two"}, {3, "three"}} {
+		if err = sink.OnData(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if conn.sent.Len() == 0 || strings.Count(conn.sent.String(), "HSET") != 2 {
+		t.Fatalf("Expected a pipeline of 2 commands, sent %q", conn.sent.String())
+	}
+	if err = sink.Flush(); err == nil || !strings.Contains(err.Error(), "wrong type") {
+		t.Fatalf("Expected error reply, got: %v", err)
+	}
+	if !strings.Contains(conn.sent.String(), "*6\r\n$4\r\nHSET\r\n$8\r\nnumber:3\r\n$7\r\nInteger\r\n$1\r\n3\r\n") {
+		t.Fatalf("Unexpected commands: %q", conn.sent.String())
+	}
+}
+
+// TestRedisSinkBroken checks that the sink refuses further use once replies
+// cannot be read.
+func TestRedisSinkBroken(t *testing.T) {
+	conn := &fakeRedis{replies: strings.NewReader("+OK\r\n+O")}
+	sink, err := bigcsv.NewRedisHashSink[Number](conn, "number:{{.Integer}}", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.OnData(Number{1, "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.OnData(Number{2, "two"}); err == nil {
+		t.Fatal("Expected an error reading the second reply")
+	}
+	sent := conn.sent.Len()
+	if err = sink.OnData(Number{3, "three"}); err == nil {
+		t.Error("Expected an error using the broken connection")
+	}
+	if err = sink.Flush(); err == nil {
+		t.Error("Expected an error flushing the broken connection")
+	}
+	if conn.sent.Len() != sent {
+		t.Errorf("Sent %q over the broken connection", conn.sent.String()[sent:])
+	}
+}
+
+// TestRedisSinkReplyErrors checks that each error reply is reported for the
+// record of its command.
+func TestRedisSinkReplyErrors(t *testing.T) {
+	replies := "-ERR one\r\n:2\r\n-ERR three\r\n"
+	conn := &fakeRedis{replies: strings.NewReader(replies)}
+	sink, err := bigcsv.NewRedisHashSink[Number](conn, "number:{{.Integer}}", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	numbers := []Number{{1, "one"}, {2, "two"}, {3, "three"}}
+	for _, n := range numbers[:2] {
+		if err = sink.OnData(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = sink.OnData(numbers[2])
+	if err == nil || !strings.Contains(err.Error(), "number:1: redis: ERR one") || !strings.Contains(err.Error(), "number:3: redis: ERR three") {
+		t.Fatalf("Expected both error replies, got: %v", err)
+	}
+
+	conn.replies = strings.NewReader(replies)
+	var failed []int
+	sink.OnReplyError = func(n Number, err error) {
+		failed = append(failed, n.Integer)
+	}
+	for _, n := range numbers {
+		if err = sink.OnData(n); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(failed) != 2 || failed[0] != 1 || failed[1] != 3 {
+		t.Fatalf("Got failed records %v, expected 1 and 3", failed)
+	}
+}
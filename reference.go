@@ -0,0 +1,129 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrOrphan is wrapped by an *OrphanError.
+var ErrOrphan = errors.New("value not in reference")
+
+// OrphanError reports a value missing from the Reference of its column, see
+// WithReference. It is passed on as an OnRow error.
+type OrphanError struct {
+	Column string
+	Value  string
+}
+
+func (e *OrphanError) Error() string {
+	return fmt.Sprintf("value %q of %s not found in reference", e.Value, e.Column)
+}
+
+func (e *OrphanError) Unwrap() error {
+	return ErrOrphan
+}
+
+// Reference is the set of valid values of a column, e.g. the keys of another
+// table referenced by a foreign key. It must be safe for concurrent use.
+type Reference interface {
+	Contains(value string) bool
+}
+
+// ReferenceFunc adapts a function to a Reference, e.g. to query a database.
+type ReferenceFunc func(value string) bool
+
+// Contains calls f.
+func (f ReferenceFunc) Contains(value string) bool {
+	return f(value)
+}
+
+// ReferenceSet is a Reference held in memory.
+type ReferenceSet map[string]struct{}
+
+// Contains tells whether the value is in the set.
+func (s ReferenceSet) Contains(value string) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// LoadReference reads the values of a column from another stream into a
+// ReferenceSet. The stream must have a header row. The first row error fails
+// the load.
+func LoadReference(ctx context.Context, stream Stream, column string, opts ...Option) (ReferenceSet, error) {
+	p, err := New[[]string](stream, append(slices.Clone(opts), WithHeaders(), WithErrorPolicy(StopOnError))...)
+	if err != nil {
+		return nil, err
+	}
+	ix, ok := p.ColumnIndex(column)
+	if !ok {
+		p.Close()
+		return nil, fmt.Errorf("no reference column '%s'", column)
+	}
+	set := ReferenceSet{}
+	p.OnRow = func(row []string) error {
+		if ix < len(row) {
+			set[strings.Clone(row[ix])] = struct{}{}
+		}
+		return nil
+	}
+	if err = p.Run(ctx, 1); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// WithReference checks the values of the column against the reference, so
+// that orphans are caught before a database load fails halfway. Rows with
+// values not in the reference are skipped and reported as *OrphanError, before
+// OnRow is called. Empty values are not checked. It requires WithHeaders, and
+// may be given for several columns.
+//
+//	customers, err := bigcsv.LoadReference(ctx, bigcsv.FileStream("customers.csv"), "id")
+//	...
+//	parser, err := bigcsv.New[Order](stream, bigcsv.WithHeaders(), bigcsv.WithReference("customer_id", customers))
+func WithReference(column string, ref Reference) Option {
+	return func(cfg *config) error {
+		if ref == nil {
+			return fmt.Errorf("no reference for column '%s'", column)
+		}
+		cfg.references = append(cfg.references, reference{column: column, ref: ref})
+		return nil
+	}
+}
+
+// reference is a Reference for a column, set by WithReference.
+type reference struct {
+	column string
+	index  int
+	ref    Reference
+}
+
+// bindReferences resolves the columns of the references.
+func (cfg *config) bindReferences(columns map[string]int) ([]reference, error) {
+	refs := slices.Clone(cfg.references)
+	for i := range refs {
+		ix, ok := columns[refs[i].column]
+		if !ok {
+			return nil, fmt.Errorf("no column '%s' for reference", refs[i].column)
+		}
+		refs[i].index = ix
+	}
+	return refs, nil
+}
+
+// checkReferences returns an *OrphanError for the first value of the row not
+// in its reference.
+func checkReferences(refs []reference, row []string) error {
+	for _, r := range refs {
+		if r.index >= len(row) || row[r.index] == "" {
+			continue
+		}
+		if !r.ref.Contains(row[r.index]) {
+			return &OrphanError{Column: r.column, Value: row[r.index]}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestReference checks flagging orphans against a loaded reference set and a
+// callback.
+func TestReference(t *testing.T) {
+	ctx := context.Background()
+	customers, err := bigcsv.LoadReference(ctx, bigcsv.ReadStream(strings.NewReader("name,id\nann,c1\nbob,c2\n")), "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(customers) != 2 || !customers.Contains("c2") {
+		t.Fatalf("Unexpected reference: %v", customers)
+	}
+	isProduct := bigcsv.ReferenceFunc(func(v string) bool { return strings.HasPrefix(v, "p") })
+	parser, err := bigcsv.NewRowParser(
+		bigcsv.ReadStream(strings.NewReader("order,customer,product\n1,c1,p1\n2,c3,p1\n3,,p2\n4,c2,x9\n")),
+		bigcsv.WithHeaders(),
+		bigcsv.WithReference("customer", customers),
+		bigcsv.WithReference("product", isProduct),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orders, orphans []string
+	for row, err := range parser.Rows(ctx) {
+		var orphan *bigcsv.OrphanError
+		switch {
+		case errors.As(err, &orphan) && errors.Is(err, bigcsv.ErrOnRow):
+			orphans = append(orphans, orphan.Column+"="+orphan.Value)
+		case err != nil:
+			t.Fatal(err)
+		default:
+			orders = append(orders, row[0])
+		}
+	}
+	if strings.Join(orders, ",") != "1,3" || strings.Join(orphans, ",") != "customer=c3,product=x9" {
+		t.Errorf("Unexpected orders %q and orphans %q", orders, orphans)
+	}
+
+	if _, err = bigcsv.LoadReference(ctx, bigcsv.ReadStream(strings.NewReader("a\n1\n")), "b"); err == nil {
+		t.Error("Expected an error for a missing reference column")
+	}
+}
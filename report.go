@@ -0,0 +1,236 @@
+package bigcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+)
+
+// ProfileReport summarizes column profiles for people: the inferred type of
+// each column, its most frequent values and the anomalies worth a look. It
+// can be written as JSON or as an HTML page.
+type ProfileReport struct {
+	Rows    int64          `json:"rows"`
+	Columns []ColumnReport `json:"columns"`
+}
+
+// ColumnReport is the part of a ProfileReport on a single column.
+type ColumnReport struct {
+	Name        string       `json:"name"`
+	Type        string       `json:"type"`
+	NullPercent float64      `json:"null_percent"`
+	Distinct    uint64       `json:"distinct"`
+	Min         *float64     `json:"min,omitempty"`
+	Max         *float64     `json:"max,omitempty"`
+	Mean        *float64     `json:"mean,omitempty"`
+	StdDev      *float64     `json:"stddev,omitempty"`
+	MinLength   int          `json:"min_length"`
+	MaxLength   int          `json:"max_length"`
+	Top         []ValueCount `json:"top"`
+	Anomalies   []string     `json:"anomalies,omitempty"`
+
+	// Suggested is the narrowest storage type safe for the values seen:
+	// "int32", "int64", "double", "boolean", "date" (without a time of
+	// day), "timestamp" or "string". Mostly numeric columns get a numeric
+	// type, their offending values are listed in Offending.
+	Suggested string      `json:"suggested_type"`
+	Offending []LineValue `json:"offending,omitempty"`
+}
+
+// Column types inferred by a ProfileReport. A column has the most specific
+// type all its values have, "empty" if it has no values at all.
+const (
+	TypeEmpty   = "empty"
+	TypeInteger = "integer"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+	TypeString  = "string"
+)
+
+// mostly is the share of values of a type at which the remaining values are
+// reported as anomalies.
+const mostly = 0.9
+
+// NewProfileReport builds the report of the column profiles, as found in
+// Stats.Profile or returned by Profiler.Columns.
+func NewProfileReport(columns []ColumnProfile) *ProfileReport {
+	report := &ProfileReport{Columns: make([]ColumnReport, len(columns))}
+	for i, c := range columns {
+		report.Rows = max(report.Rows, c.Count)
+		report.Columns[i] = newColumnReport(c)
+	}
+	return report
+}
+
+// newColumnReport infers the type of a column and looks for anomalies.
+func newColumnReport(c ColumnProfile) ColumnReport {
+	cr := ColumnReport{
+		Name:        c.Name,
+		NullPercent: 100 * c.NullRate(),
+		Distinct:    c.Distinct,
+		MinLength:   c.MinLength,
+		MaxLength:   c.MaxLength,
+		Top:         c.Top,
+	}
+	if cr.Top == nil {
+		cr.Top = []ValueCount{}
+	}
+	values := c.Count - c.Nulls
+	switch {
+	case values == 0:
+		cr.Type = TypeEmpty
+	case c.Integers == values:
+		cr.Type = TypeInteger
+	case c.Numeric == values:
+		cr.Type = TypeNumber
+	case c.Booleans == values:
+		cr.Type = TypeBoolean
+	case c.Dates == values:
+		cr.Type = TypeDate
+	default:
+		cr.Type = TypeString
+	}
+	if c.Numeric > 0 {
+		cr.Min, cr.Max, cr.Mean, cr.StdDev = &c.Min, &c.Max, &c.Mean, &c.StdDev
+	}
+	cr.Suggested = suggestType(c)
+
+	anomaly := func(format string, args ...any) {
+		cr.Anomalies = append(cr.Anomalies, fmt.Sprintf(format, args...))
+	}
+	if cr.Type == TypeString {
+		for _, t := range []struct {
+			name  string
+			count int64
+		}{{"numeric", c.Numeric}, {"dates", c.Dates}} {
+			if float64(t.count) < mostly*float64(values) {
+				continue
+			}
+			if t.name != "numeric" {
+				anomaly("%d of %d values are not %s", values-t.count, values, t.name)
+				continue
+			}
+			cr.Offending = c.NonNumeric
+			anomaly("%d of %d values are not numeric: %s", values-t.count, values, offending(c.NonNumeric, values-t.count))
+		}
+	}
+	if c.NullRate() > 0.5 && values > 0 {
+		anomaly("%.1f%% of values are null", cr.NullPercent)
+	}
+	if c.Distinct == 1 && values > 1 {
+		anomaly("all values are the same")
+	}
+	// Distinct is an estimate, off by a few percent for small counts.
+	if values >= 100 && float64(c.Distinct) >= 0.9*float64(values) && c.Nulls == 0 {
+		anomaly("values are nearly all distinct, likely a key")
+	}
+	if c.Numeric > 1 && c.StdDev > 0 {
+		for _, v := range []struct {
+			name  string
+			value float64
+		}{{"minimum", c.Min}, {"maximum", c.Max}} {
+			if d := math.Abs(v.value-c.Mean) / c.StdDev; d > 6 {
+				anomaly("%s %g is %.0f standard deviations from the mean", v.name, v.value, d)
+			}
+		}
+	}
+	return cr
+}
+
+// suggestType returns the narrowest storage type safe for the values of a
+// column, a numeric type if it is mostly numeric.
+func suggestType(c ColumnProfile) string {
+	values := c.Count - c.Nulls
+	switch {
+	case values == 0:
+		return "string"
+	case c.Numeric > 0 && float64(c.Numeric) >= mostly*float64(values):
+		if c.Integers < c.Numeric {
+			return "double"
+		}
+		if c.Min >= math.MinInt32 && c.Max <= math.MaxInt32 {
+			return "int32"
+		}
+		return "int64"
+	case c.Booleans == values:
+		return "boolean"
+	case c.Dates == values && c.Timestamps == 0:
+		return "date"
+	case c.Dates == values:
+		return "timestamp"
+	}
+	return "string"
+}
+
+// offending lists the values keeping a column from being numeric, n in total.
+func offending(values []LineValue, n int64) string {
+	list := make([]string, len(values))
+	for i, v := range values {
+		list[i] = fmt.Sprintf("%q on line %d", v.Value, v.Line)
+	}
+	if more := n - int64(len(values)); more > 0 {
+		list = append(list, fmt.Sprintf("%d more", more))
+	}
+	return strings.Join(list, ", ")
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *ProfileReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteHTML writes the report as a self-contained HTML page.
+func (r *ProfileReport) WriteHTML(w io.Writer) error {
+	return reportTemplate.Execute(w, r)
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"num": func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return fmt.Sprintf("%.6g", *f)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Data profile</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+.anomaly { color: #b00; }
+</style>
+</head>
+<body>
+<h1>Data profile</h1>
+<p>{{.Rows}} rows, {{len .Columns}} columns</p>
+<table>
+<tr><th>Column</th><th>Type</th><th>Suggested</th><th>Null %</th><th>Distinct</th><th>Min</th><th>Max</th><th>Mean</th><th>Std. dev.</th><th>Length</th><th>Top values</th><th>Anomalies</th></tr>
+{{- range .Columns}}
+<tr>
+<td>{{.Name}}</td>
+<td>{{.Type}}</td>
+<td>{{.Suggested}}</td>
+<td>{{printf "%.1f" .NullPercent}}</td>
+<td>{{.Distinct}}</td>
+<td>{{num .Min}}</td>
+<td>{{num .Max}}</td>
+<td>{{num .Mean}}</td>
+<td>{{num .StdDev}}</td>
+<td>{{.MinLength}}–{{.MaxLength}}</td>
+<td>{{range .Top}}{{.Value}} ({{.Count}})<br>{{end}}</td>
+<td class="anomaly">{{range .Anomalies}}{{.}}<br>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,107 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestProfileReport checks the inferred types, top values and anomalies.
+func TestProfileReport(t *testing.T) {
+	profiler := bigcsv.NewProfiler([]string{"id", "amount", "flag", "day", "status"})
+	for i := 1; i <= 200; i++ {
+		amount := fmt.Sprint(float64(i%50) / 4)
+		if i == 7 {
+			amount = "n/a"
+		}
+		status := "open"
+		if i%3 == 0 {
+			status = "closed"
+		}
+		profiler.OnRow([]string{fmt.Sprint(i), amount, "true", fmt.Sprintf("2024-01-%02d", i%28+1), status})
+	}
+	report := bigcsv.NewProfileReport(profiler.Columns())
+	if report.Rows != 200 || len(report.Columns) != 5 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	types := []string{bigcsv.TypeInteger, bigcsv.TypeString, bigcsv.TypeBoolean, bigcsv.TypeDate, bigcsv.TypeString}
+	for i, c := range report.Columns {
+		if c.Type != types[i] {
+			t.Errorf("Expected %s to be %s, got %s", c.Name, types[i], c.Type)
+		}
+	}
+	if a := report.Columns[0].Anomalies; len(a) != 1 || a[0] != "values are nearly all distinct, likely a key" {
+		t.Errorf("Unexpected id anomalies: %q", a)
+	}
+	if a := report.Columns[1].Anomalies; len(a) != 1 || a[0] != `1 of 200 values are not numeric: "n/a" on line 7` {
+		t.Errorf("Unexpected amount anomalies: %q", a)
+	}
+	suggested := []string{"int32", "double", "boolean", "date", "string"}
+	for i, c := range report.Columns {
+		if c.Suggested != suggested[i] {
+			t.Errorf("Expected %s to be suggested as %s, got %s", c.Name, suggested[i], c.Suggested)
+		}
+	}
+	if o := report.Columns[1].Offending; len(o) != 1 || o[0] != (bigcsv.LineValue{Line: 7, Value: "n/a"}) {
+		t.Errorf("Unexpected offending amounts: %+v", o)
+	}
+	if a := report.Columns[2].Anomalies; len(a) != 1 || a[0] != "all values are the same" {
+		t.Errorf("Unexpected flag anomalies: %q", a)
+	}
+	top := report.Columns[4].Top
+	if len(top) != 2 || top[0] != (bigcsv.ValueCount{Value: "open", Count: 134}) || top[1].Value != "closed" {
+		t.Errorf("Unexpected top values: %+v", top)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded bigcsv.ProfileReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Columns[3].Type != "date" {
+		t.Errorf("Unexpected JSON %s: %v", buf.String(), err)
+	}
+	buf.Reset()
+	if err := report.WriteHTML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if html := buf.String(); !strings.Contains(html, "<td>amount</td>") || !strings.Contains(html, "open (134)") {
+		t.Errorf("Unexpected HTML: %s", html)
+	}
+}
+
+// TestTypeNarrowing suggests storage types for a profiled Run, listing the
+// lines of values keeping a column from being numeric.
+func TestTypeNarrowing(t *testing.T) {
+	var sb strings.Builder
+	sb.WriteString("big,at,qty\n")
+	for i := 1; i <= 20; i++ {
+		qty := fmt.Sprint(i)
+		if i == 5 || i == 12 {
+			qty = "-"
+		}
+		fmt.Fprintf(&sb, "%d,2024-03-%02dT10:00:00Z,%s\n", i*1e9, i, qty)
+	}
+	parser, err := bigcsv.NewFromString[[]string](sb.String(), bigcsv.WithHeaders(), bigcsv.WithProfile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnRow = func([]string) error { return nil }
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	report := bigcsv.NewProfileReport(parser.Stats().Profile)
+	for i, suggested := range []string{"int64", "timestamp", "int32"} {
+		if c := report.Columns[i]; c.Suggested != suggested {
+			t.Errorf("Expected %s to be suggested as %s, got %s", c.Name, suggested, c.Suggested)
+		}
+	}
+	if a := report.Columns[2].Anomalies; len(a) != 1 || a[0] != `2 of 20 values are not numeric: "-" on line 6, "-" on line 13` {
+		t.Errorf("Unexpected qty anomalies: %q", a)
+	}
+}
@@ -0,0 +1,114 @@
+package bigcsv
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// Reset re-opens the Parser on the given stream, so the same configured Parser
+// can process the stream again or process another stream. If stream is nil,
+// the current stream is opened again, which must support it (e.g. FileStream
+// or HTTPStream, but not ReadStream). It returns ErrRunning while the Parser
+// is running.
+//
+// The settings of the Reader are carried over to the new Reader, and rows are
+// skipped and headers read again according to the options.
+func (p *Parser[T]) Reset(stream Stream) error {
+	if _, err := p.acquire(); err != nil {
+		return err
+	}
+	defer func() {
+		if p.closed {
+			p.release(StateClosed)
+		} else {
+			p.release(StateConfigured)
+		}
+	}()
+	if stream == nil {
+		stream = p.stream
+	}
+	if err := p.close(); err != nil {
+		return fmt.Errorf("could not close stream: %w", err)
+	}
+	r, err := stream.Open()
+	if err != nil {
+		return fmt.Errorf("could not open stream: %w", err)
+	}
+	p.cfg.log(context.Background(), "bigcsv: stream opened", "stream", describe(stream))
+
+	p.stream = stream
+	p.provenance = provenanceOf(stream)
+	p.closed = false
+	p.reattach(r)
+	if p.Reader != nil && p.prepared {
+		p.Reader.FieldsPerRecord = p.fieldsPerRecord
+	}
+	p.headers = nil
+	p.columns = nil
+	p.refs = nil
+	p.cleanups = nil
+	p.defaults = nil
+	p.schema = nil
+	p.hashColumns = nil
+	p.consumed = 0
+	p.offsetBase = 0
+	p.lastLine.Store(0)
+	p.lastOffset.Store(0)
+	p.tally.Store(nil)
+	p.peeked = nil
+	p.peekedEOF = false
+	p.prepared = false
+	p.prepareErr = nil
+	p.inBody = false
+	p.bodyRows = 0
+	p.trailer = nil
+	p.metaHeader = nil
+	p.metaFooter = nil
+	p.inFooter = false
+	return nil
+}
+
+// reattach reads from r with a new Reader, carrying over the settings of the
+// current one.
+func (p *Parser[T]) reattach(r io.ReadCloser) {
+	old := p.Reader
+	p.attach(r)
+	if reader := p.Reader; reader != nil && old != nil {
+		reader.Comma = old.Comma
+		reader.Comment = old.Comment
+		reader.FieldsPerRecord = old.FieldsPerRecord
+		reader.LazyQuotes = old.LazyQuotes
+		reader.TrimLeadingSpace = old.TrimLeadingSpace
+		reader.ReuseRecord = old.ReuseRecord
+	}
+}
+
+// Close closes the stream. It is only needed when using WithKeepOpen, or when
+// not processing the Parser at all. Closing more than once has no effect. It
+// returns ErrRunning while the Parser is running.
+func (p *Parser[T]) Close() error {
+	if _, err := p.acquire(); err != nil {
+		return err
+	}
+	defer p.release(StateClosed)
+	return p.close()
+}
+
+// finish closes the stream after processing, unless it is kept open.
+func (p *Parser[T]) finish() error {
+	if !p.cfg.keepOpen {
+		return p.close()
+	}
+	return nil
+}
+
+// close closes the stream once.
+func (p *Parser[T]) close() error {
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	p.cfg.log(context.Background(), "bigcsv: stream closed", "stream", describe(p.stream))
+	return p.closer.Close()
+}
@@ -0,0 +1,82 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestReset runs the same Parser twice over a file, as a validation pass
+// followed by a load pass.
+func TestReset(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "numbers.csv")
+	if err := os.WriteFile(filename, []byte("int;name\n1;one\n2;two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	parser, err := bigcsv.New[Number](bigcsv.FileStream(filename), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Reader.Comma = ';'
+	parser.Parse = ParseNumber
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	n := 0
+	parser.OnData = func(Number) error {
+		n++
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrClosed) {
+		t.Fatalf("Expected ErrClosed running twice, got: %v", err)
+	}
+	if err = parser.Reset(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 {
+		t.Fatalf("Processed %d rows over two runs, expected 4", n)
+	}
+}
+
+// TestKeepOpen reads trailing content after stopping a Run early, and checks
+// that line numbers continue.
+func TestKeepOpen(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\nx,three\n", bigcsv.WithKeepOpen())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer parser.Close()
+	parser.Parse = ParseNumber
+	ctx, cancel := context.WithCancel(context.Background())
+	parser.OnData = func(n Number) error {
+		if n.Integer == 2 {
+			cancel()
+		}
+		return nil
+	}
+	if err = parser.Run(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected cancellation, got: %v", err)
+	}
+	for _, err = range parser.Rows(context.Background()) {
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Fatalf("Expected parse error on line 3, got: %v", err)
+		}
+	}
+	if err = parser.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrClosed) {
+		t.Fatalf("Expected ErrClosed after Close, got: %v", err)
+	}
+}
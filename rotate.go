@@ -0,0 +1,191 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotateOptions configures a RotatingWriter.
+type RotateOptions struct {
+	// MaxBytes starts a new file once the current one reached about that
+	// size, unlimited if 0. The size is estimated without quoting.
+	MaxBytes int64
+
+	// MaxAge starts a new file for records arriving that long after the
+	// current one was created, unlimited if 0.
+	MaxAge time.Duration
+
+	// Partition is the value of {partition} in the name template.
+	Partition string
+
+	// Create opens a file for writing, by default creating it along with its
+	// directory.
+	Create func(name string) (io.WriteCloser, error)
+}
+
+// RotatingWriter is a Writer starting new files by size or age, for long
+// running pipelines. File names are expanded from a template with the fields
+//
+//	{date}       the date the file was created, as 2006-01-02
+//	{time}       the time the file was created, as 150405
+//	{partition}  RotateOptions.Partition
+//	{seq}        the sequence number of the file, from 0001
+//
+// Each file starts with the header row. Its OnData is safe for use with
+// multiple workers. Close must be called at the end.
+type RotatingWriter[T any] struct {
+	template string
+	opts     RotateOptions
+	wopts    []WriterOption
+
+	mu      sync.Mutex
+	seq     int
+	names   []string
+	created time.Time
+	size    int64 // estimated
+	out     io.WriteCloser
+	w       *Writer[T]
+}
+
+// templateField matches the fields of a name template.
+var templateField = regexp.MustCompile(`\{[^}]*\}`)
+
+// NewRotatingWriter creates a RotatingWriter naming files after template. The
+// first file is created with the first record.
+//
+//	w, err := bigcsv.NewRotatingWriter[Event]("out/{date}/events-{seq}.csv", bigcsv.RotateOptions{MaxBytes: 1 << 30})
+func NewRotatingWriter[T any](template string, opts RotateOptions, wopts ...WriterOption) (*RotatingWriter[T], error) {
+	for _, field := range templateField.FindAllString(template, -1) {
+		switch field {
+		case "{date}", "{time}", "{partition}", "{seq}":
+		default:
+			return nil, fmt.Errorf("unknown field %s in name template", field)
+		}
+	}
+	if opts.MaxBytes < 0 || opts.MaxAge < 0 {
+		return nil, fmt.Errorf("invalid rotation limits")
+	}
+	if opts.Create == nil {
+		opts.Create = createFile
+	}
+	// Check the writer options early.
+	if _, err := NewWriter[T](io.Discard, wopts...); err != nil {
+		return nil, err
+	}
+	return &RotatingWriter[T]{template: template, opts: opts, wopts: wopts}, nil
+}
+
+// createFile creates a file along with its directory.
+func createFile(name string) (io.WriteCloser, error) {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return nil, err
+	}
+	return os.Create(name)
+}
+
+// Name expands the name template for the file created at t with the sequence
+// number seq.
+func (r *RotatingWriter[T]) Name(t time.Time, seq int) string {
+	return strings.NewReplacer(
+		"{date}", t.Format(time.DateOnly),
+		"{time}", t.Format("150405"),
+		"{partition}", r.opts.Partition,
+		"{seq}", fmt.Sprintf("%04d", seq),
+	).Replace(r.template)
+}
+
+// Files returns the names of the files created so far.
+func (r *RotatingWriter[T]) Files() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.names...)
+}
+
+// OnData writes the record, first starting a new file if due.
+func (r *RotatingWriter[T]) OnData(data T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.w == nil || r.due() {
+		if err := r.rotate(); err != nil {
+			return err
+		}
+	}
+	row, err := r.w.format(data)
+	if err != nil {
+		return err
+	}
+	r.size += estimateSize(row)
+	return r.w.Write(row)
+}
+
+// Rotate starts a new file with the next record.
+func (r *RotatingWriter[T]) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
+
+// Close flushes and closes the current file.
+func (r *RotatingWriter[T]) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
+
+// due tells whether the current file reached its limits. The caller holds the
+// lock.
+func (r *RotatingWriter[T]) due() bool {
+	return r.opts.MaxBytes > 0 && r.size >= r.opts.MaxBytes ||
+		r.opts.MaxAge > 0 && time.Since(r.created) >= r.opts.MaxAge
+}
+
+// rotate closes the current file and creates the next. The caller holds the
+// lock.
+func (r *RotatingWriter[T]) rotate() error {
+	if err := r.close(); err != nil {
+		return err
+	}
+	r.seq++
+	r.created = time.Now()
+	name := r.Name(r.created, r.seq)
+	out, err := r.opts.Create(name)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", name, err)
+	}
+	r.out, r.size = out, 0
+	r.names = append(r.names, name)
+	if r.w, err = NewWriter[T](out, r.wopts...); err != nil {
+		return err
+	}
+	if !r.w.cfg.noHeader {
+		r.size = estimateSize(r.w.header)
+	}
+	return nil
+}
+
+// close flushes and closes the current file, if any. The caller holds the
+// lock.
+func (r *RotatingWriter[T]) close() error {
+	if r.w == nil {
+		return nil
+	}
+	err := errors.Join(r.w.Flush(), r.out.Close())
+	r.w, r.out = nil, nil
+	return err
+}
+
+// estimateSize returns the size of a row as CSV, ignoring quotes.
+func estimateSize(row []string) int64 {
+	var n int64
+	for _, field := range row {
+		n += int64(len(field)) + 1 // with the comma or newline
+	}
+	return n
+}
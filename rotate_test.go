@@ -0,0 +1,49 @@
+package bigcsv_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRotatingWriter checks starting new files by size, each with a header.
+func TestRotatingWriter(t *testing.T) {
+	dir := t.TempDir()
+	w, err := bigcsv.NewRotatingWriter[[]string](filepath.Join(dir, "{date}", "out-{partition}-{seq}.csv"),
+		bigcsv.RotateOptions{MaxBytes: 20, Partition: "eu"}, bigcsv.WithHeader("id", "name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"1", "alpha"}, {"2", "beta"}, {"3", "gamma"}, {"4", "delta"}, {"5", "eps"}} {
+		if err = w.OnData(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	files := w.Files()
+	date := time.Now().Format(time.DateOnly)
+	if len(files) != 3 || files[2] != filepath.Join(dir, date, "out-eu-0003.csv") {
+		t.Fatalf("Unexpected files: %q", files)
+	}
+	var contents []string
+	for _, name := range files {
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(b))
+	}
+	if want := "id,name\n1,alpha\n2,beta\n|id,name\n3,gamma\n4,delta\n|id,name\n5,eps\n"; strings.Join(contents, "|") != want {
+		t.Errorf("Expected %q, got %q", want, strings.Join(contents, "|"))
+	}
+
+	if _, err = bigcsv.NewRotatingWriter[[]string]("{host}.csv", bigcsv.RotateOptions{}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
@@ -0,0 +1,163 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// ErrNoColumn is returned when accessing a column which does not exist.
+var ErrNoColumn = errors.New("no such column")
+
+// ColumnError reports a failure to access or convert a column value.
+type ColumnError struct {
+	// Column is the header name, if known.
+	Column string
+
+	// Index is the zero-based column index, or -1 if the name is unknown.
+	Index int
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ColumnError) Error() string {
+	switch {
+	case e.Index < 0:
+		return fmt.Sprintf("column '%s': %v", e.Column, e.Err)
+	case e.Column == "":
+		return fmt.Sprintf("column %d: %v", e.Index+1, e.Err)
+	default:
+		return fmt.Sprintf("column '%s' (%d): %v", e.Column, e.Index+1, e.Err)
+	}
+}
+
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
+
+// Row wraps a raw row with typed accessors, looking up columns by header name
+// or by index. Conversion errors are *ColumnError values naming the column.
+type Row struct {
+	// Fields are the raw values of the row.
+	Fields []string
+
+	headers     []string
+	columns     map[string]int
+	hashColumns []int
+}
+
+// Row wraps the fields with the Parser's headers, e.g. for use in Parse.
+func (p *Parser[T]) Row(fields []string) Row {
+	return Row{Fields: fields, headers: p.headers, columns: p.columns, hashColumns: p.hashColumns}
+}
+
+// NewRows creates a Parser passing each row to OnData as a Row, so quick
+// scripts need neither a data type nor a Parse function. Its Parse is already
+// set. Use WithHeaders to access columns by name.
+func NewRows(stream Stream, opts ...Option) (*Parser[Row], error) {
+	p, err := New[Row](stream, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Parse = func(row []string) (Row, error) {
+		return p.Row(slices.Clone(row)), nil
+	}
+	return p, nil
+}
+
+// GetStringAt returns the value at the zero-based index.
+func (r Row) GetStringAt(ix int) (string, error) {
+	if ix < 0 || ix >= len(r.Fields) {
+		return "", r.errAt(ix, ErrNoColumn)
+	}
+	return r.Fields[ix], nil
+}
+
+// GetString returns the value of the named column.
+func (r Row) GetString(name string) (string, error) {
+	ix, ok := r.columns[name]
+	if !ok {
+		return "", &ColumnError{Column: name, Index: -1, Err: ErrNoColumn}
+	}
+	return r.GetStringAt(ix)
+}
+
+// GetIntAt converts the value at the zero-based index to an int.
+func (r Row) GetIntAt(ix int) (int, error) {
+	s, err := r.GetStringAt(ix)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, r.errAt(ix, err)
+	}
+	return i, nil
+}
+
+// GetInt converts the value of the named column to an int.
+func (r Row) GetInt(name string) (int, error) {
+	ix, ok := r.columns[name]
+	if !ok {
+		return 0, &ColumnError{Column: name, Index: -1, Err: ErrNoColumn}
+	}
+	return r.GetIntAt(ix)
+}
+
+// GetFloatAt converts the value at the zero-based index to a float64.
+func (r Row) GetFloatAt(ix int) (float64, error) {
+	s, err := r.GetStringAt(ix)
+	if err != nil {
+		return 0, err
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, r.errAt(ix, err)
+	}
+	return f, nil
+}
+
+// GetFloat converts the value of the named column to a float64.
+func (r Row) GetFloat(name string) (float64, error) {
+	ix, ok := r.columns[name]
+	if !ok {
+		return 0, &ColumnError{Column: name, Index: -1, Err: ErrNoColumn}
+	}
+	return r.GetFloatAt(ix)
+}
+
+// GetTimeAt parses the value at the zero-based index using the layout, see
+// time.Parse.
+func (r Row) GetTimeAt(ix int, layout string) (time.Time, error) {
+	s, err := r.GetStringAt(ix)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, r.errAt(ix, err)
+	}
+	return t, nil
+}
+
+// GetTime parses the value of the named column using the layout, see
+// time.Parse.
+func (r Row) GetTime(name string, layout string) (time.Time, error) {
+	ix, ok := r.columns[name]
+	if !ok {
+		return time.Time{}, &ColumnError{Column: name, Index: -1, Err: ErrNoColumn}
+	}
+	return r.GetTimeAt(ix, layout)
+}
+
+// errAt wraps err as a *ColumnError for the zero-based index.
+func (r Row) errAt(ix int, err error) error {
+	e := &ColumnError{Index: ix, Err: err}
+	if ix >= 0 && ix < len(r.headers) {
+		e.Column = r.headers[ix]
+	}
+	return e
+}
@@ -0,0 +1,89 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRowAccessors checks the typed accessors and their errors.
+func TestRowAccessors(t *testing.T) {
+	parser, err := bigcsv.NewRows(
+		bigcsv.ReadStream(strings.NewReader("name,count,ratio,date\nfoo,3,0.5,2024-12-31\nbar,x,1,2024-01-01\n")),
+		bigcsv.WithHeaders(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []bigcsv.Row
+	for row, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("Got %d rows, expected 2", len(rows))
+	}
+
+	name, _ := rows[0].GetString("name")
+	count, err1 := rows[0].GetInt("count")
+	ratio, err2 := rows[0].GetFloatAt(2)
+	date, err3 := rows[0].GetTime("date", time.DateOnly)
+	if err = errors.Join(err1, err2, err3); err != nil {
+		t.Fatal(err)
+	}
+	if name != "foo" || count != 3 || ratio != 0.5 || date.Year() != 2024 {
+		t.Fatalf("Unexpected values: %s, %d, %f, %v", name, count, ratio, date)
+	}
+
+	_, err = rows[1].GetInt("count")
+	var colErr *bigcsv.ColumnError
+	if !errors.As(err, &colErr) || colErr.Column != "count" || colErr.Index != 1 {
+		t.Fatalf("Expected column error, got: %v", err)
+	}
+	if _, err = rows[1].GetString("missing"); !errors.Is(err, bigcsv.ErrNoColumn) {
+		t.Fatalf("Expected ErrNoColumn, got: %v", err)
+	}
+}
+
+// TestParseHelper checks that the first conversion error names the column and
+// the line.
+func TestParseHelper(t *testing.T) {
+	parser, err := bigcsv.NewFromString[float64]("name,Walkability\na,12.5\nb,n/a\n", bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) (float64, error) {
+		h := parser.Helper(row)
+		_ = h.String("name")
+		return h.Float("Walkability"), h.Err()
+	}
+	var values []float64
+	parser.OnData = func(f float64) error {
+		values = append(values, f)
+		return nil
+	}
+	var errs []string
+	parser.OnError = func(err error) {
+		errs = append(errs, err.Error())
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := `Parse error: line 3 (offset 24): column 'Walkability' (2): invalid float "n/a"`
+	if len(values) != 1 || values[0] != 12.5 || len(errs) != 1 || errs[0] != expected {
+		t.Fatalf("Got values %v, errors %q", values, errs)
+	}
+
+	h := parser.Helper([]string{"x", "1"})
+	h.Int("missing")
+	h.Int("name")
+	if !errors.Is(h.Err(), bigcsv.ErrNoColumn) {
+		t.Errorf("Expected the first error for the missing column, got: %v", h.Err())
+	}
+}
@@ -0,0 +1,85 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestRowHash checks that hashes only depend on the selected columns.
+func TestRowHash(t *testing.T) {
+	parser, err := bigcsv.NewFromString[[]string]("id,name,loaded\n1,ann,mon\n1,ann,tue\n1,bob,tue\n",
+		bigcsv.WithHeaders(), bigcsv.WithRowHash("id", "name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes []uint64
+	parser.OnRow = func(row []string) error {
+		hashes = append(hashes, parser.RowHash(row))
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(hashes) != 3 || hashes[0] != hashes[1] || hashes[1] == hashes[2] {
+		t.Errorf("Got hashes %x, expected the first two to match only", hashes)
+	}
+	if h := parser.Row([]string{"1", "ann", "wed"}).Hash(); h != hashes[0] {
+		t.Errorf("Got row hash %x, expected %x", h, hashes[0])
+	}
+	all, err := bigcsv.NewFromString[[]string]("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := all.RowHash([]string{"a", "b"}); h != 0xcb005d77e97d7b40 {
+		t.Errorf("Got hash %x, expected a stable one", h)
+	}
+	if all.RowHash([]string{"ab", "c"}) == all.RowHash([]string{"a", "bc"}) {
+		t.Error("Expected different hashes for fields split differently")
+	}
+	if _, err = bigcsv.NewFromString[[]string]("a\n", bigcsv.WithRowHash("a")); err == nil {
+		t.Error("Expected an error for row hash columns without headers")
+	}
+}
+
+type hashedCustomer struct {
+	ID   string `csv:"id"`
+	Name string `csv:"name"`
+	Hash string `csv:"row_hash,rowhash"`
+}
+
+// TestRowHashTag sets a struct field to the row hash and writes it along.
+func TestRowHashTag(t *testing.T) {
+	input := "id,name\nc1,ann\nc2,bob\n"
+	parser, err := bigcsv.NewFromString[hashedCustomer](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	w, err := bigcsv.NewWriter[hashedCustomer](&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnData = w.OnData
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("id,name,row_hash\nc1,ann,%016x\nc2,bob,%016x\n",
+		parser.RowHash([]string{"c1", "ann"}), parser.RowHash([]string{"c2", "bob"}))
+	if out.String() != want {
+		t.Fatalf("Output is %q, expected %q", out.String(), want)
+	}
+
+	type badHash struct {
+		Hash int `csv:"hash,rowhash"`
+	}
+	if _, err = bigcsv.NewFromString[badHash]("a\n", bigcsv.WithStructTags()); err == nil {
+		t.Error("Expected an error for an int row hash field")
+	}
+}
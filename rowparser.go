@@ -0,0 +1,49 @@
+package bigcsv
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RowParser is a Parser whose OnData receives the raw rows, for tools
+// processing arbitrary CSVs without a data type known at compile time.
+type RowParser = Parser[[]string]
+
+// MapParser is a Parser whose OnData receives each row as a map from header to
+// value.
+type MapParser = Parser[map[string]string]
+
+// NewRowParser creates a RowParser. Its Parse is already set and passes on a
+// copy of each row.
+func NewRowParser(stream Stream, opts ...Option) (*RowParser, error) {
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Parse = func(row []string) ([]string, error) {
+		return slices.Clone(row), nil
+	}
+	return p, nil
+}
+
+// NewMapParser creates a MapParser. The header row is always consumed, as if
+// WithHeaders was given, and its Parse is already set. Rows with more fields
+// than headers are a Parse error, fields missing from short rows are not set
+// in the map.
+func NewMapParser(stream Stream, opts ...Option) (*MapParser, error) {
+	p, err := New[map[string]string](stream, append(opts, WithHeaders())...)
+	if err != nil {
+		return nil, err
+	}
+	p.Parse = func(row []string) (map[string]string, error) {
+		if len(row) > len(p.headers) {
+			return nil, fmt.Errorf("got %d columns, but only %d headers", len(row), len(p.headers))
+		}
+		m := make(map[string]string, len(row))
+		for i, v := range row {
+			m[p.headers[i]] = v
+		}
+		return m, nil
+	}
+	return p, nil
+}
@@ -0,0 +1,35 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestMapParser checks processing rows as maps keyed by header.
+func TestMapParser(t *testing.T) {
+	parser, err := bigcsv.NewMapParser(bigcsv.ReadStream(strings.NewReader("int,name\n1,one\n2,two\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mu := sync.Mutex{}
+	names := map[string]string{}
+	parser.OnData = func(m map[string]string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		names[m["int"]] = m["name"]
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err = parser.Run(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names["2"] != "two" {
+		t.Fatalf("Unexpected rows: %v", names)
+	}
+}
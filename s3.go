@@ -0,0 +1,112 @@
+package bigcsv
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"runtime"
+	"strings"
+)
+
+// S3Client fetches objects from S3. Implement it by wrapping the GetObject
+// method of an AWS SDK client.
+type S3Client interface {
+	GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error)
+}
+
+// S3Stream provides a reader for an S3 object, decompressing gzip if the key
+// ends in .gz or the object starts with the gzip magic number.
+type S3Stream struct {
+	Client S3Client
+	Bucket string
+	Key    string
+
+	// Context is used for the request, context.Background if nil.
+	Context context.Context
+}
+
+func (s S3Stream) Open() (io.ReadCloser, error) {
+	ctx := s.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	body, err := s.Client.GetObject(ctx, s.Bucket, s.Key)
+	if err != nil {
+		return nil, fmt.Errorf("could not get s3://%s/%s: %w", s.Bucket, s.Key, err)
+	}
+	r := bufio.NewReader(body)
+	magic, _ := r.Peek(2)
+	if !strings.HasSuffix(strings.ToLower(s.Key), ".gz") && string(magic) != "\x1f\x8b" {
+		return readCloser{r, body}, nil
+	}
+	gz, err := gunzip(readCloser{r, body})
+	if err != nil {
+		body.Close()
+		return nil, fmt.Errorf("gzip failed for s3://%s/%s: %w", s.Bucket, s.Key, err)
+	}
+	return gz, nil
+}
+
+// readCloser reads from a wrapping reader, closing the underlying one.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// S3Event is an S3 event notification as received by AWS Lambda, compatible
+// with the JSON of events.S3Event.
+type S3Event struct {
+	Records []S3EventRecord `json:"Records"`
+}
+
+// S3EventRecord is a single record of an S3Event. Only the fields needed to
+// locate the object are decoded.
+type S3EventRecord struct {
+	EventName string `json:"eventName"`
+	S3        struct {
+		Bucket struct {
+			Name string `json:"name"`
+		} `json:"bucket"`
+		Object struct {
+			Key  string `json:"key"`
+			Size int64  `json:"size"`
+		} `json:"object"`
+	} `json:"s3"`
+}
+
+// Stream returns the S3Stream of the object in the event. Object keys in
+// events are URL-encoded, and decoded here.
+func (rec S3EventRecord) Stream(ctx context.Context, client S3Client) (S3Stream, error) {
+	key, err := url.QueryUnescape(rec.S3.Object.Key)
+	if err != nil {
+		return S3Stream{}, fmt.Errorf("invalid object key %q: %w", rec.S3.Object.Key, err)
+	}
+	return S3Stream{Client: client, Bucket: rec.S3.Bucket.Name, Key: key, Context: ctx}, nil
+}
+
+// NewFromS3Event creates a Parser for the object of an S3 event record, the
+// common case of a Lambda function processing uploaded CSV files:
+//
+//	func handler(ctx context.Context, event bigcsv.S3Event) error {
+//		for _, rec := range event.Records {
+//			parser, err := bigcsv.NewFromS3Event[Place](ctx, client, rec, bigcsv.WithHeaders())
+//			...
+//		}
+//	}
+//
+// Its defaults are a worker per CPU and skipping blank lines, the options are
+// applied after them. Like New, it does not log unless given WithLogger, e.g.
+// WithLogger(slog.Default(), slog.LevelInfo) to log to CloudWatch via Lambda.
+func NewFromS3Event[T any](ctx context.Context, client S3Client, rec S3EventRecord, opts ...Option) (*Parser[T], error) {
+	stream, err := rec.Stream(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	defaults := []Option{
+		WithWorkers(runtime.GOMAXPROCS(0)),
+		WithSkipBlankLines(),
+	}
+	return New[T](stream, append(defaults, opts...)...)
+}
@@ -0,0 +1,54 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// objects is an S3Client serving objects from memory.
+type objects map[string][]byte
+
+func (o objects) GetObject(ctx context.Context, bucket, key string) (io.ReadCloser, error) {
+	data, ok := o[bucket+"/"+key]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %s", key)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// TestNewFromS3Event parses a gzipped object named by a Lambda event.
+func TestNewFromS3Event(t *testing.T) {
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("int,name\n1,one\n\n2,two\n"))
+	w.Close()
+	client := objects{"uploads/daily numbers": gz.Bytes()}
+
+	var event bigcsv.S3Event
+	err := json.Unmarshal([]byte(`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"uploads"},"object":{"key":"daily+numbers","size":42}}}]}`), &event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := bigcsv.NewFromS3Event[Number](context.Background(), client, event.Records[0], bigcsv.WithHeaders(), bigcsv.WithWorkers(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	var numbers []Number
+	for n, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) != 2 || numbers[1].String != "two" {
+		t.Fatalf("Unexpected numbers: %v", numbers)
+	}
+}
@@ -0,0 +1,86 @@
+package bigcsv
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"slices"
+)
+
+// Sample writes a uniform random sample of n rows of the stream to dst as
+// CSV, e.g. a 100k row fixture of a file with hundreds of millions of rows for
+// development and tests. It reads the stream once, holding only the sample in
+// memory, by reservoir sampling (Algorithm L). The rows keep their order, and
+// the header row comes first if consumed with WithHeaders. The delimiter is
+// that of the stream. Streams with no more than n rows are copied entirely.
+//
+// Rows are read sequentially, and a row error fails sampling.
+func Sample(ctx context.Context, stream Stream, dst io.Writer, n int, opts ...Option) error {
+	if n < 1 {
+		return fmt.Errorf("invalid sample size: %d", n)
+	}
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return err
+	}
+	p.Parse = func(row []string) ([]string, error) { return row, nil }
+	headers := p.Headers()
+	comma := ','
+	if p.Reader != nil {
+		comma = p.Reader.Comma
+	}
+
+	type sampled struct {
+		seq int
+		row []string
+	}
+	reservoir := make([]sampled, 0, min(n, 1<<16))
+	// random returns a number in (0, 1], so that its logarithm is finite.
+	random := func() float64 { return 1 - rand.Float64() }
+	w := math.Exp(math.Log(random()) / float64(n))
+	next := n + skipLength(w, random()) + 1
+	seq := 0
+	for row, err := range p.Rows(ctx) {
+		if err != nil {
+			return err
+		}
+		seq++
+		switch {
+		case seq <= n:
+			reservoir = append(reservoir, sampled{seq, slices.Clone(row)})
+		case seq == next:
+			reservoir[rand.IntN(n)] = sampled{seq, slices.Clone(row)}
+			w *= math.Exp(math.Log(random()) / float64(n))
+			next += skipLength(w, random()) + 1
+		}
+	}
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	slices.SortFunc(reservoir, func(a, b sampled) int { return cmp.Compare(a.seq, b.seq) })
+	cw := csv.NewWriter(dst)
+	cw.Comma = comma
+	if headers != nil {
+		cw.Write(headers)
+	}
+	for _, s := range reservoir {
+		cw.Write(s.row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// skipLength returns the number of rows to skip before the next row enters the
+// reservoir, given the weight w of Algorithm L and a random number in (0, 1].
+func skipLength(w, random float64) int {
+	skip := math.Floor(math.Log(random) / math.Log(1-w))
+	if math.IsNaN(skip) || skip > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(skip)
+}
@@ -0,0 +1,49 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSample samples rows uniformly, keeping their order.
+func TestSample(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id;name\n")
+	for i := range 10000 {
+		fmt.Fprintf(&b, "%d;row %d\n", i, i)
+	}
+	var out bytes.Buffer
+	err := bigcsv.Sample(context.Background(), bigcsv.ReadStream(strings.NewReader(b.String())), &out, 200, bigcsv.WithComma(';'), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 201 || lines[0] != "id;name" {
+		t.Fatalf("Got %d lines starting with %q, expected the header and 200 rows", len(lines), lines[0])
+	}
+	last, sum := -1, 0
+	for _, line := range lines[1:] {
+		id, err := strconv.Atoi(strings.Split(line, ";")[0])
+		if err != nil || id <= last {
+			t.Fatalf("Unexpected row %q after id %d", line, last)
+		}
+		last, sum = id, sum+id
+	}
+	if mean := sum / 200; mean < 4000 || mean > 6000 {
+		t.Errorf("Got a mean id of %d, expected a uniform sample around 5000", mean)
+	}
+
+	out.Reset()
+	if err = bigcsv.Sample(context.Background(), bigcsv.ReadStream(strings.NewReader("a\nb\n")), &out, 5); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a\nb\n" {
+		t.Errorf("Expected a short stream to be copied, got %q", &out)
+	}
+}
@@ -0,0 +1,104 @@
+package bigcsv
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// RowSample is a row sampled by WithSampling, with the time it spent in each
+// stage.
+type RowSample struct {
+	// Line and Offset are the position of the row, see RowError, and Row
+	// the row as read, before OnRow or preprocessing.
+	Line   int
+	Offset int64
+	Row    []string
+
+	// InFlight is the number of rows being processed when the row was
+	// read, including itself.
+	InFlight int
+
+	// Wait is the time spent waiting for a free worker before reading the
+	// row, and Read, OnRow, Parse and OnData the time spent in each stage,
+	// as summed up by Stats. OnData is the time until handing the data
+	// off when using OnDataAsync.
+	Wait   time.Duration
+	Read   time.Duration
+	OnRow  time.Duration
+	Parse  time.Duration
+	OnData time.Duration
+
+	// Total is the time from reading the row until it was done, including
+	// time queued for a worker, see WithMaxInFlight.
+	Total time.Duration
+
+	// Err is the error of the row, if any.
+	Err error
+
+	// started is when the row was read.
+	started time.Time
+}
+
+// WithSampling passes every nth row read by Run or Chan to hook once it is
+// done, along with its stage timings. Sampling a small share of the rows in
+// production shows where time goes, e.g. whether throughput drops because of
+// certain rows or a slowing sink, without the volume of full debug logging.
+//
+// Like OnError, hook may be called by several workers at once, and should
+// return quickly as it delays the worker.
+func WithSampling(n int, hook func(*RowSample)) Option {
+	return func(cfg *config) error {
+		if n < 1 || hook == nil {
+			return fmt.Errorf("invalid sampling of every %d rows", n)
+		}
+		cfg.sampleEvery, cfg.sampleHook = n, hook
+		return nil
+	}
+}
+
+// sample starts a RowSample for every nth row read, or returns nil.
+func (r *run[T]) sample(rec record, waiting, reading time.Time) *RowSample {
+	if r.p.cfg.sampleHook == nil {
+		return nil
+	}
+	r.rowsRead++
+	if r.rowsRead%r.p.cfg.sampleEvery != 0 {
+		return nil
+	}
+	return &RowSample{
+		Line:     rec.line,
+		Offset:   rec.offset,
+		Row:      slices.Clone(rec.row),
+		InFlight: len(r.sem),
+		Wait:     reading.Sub(waiting),
+		Read:     time.Since(reading),
+		started:  reading,
+	}
+}
+
+// spent adds the time since start to the stage, like tally.spent. It has no
+// effect on a nil RowSample.
+func (s *RowSample) spent(stage error, start time.Time) {
+	if s == nil {
+		return
+	}
+	d := time.Since(start)
+	switch stage {
+	case ErrOnRow:
+		s.OnRow += d
+	case ErrParse:
+		s.Parse += d
+	case ErrOnData:
+		s.OnData += d
+	}
+}
+
+// sampleDone passes the RowSample of a row done with err to the hook.
+func (r *run[T]) sampleDone(s *RowSample, err error) {
+	if s == nil {
+		return
+	}
+	s.Total, s.Err = time.Since(s.started), err
+	r.p.cfg.sampleHook(s)
+}
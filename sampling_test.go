@@ -0,0 +1,53 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSampling samples every third row with its stage timings.
+func TestSampling(t *testing.T) {
+	var mu sync.Mutex
+	var samples []*bigcsv.RowSample
+	parser, err := bigcsv.NewFromString[int]("1\n2\n3\n4\n5\nsix\n7\n",
+		bigcsv.WithSampling(3, func(s *bigcsv.RowSample) {
+			mu.Lock()
+			defer mu.Unlock()
+			samples = append(samples, s)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) (int, error) {
+		time.Sleep(time.Millisecond)
+		return strconv.Atoi(row[0])
+	}
+	parser.OnData = func(int) error { return nil }
+	parser.OnError = func(error) {}
+	if err = parser.Run(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	slices.SortFunc(samples, func(a, b *bigcsv.RowSample) int { return a.Line - b.Line })
+	if len(samples) != 2 || samples[0].Line != 3 || samples[1].Line != 6 {
+		t.Fatalf("Got samples %+v, expected lines 3 and 6", samples)
+	}
+	for _, s := range samples {
+		if s.Parse < time.Millisecond || s.Total < s.Parse || s.InFlight < 1 {
+			t.Errorf("Unexpected timings of line %d: %+v", s.Line, s)
+		}
+	}
+	var rowErr *bigcsv.RowError
+	if samples[0].Err != nil || !errors.As(samples[1].Err, &rowErr) || strings.Join(samples[1].Row, ",") != "six" {
+		t.Errorf("Unexpected sample of line 6: %+v", samples[1])
+	}
+}
@@ -0,0 +1,198 @@
+package bigcsv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrSchema is wrapped by errors of rows and headers not matching the Schema,
+// see WithSchema.
+var ErrSchema = errors.New("schema mismatch")
+
+var (
+	// WarnNewColumn reports a column not in the Schema, appended after its
+	// columns.
+	WarnNewColumn = errors.New("new column")
+
+	// WarnMissingColumn reports an optional column of the Schema missing
+	// from the headers.
+	WarnMissingColumn = errors.New("missing column")
+
+	// WarnTypeMismatch reports a value of an optional column not of the type
+	// of the Schema, or violating its constraints.
+	WarnTypeMismatch = errors.New("type mismatch")
+)
+
+// SchemaError reports headers incompatible with the Schema, such as a required
+// column which is missing or was renamed.
+type SchemaError struct {
+	Column string
+	Reason string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%v: column '%s' %s", ErrSchema, e.Column, e.Reason)
+}
+
+func (e *SchemaError) Unwrap() error {
+	return ErrSchema
+}
+
+// Schema describes the columns of a stream: their types and constraints. It
+// can be declared in Go or loaded from JSON with LoadSchema, checked against
+// streams with WithSchema and turned into Go code with Generate.
+type Schema struct {
+	Columns []SchemaColumn `json:"columns"`
+}
+
+// SchemaColumn is a column of a Schema. Its values are converted and checked
+// like those of a ColumnSpec.
+type SchemaColumn struct {
+	// Name is the header name.
+	Name string `json:"name"`
+
+	// Type is one of the types inferred by a ProfileReport, such as
+	// TypeInteger, or TypeString if empty. Format names the number or date
+	// format, see ColumnSpec.
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+
+	// Required columns must be present and have values.
+	Required bool `json:"required,omitempty"`
+
+	// Pattern is a regular expression the whole value must match, Values
+	// the allowed values if not empty, and Min and Max bound numbers.
+	Pattern string   `json:"pattern,omitempty"`
+	Values  []string `json:"values,omitempty"`
+	Min     *float64 `json:"min,omitempty"`
+	Max     *float64 `json:"max,omitempty"`
+}
+
+// LoadSchema reads a Schema from JSON, such as
+//
+//	{"columns": [{"name": "id", "type": "integer", "required": true}, {"name": "amount", "type": "number", "min": 0}]}
+func LoadSchema(r io.Reader) (Schema, error) {
+	var s Schema
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&s); err != nil {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	if err := s.validate(); err != nil {
+		return Schema{}, fmt.Errorf("invalid schema: %w", err)
+	}
+	return s, nil
+}
+
+// validate checks the names, types and constraints of the columns.
+func (s Schema) validate() error {
+	seen := map[string]bool{}
+	for _, col := range s.Columns {
+		if col.Name == "" || seen[col.Name] {
+			return fmt.Errorf("invalid column name %q", col.Name)
+		}
+		seen[col.Name] = true
+		if _, err := col.spec().compile(); err != nil {
+			return fmt.Errorf("column '%s': %w", col.Name, err)
+		}
+	}
+	return nil
+}
+
+// spec returns the column as a ColumnSpec, sharing its conversion and checks.
+func (col SchemaColumn) spec() ColumnSpec {
+	return ColumnSpec{
+		Name:     col.Name,
+		Type:     col.Type,
+		Format:   col.Format,
+		Required: col.Required,
+		Pattern:  col.Pattern,
+		Values:   col.Values,
+		Min:      col.Min,
+		Max:      col.Max,
+	}
+}
+
+// WithSchema checks the headers and rows against the schema, tolerating
+// routine schema drift so that nightly jobs keep running: new columns after
+// those of the schema, missing optional columns and invalid values of optional
+// columns are passed to OnWarning. A required column which is missing, or a
+// new column before those of the schema, which suggests a renamed column,
+// fails the headers with a *SchemaError. Rows with an empty or invalid value
+// in a required column fail with ErrSchema as an OnRow error. It requires
+// WithHeaders.
+func WithSchema(s Schema) Option {
+	return func(cfg *config) error {
+		if err := s.validate(); err != nil {
+			return fmt.Errorf("invalid schema: %w", err)
+		}
+		cfg.schema = &s
+		return nil
+	}
+}
+
+// schemaCheck is a column of the Schema, bound to its index.
+type schemaCheck struct {
+	index int
+	SchemaColumn
+	convert func(string) (any, error)
+}
+
+// bindSchema checks the headers against the Schema, binding its columns.
+func (p *Parser[T]) bindSchema() ([]schemaCheck, error) {
+	s := p.cfg.schema
+	if s == nil {
+		return nil, nil
+	}
+	var checks []schemaCheck
+	last := -1
+	for _, col := range s.Columns {
+		ix, ok := p.columns[col.Name]
+		switch {
+		case !ok && col.Required:
+			return nil, &SchemaError{Column: col.Name, Reason: "is missing or was renamed"}
+		case !ok:
+			p.warn(&Warning{Kind: WarnMissingColumn, Line: p.consumed, Column: col.Name, Index: -1, Detail: "optional column missing"})
+			continue
+		}
+		convert, _ := col.spec().compile() // validated by WithSchema
+		checks = append(checks, schemaCheck{index: ix, SchemaColumn: col, convert: convert})
+		last = max(last, ix)
+	}
+	known := map[string]bool{}
+	for _, col := range s.Columns {
+		known[col.Name] = true
+	}
+	for ix, name := range p.headers {
+		switch {
+		case known[name]:
+		case ix < last:
+			return nil, &SchemaError{Column: name, Reason: "is not in the schema, new columns must be appended"}
+		default:
+			p.warn(p.fieldWarning(WarnNewColumn, p.consumed, ix, "column not in the schema"))
+		}
+	}
+	return checks, nil
+}
+
+// checkSchema checks the values of a row against the Schema, failing required
+// columns and warning of optional ones.
+func (p *Parser[T]) checkSchema(line int, row []string) error {
+	for _, c := range p.schema {
+		value := ""
+		if c.index < len(row) {
+			value = row[c.index]
+		}
+		_, err := c.convert(value)
+		switch {
+		case err == nil:
+		case !c.Required:
+			p.warn(p.fieldWarning(WarnTypeMismatch, line, c.index, err.Error()))
+		default:
+			return &ColumnError{Column: c.Name, Index: c.index, Err: fmt.Errorf("%w: %v", ErrSchema, err)}
+		}
+	}
+	return nil
+}
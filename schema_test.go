@@ -0,0 +1,70 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+var testSchema = bigcsv.Schema{Columns: []bigcsv.SchemaColumn{
+	{Name: "id", Type: bigcsv.TypeInteger, Required: true},
+	{Name: "amount", Type: bigcsv.TypeNumber, Min: new(float64)},
+	{Name: "note"},
+}}
+
+// TestSchemaDrift tolerates a new trailing and a missing optional column,
+// warning of them, but fails rows retyping a required column.
+func TestSchemaDrift(t *testing.T) {
+	input := "id,amount,source\n1,2.5,web\nx,3,app\n3,n/a,web\n4,-2,app\n"
+	parser, err := bigcsv.NewFromString[[]string](input, bigcsv.WithHeaders(), bigcsv.WithSchema(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	parser.OnWarning = func(w *bigcsv.Warning) {
+		warnings = append(warnings, w.Error())
+	}
+	var errs []error
+	parser.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	parser.OnRow = func([]string) error { return nil }
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"missing column: line 1, column 'note': optional column missing",
+		"new column: line 1, column 'source' (3): column not in the schema",
+		`type mismatch: line 4, column 'amount' (2): invalid number: "n/a"`,
+		"type mismatch: line 5, column 'amount' (2): value -2 out of range",
+	}
+	if !slices.Equal(warnings, expected) {
+		t.Errorf("Got warnings:\n%s\nexpected:\n%s", strings.Join(warnings, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], bigcsv.ErrSchema) || !strings.Contains(errs[0].Error(), `column 'id' (1): schema mismatch: invalid number: "x"`) {
+		t.Errorf("Expected the retyped id to fail, got: %v", errs)
+	}
+}
+
+// TestSchemaIncompatible fails headers missing a required column or with a
+// column inserted before those of the schema.
+func TestSchemaIncompatible(t *testing.T) {
+	for _, input := range []string{"ident,amount,note\n1,2,x\n", "id,source,amount,note\n1,web,2,x\n"} {
+		parser, err := bigcsv.NewFromString[[]string](input, bigcsv.WithHeaders(), bigcsv.WithSchema(testSchema))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.OnRow = func([]string) error { return nil }
+		var schemaErr *bigcsv.SchemaError
+		if err = parser.Run(context.Background(), 1); !errors.As(err, &schemaErr) {
+			t.Errorf("Expected a *SchemaError for %q, got: %v", input, err)
+		}
+	}
+	if _, err := bigcsv.NewFromString[[]string]("a\n", bigcsv.WithSchema(testSchema)); err == nil {
+		t.Error("Expected an error without headers")
+	}
+}
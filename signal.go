@@ -0,0 +1,51 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ErrInterrupted is returned by Run when stopped by a signal, see
+// WithSignals.
+var ErrInterrupted = errors.New("interrupted")
+
+// WithSignals stops Run gracefully on the given signals, SIGINT and SIGTERM if
+// none are given: no more rows are read, rows in progress are completed, and
+// the checkpoint is saved if using WithCheckpoint. Run then returns an error
+// wrapping ErrInterrupted, so the caller can tell it apart, after which sinks
+// should still be flushed:
+//
+//	err := parser.Run(ctx, 8)
+//	err = errors.Join(err, batcher.Flush())
+//	if errors.Is(err, bigcsv.ErrInterrupted) {
+//		...
+//	}
+//
+// A second signal is no longer trapped, so it terminates the process as
+// usual.
+func WithSignals(sigs ...os.Signal) Option {
+	return func(cfg *config) error {
+		if len(sigs) == 0 {
+			sigs = []os.Signal{os.Interrupt, syscall.SIGTERM}
+		}
+		cfg.signals = sigs
+		return nil
+	}
+}
+
+// trap stops the run on the configured signals until ctx is done.
+func (r *run[T]) trap(ctx context.Context) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, r.p.cfg.signals...)
+	defer signal.Stop(ch)
+	select {
+	case sig := <-ch:
+		r.p.cfg.log(ctx, "bigcsv: interrupted", "signal", sig.String())
+		r.stop(fmt.Errorf("%w by %v", ErrInterrupted, sig))
+	case <-ctx.Done():
+	}
+}
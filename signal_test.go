@@ -0,0 +1,39 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSignals checks that a signal stops the Run gracefully.
+func TestSignals(t *testing.T) {
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(strings.Repeat("x\n", 1000))),
+		bigcsv.WithSignals(os.Interrupt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	parser.OnData = func([]string) error {
+		if rows++; rows == 10 {
+			if err := self.Signal(os.Interrupt); err != nil {
+				t.Skip(err)
+			}
+		}
+		time.Sleep(time.Millisecond) // leave time for the signal to arrive
+		return nil
+	}
+	err = parser.Run(context.Background(), 1)
+	if !errors.Is(err, bigcsv.ErrInterrupted) || rows >= 1000 {
+		t.Errorf("Unexpected error after %d rows: %v", rows, err)
+	}
+}
@@ -0,0 +1,102 @@
+package bigcsv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// BatchSender streams record batches to a remote service. FlightSender and
+// HTTPBatchSender implement it for Arrow Flight and plain HTTP services; for
+// others, wrap the client stream of the service, e.g. of a gRPC streaming
+// method.
+type BatchSender interface {
+	// Send sends a batch. It is never called concurrently.
+	Send(batch *RecordBatch) error
+
+	// CloseSend ends the stream after the last batch, returning the
+	// result of the stream.
+	CloseSend() error
+}
+
+// BatchSink streams the records of a Parser as RecordBatches to a BatchSender,
+// so parsed data can be pushed into analytic services without intermediate
+// files.
+//
+//	sink := bigcsv.NewBatchSink[Place](sender, 10000)
+//	parser.OnData = sink.OnData
+//	err = errors.Join(parser.Run(ctx, 4), sink.Close())
+type BatchSink[T any] struct {
+	batcher *Batcher[T]
+	sender  BatchSender
+}
+
+// NewBatchSink creates a BatchSink sending batches of size rows, see
+// ArrowBatches for the schema and names.
+func NewBatchSink[T any](sender BatchSender, size int, names ...string) *BatchSink[T] {
+	return &BatchSink[T]{
+		batcher: ArrowBatches[T](size, sender.Send, names...),
+		sender:  sender,
+	}
+}
+
+// OnData adds a record, sending the batch once it is full.
+func (s *BatchSink[T]) OnData(data T) error {
+	return s.batcher.OnData(data)
+}
+
+// Close sends the last batch and ends the stream.
+func (s *BatchSink[T]) Close() error {
+	return errors.Join(s.batcher.Flush(), s.sender.CloseSend())
+}
+
+// httpSender streams batches as JSON lines in a single request body.
+type httpSender struct {
+	pw   *io.PipeWriter
+	enc  *json.Encoder
+	done chan error
+}
+
+// HTTPBatchSender streams record batches as JSON lines, one batch per line, in
+// the body of a single POST request to url, for services without an Arrow
+// Flight or gRPC endpoint. Cancelling ctx aborts the request. CloseSend
+// returns an error if the response status is not 2xx.
+func HTTPBatchSender(ctx context.Context, client *http.Client, url string) (BatchSender, error) {
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	s := &httpSender{pw: pw, enc: json.NewEncoder(pw), done: make(chan error, 1)}
+	go func() {
+		res, err := client.Do(req)
+		if err != nil {
+			err = fmt.Errorf("could not request: %w", err)
+		} else {
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			if res.StatusCode/100 != 2 {
+				err = fmt.Errorf("unexpected response status: %s", res.Status)
+			}
+		}
+		pr.CloseWithError(err) // unblock Send if the request failed early
+		s.done <- err
+	}()
+	return s, nil
+}
+
+func (s *httpSender) Send(batch *RecordBatch) error {
+	if err := s.enc.Encode(batch); err != nil {
+		return fmt.Errorf("could not send batch: %w", err)
+	}
+	return nil
+}
+
+func (s *httpSender) CloseSend() error {
+	s.pw.Close()
+	return <-s.done
+}
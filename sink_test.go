@@ -0,0 +1,53 @@
+package bigcsv_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestHTTPBatchSender streams batches to a test server.
+func TestHTTPBatchSender(t *testing.T) {
+	var rows int
+	var schema []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var batch struct {
+				Schema []map[string]any
+				Rows   int
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &batch); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			rows += batch.Rows
+			schema = batch.Schema
+		}
+	}))
+	defer srv.Close()
+
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n3,three\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := bigcsv.HTTPBatchSender(context.Background(), srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := bigcsv.NewBatchSink[Number](sender, 2)
+	parser.Parse = ParseNumber
+	parser.OnData = sink.OnData
+	if err = errors.Join(parser.Run(context.Background(), 1), sink.Close()); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 3 || len(schema) != 2 || schema[0]["Type"] != "int64" {
+		t.Fatalf("Server received %d rows with schema %v", rows, schema)
+	}
+}
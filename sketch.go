@@ -0,0 +1,225 @@
+package bigcsv
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"hash/maphash"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ValueCounter finds the most frequent and the distinct values of a column in
+// bounded memory, for files too large to count exactly: a Space-Saving sketch
+// tracks the candidates for the top values, a count-min sketch bounds their
+// counts and a HyperLogLog estimates the number of distinct values. The
+// distinct values themselves are kept up to a limit. Empty values are not
+// counted, like nulls of a ColumnProfile.
+//
+// Its OnRow is safe for use with multiple workers and can be used as OnRow of
+// a Parser, see also CountValues.
+type ValueCounter struct {
+	mu          sync.Mutex
+	column      int
+	k           int
+	maxDistinct int
+	seed        maphash.Seed
+	top         topK
+	cms         countMin
+	hll         hyperLogLog
+	distinct    map[string]struct{} // nil once more than maxDistinct
+	total       int64
+}
+
+// NewValueCounter creates a ValueCounter for the column with the given index,
+// tracking the k most frequent values and keeping up to maxDistinct distinct
+// values. The memory needed grows with both.
+func NewValueCounter(column, k, maxDistinct int) (*ValueCounter, error) {
+	if column < 0 || k < 1 || maxDistinct < 0 {
+		return nil, fmt.Errorf("invalid value counter of column %d, top %d and %d distinct values", column, k, maxDistinct)
+	}
+	return &ValueCounter{
+		column:      column,
+		k:           k,
+		maxDistinct: maxDistinct,
+		seed:        maphash.MakeSeed(),
+		top:         topK{capacity: max(topKCapacity, 10*k)},
+		distinct:    map[string]struct{}{},
+	}, nil
+}
+
+// CountValues counts the values of the column with the given header name in
+// the stream, see NewValueCounter. The stream must have a header row. The
+// first row error fails counting.
+//
+//	counter, err := bigcsv.CountValues(ctx, bigcsv.FileStream("orders.csv.gz"), "state", 10, 1000)
+//	...
+//	for _, vc := range counter.Top() {
+//		fmt.Println(vc.Value, vc.Count)
+//	}
+func CountValues(ctx context.Context, stream Stream, column string, k, maxDistinct int, opts ...Option) (*ValueCounter, error) {
+	p, err := New[[]string](stream, append(slices.Clone(opts), WithHeaders(), WithErrorPolicy(StopOnError))...)
+	if err != nil {
+		return nil, err
+	}
+	ix, ok := p.ColumnIndex(column)
+	if !ok {
+		p.Close()
+		return nil, fmt.Errorf("no column '%s' to count", column)
+	}
+	c, err := NewValueCounter(ix, k, maxDistinct)
+	if err != nil {
+		p.Close()
+		return nil, err
+	}
+	p.OnRow = c.OnRow
+	if err = p.Run(ctx, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// OnRow counts the value of the column in the row.
+func (c *ValueCounter) OnRow(row []string) error {
+	if c.column < len(row) {
+		c.Add(row[c.column])
+	}
+	return nil
+}
+
+// Add counts a value.
+func (c *ValueCounter) Add(value string) {
+	if value == "" {
+		return
+	}
+	h := maphash.String(c.seed, value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.total++
+	c.top.add(value)
+	c.cms.add(h)
+	c.hll.add(h)
+	if c.distinct != nil {
+		if _, ok := c.distinct[value]; !ok {
+			if len(c.distinct) == c.maxDistinct {
+				c.distinct = nil
+			} else {
+				c.distinct[strings.Clone(value)] = struct{}{}
+			}
+		}
+	}
+}
+
+// Total returns the number of values counted.
+func (c *ValueCounter) Total() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.total
+}
+
+// Top returns the k most frequent values, most frequent first. Their counts
+// are upper bounds, exact unless the column has many more distinct values
+// than k.
+func (c *ValueCounter) Top() []ValueCount {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	values := c.top.top(len(c.top.counts))
+	for i, vc := range values {
+		values[i].Count = min(vc.Count, c.cms.estimate(maphash.String(c.seed, vc.Value)))
+	}
+	slices.SortFunc(values, func(a, b ValueCount) int {
+		if a.Count != b.Count {
+			return cmp.Compare(b.Count, a.Count)
+		}
+		return strings.Compare(a.Value, b.Value)
+	})
+	return values[:min(c.k, len(values))]
+}
+
+// Count returns an upper bound of the count of a value.
+func (c *ValueCounter) Count(value string) int64 {
+	if value == "" {
+		return 0
+	}
+	h := maphash.String(c.seed, value)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if n, ok := c.top.counts[value]; ok {
+		return min(n, c.cms.estimate(h))
+	}
+	return c.cms.estimate(h)
+}
+
+// Distinct returns the number of distinct values, exact if there are no more
+// than maxDistinct, or else estimated within a few percent.
+func (c *ValueCounter) Distinct() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.distinct != nil {
+		return uint64(len(c.distinct))
+	}
+	return c.hll.estimate()
+}
+
+// Values returns the distinct values in sorted order, or false if there are
+// more than maxDistinct.
+func (c *ValueCounter) Values() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.distinct == nil {
+		return nil, false
+	}
+	values := make([]string, 0, len(c.distinct))
+	for v := range c.distinct {
+		values = append(values, v)
+	}
+	slices.Sort(values)
+	return values, true
+}
+
+// countMinDepth and countMinWidth are the dimensions of a countMin. With a
+// width of 2^14, counts are overestimated by less than 0.02% of the total
+// with a probability of over 98%.
+const (
+	countMinDepth = 4
+	countMinWidth = 1 << 14
+)
+
+// countMin is a count-min sketch, estimating the count of hashes as an upper
+// bound.
+type countMin struct {
+	rows [][]int64
+}
+
+// add counts a hash.
+func (s *countMin) add(x uint64) {
+	if s.rows == nil {
+		s.rows = make([][]int64, countMinDepth)
+		for i := range s.rows {
+			s.rows[i] = make([]int64, countMinWidth)
+		}
+	}
+	for i, row := range s.rows {
+		row[countMinIndex(x, i)]++
+	}
+}
+
+// estimate returns the estimated count of a hash.
+func (s *countMin) estimate(x uint64) int64 {
+	if s.rows == nil {
+		return 0
+	}
+	n := s.rows[0][countMinIndex(x, 0)]
+	for i, row := range s.rows[1:] {
+		n = min(n, row[countMinIndex(x, i+1)])
+	}
+	return n
+}
+
+// countMinIndex returns the counter of a hash in row i, combining the halves
+// of the hash as independent hashes.
+func countMinIndex(x uint64, i int) int {
+	h := uint32(x) + uint32(i)*uint32(x>>32)
+	return int(h % countMinWidth)
+}
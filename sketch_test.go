@@ -0,0 +1,58 @@
+package bigcsv_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestValueCounter finds the top values among many rare ones, and the
+// distinct values up to the limit.
+func TestValueCounter(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("id,state\n")
+	for i := range 20000 {
+		state := fmt.Sprintf("rare%d", i) // each once
+		switch {
+		case i%4 == 0:
+			state = "CA"
+		case i%10 == 1:
+			state = "NY"
+		case i%50 == 3:
+			state = "TX"
+		case i%7 == 2:
+			state = ""
+		}
+		fmt.Fprintf(&b, "%d,%s\n", i, state)
+	}
+	counter, err := bigcsv.CountValues(context.Background(), bigcsv.ReadStream(strings.NewReader(b.String())), "state", 3, 100, bigcsv.WithWorkers(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []bigcsv.ValueCount{{Value: "CA", Count: 5000}, {Value: "NY", Count: 2000}, {Value: "TX", Count: 400}}
+	if top := counter.Top(); !slices.Equal(top, expected) {
+		t.Errorf("Got top values %v, expected %v", top, expected)
+	}
+	if _, ok := counter.Values(); ok {
+		t.Error("Expected too many distinct values")
+	}
+	if n := counter.Distinct(); n < 10000 || n > 11600 {
+		t.Errorf("Got %d distinct values, expected about 10802", n)
+	}
+
+	counter, err = bigcsv.NewValueCounter(1, 2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range [][]string{{"1", "b"}, {"2", "a"}, {"3", "b"}, {"4"}, {"5", ""}} {
+		counter.OnRow(row)
+	}
+	values, ok := counter.Values()
+	if !ok || !slices.Equal(values, []string{"a", "b"}) || counter.Distinct() != 2 || counter.Count("b") != 2 || counter.Total() != 3 {
+		t.Errorf("Got values %q, %d distinct, expected a and b", values, counter.Distinct())
+	}
+}
@@ -0,0 +1,140 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// WithSkipBlankLines drops rows whose fields are all empty or whitespace, such
+// as separator lines of only delimiters, instead of passing them on or failing
+// them for their number of fields. Dropped rows still count for line numbers.
+func WithSkipBlankLines() Option {
+	return func(cfg *config) error {
+		cfg.skipBlank = true
+		return nil
+	}
+}
+
+// WithCommentPrefix drops lines starting with prefix, which unlike
+// csv.Reader.Comment may be longer than one character. Lines within quoted
+// fields are kept. As comment lines are dropped before the CSV is read, they do
+// not count for line numbers.
+func WithCommentPrefix(prefix string) Option {
+	return func(cfg *config) error {
+		if prefix == "" || strings.ContainsAny(prefix, "\r\n") {
+			return fmt.Errorf("invalid comment prefix %q", prefix)
+		}
+		cfg.commentPrefix = prefix
+		return nil
+	}
+}
+
+// input wraps the opened stream for the csv.Reader according to the options.
+func (cfg *config) input(r io.Reader) io.Reader {
+	if cfg.encoding != "" {
+		r = newDecoder(r, cfg.encoding)
+	}
+	if cfg.commentPrefix != "" {
+		r = &commentFilter{
+			r:       bufio.NewReader(r),
+			prefix:  []byte(cfg.commentPrefix),
+			atStart: true,
+		}
+	}
+	if cfg.strict && cfg.newReader == nil {
+		r = newStrictReader(r, cfg.comma)
+	}
+	return r
+}
+
+// next reads the next row from the RecordReader, dropping blank rows if
+// configured, and the trailer and footer rows. It returns the number of rows
+// read, including the dropped ones.
+func (p *Parser[T]) next() (record, int) {
+	for n := 1; ; n++ {
+		var fields int
+		if p.Reader != nil {
+			fields = p.Reader.FieldsPerRecord
+		}
+		offset := p.inputOffset()
+		row, err := p.records.Read()
+		if errors.Is(err, io.EOF) {
+			return record{err: err}, n - 1
+		}
+		rec := record{row: row, offset: offset, err: err}
+		if l, ok := p.records.(interface{ Line() int }); ok {
+			rec.line = l.Line()
+		}
+		p.physicalLines(&rec)
+		if p.footer(row, rec.err) {
+			continue
+		}
+		trailer := p.isTrailer(row, rec.err)
+		if trailer {
+			p.trailer = slices.Clone(row)
+		}
+		if trailer || p.cfg.skipBlank && isBlank(row) && (rec.err == nil || errors.Is(rec.err, csv.ErrFieldCount)) {
+			// A dropped row must not determine the number of fields.
+			if p.Reader != nil {
+				p.Reader.FieldsPerRecord = fields
+			}
+			continue
+		}
+		return rec, n
+	}
+}
+
+// isBlank tells whether all fields of a row are empty or whitespace.
+func isBlank(row []string) bool {
+	for _, field := range row {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// commentFilter drops lines starting with a prefix, keeping track of quoted
+// fields so that lines within them are not mistaken for comments.
+type commentFilter struct {
+	r        *bufio.Reader
+	prefix   []byte
+	atStart  bool // the next chunk starts a line
+	inQuote  bool // the next chunk is within a quoted field
+	dropping bool // the next chunk continues a dropped line
+	pending  []byte
+	err      error
+}
+
+func (f *commentFilter) Read(b []byte) (int, error) {
+	for len(f.pending) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		chunk, err := f.r.ReadSlice('\n')
+		if errors.Is(err, bufio.ErrBufferFull) {
+			err = nil
+		}
+		f.err = err
+		start := f.atStart
+		f.atStart = bytes.HasSuffix(chunk, []byte{'\n'})
+
+		if f.dropping || (start && !f.inQuote && bytes.HasPrefix(chunk, f.prefix)) {
+			f.dropping = !f.atStart
+			continue
+		}
+		if bytes.Count(chunk, []byte{'"'})%2 == 1 {
+			f.inQuote = !f.inQuote
+		}
+		f.pending = chunk
+	}
+	n := copy(b, f.pending)
+	f.pending = f.pending[n:]
+	return n, nil
+}
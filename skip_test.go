@@ -0,0 +1,43 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSkipBlankAndComments checks that blank separator lines and comments are
+// dropped, but not comment-like lines within quoted fields.
+func TestSkipBlankAndComments(t *testing.T) {
+	csv := strings.Join([]string{
+		"// exported by vendor, \"quoted\" comment",
+		"1,one",
+		" , ",
+		",",
+		"2,\"two",
+		"// not a comment\"",
+		"// trailing comment",
+		"3,three",
+	}, "\n")
+	parser, err := bigcsv.NewFromString[Number](csv, bigcsv.WithSkipBlankLines(), bigcsv.WithCommentPrefix("//"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	var numbers []Number
+	parser.OnData = func(n Number) error {
+		numbers = append(numbers, n)
+		return nil
+	}
+	parser.OnError = func(err error) {
+		t.Error(err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(numbers) != 3 || numbers[1].String != "two\n// not a comment" {
+		t.Fatalf("Unexpected records: %q", numbers)
+	}
+}
@@ -0,0 +1,248 @@
+package bigcsv
+
+import (
+	"cmp"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// defaultMaxOpen is the number of files kept open by Split by default.
+const defaultMaxOpen = 64
+
+// SplitOptions configures Split.
+type SplitOptions struct {
+	// Rows starts a new file every Rows rows, unlimited if 0.
+	Rows int
+
+	// Key groups the rows into a file per value of the column with that
+	// header name, e.g. one file per state. It requires WithHeaders.
+	Key string
+
+	// Template names the files, expanding the fields
+	//
+	//	{key}  the value of the Key column, with characters other than
+	//	       ASCII letters, digits and '-' replaced by '_'
+	//	{seq}  the sequence number of the file, per key, from 0001
+	//
+	// It defaults to "split-{seq}.csv", "split-{key}.csv" or
+	// "split-{key}-{seq}.csv".
+	Template string
+
+	// MaxOpen bounds the files kept open when splitting by Key. The file
+	// used least recently is closed, and appended to when its key appears
+	// again. It defaults to 64.
+	MaxOpen int
+}
+
+// Split writes the rows of the stream into smaller files every Rows rows,
+// grouped by the value of the Key column, or both, returning the names of the
+// files in the order they were created. Each file starts with the header row,
+// if consumed with WithHeaders, and uses the delimiter of the stream. Files
+// are created along with their directory, existing files are overwritten.
+//
+//	files, err := bigcsv.Split(ctx, bigcsv.FileStream("places.csv"), bigcsv.SplitOptions{Key: "state", Template: "out/{key}.csv"}, bigcsv.WithHeaders())
+//
+// Rows are read sequentially, and a row error stops splitting, returning the
+// error along with the files written so far.
+func Split(ctx context.Context, stream Stream, split SplitOptions, opts ...Option) ([]string, error) {
+	if split.Rows < 0 || split.MaxOpen < 0 {
+		return nil, fmt.Errorf("invalid split limits")
+	}
+	if split.Template == "" {
+		split.Template = "split-{seq}.csv"
+		if split.Key != "" {
+			split.Template = "split-{key}.csv"
+			if split.Rows > 0 {
+				split.Template = "split-{key}-{seq}.csv"
+			}
+		}
+	}
+	for _, field := range templateField.FindAllString(split.Template, -1) {
+		if field != "{key}" && field != "{seq}" {
+			return nil, fmt.Errorf("unknown field %s in name template", field)
+		}
+	}
+	if split.Key != "" && !strings.Contains(split.Template, "{key}") {
+		return nil, fmt.Errorf("name template %q lacks {key}", split.Template)
+	}
+	if (split.Rows > 0 || split.Key == "") && !strings.Contains(split.Template, "{seq}") {
+		return nil, fmt.Errorf("name template %q lacks {seq}", split.Template)
+	}
+
+	p, err := New[[]string](stream, opts...)
+	if err != nil {
+		return nil, err
+	}
+	p.Parse = func(row []string) ([]string, error) { return row, nil }
+	headers := p.Headers()
+	key := -1
+	if split.Key != "" {
+		ix, ok := p.ColumnIndex(split.Key)
+		if !ok {
+			p.Close()
+			return nil, fmt.Errorf("no key column '%s'", split.Key)
+		}
+		key = ix
+	}
+	s := &splitter{
+		SplitOptions: split,
+		headers:      headers,
+		comma:        ',',
+		parts:        map[string]*splitPart{},
+		keys:         map[string]string{},
+	}
+	if p.Reader != nil {
+		s.comma = p.Reader.Comma
+	}
+	for row, err := range p.Rows(ctx) {
+		if err == nil {
+			value := ""
+			if key >= 0 && key < len(row) {
+				value = row[key]
+			}
+			err = s.write(value, row)
+		}
+		if err != nil {
+			return s.names, errors.Join(err, s.close())
+		}
+	}
+	return s.names, errors.Join(ctx.Err(), s.close())
+}
+
+// splitter holds the files written by Split.
+type splitter struct {
+	SplitOptions
+	headers []string
+	comma   rune
+	parts   map[string]*splitPart // by key
+	keys    map[string]string     // the keys by file name
+	names   []string
+	open    int
+	uses    int64
+}
+
+// splitPart is the current file of a key.
+type splitPart struct {
+	name    string
+	seq     int
+	rows    int
+	lastUse int64
+
+	// out and w are nil while the file is closed.
+	out io.WriteCloser
+	w   *csv.Writer
+}
+
+// write writes a row with the given key, starting a new file if due.
+func (s *splitter) write(key string, row []string) error {
+	part := s.parts[key]
+	if part == nil {
+		part = &splitPart{}
+		s.parts[key] = part
+	}
+	switch {
+	case part.seq == 0 || s.Rows > 0 && part.rows == s.Rows:
+		if part.w != nil {
+			if err := part.close(); err != nil {
+				return err
+			}
+			s.open--
+		}
+		part.seq++
+		part.rows = 0
+		part.name = strings.NewReplacer("{key}", fileKey(key), "{seq}", fmt.Sprintf("%04d", part.seq)).Replace(s.Template)
+		if other, ok := s.keys[part.name]; ok {
+			return fmt.Errorf("keys %q and %q both map to the file %s", other, key, part.name)
+		}
+		s.keys[part.name] = key
+		s.names = append(s.names, part.name)
+		if err := s.openPart(part, false); err != nil {
+			return err
+		}
+		if s.headers != nil {
+			part.w.Write(s.headers)
+		}
+	case part.w == nil:
+		if err := s.openPart(part, true); err != nil {
+			return err
+		}
+	}
+	s.uses++
+	part.lastUse = s.uses
+	part.rows++
+	part.w.Write(row)
+	return part.w.Error()
+}
+
+// openPart opens the file of a part, creating it or appending to it, first
+// closing the least recently used file if too many are open.
+func (s *splitter) openPart(part *splitPart, appending bool) error {
+	if s.open >= cmp.Or(s.MaxOpen, defaultMaxOpen) {
+		var lru *splitPart
+		for _, other := range s.parts {
+			if other.w != nil && (lru == nil || other.lastUse < lru.lastUse) {
+				lru = other
+			}
+		}
+		if err := lru.close(); err != nil {
+			return err
+		}
+		s.open--
+	}
+	var out io.WriteCloser
+	var err error
+	if appending {
+		out, err = os.OpenFile(part.name, os.O_WRONLY|os.O_APPEND, 0)
+	} else {
+		out, err = createFile(part.name)
+	}
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", part.name, err)
+	}
+	part.out, part.w = out, csv.NewWriter(out)
+	part.w.Comma = s.comma
+	s.open++
+	return nil
+}
+
+// close flushes and closes the file of the part, if open.
+func (part *splitPart) close() error {
+	if part.w == nil {
+		return nil
+	}
+	part.w.Flush()
+	err := errors.Join(part.w.Error(), part.out.Close())
+	part.out, part.w = nil, nil
+	if err != nil {
+		return fmt.Errorf("could not write %s: %w", part.name, err)
+	}
+	return nil
+}
+
+// close closes all open files.
+func (s *splitter) close() error {
+	var errs []error
+	for _, part := range s.parts {
+		errs = append(errs, part.close())
+	}
+	s.open = 0
+	return errors.Join(errs...)
+}
+
+// fileKey makes a key safe for use in file names.
+func fileKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,58 @@
+package bigcsv_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestSplit splits by key with a single file open at a time, and by key and
+// row count.
+func TestSplit(t *testing.T) {
+	const src = "id;state\n1;CA\n2;NY\n3;CA\n4;N/A\n5;CA\n"
+	dir := t.TempDir()
+	files, err := bigcsv.Split(context.Background(), bigcsv.ReadStream(strings.NewReader(src)), bigcsv.SplitOptions{
+		Key:      "state",
+		Template: filepath.Join(dir, "by-key", "{key}.csv"),
+		MaxOpen:  1,
+	}, bigcsv.WithComma(';'), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{filepath.Join(dir, "by-key", "CA.csv"), filepath.Join(dir, "by-key", "NY.csv"), filepath.Join(dir, "by-key", "N_A.csv")}
+	if !slices.Equal(files, expected) {
+		t.Errorf("Got files %q, expected %q", files, expected)
+	}
+	b, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "id;state\n1;CA\n3;CA\n5;CA\n" {
+		t.Errorf("Unexpected file of CA, reopened for appending: %q", b)
+	}
+
+	files, err = bigcsv.Split(context.Background(), bigcsv.ReadStream(strings.NewReader(src)), bigcsv.SplitOptions{
+		Rows:     2,
+		Key:      "state",
+		Template: filepath.Join(dir, "{key}-{seq}.csv"),
+	}, bigcsv.WithComma(';'), bigcsv.WithHeaders())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 4 || files[3] != filepath.Join(dir, "CA-0002.csv") {
+		t.Errorf("Unexpected files: %q", files)
+	}
+	if b, err = os.ReadFile(files[3]); err != nil || string(b) != "id;state\n5;CA\n" {
+		t.Errorf("Unexpected second file of CA: %q, %v", b, err)
+	}
+
+	_, err = bigcsv.Split(context.Background(), bigcsv.ReadStream(strings.NewReader(src)), bigcsv.SplitOptions{Key: "state", Template: "{seq}.csv"})
+	if err == nil {
+		t.Error("Expected an error for a template without {key}")
+	}
+}
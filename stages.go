@@ -0,0 +1,58 @@
+package bigcsv
+
+import "sync"
+
+// Map returns an OnData function converting each record with fn and passing
+// the result to next. Errors from fn are returned as OnData errors.
+//
+//	parser.OnData = bigcsv.Map(toSummary, bigcsv.Filter(isLarge, store))
+func Map[T, U any](fn func(T) (U, error), next func(U) error) func(T) error {
+	return func(data T) error {
+		u, err := fn(data)
+		if err != nil {
+			return err
+		}
+		return next(u)
+	}
+}
+
+// Filter returns an OnData function passing only the records for which keep
+// returns true to next.
+func Filter[T any](keep func(T) bool, next func(T) error) func(T) error {
+	return func(data T) error {
+		if !keep(data) {
+			return nil
+		}
+		return next(data)
+	}
+}
+
+// Reducer folds records into a single result. Its OnData is safe for use
+// with multiple workers, but records arrive in no particular order then, so
+// the reducing function should not depend on the order.
+type Reducer[T, R any] struct {
+	mu  sync.Mutex
+	acc R
+	fn  func(acc R, data T) R
+}
+
+// Reduce creates a Reducer starting with init and folding each record into
+// the result with fn.
+func Reduce[T, R any](init R, fn func(acc R, data T) R) *Reducer[T, R] {
+	return &Reducer[T, R]{acc: init, fn: fn}
+}
+
+// OnData folds the record into the result.
+func (r *Reducer[T, R]) OnData(data T) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acc = r.fn(r.acc, data)
+	return nil
+}
+
+// Result returns the result so far.
+func (r *Reducer[T, R]) Result() R {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.acc
+}
@@ -0,0 +1,35 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestStages chains Map, Filter and Reduce to sum the lengths of the long
+// names.
+func TestStages(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n3,three\n4,four\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := bigcsv.Reduce(0, func(acc int, n int) int {
+		return acc + n
+	})
+	parser.Parse = ParseNumber
+	parser.OnData = bigcsv.Map(
+		func(n Number) (int, error) {
+			return len(n.String), nil
+		},
+		bigcsv.Filter(func(n int) bool {
+			return n > 3
+		}, sum.OnData),
+	)
+	if err = parser.Run(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	if sum.Result() != 9 {
+		t.Fatalf("Sum is %d, expected 9", sum.Result())
+	}
+}
@@ -0,0 +1,131 @@
+package bigcsv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStalled can be returned by the stall handler to stop the Run, see
+// WithStallDetector.
+var ErrStalled = errors.New("run stalled")
+
+// Stall describes a Run in which no row completed for a while.
+type Stall struct {
+	// Duration is the time since the last row completed, or since the start.
+	Duration time.Duration
+
+	// Stage is where the oldest unfinished row is stuck: ErrRead while
+	// waiting for the stream, else ErrOnRow, ErrParse or ErrOnData.
+	Stage error
+
+	// Line is the line of the stuck row, see RowError.
+	Line int
+
+	// InFlight is the number of rows being processed by workers.
+	InFlight int
+}
+
+func (s Stall) String() string {
+	return fmt.Sprintf("no row completed for %v, line %d stuck in %v with %d rows in flight", s.Duration, s.Line, s.Stage, s.InFlight)
+}
+
+// WithStallDetector calls onStall when no row completed within d during Run,
+// once per stall. Silent network stalls otherwise hang a Run forever.
+//
+// If onStall returns an error, the Run stops and returns it. As a read may be
+// blocked, the stream is closed to interrupt it.
+//
+//	bigcsv.WithStallDetector(time.Minute, func(s bigcsv.Stall) error {
+//		return fmt.Errorf("%w: %v", bigcsv.ErrStalled, s)
+//	})
+func WithStallDetector(d time.Duration, onStall func(Stall) error) Option {
+	return func(cfg *config) error {
+		if d <= 0 || onStall == nil {
+			return fmt.Errorf("invalid stall detector: %v", d)
+		}
+		cfg.stallAfter = d
+		cfg.onStall = onStall
+		return nil
+	}
+}
+
+// watch tracks the progress of a run for stall detection. A nil watch tracks
+// nothing.
+type watch struct {
+	mu   sync.Mutex
+	last time.Time
+	rows map[int]error // stage of the rows in flight by line
+}
+
+// stage records the stage a row entered.
+func (w *watch) stage(line int, stage error) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.rows[line] = stage
+}
+
+// done records the completion of a row.
+func (w *watch) done(line int) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.rows, line)
+	w.last = time.Now()
+}
+
+// check returns the stall if no row completed for d.
+func (w *watch) check(d time.Duration, next int) (Stall, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	since := time.Since(w.last)
+	if since < d {
+		return Stall{}, false
+	}
+	s := Stall{Duration: since, Stage: ErrRead, Line: next, InFlight: len(w.rows)}
+	for line, stage := range w.rows {
+		if s.Stage == ErrRead || line < s.Line {
+			s.Line, s.Stage = line, stage
+		}
+	}
+	return s, true
+}
+
+// watchdog checks for stalls until ctx is done.
+func (r *run[T]) watchdog(ctx context.Context) {
+	d := r.p.cfg.stallAfter
+	ticker := time.NewTicker(max(d/4, time.Millisecond))
+	defer ticker.Stop()
+	var reported time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		s, stalled := r.watch.check(d, int(r.p.lastLine.Load())+1)
+		if !stalled || r.watch.lastDone() == reported {
+			continue
+		}
+		reported = r.watch.lastDone()
+		if err := r.p.cfg.onStall(s); err != nil {
+			r.stop(err)
+			r.p.closer.Close() // interrupt a blocked read
+			return
+		}
+	}
+}
+
+// lastDone returns the time the last row completed.
+func (w *watch) lastDone() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.last
+}
@@ -0,0 +1,67 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestStallInOnData checks that a blocked OnData is reported with its line.
+func TestStallInOnData(t *testing.T) {
+	unblock := make(chan struct{})
+	var stall bigcsv.Stall
+	parser, err := bigcsv.NewFromString[Number]("1,one\n2,two\n3,three\n",
+		bigcsv.WithStallDetector(20*time.Millisecond, func(s bigcsv.Stall) error {
+			stall = s
+			close(unblock)
+			return bigcsv.ErrStalled
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(n Number) error {
+		if n.Integer == 2 {
+			<-unblock
+		}
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrStalled) {
+		t.Fatalf("Expected stall, got: %v", err)
+	}
+	if stall.Stage != bigcsv.ErrOnData || stall.Line != 2 || stall.InFlight != 1 {
+		t.Fatalf("Unexpected stall: %v", stall)
+	}
+}
+
+// TestStallInRead checks that a stalled stream is interrupted.
+func TestStallInRead(t *testing.T) {
+	pr, pw := io.Pipe()
+	go pw.Write([]byte("1,one\n")) // then nothing
+	var stall bigcsv.Stall
+	parser, err := bigcsv.NewFromReader[Number](pr,
+		bigcsv.WithStallDetector(20*time.Millisecond, func(s bigcsv.Stall) error {
+			stall = s
+			return bigcsv.ErrStalled
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	parser.OnError = func(err error) {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrStalled) {
+		t.Fatalf("Expected stall, got: %v", err)
+	}
+	if stall.Stage != bigcsv.ErrRead || stall.Line != 2 {
+		t.Fatalf("Unexpected stall: %v", stall)
+	}
+}
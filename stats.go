@@ -0,0 +1,125 @@
+package bigcsv
+
+import (
+	"errors"
+	"time"
+)
+
+// Stats summarizes the rows and errors of a Run.
+type Stats struct {
+	// Rows is the number of rows read, not counting skipped rows, headers
+	// and unreadable rows.
+	Rows int64
+
+	// ReadErrors, OnRowErrors, ParseErrors and OnDataErrors count the errors
+	// by stage, see RowError.
+	ReadErrors   int64
+	OnRowErrors  int64
+	ParseErrors  int64
+	OnDataErrors int64
+
+	// Warnings counts the rows and fields repaired, see Warning.
+	Warnings int64
+
+	// Elapsed is the duration of the Run, so far if it is still running.
+	Elapsed time.Duration
+
+	// ReadTime, OnRowTime, ParseTime and OnDataTime are the time spent in
+	// each stage, summed over all workers. WaitTime is the time spent
+	// waiting for a free worker before reading the next row. A high
+	// WaitTime with low OnDataTime suggests adding workers or optimizing
+	// Parse, a high OnDataTime a slow sink, a high ReadTime a slow stream.
+	ReadTime   time.Duration
+	OnRowTime  time.Duration
+	ParseTime  time.Duration
+	OnDataTime time.Duration
+	WaitTime   time.Duration
+
+	// Profile holds the column profiles if using WithProfile. It is not
+	// combined by Add.
+	Profile []ColumnProfile
+}
+
+// Errors returns the total number of errors.
+func (s Stats) Errors() int64 {
+	return s.ReadErrors + s.OnRowErrors + s.ParseErrors + s.OnDataErrors
+}
+
+// Add returns the sum of both Stats, e.g. to combine the Stats of several
+// Parsers.
+func (s Stats) Add(o Stats) Stats {
+	return Stats{
+		Rows:         s.Rows + o.Rows,
+		ReadErrors:   s.ReadErrors + o.ReadErrors,
+		OnRowErrors:  s.OnRowErrors + o.OnRowErrors,
+		ParseErrors:  s.ParseErrors + o.ParseErrors,
+		OnDataErrors: s.OnDataErrors + o.OnDataErrors,
+		Warnings:     s.Warnings + o.Warnings,
+		Elapsed:      s.Elapsed + o.Elapsed,
+		ReadTime:     s.ReadTime + o.ReadTime,
+		OnRowTime:    s.OnRowTime + o.OnRowTime,
+		ParseTime:    s.ParseTime + o.ParseTime,
+		OnDataTime:   s.OnDataTime + o.OnDataTime,
+		WaitTime:     s.WaitTime + o.WaitTime,
+	}
+}
+
+// Stats returns the Stats of the current or last Run, or Chan. It is safe to
+// call during processing. Rows does not record Stats.
+func (p *Parser[T]) Stats() Stats {
+	t := p.tally.Load()
+	if t == nil {
+		return Stats{}
+	}
+	return t.stats()
+}
+
+// stats returns the counts so far.
+func (t *tally) stats() Stats {
+	elapsed := time.Duration(t.elapsed.Load())
+	if elapsed == 0 {
+		elapsed = time.Since(t.started)
+	}
+	s := Stats{
+		Rows:         t.rows.Load(),
+		ReadErrors:   t.readErrs.Load(),
+		OnRowErrors:  t.onRowErrs.Load(),
+		ParseErrors:  t.parseErrs.Load(),
+		OnDataErrors: t.onDataErrs.Load(),
+		Warnings:     t.warnings.Load(),
+		Elapsed:      elapsed,
+		ReadTime:     time.Duration(t.readTime.Load()),
+		OnRowTime:    time.Duration(t.onRowTime.Load()),
+		ParseTime:    time.Duration(t.parseTime.Load()),
+		OnDataTime:   time.Duration(t.onDataTime.Load()),
+		WaitTime:     time.Duration(t.waitTime.Load()),
+	}
+	if t.profiler != nil {
+		s.Profile = t.profiler.Columns()
+	}
+	return s
+}
+
+// errWait identifies the time waiting for a worker in tally.spent.
+var errWait = errors.New("wait")
+
+// spent adds the time since start to the stage, one of ErrRead, ErrOnRow,
+// ErrParse, ErrOnData or errWait. It has no effect on a nil tally.
+func (t *tally) spent(stage error, start time.Time) {
+	if t == nil {
+		return
+	}
+	d := int64(time.Since(start))
+	switch stage {
+	case ErrRead:
+		t.readTime.Add(d)
+	case ErrOnRow:
+		t.onRowTime.Add(d)
+	case ErrParse:
+		t.parseTime.Add(d)
+	case ErrOnData:
+		t.onDataTime.Add(d)
+	case errWait:
+		t.waitTime.Add(d)
+	}
+}
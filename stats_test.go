@@ -0,0 +1,56 @@
+package bigcsv_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestStats checks the counts of a Run.
+func TestStats(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\nx,two\n3,three\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := parser.Stats(); stats.Rows != 0 {
+		t.Fatalf("Expected empty stats before the run, got: %+v", stats)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	parser.OnError = func(error) {}
+	if err = parser.Run(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	stats := parser.Stats()
+	if stats.Rows != 3 || stats.ParseErrors != 1 || stats.Errors() != 1 || stats.Elapsed <= 0 {
+		t.Fatalf("Unexpected stats: %+v", stats)
+	}
+}
+
+// TestStatsTiming checks that the time of a slow sink shows in OnDataTime and
+// in the time waiting for workers.
+func TestStatsTiming(t *testing.T) {
+	parser, err := bigcsv.NewFromString[string]("a\nb\nc\nd\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = func(row []string) (string, error) {
+		return row[0], nil
+	}
+	parser.OnData = func(string) error {
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	s := parser.Stats()
+	if s.OnDataTime < 20*time.Millisecond || s.WaitTime < 15*time.Millisecond {
+		t.Errorf("Expected the sink to dominate, got OnData %v, wait %v", s.OnDataTime, s.WaitTime)
+	}
+	if s.ParseTime > s.OnDataTime || s.ReadTime > s.OnDataTime {
+		t.Errorf("Unexpected time spent reading %v and parsing %v", s.ReadTime, s.ParseTime)
+	}
+}
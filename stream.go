@@ -1,7 +1,7 @@
 package bigcsv
 
 import (
-	"compress/gzip"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +16,10 @@ type Stream interface {
 }
 
 // HTTPStream provides a reader for the CSV stream directly via HTTP(s).
+//
+// If the response announces its Content-Length, reading fails with a
+// TruncatedResponseError when the response ends early, so that a truncated
+// download is not mistaken for the complete file.
 type HTTPStream string
 
 func (hs HTTPStream) Open() (io.ReadCloser, error) {
@@ -27,22 +31,67 @@ func (hs HTTPStream) Open() (io.ReadCloser, error) {
 	if err != nil {
 		return nil, fmt.Errorf("could not request: %w", err)
 	}
-	r := res.Body
+	return responseBody(string(hs), res)
+}
+
+// responseBody returns the body of a response, checking its length and
+// detecting gzip.
+func responseBody(url string, res *http.Response) (io.ReadCloser, error) {
+	var r io.ReadCloser = res.Body
+	if res.ContentLength >= 0 {
+		r = &lengthChecker{ReadCloser: res.Body, url: url, expected: res.ContentLength}
+	}
 
 	// Detect gzip
 	if strings.Contains(res.Header.Get("content-type"), "gzip") {
-		r, err = gzip.NewReader(res.Body)
+		gz, err := gunzip(r)
 		if err != nil {
+			r.Close()
 			return nil, fmt.Errorf("could not read gzip body: %w", err)
 		}
+		r = gz
 	}
 	return r, nil
 }
 
+// TruncatedResponseError is returned reading an HTTPStream whose response
+// ended before its Content-Length was received. It wraps io.ErrUnexpectedEOF.
+type TruncatedResponseError struct {
+	URL      string
+	Expected int64 // the Content-Length
+	Received int64
+}
+
+func (e *TruncatedResponseError) Error() string {
+	return fmt.Sprintf("truncated response from %s: received %d of %d bytes", e.URL, e.Received, e.Expected)
+}
+
+func (e *TruncatedResponseError) Unwrap() error {
+	return io.ErrUnexpectedEOF
+}
+
+// lengthChecker counts the bytes of a response body, failing with a
+// TruncatedResponseError if it ends early.
+type lengthChecker struct {
+	io.ReadCloser
+	url      string
+	expected int64
+	received int64
+}
+
+func (c *lengthChecker) Read(b []byte) (int, error) {
+	n, err := c.ReadCloser.Read(b)
+	c.received += int64(n)
+	if errors.Is(err, io.ErrUnexpectedEOF) || (errors.Is(err, io.EOF) && c.received != c.expected) {
+		err = &TruncatedResponseError{URL: c.url, Expected: c.expected, Received: c.received}
+	}
+	return n, err
+}
+
 // FileStream provides a reader for CSV processing from the filesystem.
 //
-// FileStream will automatically decompress *.gz as gzip files. Everything else
-// will be treated as a CSV.
+// FileStream will automatically decompress *.gz as gzip files, including
+// concatenated gzip files and BGZF. Everything else will be treated as a CSV.
 type FileStream string
 
 func (fs FileStream) Open() (io.ReadCloser, error) {
@@ -57,7 +106,7 @@ func (fs FileStream) Open() (io.ReadCloser, error) {
 
 	// Detect gzip in filename.
 	if ext == ".gz" {
-		gz, err := gzip.NewReader(r)
+		gz, err := gunzip(r)
 		if err != nil {
 			r.Close()
 			return nil, fmt.Errorf("gzip failed for '%s': %w", fs, err)
@@ -85,3 +134,31 @@ func (ra readerAdapter) Open() (io.ReadCloser, error) {
 	}
 	return io.NopCloser(ra.Reader), nil
 }
+
+// describe names a stream for logging: file names, including those of files
+// passed to ReadStream, and URLs are given as is, other streams by their type.
+func describe(stream Stream) string {
+	switch s := stream.(type) {
+	case FileStream:
+		return string(s)
+	case HTTPStream:
+		return string(s)
+	case ConditionalHTTPStream:
+		return s.URL
+	case IndexedStream:
+		return fmt.Sprintf("%s:%d", s.Path, s.Row)
+	case entryStream:
+		return s.name
+	case S3Stream:
+		return "s3://" + s.Bucket + "/" + s.Key
+	case teeStream:
+		return describe(s.inner)
+	case readerAdapter:
+		if f, ok := s.Reader.(*os.File); ok {
+			return f.Name()
+		}
+		return fmt.Sprintf("%T", stream)
+	default:
+		return fmt.Sprintf("%T", stream)
+	}
+}
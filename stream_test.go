@@ -0,0 +1,44 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestHTTPStreamTruncated checks that a response shorter than its
+// Content-Length fails the Run.
+func TestHTTPStreamTruncated(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		io.WriteString(w, "1,one\n2,two\n")
+	}))
+	defer server.Close()
+
+	parser, err := bigcsv.New[Number](bigcsv.HTTPStream(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	n := 0
+	parser.OnData = func(Number) error {
+		n++
+		return nil
+	}
+	err = parser.Run(context.Background(), 1)
+	var truncated *bigcsv.TruncatedResponseError
+	if !errors.As(err, &truncated) || truncated.Expected != 100 || truncated.Received != 12 {
+		t.Fatalf("Expected a truncated response of 12 of 100 bytes, got: %v", err)
+	}
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected the error to wrap io.ErrUnexpectedEOF")
+	}
+	if n != 2 {
+		t.Errorf("Processed %d rows, expected 2", n)
+	}
+}
@@ -0,0 +1,222 @@
+package bigcsv
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+var (
+	// ErrBareCR reports a carriage return not followed by a line feed
+	// outside of a quoted field.
+	ErrBareCR = errors.New("bare \\r in non-quoted field")
+
+	// ErrTrailingDelimiter reports a line ending with a delimiter.
+	ErrTrailingDelimiter = errors.New("trailing delimiter")
+)
+
+// ConformanceError reports input violating RFC 4180, see WithStrict. Err is
+// ErrBareCR, ErrTrailingDelimiter, csv.ErrBareQuote or csv.ErrQuote.
+type ConformanceError struct {
+	// Line and Column locate the violation, both counting from 1. The
+	// column is a byte index into the line like for csv.ParseError.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *ConformanceError) Error() string {
+	return fmt.Sprintf("not RFC 4180 conformant at line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *ConformanceError) Unwrap() error {
+	return e.Err
+}
+
+// WithStrict rejects input which csv.Reader tolerates but RFC 4180 does not:
+// carriage returns outside of quoted fields which do not end a line, quotes in
+// unquoted fields or after a closing quote, unterminated quoted fields and
+// lines ending with a delimiter. It is meant for validating files we produce
+// ourselves. The first violation stops the Parser like a failing stream, Run
+// returns it as a *ConformanceError. Rows before it are processed as usual.
+//
+// WithStrict cannot be combined with WithHeaderMetadata or WithFooter, which
+// read their lines leniently, and has no effect with WithRecordReader.
+func WithStrict() Option {
+	return func(cfg *config) error {
+		cfg.strict = true
+		return nil
+	}
+}
+
+// strictReader passes the stream on unchanged up to the first violation of
+// RFC 4180, which it returns as error.
+type strictReader struct {
+	r     *bufio.Reader
+	comma []byte
+
+	line, column int
+	fieldStart   bool // the next byte starts a field
+	afterComma   bool // the last byte ended a delimiter
+	commaColumn  int  // the column of the last delimiter
+	inQuote      bool // within a quoted field
+	closing      bool // a quote within a quoted field was read
+
+	// repaired is called with the line of misplaced quotes instead of
+	// failing, which csv.Reader repairs with LazyQuotes, see WithRepairQuotes.
+	// Other violations are ignored then.
+	repaired func(line int)
+
+	buf     []byte
+	pending []byte
+	err     error
+}
+
+func newStrictReader(r io.Reader, comma rune) *strictReader {
+	return &strictReader{
+		r:          bufio.NewReader(r),
+		comma:      utf8.AppendRune(nil, comma),
+		line:       1,
+		fieldStart: true,
+	}
+}
+
+func (s *strictReader) Read(b []byte) (int, error) {
+	for len(s.pending) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		s.fill()
+	}
+	n := copy(b, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
+
+// fill checks the next chunk of the stream, setting it as pending.
+func (s *strictReader) fill() {
+	s.buf = s.buf[:0]
+	for len(s.buf) < 4096 {
+		c, err := s.r.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = cmp.Or(s.atEOF(), err)
+			}
+			s.err = err
+			break
+		}
+		s.column++
+		s.buf = append(s.buf, c)
+		if err = s.check(c); err != nil {
+			s.err = err
+			break
+		}
+	}
+	s.pending = s.buf
+}
+
+// check advances the state by one byte.
+func (s *strictReader) check(c byte) error {
+	if s.inQuote {
+		switch {
+		case s.closing && c == '"': // escaped quote
+			s.closing = false
+			return nil
+		case s.closing:
+			s.closing = false
+			if c != '\r' && c != '\n' && !s.isComma(c) {
+				if s.repaired != nil { // kept as part of the field
+					s.repaired(s.line)
+					return nil
+				}
+				s.inQuote = false
+				return s.violation(csv.ErrQuote)
+			}
+			s.inQuote = false
+		case c == '"':
+			s.closing = true
+			return nil
+		case c == '\n':
+			s.line, s.column = s.line+1, 0
+			return nil
+		default:
+			return nil
+		}
+	}
+	afterComma := s.afterComma
+	s.afterComma = false
+	switch {
+	case c == '"':
+		if !s.fieldStart {
+			return s.quote(csv.ErrBareQuote)
+		}
+		s.inQuote = true
+	case s.isComma(c):
+		s.fieldStart, s.afterComma, s.commaColumn = true, true, s.column-len(s.comma)+1
+	case c == '\r':
+		if next, _ := s.r.Peek(1); s.repaired == nil && (len(next) == 0 || next[0] != '\n') {
+			return s.violation(ErrBareCR)
+		}
+		s.afterComma = afterComma
+	case c == '\n':
+		if afterComma && s.repaired == nil {
+			return s.violationAt(s.commaColumn, ErrTrailingDelimiter)
+		}
+		s.line, s.column, s.fieldStart = s.line+1, 0, true
+	default:
+		s.fieldStart = false
+	}
+	return nil
+}
+
+// isComma tells whether a byte starts the delimiter, consuming the rest of it.
+func (s *strictReader) isComma(c byte) bool {
+	if c != s.comma[0] {
+		return false
+	}
+	if len(s.comma) == 1 {
+		return true
+	}
+	rest, _ := s.r.Peek(len(s.comma) - 1)
+	if !bytes.Equal(rest, s.comma[1:]) {
+		return false
+	}
+	s.buf = append(s.buf, rest...)
+	s.column += len(rest)
+	_, _ = s.r.Discard(len(rest))
+	return true
+}
+
+// atEOF checks the state at the end of the stream.
+func (s *strictReader) atEOF() error {
+	switch {
+	case s.inQuote && !s.closing:
+		return s.quote(csv.ErrQuote)
+	case s.afterComma && s.repaired == nil:
+		return s.violationAt(s.commaColumn, ErrTrailingDelimiter)
+	}
+	return nil
+}
+
+// quote reports a misplaced quote, or records it if repairing.
+func (s *strictReader) quote(err error) error {
+	if s.repaired != nil {
+		s.repaired(s.line)
+		return nil
+	}
+	return s.violation(err)
+}
+
+// violation reports err at the current byte.
+func (s *strictReader) violation(err error) error {
+	return s.violationAt(s.column, err)
+}
+
+func (s *strictReader) violationAt(column int, err error) error {
+	return &ConformanceError{Line: s.line, Column: column, Err: err}
+}
@@ -0,0 +1,52 @@
+package bigcsv_test
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestStrict checks that violations of RFC 4180 are located, and that
+// conformant input passes.
+func TestStrict(t *testing.T) {
+	cases := []struct {
+		input  string
+		line   int
+		column int
+		err    error
+	}{
+		{"a,b\r\n1,\"x\r\ny\"\r\n", 0, 0, nil},
+		{"a,b\n1,\"say \"\"hi\"\"\"\n", 0, 0, nil},
+		{"a,b\n1,2\r3,4\n", 2, 4, bigcsv.ErrBareCR},
+		{"a,b\n1,x\"y\n", 2, 4, csv.ErrBareQuote},
+		{"a,b\n1,\"x\"y\n", 2, 6, csv.ErrQuote},
+		{"a,b\n1,\"x\n", 3, 0, csv.ErrQuote},
+		{"a,b\n1,2,\r\n", 2, 4, bigcsv.ErrTrailingDelimiter},
+		{"a,b\n1,2,", 2, 4, bigcsv.ErrTrailingDelimiter},
+	}
+	for _, c := range cases {
+		parser, err := bigcsv.NewFromString[[]string](c.input, bigcsv.WithStrict(), bigcsv.WithFieldsPerRecord(-1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.OnRow = func([]string) error { return nil }
+		err = parser.Run(context.Background(), 1)
+		if c.err == nil {
+			if err != nil {
+				t.Errorf("Unexpected error for %q: %v", c.input, err)
+			}
+			continue
+		}
+		var conformanceErr *bigcsv.ConformanceError
+		if !errors.As(err, &conformanceErr) || !errors.Is(err, c.err) ||
+			conformanceErr.Line != c.line || conformanceErr.Column != c.column {
+			t.Errorf("Expected %v at line %d, column %d for %q, got: %v", c.err, c.line, c.column, c.input, err)
+		}
+	}
+	if _, err := bigcsv.NewFromString[[]string]("", bigcsv.WithStrict(), bigcsv.WithFooter("^Total")); err == nil {
+		t.Error("Expected an error for strict mode with a footer")
+	}
+}
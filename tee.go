@@ -0,0 +1,52 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// TeeStream copies the bytes read from inner to w while the Parser consumes
+// them, e.g. to archive the original file to disk or S3 for auditing without
+// downloading it twice:
+//
+//	archive, err := os.Create("archive/places.csv")
+//	parser, err := bigcsv.New[Place](bigcsv.TeeStream(bigcsv.HTTPStream(url), archive))
+//	err = errors.Join(parser.Run(ctx, 4), archive.Close())
+//
+// The bytes are those returned by inner, so FileStream, HTTPStream and S3Stream
+// pass them on decompressed. If the Parser stops early, the rest of the stream
+// is copied when it is closed, so w always receives the whole stream. w is not
+// closed, and each Open copies the stream again, e.g. after Reset.
+//
+// A failing write fails the read, which stops the Run with a fatal error.
+func TeeStream(inner Stream, w io.Writer) Stream {
+	return teeStream{inner, w}
+}
+
+type teeStream struct {
+	inner Stream
+	w     io.Writer
+}
+
+func (t teeStream) Open() (io.ReadCloser, error) {
+	rc, err := t.inner.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &teeReader{Reader: io.TeeReader(rc, t.w), rc: rc}, nil
+}
+
+// teeReader copies the rest of the stream when closed.
+type teeReader struct {
+	io.Reader
+	rc io.ReadCloser
+}
+
+func (t *teeReader) Close() error {
+	var err error
+	if _, cerr := io.Copy(io.Discard, t.Reader); cerr != nil {
+		err = fmt.Errorf("could not copy the rest of the stream: %w", cerr)
+	}
+	return errors.Join(err, t.rc.Close())
+}
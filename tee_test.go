@@ -0,0 +1,51 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestTeeStream checks that the whole stream is copied, even when the Run
+// stops early.
+func TestTeeStream(t *testing.T) {
+	const input = "1,one\nx,two\n3,three\n"
+	var archive strings.Builder
+	stream := bigcsv.TeeStream(bigcsv.ReadStream(strings.NewReader(input)), &archive)
+	parser, err := bigcsv.New[Number](stream, bigcsv.WithErrorPolicy(bigcsv.StopOnError))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	if err = parser.Run(context.Background(), 1); !errors.Is(err, bigcsv.ErrParse) {
+		t.Fatalf("Expected the parse error on line 2, got: %v", err)
+	}
+	if archive.String() != input {
+		t.Fatalf("Archived %q, expected %q", archive.String(), input)
+	}
+}
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+// TestTeeStreamWriteError checks that a failing archive fails the Run.
+func TestTeeStreamWriteError(t *testing.T) {
+	stream := bigcsv.TeeStream(bigcsv.ReadStream(strings.NewReader("1,one\n")), failingWriter{})
+	parser, err := bigcsv.New[Number](stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	parser.OnData = func(Number) error { return nil }
+	if err = parser.Run(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Expected the write error, got: %v", err)
+	}
+}
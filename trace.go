@@ -0,0 +1,234 @@
+package bigcsv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+)
+
+// TraceEvent is a line of a trace written with WithTrace, in JSON.
+type TraceEvent struct {
+	// Seq numbers the events from 1, in the order they happened.
+	Seq int64 `json:"seq"`
+
+	// Event is "start" and "end" for a row processed by a worker, "data"
+	// when passing its data to OnData or OnDataAsync in between, and
+	// "error" for a row failing before being dispatched, e.g. a read error.
+	Event string `json:"event"`
+
+	// Worker identifies the worker processing the row, counting from 0,
+	// or is -1 for errors before dispatching. Workers are numbered by
+	// availability, the lowest free number being taken.
+	Worker int `json:"worker"`
+
+	// Line is the line of the row, see RowError.
+	Line int `json:"line"`
+
+	// Error is the error of the row, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// WithTrace records the rows dispatched to the workers of Run and their
+// errors to w as TraceEvents in JSON lines. ReplayTrace processes the rows
+// in the recorded order on a single goroutine, to reproduce bugs in
+// callbacks which only show with concurrency.
+//
+// Writing a trace serializes the workers briefly for each row. Failing to
+// write stops the Parser, returning the error.
+func WithTrace(w io.Writer) Option {
+	return func(cfg *config) error {
+		if w == nil {
+			return fmt.Errorf("invalid trace writer")
+		}
+		cfg.trace = &tracer{enc: json.NewEncoder(w)}
+		return nil
+	}
+}
+
+// tracer writes TraceEvents.
+type tracer struct {
+	mu   sync.Mutex
+	enc  *json.Encoder
+	seq  int64
+	busy []bool // the workers processing a row
+}
+
+// start records the dispatch of a row, returning the worker processing it.
+func (t *tracer) start(line int) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	worker := slices.Index(t.busy, false)
+	if worker < 0 {
+		worker = len(t.busy)
+		t.busy = append(t.busy, false)
+	}
+	t.busy[worker] = true
+	return worker, t.write(TraceEvent{Event: "start", Worker: worker, Line: line})
+}
+
+// data records the call of OnData for a row.
+func (t *tracer) data(worker, line int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.write(TraceEvent{Event: "data", Worker: worker, Line: line})
+}
+
+// end records the completion of a row by a worker.
+func (t *tracer) end(worker, line int, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.busy[worker] = false
+	return t.write(TraceEvent{Event: "end", Worker: worker, Line: line, Error: errorString(err)})
+}
+
+// fail records a row failing before being dispatched.
+func (t *tracer) fail(line int, err error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.write(TraceEvent{Event: "error", Worker: -1, Line: line, Error: errorString(err)})
+}
+
+func (t *tracer) write(e TraceEvent) error {
+	t.seq++
+	e.Seq = t.seq
+	if err := t.enc.Encode(e); err != nil {
+		return fmt.Errorf("could not write trace: %w", err)
+	}
+	return nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// traceStart records the start of a row if tracing, returning the worker.
+func (r *run[T]) traceStart(line int) int {
+	t := r.p.cfg.trace
+	if t == nil {
+		return -1
+	}
+	worker, err := t.start(line)
+	if err != nil {
+		r.stop(err)
+	}
+	return worker
+}
+
+// traceData records the call of OnData for a row started by traceStart.
+func (r *run[T]) traceData(worker, line int) {
+	if t := r.p.cfg.trace; t != nil {
+		if err := t.data(worker, line); err != nil {
+			r.stop(err)
+		}
+	}
+}
+
+// traceEnd records the end of a row started by traceStart.
+func (r *run[T]) traceEnd(worker, line int, err error) {
+	if t := r.p.cfg.trace; t != nil {
+		if err := t.end(worker, line, err); err != nil {
+			r.stop(err)
+		}
+	}
+}
+
+// traceFail records a row failing before being dispatched.
+func (r *run[T]) traceFail(line int, err error) {
+	if t := r.p.cfg.trace; t != nil {
+		if err := t.fail(line, err); err != nil {
+			r.stop(err)
+		}
+	}
+}
+
+// ReplayTrace processes the rows of the stream in the order recorded by
+// WithTrace, one after another on the calling goroutine: each row passes
+// OnRow, Parse and OnData in the order OnData was called in the trace, or
+// the row ended if it failed before, and errors are passed to OnError. Rows
+// not processed in the trace are skipped, except for errors reading them.
+// Parse the same stream as the traced Run, e.g. after Reset.
+//
+// Replaying does not reproduce races between rows in progress at the same
+// time, but the order of rows which e.g. makes a stateful OnData fail.
+func (p *Parser[T]) ReplayTrace(ctx context.Context, trace io.Reader) (err error) {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer func() {
+		if rerr := p.end(); rerr != nil && err == nil {
+			err = rerr
+		}
+	}()
+	defer p.finish()
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	var order []int
+	ordered := map[int]bool{}
+	dec := json.NewDecoder(trace)
+	for {
+		var e TraceEvent
+		if err := dec.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("could not read trace: %w", err)
+		}
+		if (e.Event == "data" || e.Event == "end") && !ordered[e.Line] {
+			order = append(order, e.Line)
+			ordered[e.Line] = true
+		}
+	}
+	if err := p.prepare(); err != nil {
+		return err
+	}
+
+	onError := func(err error) {
+		if p.active.onError != nil {
+			p.active.onError(err)
+		}
+	}
+	read := map[int]record{} // rows read ahead of their turn
+	eof := false
+	for _, line := range order {
+		for !eof && read[line].line == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			rec := p.read()
+			switch {
+			case errors.Is(rec.err, io.EOF):
+				eof = true
+			case rec.err != nil:
+				onError(rec.error(ErrRead, rec.err))
+				if !isParseError(rec.err) {
+					return rec.error(ErrRead, rec.err)
+				}
+			default:
+				rec.row = slices.Clone(rec.row)
+				read[rec.line] = rec
+			}
+		}
+		rec, ok := read[line]
+		if !ok {
+			return fmt.Errorf("line %d of the trace is not in the stream", line)
+		}
+		delete(read, line)
+		data, err := p.parseRow(ctx, rec, nil, nil)
+		if onData := p.onData(ctx); err == nil && p.parses() && onData != nil {
+			if err = onData(data); err != nil {
+				err = rec.error(ErrOnData, err)
+			}
+		}
+		if err != nil {
+			onError(err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package bigcsv_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestTrace records a concurrent Run whose OnData fails on rows out of order,
+// replaying it to the same calls and errors.
+func TestTrace(t *testing.T) {
+	var input strings.Builder
+	for i := range 40 {
+		fmt.Fprintf(&input, "%d\n", i)
+	}
+	trace := &bytes.Buffer{}
+	record := func(opts ...bigcsv.Option) (*bigcsv.Parser[int], *[]int, *[]int) {
+		parser, err := bigcsv.NewFromString[int](input.String(), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.Parse = func(row []string) (int, error) {
+			n, err := strconv.Atoi(row[0])
+			time.Sleep(time.Duration(n*7%5) * time.Millisecond)
+			return n, err
+		}
+		var calls, failed []int
+		last := -1
+		parser.OnData = func(n int) error {
+			calls = append(calls, n)
+			if n < last {
+				return errors.New("out of order")
+			}
+			last = n
+			return nil
+		}
+		parser.OnError = func(err error) {
+			var rowErr *bigcsv.RowError
+			if errors.As(err, &rowErr) {
+				failed = append(failed, rowErr.Line)
+			}
+		}
+		return parser, &calls, &failed
+	}
+
+	parser, calls, failed := record(bigcsv.WithTrace(trace))
+	if err := parser.Run(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	if len(*failed) == 0 {
+		t.Fatal("Expected rows out of order")
+	}
+	parser, replayed, replayFailed := record()
+	if err := parser.ReplayTrace(context.Background(), trace); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(*replayed, *calls) || !slices.Equal(*replayFailed, *failed) {
+		t.Errorf("Replayed calls %v with errors on %v, expected %v with errors on %v", *replayed, *replayFailed, *calls, *failed)
+	}
+}
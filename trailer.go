@@ -0,0 +1,91 @@
+package bigcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrTrailer is wrapped by a *TrailerError.
+var ErrTrailer = errors.New("trailer mismatch")
+
+// TrailerError reports a trailer whose declared number of rows does not match
+// the rows read, see WithTrailer.
+type TrailerError struct {
+	// Trailer is the trailer row, nil if it was missing.
+	Trailer []string
+
+	// Declared is the number of rows declared by the trailer, Rows the
+	// number of rows read.
+	Declared int64
+	Rows     int64
+}
+
+func (e *TrailerError) Error() string {
+	if e.Trailer == nil {
+		return fmt.Sprintf("%v: no trailer after %d rows", ErrTrailer, e.Rows)
+	}
+	return fmt.Sprintf("%v: trailer declares %d rows, read %d", ErrTrailer, e.Declared, e.Rows)
+}
+
+func (e *TrailerError) Unwrap() error {
+	return ErrTrailer
+}
+
+// WithTrailer takes rows matching the regular expression as the trailer of the
+// stream, like the record count line of many financial feeds. The trailer is
+// not processed, but kept for Trailer. The pattern is matched against the
+// fields of each row after the header, joined by the delimiter.
+//
+// If the pattern has a group named "count", such as in `^TRL,(?P<count>\d+)`,
+// Run verifies that the number of rows read (including failing rows, but not
+// the header and trailer) matches it when reaching the end of the stream. A
+// mismatch or a missing trailer is returned as *TrailerError.
+func WithTrailer(pattern string) Option {
+	return func(cfg *config) error {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid trailer pattern: %w", err)
+		}
+		cfg.trailer = re
+		return nil
+	}
+}
+
+// isTrailer tells whether a row read after the header is the trailer.
+func (p *Parser[T]) isTrailer(row []string, err error) bool {
+	if p.cfg.trailer == nil || !p.inBody || row == nil {
+		return false
+	}
+	if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+		return false
+	}
+	return p.cfg.trailer.MatchString(strings.Join(row, string(p.cfg.comma)))
+}
+
+// Trailer returns the trailer row, if WithTrailer is used and it was read.
+func (p *Parser[T]) Trailer() []string {
+	return slices.Clone(p.trailer)
+}
+
+// checkTrailer verifies the number of rows declared by the trailer, once the
+// stream was read to its end.
+func (p *Parser[T]) checkTrailer() error {
+	re := p.cfg.trailer
+	if re == nil || re.SubexpIndex("count") < 0 {
+		return nil
+	}
+	if p.trailer == nil {
+		return &TrailerError{Rows: p.bodyRows}
+	}
+	m := re.FindStringSubmatch(strings.Join(p.trailer, string(p.cfg.comma)))
+	declared, err := strconv.ParseInt(strings.TrimSpace(m[re.SubexpIndex("count")]), 10, 64)
+	if err != nil || declared != p.bodyRows {
+		return &TrailerError{Trailer: slices.Clone(p.trailer), Declared: declared, Rows: p.bodyRows}
+	}
+	return nil
+}
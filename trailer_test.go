@@ -0,0 +1,70 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestTrailer checks that the trailer is excluded and its count verified.
+func TestTrailer(t *testing.T) {
+	for input, declared := range map[string]int64{
+		"id,name\n1,one\nx,two\n3,three\nTRL,3\n": -1, // matches
+		"id,name\n1,one\n2,two\nTRL,3\n":          3,
+		"id,name\n1,one\n2,two\n":                 0, // missing
+	} {
+		parser, err := bigcsv.NewFromString[Number](input, bigcsv.WithHeaders(), bigcsv.WithTrailer(`^TRL,(?P<count>\d+)$`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.Parse = ParseNumber
+		var numbers []int
+		parser.OnData = func(n Number) error {
+			numbers = append(numbers, n.Integer)
+			return nil
+		}
+		parser.OnError = func(error) {}
+		err = parser.Run(context.Background(), 1)
+		var trailerErr *bigcsv.TrailerError
+		switch {
+		case declared < 0 && err != nil:
+			t.Errorf("%q: %v", input, err)
+		case declared < 0 && (!slices.Equal(parser.Trailer(), []string{"TRL", "3"}) || len(numbers) != 2):
+			t.Errorf("%q: unexpected trailer %q and rows %v", input, parser.Trailer(), numbers)
+		case declared >= 0 && (!errors.As(err, &trailerErr) || trailerErr.Declared != declared || trailerErr.Rows != 2):
+			t.Errorf("%q: expected a trailer error, got: %v", input, err)
+		}
+	}
+}
+
+// TestTrailerRows checks the trailer with Rows.
+func TestTrailerRows(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\nTRL,2\n", bigcsv.WithTrailer(`^TRL,(?P<count>\d+)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.Parse = ParseNumber
+	var last error
+	for _, err := range parser.Rows(context.Background()) {
+		last = err
+	}
+	if !errors.Is(last, bigcsv.ErrTrailer) {
+		t.Fatalf("Expected a trailer error, got: %v", last)
+	}
+}
+
+// TestCountTrailer checks that Count leaves out the trailer like Run.
+func TestCountTrailer(t *testing.T) {
+	n, err := bigcsv.Count(context.Background(), bigcsv.ReadStream(strings.NewReader("id,name\n1,one\n2,two\nTRL,2\n")),
+		bigcsv.WithHeaders(), bigcsv.WithTrailer(`^TRL,(?P<count>\d+)$`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Counted %d records, expected 2", n)
+	}
+}
@@ -0,0 +1,132 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrDuplicate is wrapped by a *DuplicateError.
+var ErrDuplicate = errors.New("duplicate value")
+
+// DuplicateError reports a row whose values of the columns given to
+// WithUnique were seen in an earlier row. It is passed on as an OnRow error
+// whose *RowError gives the line of the duplicate.
+type DuplicateError struct {
+	// Columns and Values are the unique columns and their duplicate values.
+	Columns []string
+	Values  []string
+
+	// FirstLine is the line the values were first seen on, or 0 if unknown
+	// as with WithUniqueApprox.
+	FirstLine int
+}
+
+func (e *DuplicateError) Error() string {
+	s := fmt.Sprintf("duplicate value %q of %s", strings.Join(e.Values, ","), strings.Join(e.Columns, ","))
+	if e.FirstLine > 0 {
+		s += fmt.Sprintf(", first seen on line %d", e.FirstLine)
+	}
+	return s
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrDuplicate
+}
+
+// WithUnique asserts that the values of the columns, taken together, are
+// unique across the stream, as required by a unique constraint of a table.
+// Rows repeating values seen before are skipped and reported as
+// *DuplicateError, giving the lines of both rows. It requires WithHeaders.
+//
+// All values are kept in memory. For streams too large, see WithUniqueApprox.
+func WithUnique(columns ...string) Option {
+	return func(cfg *config) error {
+		if len(columns) == 0 {
+			return fmt.Errorf("no unique columns")
+		}
+		cfg.unique = &uniqueConfig{columns: columns}
+		return nil
+	}
+}
+
+// WithUniqueApprox is like WithUnique, but uses a Bloom filter sized by opts
+// instead of keeping the values, see Dedupe. It never misses a duplicate, but
+// reports unique rows as duplicates at the configured rate, and does not know
+// the line a value was first seen on.
+func WithUniqueApprox(opts DedupeOptions, columns ...string) Option {
+	return func(cfg *config) error {
+		if len(columns) == 0 {
+			return fmt.Errorf("no unique columns")
+		}
+		if _, err := newBloomFilter(opts); err != nil {
+			return err
+		}
+		cfg.unique = &uniqueConfig{columns: columns, approx: &opts}
+		return nil
+	}
+}
+
+// uniqueConfig holds the settings made by WithUnique and WithUniqueApprox.
+type uniqueConfig struct {
+	columns []string
+	approx  *DedupeOptions
+}
+
+// uniqueness checks the rows of a run for duplicates. Rows are checked as
+// they are read, so it needs no lock.
+type uniqueness struct {
+	columns []string
+	indexes []int
+	seen    map[string]int // the first line of each key
+	filter  *bloomFilter
+}
+
+// uniqueness resolves the unique columns for a run, returning nil if there are
+// none.
+func (cfg *config) uniqueness(columns map[string]int) (*uniqueness, error) {
+	if cfg.unique == nil {
+		return nil, nil
+	}
+	u := &uniqueness{columns: cfg.unique.columns}
+	for _, name := range u.columns {
+		ix, ok := columns[name]
+		if !ok {
+			return nil, fmt.Errorf("no unique column '%s'", name)
+		}
+		u.indexes = append(u.indexes, ix)
+	}
+	if cfg.unique.approx == nil {
+		u.seen = map[string]int{}
+		return u, nil
+	}
+	var err error
+	u.filter, err = newBloomFilter(*cfg.unique.approx)
+	return u, err
+}
+
+// check returns a *DuplicateError if the values of the row were seen before.
+// A nil uniqueness accepts all rows.
+func (u *uniqueness) check(rec record) error {
+	if u == nil {
+		return nil
+	}
+	values := make([]string, len(u.indexes))
+	for i, ix := range u.indexes {
+		if ix < len(rec.row) {
+			values[i] = rec.row[ix]
+		}
+	}
+	key := strings.Join(values, "\x00")
+	if u.filter != nil {
+		if u.filter.add(key) {
+			return nil
+		}
+		return &DuplicateError{Columns: u.columns, Values: values}
+	}
+	if first, ok := u.seen[key]; ok {
+		return &DuplicateError{Columns: u.columns, Values: values, FirstLine: first}
+	}
+	u.seen[strings.Clone(key)] = rec.line
+	return nil
+}
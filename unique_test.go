@@ -0,0 +1,68 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestUnique checks that duplicates are reported with both line numbers.
+func TestUnique(t *testing.T) {
+	input := "id,region,name\n1,eu,a\n2,eu,b\n1,us,c\n1,eu,d\n2,eu,e\n"
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader(input)), bigcsv.WithHeaders(), bigcsv.WithUnique("id", "region"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	var dups []string
+	parser.OnData = func(row []string) error {
+		names = append(names, row[2])
+		return nil
+	}
+	parser.OnError = func(err error) {
+		var rowErr *bigcsv.RowError
+		var dupErr *bigcsv.DuplicateError
+		if !errors.As(err, &rowErr) || !errors.As(err, &dupErr) || !errors.Is(err, bigcsv.ErrDuplicate) {
+			t.Errorf("Unexpected error: %v", err)
+			return
+		}
+		dups = append(dups, rowErr.Error())
+		if rowErr.Line-dupErr.FirstLine != 3 {
+			t.Errorf("Unexpected lines: %v", err)
+		}
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 3 || len(dups) != 2 || parser.Stats().OnRowErrors != 2 {
+		t.Fatalf("Unexpected rows %q and duplicates %q", names, dups)
+	}
+	if want := `OnRow error: line 5 (offset 36): duplicate value "1,eu" of id,region, first seen on line 2`; dups[0] != want {
+		t.Errorf("Expected %q, got %q", want, dups[0])
+	}
+}
+
+// TestUniqueApprox checks finding duplicates with a Bloom filter in Rows.
+func TestUniqueApprox(t *testing.T) {
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(strings.NewReader("id\na\nb\na\n")),
+		bigcsv.WithHeaders(), bigcsv.WithUniqueApprox(bigcsv.DedupeOptions{Expected: 100}, "id"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dups int
+	for _, err := range parser.Rows(context.Background()) {
+		var dupErr *bigcsv.DuplicateError
+		if errors.As(err, &dupErr) && dupErr.Values[0] == "a" && dupErr.FirstLine == 0 {
+			dups++
+		}
+	}
+	if dups != 1 {
+		t.Errorf("Expected 1 duplicate, got %d", dups)
+	}
+	if _, err = bigcsv.NewFromString[[]string]("a\n", bigcsv.WithUnique("a")); err == nil {
+		t.Error("Expected an error without headers")
+	}
+}
@@ -0,0 +1,65 @@
+package bigcsv
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ConfigError describes an invalid Parser configuration.
+type ConfigError struct {
+	// Field names the setting at fault, e.g. "OnData".
+	Field string
+
+	// Reason explains what is wrong with the setting, unless Err does.
+	Reason string
+
+	// Err is the underlying error, e.g. of an Option, if any.
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	switch {
+	case e.Err == nil:
+		return fmt.Sprintf("invalid configuration of %s: %s", e.Field, e.Reason)
+	case e.Reason == "":
+		return fmt.Sprintf("invalid configuration of %s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("invalid configuration of %s: %s: %v", e.Field, e.Reason, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks the combination of callbacks for Run, so mistakes are found
+// before any rows are processed rather than failing mid-run or silently doing
+// less than expected. All problems found are returned as *ConfigError values
+// joined into one error, see errors.As.
+//
+// Run calls Validate before reading the stream.
+func (p *Parser[T]) Validate() error {
+	var errs []error
+	parses, onData := p.Parse != nil || p.ParseContext != nil, p.OnData != nil || p.OnDataContext != nil
+	if p.OnRow == nil && !parses && !onData && p.OnDataAsync == nil && p.OnError == nil {
+		errs = append(errs, &ConfigError{Field: "Parser", Reason: "no callbacks set, rows would be read without effect"})
+	}
+	if p.Parse != nil && p.ParseContext != nil {
+		errs = append(errs, &ConfigError{Field: "ParseContext", Reason: "cannot be combined with Parse"})
+	}
+	if p.OnData != nil && p.OnDataContext != nil {
+		errs = append(errs, &ConfigError{Field: "OnDataContext", Reason: "cannot be combined with OnData"})
+	}
+	if onData && !parses {
+		errs = append(errs, &ConfigError{Field: "OnData", Reason: "cannot call OnData without Parse"})
+	}
+	if p.OnDataAsync != nil && !parses {
+		errs = append(errs, &ConfigError{Field: "OnDataAsync", Reason: "cannot call OnDataAsync without Parse"})
+	}
+	if onData && p.OnDataAsync != nil {
+		errs = append(errs, &ConfigError{Field: "OnDataAsync", Reason: "cannot be combined with OnData"})
+	}
+	if parses && !onData && p.OnDataAsync == nil {
+		errs = append(errs, &ConfigError{Field: "Parse", Reason: "parsed data is discarded without OnData"})
+	}
+	return errors.Join(errs...)
+}
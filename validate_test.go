@@ -0,0 +1,28 @@
+package bigcsv_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestValidate checks that all configuration problems are reported.
+func TestValidate(t *testing.T) {
+	parser, err := bigcsv.NewFromString[Number]("1,one\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = parser.Validate(); err == nil {
+		t.Fatal("Expected error without callbacks")
+	}
+	parser.Parse = ParseNumber
+	var cfgErr *bigcsv.ConfigError
+	if err = parser.Validate(); !errors.As(err, &cfgErr) || cfgErr.Field != "Parse" {
+		t.Fatalf("Expected configuration error for Parse, got: %v", err)
+	}
+	parser.OnData = func(Number) error { return nil }
+	if err = parser.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,215 @@
+package bigcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"slices"
+	"unicode/utf8"
+)
+
+var (
+	// WarnPaddedRow reports a short row padded with empty fields, see
+	// WithPadRows.
+	WarnPaddedRow = errors.New("padded short row")
+
+	// WarnRepairedQuote reports misplaced quotes kept as part of a field,
+	// see WithRepairQuotes.
+	WarnRepairedQuote = errors.New("repaired quoting")
+
+	// WarnTruncatedField reports a field cut to its maximum length, see
+	// WithMaxFieldLength.
+	WarnTruncatedField = errors.New("truncated field")
+
+	// WarnDefaultApplied reports an empty field set to its default, see
+	// WithDefault.
+	WarnDefaultApplied = errors.New("default applied")
+)
+
+// Warning reports a data-quality issue the Parser repaired, passed to
+// OnWarning. Unlike for a RowError the row is processed as usual, so warnings
+// can be monitored without triggering the alerts of the error path.
+type Warning struct {
+	// Kind is WarnPaddedRow, WarnRepairedQuote, WarnTruncatedField,
+	// WarnDefaultApplied, or WarnNewColumn, WarnMissingColumn and
+	// WarnTypeMismatch of WithSchema.
+	Kind error
+
+	// Line is the number of the row in the stream, see RowError.
+	Line int
+
+	// Column is the header name, if known, and Index the zero-based index
+	// of the field concerned. Index is -1 if the warning concerns the whole
+	// row, or a column missing from the row.
+	Column string
+	Index  int
+
+	// Detail describes the repair.
+	Detail string
+}
+
+func (w *Warning) Error() string {
+	switch {
+	case w.Index < 0 && w.Column == "":
+		return fmt.Sprintf("%v: line %d: %s", w.Kind, w.Line, w.Detail)
+	case w.Index < 0:
+		return fmt.Sprintf("%v: line %d, column '%s': %s", w.Kind, w.Line, w.Column, w.Detail)
+	case w.Column == "":
+		return fmt.Sprintf("%v: line %d, column %d: %s", w.Kind, w.Line, w.Index+1, w.Detail)
+	default:
+		return fmt.Sprintf("%v: line %d, column '%s' (%d): %s", w.Kind, w.Line, w.Column, w.Index+1, w.Detail)
+	}
+}
+
+func (w *Warning) Unwrap() error {
+	return w.Kind
+}
+
+// WithPadRows pads rows with fewer fields than expected with empty fields,
+// instead of failing them with csv.ErrFieldCount. The number of fields is
+// that of the header, or of csv.Reader.FieldsPerRecord if set. Rows with too
+// many fields still fail. Each padded row is passed to OnWarning.
+func WithPadRows() Option {
+	return func(cfg *config) error {
+		cfg.padRows = true
+		return nil
+	}
+}
+
+// WithRepairQuotes keeps misplaced quotes as part of their field, like
+// csv.Reader.LazyQuotes, instead of failing the row. Each repaired row is
+// passed to OnWarning. It has no effect with WithRecordReader, and cannot be
+// combined with WithStrict.
+func WithRepairQuotes() Option {
+	return func(cfg *config) error {
+		cfg.repairQuotes = true
+		return nil
+	}
+}
+
+// WithMaxFieldLength cuts fields longer than n bytes to n bytes, or less to
+// keep UTF-8 characters whole. Each truncated field is passed to OnWarning.
+func WithMaxFieldLength(n int) Option {
+	return func(cfg *config) error {
+		if n < 1 {
+			return fmt.Errorf("invalid maximum field length: %d", n)
+		}
+		cfg.maxFieldLength = n
+		return nil
+	}
+}
+
+// WithDefault sets empty fields of the column to value, after preprocessing
+// and before OnRow. Each field set is passed to OnWarning. It requires
+// WithHeaders and may be used for several columns.
+func WithDefault(column, value string) Option {
+	return func(cfg *config) error {
+		if cfg.defaults == nil {
+			cfg.defaults = map[string]string{}
+		}
+		cfg.defaults[column] = value
+		return nil
+	}
+}
+
+// fieldDefault is the default of a column, bound to its index.
+type fieldDefault struct {
+	index int
+	value string
+}
+
+// bindDefaults resolves the columns of the defaults, in column order.
+func (cfg *config) bindDefaults(columns map[string]int) ([]fieldDefault, error) {
+	var defaults []fieldDefault
+	for column, value := range cfg.defaults {
+		ix, ok := columns[column]
+		if !ok {
+			return nil, fmt.Errorf("no column '%s' to default", column)
+		}
+		defaults = append(defaults, fieldDefault{index: ix, value: value})
+	}
+	slices.SortFunc(defaults, func(a, b fieldDefault) int { return a.index - b.index })
+	return defaults, nil
+}
+
+// repair pads a row read, checks its quotes and truncates its fields, as
+// configured.
+func (p *Parser[T]) repair(rec *record) {
+	if p.cfg.padRows && (rec.err == nil || errors.Is(rec.err, csv.ErrFieldCount)) {
+		n := len(p.headers)
+		if p.Reader != nil && p.Reader.FieldsPerRecord > 0 {
+			n = p.Reader.FieldsPerRecord
+		}
+		if missing := n - len(rec.row); missing > 0 {
+			rec.row = append(rec.row, make([]string, missing)...)
+			rec.err = nil
+			p.warn(&Warning{Kind: WarnPaddedRow, Line: rec.line, Index: -1, Detail: fmt.Sprintf("%d of %d fields missing", missing, n)})
+		}
+	}
+	if p.cfg.repairQuotes && rec.err == nil {
+		p.checkQuotes(rec)
+	}
+	if limit := p.cfg.maxFieldLength; limit > 0 && rec.err == nil {
+		for ix, field := range rec.row {
+			if len(field) <= limit {
+				continue
+			}
+			cut := limit
+			for cut > 0 && !utf8.RuneStart(field[cut]) {
+				cut--
+			}
+			rec.row[ix] = field[:cut]
+			p.warn(p.fieldWarning(WarnTruncatedField, rec.line, ix, fmt.Sprintf("%d bytes cut to %d", len(field), cut)))
+		}
+	}
+}
+
+// checkQuotes warns if quotes were repaired in the lines of the row, dropping
+// the lines recorded up to it.
+func (p *Parser[T]) checkQuotes(rec *record) {
+	if rec.startLine == 0 {
+		return
+	}
+	repaired := false
+	for len(p.repairedLines) > 0 && p.repairedLines[0] <= rec.endLine {
+		repaired = repaired || p.repairedLines[0] >= rec.startLine
+		p.repairedLines = p.repairedLines[1:]
+	}
+	if repaired {
+		p.warn(&Warning{Kind: WarnRepairedQuote, Line: rec.line, Index: -1, Detail: "misplaced quotes kept"})
+	}
+}
+
+// applyDefaults sets the empty fields of a row to their defaults.
+func (p *Parser[T]) applyDefaults(line int, row []string) {
+	for _, d := range p.defaults {
+		if d.index < len(row) && row[d.index] == "" {
+			row[d.index] = d.value
+			p.warn(p.fieldWarning(WarnDefaultApplied, line, d.index, fmt.Sprintf("set to %q", d.value)))
+		}
+	}
+}
+
+// fieldWarning returns a Warning concerning the field at index ix.
+func (p *Parser[T]) fieldWarning(kind error, line, ix int, detail string) *Warning {
+	w := &Warning{Kind: kind, Line: line, Index: ix, Detail: detail}
+	if ix < len(p.headers) {
+		w.Column = p.headers[ix]
+	}
+	return w
+}
+
+// warn counts a warning and passes it to OnWarning, as set for the run if
+// running, e.g. not when reading the headers for Headers.
+func (p *Parser[T]) warn(w *Warning) {
+	if t := p.tally.Load(); t != nil {
+		t.warnings.Add(1)
+	}
+	onWarning := p.OnWarning
+	if p.State() == StateRunning {
+		onWarning = p.active.onWarning
+	}
+	if onWarning != nil {
+		onWarning(w)
+	}
+}
@@ -0,0 +1,86 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestWarnings repairs rows, passing warnings to OnWarning but not OnError.
+func TestWarnings(t *testing.T) {
+	input := "id,name,country\n" +
+		"1,Ann,DE\n" +
+		"2,Bob\n" + // padded
+		"3,Jo \"JJ\" Doe,AT\n" + // bare quotes
+		"4,Ramón-Núñez,\n" + // truncated, defaulted
+		"5,Eve,FR,extra\n" // too many fields
+	parser, err := bigcsv.NewFromString[[]string](input,
+		bigcsv.WithHeaders(),
+		bigcsv.WithPadRows(),
+		bigcsv.WithRepairQuotes(),
+		bigcsv.WithMaxFieldLength(11),
+		bigcsv.WithDefault("country", "XX"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	parser.OnRow = func(row []string) error {
+		rows = append(rows, slices.Clone(row))
+		return nil
+	}
+	var warnings []string
+	parser.OnWarning = func(w *bigcsv.Warning) {
+		warnings = append(warnings, w.Error())
+	}
+	var errs []error
+	parser.OnError = func(err error) {
+		errs = append(errs, err)
+	}
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	expectedRows := [][]string{
+		{"1", "Ann", "DE"},
+		{"2", "Bob", "XX"},
+		{"3", `Jo "JJ" Doe`, "AT"},
+		{"4", "Ramón-Nú", "XX"},
+	}
+	if !slices.EqualFunc(rows, expectedRows, slices.Equal) {
+		t.Errorf("Got rows %q, expected %q", rows, expectedRows)
+	}
+	expected := []string{
+		"padded short row: line 3: 1 of 3 fields missing",
+		"default applied: line 3, column 'country' (3): set to \"XX\"",
+		"repaired quoting: line 4: misplaced quotes kept",
+		"truncated field: line 5, column 'name' (2): 14 bytes cut to 10",
+		"default applied: line 5, column 'country' (3): set to \"XX\"",
+	}
+	if !slices.Equal(warnings, expected) {
+		t.Errorf("Got warnings:\n%s\nexpected:\n%s", strings.Join(warnings, "\n"), strings.Join(expected, "\n"))
+	}
+	if len(errs) != 1 || errors.Is(errs[0], bigcsv.WarnPaddedRow) {
+		t.Errorf("Expected the row with too many fields to fail, got: %v", errs)
+	}
+	if s := parser.Stats(); s.Warnings != 5 || s.Errors() != 1 {
+		t.Errorf("Unexpected stats %+v", s)
+	}
+}
+
+// TestWarningOptions checks that invalid options are rejected.
+func TestWarningOptions(t *testing.T) {
+	opts := [][]bigcsv.Option{
+		{bigcsv.WithMaxFieldLength(0)},
+		{bigcsv.WithDefault("a", "x")},
+		{bigcsv.WithStrict(), bigcsv.WithRepairQuotes()},
+	}
+	for _, o := range opts {
+		if _, err := bigcsv.NewFromString[[]string]("a\n1\n", o...); err == nil {
+			t.Errorf("Expected an error for %d options", len(o))
+		}
+	}
+}
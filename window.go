@@ -0,0 +1,142 @@
+package bigcsv
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrLate is returned by Windower.OnData for a record whose windows were
+// passed on already.
+var ErrLate = errors.New("record too late for its window")
+
+// WindowOptions configures the windows of a Windower.
+type WindowOptions struct {
+	// Size is the duration of each window.
+	Size time.Duration
+
+	// Slide is the interval at which windows start. Windows overlap if it
+	// is shorter than Size, so that a record belongs to several windows.
+	// It defaults to Size, for tumbling windows.
+	Slide time.Duration
+
+	// Lateness is how far a record may lag behind the latest timestamp seen
+	// and still be added to its windows. Records arriving out of order,
+	// e.g. because of several workers, need a little lateness.
+	Lateness time.Duration
+}
+
+// WindowBatch is the records of a time window, see Window.
+type WindowBatch[T any] struct {
+	// Start and End bound the window, End being exclusive.
+	Start time.Time
+	End   time.Time
+
+	// Records are the records with timestamps in the window, in the order
+	// added.
+	Records []T
+}
+
+// Windower groups time-ordered records, such as an event log exported as CSV,
+// into windows by their timestamp and passes each window on as a batch once
+// it is complete: when a record at least Lateness past its end is seen. Its
+// OnData is safe for use with multiple workers, and the batch function is
+// never called concurrently. Call Flush after the Run to pass on the windows
+// still open.
+//
+//	windower, err := bigcsv.Window(func(e Event) time.Time { return e.Time }, bigcsv.WindowOptions{Size: time.Minute, Lateness: time.Second}, aggregate)
+//	...
+//	parser.OnData = windower.OnData
+//	err = errors.Join(parser.Run(ctx, 4), windower.Flush())
+type Windower[T any] struct {
+	mu        sync.Mutex
+	timestamp func(T) time.Time
+	opts      WindowOptions
+	onBatch   func(batch WindowBatch[T]) error
+	open      map[time.Time]*WindowBatch[T] // by start
+	latest    time.Time
+	closed    time.Time // windows ending by then were passed on
+}
+
+// Window creates a Windower passing the windows of the records, as given by
+// the timestamp function, to onBatch in the order of their start. Windows are
+// aligned to multiples of Slide since the zero time, see time.Time.Truncate;
+// windows without records are not passed on.
+func Window[T any](timestamp func(T) time.Time, opts WindowOptions, onBatch func(batch WindowBatch[T]) error) (*Windower[T], error) {
+	opts.Slide = cmp.Or(opts.Slide, opts.Size)
+	if opts.Size <= 0 || opts.Slide < 0 || opts.Slide > opts.Size || opts.Lateness < 0 {
+		return nil, fmt.Errorf("invalid windows of %s every %s with lateness %s", opts.Size, opts.Slide, opts.Lateness)
+	}
+	return &Windower[T]{timestamp: timestamp, opts: opts, onBatch: onBatch, open: map[time.Time]*WindowBatch[T]{}}, nil
+}
+
+// OnData adds the record to its windows, passing on the windows completed.
+// A record for which all windows were passed on already is not added, and
+// returned as ErrLate error. An error from the batch function is returned as
+// OnData error.
+func (w *Windower[T]) OnData(data T) error {
+	ts := w.timestamp(data)
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	last := ts.Truncate(w.opts.Slide)
+	if !w.closed.IsZero() && !last.Add(w.opts.Size).After(w.closed) {
+		return fmt.Errorf("%w: %s, windows until %s are done", ErrLate, ts.Format(time.RFC3339Nano), w.closed.Format(time.RFC3339Nano))
+	}
+	for start := last; start.Add(w.opts.Size).After(ts); start = start.Add(-w.opts.Slide) {
+		end := start.Add(w.opts.Size)
+		if !w.closed.IsZero() && !end.After(w.closed) {
+			break
+		}
+		batch := w.open[start]
+		if batch == nil {
+			batch = &WindowBatch[T]{Start: start, End: end}
+			w.open[start] = batch
+		}
+		batch.Records = append(batch.Records, data)
+	}
+	if ts.After(w.latest) || w.latest.IsZero() {
+		w.latest = ts
+	}
+	return w.flush(w.latest.Add(-w.opts.Lateness))
+}
+
+// Flush passes on the windows still open, if any.
+func (w *Windower[T]) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var end time.Time
+	for _, batch := range w.open {
+		if batch.End.After(end) {
+			end = batch.End
+		}
+	}
+	return w.flush(end)
+}
+
+// flush passes on the windows ending by the watermark, in the order of their
+// start. The caller holds the lock.
+func (w *Windower[T]) flush(watermark time.Time) error {
+	var done []*WindowBatch[T]
+	for start, batch := range w.open {
+		if !batch.End.After(watermark) {
+			done = append(done, batch)
+			delete(w.open, start)
+		}
+	}
+	if watermark.After(w.closed) {
+		w.closed = watermark
+	}
+	slices.SortFunc(done, func(a, b *WindowBatch[T]) int { return a.Start.Compare(b.Start) })
+	for i, batch := range done {
+		if err := w.onBatch(*batch); err != nil {
+			for _, rest := range done[i+1:] {
+				w.open[rest.Start] = rest
+			}
+			return err
+		}
+	}
+	return nil
+}
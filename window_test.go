@@ -0,0 +1,81 @@
+package bigcsv_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+type event struct {
+	Time  time.Time `csv:"time"`
+	Value int       `csv:"value"`
+}
+
+// windows runs the events through a Windower, returning its windows as
+// "start-end:values" strings and the errors passed to OnError.
+func windows(t *testing.T, input string, opts bigcsv.WindowOptions) ([]string, []error) {
+	t.Helper()
+	parser, err := bigcsv.NewFromString[event](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	windower, err := bigcsv.Window(func(e event) time.Time { return e.Time }, opts, func(batch bigcsv.WindowBatch[event]) error {
+		var values []string
+		for _, e := range batch.Records {
+			values = append(values, fmt.Sprint(e.Value))
+		}
+		got = append(got, fmt.Sprintf("%s-%s:%s", batch.Start.Format("04"), batch.End.Format("04"), strings.Join(values, ",")))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var errs []error
+	parser.OnData = windower.OnData
+	parser.OnError = func(err error) { errs = append(errs, err) }
+	if err = errors.Join(parser.Run(context.Background(), 1), windower.Flush()); err != nil {
+		t.Fatal(err)
+	}
+	return got, errs
+}
+
+// TestWindow groups events into tumbling windows, tolerating some lateness.
+func TestWindow(t *testing.T) {
+	input := "time,value\n" +
+		"2024-05-01T10:00:10Z,1\n" +
+		"2024-05-01T10:01:05Z,2\n" +
+		"2024-05-01T10:00:50Z,3\n" +
+		"2024-05-01T10:03:30Z,4\n" +
+		"2024-05-01T10:01:59Z,5\n" +
+		"2024-05-01T10:03:40Z,6\n"
+	got, errs := windows(t, input, bigcsv.WindowOptions{Size: time.Minute, Lateness: 20 * time.Second})
+	want := []string{"00-01:1,3", "01-02:2", "03-04:4,6"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got windows %v, expected %v", got, want)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], bigcsv.ErrLate) || !strings.Contains(errs[0].Error(), "line 6") {
+		t.Errorf("Expected line 6 to be late, got: %v", errs)
+	}
+	if _, err := bigcsv.Window(func(e event) time.Time { return e.Time }, bigcsv.WindowOptions{Size: time.Minute, Slide: time.Hour}, nil); err == nil {
+		t.Error("Expected an error for a slide longer than the windows")
+	}
+}
+
+// TestSlidingWindow adds events to each of their overlapping windows.
+func TestSlidingWindow(t *testing.T) {
+	input := "time,value\n" +
+		"2024-05-01T10:00:10Z,1\n" +
+		"2024-05-01T10:01:10Z,2\n" +
+		"2024-05-01T10:02:10Z,3\n"
+	got, _ := windows(t, input, bigcsv.WindowOptions{Size: 2 * time.Minute, Slide: time.Minute})
+	want := []string{"59-01:1", "00-02:1,2", "01-03:2,3", "02-04:3"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Got windows %v, expected %v", got, want)
+	}
+}
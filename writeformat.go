@@ -0,0 +1,126 @@
+package bigcsv
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// WithWriterNumberFormat names a number format for the `csv` tag option
+// "number" of struct fields written, like WithNumberFormat for a Parser.
+func WithWriterNumberFormat(name string, f NumberFormat) WriterOption {
+	return func(cfg *writerConfig) error {
+		if name == "" || f.Decimal == 0 || f.Decimal == f.Group || unicode.IsDigit(f.Decimal) || unicode.IsDigit(f.Group) {
+			return fmt.Errorf("invalid number format %q", name)
+		}
+		if cfg.numberFormats == nil {
+			cfg.numberFormats = map[string]NumberFormat{}
+		}
+		cfg.numberFormats[name] = f
+		return nil
+	}
+}
+
+// WithWriterDateFormat names a date format for the `csv` tag option "date" of
+// struct fields written, like WithDateFormat for a Parser. Times are written
+// in the first of its layouts.
+func WithWriterDateFormat(name string, f DateFormat) WriterOption {
+	return func(cfg *writerConfig) error {
+		if name == "" || len(f.Layouts) == 0 {
+			return fmt.Errorf("invalid date format %q", name)
+		}
+		if cfg.dateFormats == nil {
+			cfg.dateFormats = map[string]DateFormat{}
+		}
+		cfg.dateFormats[name] = f
+		return nil
+	}
+}
+
+// fieldFormatter returns the formatting of a struct field as set by the tag
+// options
+//
+//	number=name  write numbers in the named NumberFormat
+//	nogroup      leave out its group separators
+//	decimals=n   write floats with n decimal places
+//	date=name    write times in the first layout of the named DateFormat
+//	layout=l     write times in the layout l, see time.Layout
+//
+// or nil if there are none. Floats are never written in scientific notation.
+func (cfg *writerConfig) fieldFormatter(typ reflect.Type, t tag) (func(v reflect.Value) string, error) {
+	if typ.Kind() == reflect.Pointer {
+		elem, err := cfg.fieldFormatter(typ.Elem(), t)
+		if elem == nil || err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) string {
+			if v.IsNil() {
+				return ""
+			}
+			return elem(v.Elem())
+		}, nil
+	}
+
+	if layout, ok := t.options["layout"]; ok {
+		if typ != timeType {
+			return nil, fmt.Errorf("date layout for type %s", typ)
+		}
+		return func(v reflect.Value) string {
+			return v.Interface().(time.Time).Format(layout)
+		}, nil
+	}
+	if name, ok := t.options["date"]; ok {
+		f, ok := cfg.dateFormats[name]
+		if !ok {
+			f, ok = dateFormats[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown date format %q", name)
+		}
+		if typ != timeType {
+			return nil, fmt.Errorf("date format for type %s", typ)
+		}
+		return func(v reflect.Value) string {
+			return f.FormatTime(v.Interface().(time.Time))
+		}, nil
+	}
+
+	name, number := t.options["number"]
+	decimals, fixed := t.options["decimals"]
+	if !number && !fixed {
+		return nil, nil
+	}
+	f := NumberFormat{Decimal: '.'}
+	if number {
+		var ok bool
+		if f, ok = cfg.numberFormats[name]; !ok {
+			if f, ok = numberFormats[name]; !ok {
+				return nil, fmt.Errorf("unknown number format %q", name)
+			}
+		}
+	}
+	if t.has("nogroup") {
+		f.Group = 0
+	}
+	places := -1
+	if fixed {
+		var err error
+		if places, err = strconv.Atoi(decimals); err != nil || places < 0 {
+			return nil, fmt.Errorf("invalid decimals %q", decimals)
+		}
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !fixed {
+			return func(v reflect.Value) string { return f.FormatInt(v.Int()) }, nil
+		}
+		return func(v reflect.Value) string { return f.FormatFloat(float64(v.Int()), places) }, nil
+	case reflect.Float32, reflect.Float64:
+		return func(v reflect.Value) string {
+			return f.format(strconv.FormatFloat(v.Float(), 'f', places, typ.Bits()))
+		}, nil
+	}
+	return nil, fmt.Errorf("number format for non-numeric type %s", typ)
+}
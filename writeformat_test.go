@@ -0,0 +1,72 @@
+package bigcsv_test
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/typeduck/bigcsv"
+)
+
+type invoice struct {
+	ID     int        `csv:"id"`
+	Amount float64    `csv:"amount,number=de,decimals=2"`
+	Net    float64    `csv:"net,number=de,decimals=2,nogroup"`
+	Rate   float64    `csv:"rate"`
+	Due    time.Time  `csv:"due,layout=02.01.2006"`
+	Paid   *time.Time `csv:"paid,date=iso"`
+}
+
+// TestWriteFormat round-trips formatted columns byte for byte.
+func TestWriteFormat(t *testing.T) {
+	input := "id,amount,net,rate,due,paid\n" +
+		"1,\"1.234,50\",\"1000,00\",0.00001,31.12.2024,\n" +
+		"2,\"-1.234.567,00\",\"-1037451,26\",19,01.02.2025,2025-02-03T10:00:00Z\n"
+	parser, err := bigcsv.NewFromString[invoice](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	w, err := bigcsv.NewWriter[invoice](&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnData = w.OnData
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != input {
+		t.Fatalf("Output is %q, expected %q", out.String(), input)
+	}
+
+	type bad struct {
+		Name string `csv:"name,decimals=2"`
+	}
+	if _, err = bigcsv.NewWriter[bad](&out); err == nil {
+		t.Error("Expected an error for decimals of a string field")
+	}
+}
+
+// TestFormatNumber formats numbers in locale formats.
+func TestFormatNumber(t *testing.T) {
+	for _, test := range []struct {
+		got, want string
+	}{
+		{bigcsv.NumberFormatEN.FormatFloat(1234567.891, 2), "1,234,567.89"},
+		{bigcsv.NumberFormatFR.FormatFloat(-1234.5, -1), "-1 234,5"},
+		{bigcsv.NumberFormatCH.FormatInt(999), "999"},
+		{bigcsv.NumberFormat{Decimal: ','}.FormatFloat(1e21, 0), "1000000000000000000000"},
+		{bigcsv.NumberFormatDE.FormatFloat(math.Inf(1), 2), "+Inf"},
+		{bigcsv.NumberFormatDE.FormatFloat(math.Inf(-1), 2), "-Inf"},
+		{bigcsv.NumberFormatFR.FormatFloat(math.NaN(), -1), "NaN"},
+	} {
+		if test.got != test.want {
+			t.Errorf("Got %q, expected %q", test.got, test.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package bigcsv
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+	"unicode/utf8"
+)
+
+// WriterOption configures a Writer.
+type WriterOption func(*writerConfig) error
+
+// writerConfig holds the settings made by writer options.
+type writerConfig struct {
+	comma       rune
+	header      []string
+	noHeader    bool
+	headerCase  HeaderCase
+	headerNames map[string]string
+	headerTag   string
+	quoting     QuoteStyle
+	terminator  string
+
+	numberFormats map[string]NumberFormat
+	dateFormats   map[string]DateFormat
+}
+
+// WithWriterComma sets the field delimiter of the output.
+func WithWriterComma(r rune) WriterOption {
+	return func(cfg *writerConfig) error {
+		if r == 0 || r == '"' || r == '\r' || r == '\n' || !utf8.ValidRune(r) || r == utf8.RuneError {
+			return fmt.Errorf("invalid comma %q", r)
+		}
+		cfg.comma = r
+		return nil
+	}
+}
+
+// WithHeader sets the header row, replacing the names of struct fields.
+func WithHeader(names ...string) WriterOption {
+	return func(cfg *writerConfig) error {
+		cfg.header = names
+		return nil
+	}
+}
+
+// WithoutHeader omits the header row.
+func WithoutHeader() WriterOption {
+	return func(cfg *writerConfig) error {
+		cfg.noHeader = true
+		return nil
+	}
+}
+
+// Writer writes records as CSV, the counterpart of a Parser. Structs are
+// written by their fields as for WithStructTags, formatted by the tag options
+// "number", "decimals", "date" and "layout" (see WithWriterNumberFormat),
+// fields tagged "json" as JSON and those tagged with a binary encoding in it,
+// []string rows as they are. The header row is written before the first
+// record if known, from struct tags or WithHeader, and may be renamed for the
+// consumer, see WithHeaderCase. Its OnData is safe for use with multiple
+// workers, so it can be used as OnData of a Parser directly. Flush must be
+// called at the end.
+type Writer[T any] struct {
+	mu      sync.Mutex
+	csv     *recordWriter
+	cfg     *writerConfig
+	header  []string
+	pending bool // header not yet written
+	rows    int64
+	format  func(data T) ([]string, error)
+}
+
+// NewWriter creates a Writer for w.
+func NewWriter[T any](w io.Writer, opts ...WriterOption) (*Writer[T], error) {
+	cfg := &writerConfig{comma: ',', quoting: QuoteMinimal, terminator: "\n"}
+	for _, opt := range opts {
+		if err := opt(cfg); err != nil {
+			return nil, fmt.Errorf("invalid option: %w", err)
+		}
+	}
+	wr := &Writer[T]{csv: newRecordWriter(w, cfg), cfg: cfg}
+
+	typ := reflect.TypeFor[T]()
+	switch {
+	case typ.Kind() == reflect.Struct:
+		fields := taggedFields(typ, nil)
+		formats := make([]func(v reflect.Value) string, len(fields))
+		for i, f := range fields {
+			wr.header = append(wr.header, cfg.headerName(f))
+			var err error
+			if formats[i], err = cfg.fieldFormatter(f.typ, f.tag); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.field, err)
+			}
+		}
+		wr.format = func(data T) ([]string, error) {
+			v := reflect.ValueOf(data)
+			row := make([]string, len(fields))
+			for i, f := range fields {
+				if formats[i] != nil {
+					row[i] = formats[i](v.FieldByIndex(f.index))
+					continue
+				}
+				if f.tag.binaryEncoding() != "" {
+					row[i] = formatBinary(v.FieldByIndex(f.index), f.tag)
+					continue
+				}
+				if !f.tag.has("json") {
+					row[i] = formatField(v.FieldByIndex(f.index))
+					continue
+				}
+				var err error
+				if row[i], err = formatJSON(v.FieldByIndex(f.index)); err != nil {
+					return nil, &ColumnError{Column: f.tag.name, Index: i, Err: err}
+				}
+			}
+			return row, nil
+		}
+	case typ == reflect.TypeFor[[]string]():
+		wr.format = func(data T) ([]string, error) {
+			return any(data).([]string), nil
+		}
+	default:
+		return nil, fmt.Errorf("cannot write type %s, use a struct or []string", typ)
+	}
+	if cfg.header != nil {
+		if wr.header != nil && len(cfg.header) != len(wr.header) {
+			return nil, fmt.Errorf("got %d header names for %d fields", len(cfg.header), len(wr.header))
+		}
+		wr.header = cfg.header
+	}
+	if wr.header != nil {
+		var err error
+		if wr.header, err = cfg.renameHeader(wr.header); err != nil {
+			return nil, err
+		}
+	} else if cfg.headerNames != nil {
+		return nil, fmt.Errorf("no header to rename columns of")
+	}
+	wr.pending = wr.header != nil && !cfg.noHeader
+	return wr, nil
+}
+
+// Header returns the names of the columns, nil if unknown.
+func (w *Writer[T]) Header() []string {
+	return w.header
+}
+
+// Rows returns the number of records written, not counting the header row.
+func (w *Writer[T]) Rows() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.rows
+}
+
+// OnData writes the record.
+func (w *Writer[T]) OnData(data T) error {
+	row, err := w.format(data)
+	if err != nil {
+		return err
+	}
+	return w.Write(row)
+}
+
+// Write writes a formatted row, preceded by the header row if not written yet.
+func (w *Writer[T]) Write(row []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.pending {
+		w.pending = false
+		if err := w.csv.Write(w.header); err != nil {
+			return fmt.Errorf("could not write header: %w", err)
+		}
+	}
+	if err := w.csv.Write(row); err != nil {
+		return fmt.Errorf("could not write row: %w", err)
+	}
+	w.rows++
+	return nil
+}
+
+// Flush writes any buffered output.
+func (w *Writer[T]) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// formatField formats a struct field, nil pointers as empty fields.
+func formatField(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return formatValue(v)
+}
+
+// formatJSON formats a struct field tagged "json", zero values as empty
+// fields.
+func formatJSON(v reflect.Value) (string, error) {
+	if v.IsZero() {
+		return "", nil
+	}
+	b, err := json.Marshal(v.Interface())
+	return string(b), err
+}
@@ -0,0 +1,34 @@
+package bigcsv_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// TestWriter round-trips structs through a Parser and a Writer.
+func TestWriter(t *testing.T) {
+	input := "name,address,port,timeout,weight\nweb,10.0.0.1,80,1m0s,0.5\ndb,10.0.0.2,5432,10s,\n"
+	parser, err := bigcsv.NewFromString[Server](input, bigcsv.WithHeaders(), bigcsv.WithStructTags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	w, err := bigcsv.NewWriter[Server](&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.OnData = w.OnData
+	if err = parser.Run(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "name,address,port,timeout,weight,comment\nweb,10.0.0.1,80,1m0s,0.5,\ndb,10.0.0.2,5432,10s,,\n"
+	if out.String() != want {
+		t.Fatalf("Output is %q, expected %q", out.String(), want)
+	}
+}
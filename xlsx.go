@@ -0,0 +1,356 @@
+package bigcsv
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSX returns a RecordReader constructor for a sheet of an Excel workbook,
+// the first sheet if name is empty. Only the shared strings and styles are
+// loaded up front, the rows of the sheet are decoded as they are read.
+//
+//	parser, err := bigcsv.New[Place](bigcsv.FileStream("places.xlsx"), bigcsv.WithRecordReader(bigcsv.XLSX("Places")))
+//
+// Workbooks are zip archives, which need random access: streams other than
+// files are read into memory. Numbers are returned as stored, dates as
+// "2006-01-02" or RFC 3339 if they have a time, booleans as TRUE or FALSE.
+// Missing rows are returned as empty records, see WithSkipBlankLines.
+func XLSX(name string) func(io.Reader) RecordReader {
+	return func(r io.Reader) RecordReader {
+		x, err := openXLSX(r, name)
+		if err != nil {
+			return failedReader{fmt.Errorf("could not open workbook: %w", err)}
+		}
+		return x
+	}
+}
+
+// failedReader returns the error of a RecordReader which could not be
+// created.
+type failedReader struct {
+	err error
+}
+
+func (f failedReader) Read() ([]string, error) {
+	return nil, f.err
+}
+
+// openZip opens a zip archive from a stream, reading it into memory unless it
+// supports random access.
+func openZip(r io.Reader) (*zip.Reader, error) {
+	if f, ok := r.(interface {
+		io.ReaderAt
+		Stat() (fs.FileInfo, error)
+	}); ok {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return zip.NewReader(f, info.Size())
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(b), int64(len(b)))
+}
+
+// openXML opens a file of the archive, returning nil if it does not exist.
+func openXML(z *zip.Reader, name string) (io.ReadCloser, error) {
+	f, err := z.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return f, err
+}
+
+// decodeXML decodes a file of the archive into v, leaving v as is if the
+// file does not exist.
+func decodeXML(z *zip.Reader, name string, v any) error {
+	f, err := openXML(z, name)
+	if err != nil || f == nil {
+		return err
+	}
+	defer f.Close()
+	if err = xml.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxReader reads the rows of a worksheet.
+type xlsxReader struct {
+	sheet      io.ReadCloser
+	dec        *xml.Decoder
+	strings    []string
+	dates      []bool // by style index, whether numbers are dates
+	date1904   bool
+	row        int // number of the last row returned
+	pending    []string
+	pendingRow int
+}
+
+// openXLSX locates the sheet and loads the shared strings and styles.
+func openXLSX(r io.Reader, name string) (*xlsxReader, error) {
+	z, err := openZip(r)
+	if err != nil {
+		return nil, err
+	}
+	var workbook struct {
+		Pr struct {
+			Date1904 bool `xml:"date1904,attr"`
+		} `xml:"workbookPr"`
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	if err = decodeXML(z, "xl/workbook.xml", &workbook); err != nil {
+		return nil, err
+	}
+	var rels struct {
+		Rels []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err = decodeXML(z, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	target := ""
+	for _, sheet := range workbook.Sheets {
+		if name != "" && sheet.Name != name {
+			continue
+		}
+		for _, rel := range rels.Rels {
+			if rel.ID == sheet.ID {
+				target = rel.Target
+			}
+		}
+		break
+	}
+	if target == "" {
+		return nil, fmt.Errorf("no sheet %q", name)
+	}
+	if strings.HasPrefix(target, "/") {
+		target = strings.TrimPrefix(target, "/")
+	} else {
+		target = path.Join("xl", target)
+	}
+
+	x := &xlsxReader{date1904: workbook.Pr.Date1904}
+	if x.strings, err = sharedStrings(z); err != nil {
+		return nil, err
+	}
+	if x.dates, err = dateStyles(z); err != nil {
+		return nil, err
+	}
+	if x.sheet, err = openXML(z, target); err != nil || x.sheet == nil {
+		return nil, fmt.Errorf("could not open sheet %s: %w", target, errors.Join(err, fs.ErrNotExist))
+	}
+	x.dec = xml.NewDecoder(x.sheet)
+	return x, nil
+}
+
+// xlsxText is rich or plain text of a string item.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+// sharedStrings loads the strings referenced by cells.
+func sharedStrings(z *zip.Reader) ([]string, error) {
+	var sst struct {
+		Items []xlsxText `xml:"si"`
+	}
+	if err := decodeXML(z, "xl/sharedStrings.xml", &sst); err != nil {
+		return nil, err
+	}
+	s := make([]string, len(sst.Items))
+	for i, item := range sst.Items {
+		s[i] = item.String()
+	}
+	return s, nil
+}
+
+// dateStyles tells by cell style whether numbers are formatted as dates.
+func dateStyles(z *zip.Reader) ([]bool, error) {
+	var styles struct {
+		NumFmts []struct {
+			ID   int    `xml:"numFmtId,attr"`
+			Code string `xml:"formatCode,attr"`
+		} `xml:"numFmts>numFmt"`
+		Xfs []struct {
+			NumFmtID int `xml:"numFmtId,attr"`
+		} `xml:"cellXfs>xf"`
+	}
+	if err := decodeXML(z, "xl/styles.xml", &styles); err != nil {
+		return nil, err
+	}
+	custom := map[int]bool{}
+	for _, f := range styles.NumFmts {
+		custom[f.ID] = isDateFormat(f.Code)
+	}
+	dates := make([]bool, len(styles.Xfs))
+	for i, xf := range styles.Xfs {
+		id := xf.NumFmtID
+		dates[i] = (id >= 14 && id <= 22) || (id >= 45 && id <= 47) || custom[id]
+	}
+	return dates, nil
+}
+
+// isDateFormat tells whether a number format code formats dates or times,
+// ignoring quoted text and colors like [Red].
+func isDateFormat(code string) bool {
+	quoted, bracket := false, false
+	for _, c := range strings.ToLower(code) {
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[':
+			bracket = true
+		case c == ']':
+			bracket = false
+		case bracket:
+		case strings.ContainsRune("dmyhs", c):
+			return true
+		}
+	}
+	return false
+}
+
+func (x *xlsxReader) Read() ([]string, error) {
+	if x.pending != nil {
+		if x.row+1 < x.pendingRow { // a missing row
+			x.row++
+			return []string{}, nil
+		}
+		row := x.pending
+		x.row, x.pending = x.pendingRow, nil
+		return row, nil
+	}
+	for {
+		tok, err := x.dec.Token()
+		if err != nil {
+			x.sheet.Close()
+			return nil, err // io.EOF at the end of the sheet
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				R  string   `xml:"r,attr"`
+				T  string   `xml:"t,attr"`
+				S  int      `xml:"s,attr"`
+				V  string   `xml:"v"`
+				Is xlsxText `xml:"is"`
+			} `xml:"c"`
+		}
+		if err = x.dec.DecodeElement(&row, &start); err != nil {
+			return nil, err
+		}
+		if row.R == 0 {
+			row.R = x.row + 1
+		}
+		var fields []string
+		for _, c := range row.Cells {
+			col := len(fields)
+			if ix := columnIndex(c.R); ix >= 0 {
+				col = ix
+			}
+			for len(fields) <= col {
+				fields = append(fields, "")
+			}
+			if fields[col], err = x.value(c.T, c.S, c.V, c.Is); err != nil {
+				return nil, fmt.Errorf("%w: cell %s: %w", ErrMalformedRecord, c.R, err)
+			}
+		}
+		if fields == nil {
+			fields = []string{}
+		}
+		x.pending, x.pendingRow = fields, row.R
+		return x.Read()
+	}
+}
+
+// value converts a cell value by its type and style.
+func (x *xlsxReader) value(typ string, style int, v string, is xlsxText) (string, error) {
+	switch typ {
+	case "s":
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 || i >= len(x.strings) {
+			return "", fmt.Errorf("invalid shared string %q", v)
+		}
+		return x.strings[i], nil
+	case "inlineStr":
+		return is.String(), nil
+	case "b":
+		if v == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case "", "n":
+		if v == "" || style >= len(x.dates) || !x.dates[style] {
+			return v, nil
+		}
+		serial, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return "", err
+		}
+		return formatSerial(serial, x.date1904), nil
+	}
+	return v, nil // str (formula result), e (error) and d (ISO date)
+}
+
+// formatSerial formats an Excel date serial number.
+func formatSerial(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	days, frac := math.Modf(serial)
+	t := epoch.AddDate(0, 0, int(days)).Add(time.Duration(math.Round(frac*86400)) * time.Second)
+	if frac == 0 {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// columnIndex returns the 0-based column of a cell reference like "AB12".
+func columnIndex(ref string) int {
+	col := 0
+	for _, c := range ref {
+		if c < 'A' || c > 'Z' {
+			break
+		}
+		col = col*26 + int(c-'A') + 1
+	}
+	return col - 1
+}
@@ -0,0 +1,61 @@
+package bigcsv_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/typeduck/bigcsv"
+)
+
+// zipFiles creates a zip archive of the files.
+func zipFiles(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// TestXLSX reads a sheet with shared strings, inline strings, dates and gaps.
+func TestXLSX(t *testing.T) {
+	workbook := zipFiles(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Notes" sheetId="1" r:id="rId1"/><sheet name="Data" sheetId="2" r:id="rId2"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>
+<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Target="worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>name</t></si><si><r><t>da</t></r><r><t>y</t></r></si><si><t>one</t></si></sst>`,
+		"xl/styles.xml":        `<styleSheet><cellXfs><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData>
+<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="inlineStr"><is><t>ok</t></is></c></row>
+<row r="3"><c r="A3" t="s"><v>2</v></c><c r="B3" s="1"><v>45292</v></c><c r="C3" t="b"><v>1</v></c></row>
+<row r="4"><c r="C4"><v>1.5</v></c></row>
+</sheetData></worksheet>`,
+	})
+	parser, err := bigcsv.NewRowParser(bigcsv.ReadStream(bytes.NewReader(workbook)), bigcsv.WithRecordReader(bigcsv.XLSX("Data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows [][]string
+	for row, err := range parser.Rows(context.Background()) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	want := [][]string{{"name", "day", "ok"}, {}, {"one", "2024-01-01", "TRUE"}, {"", "", "1.5"}}
+	if !slices.EqualFunc(rows, want, slices.Equal) {
+		t.Fatalf("Rows are %q, expected %q", rows, want)
+	}
+}
@@ -0,0 +1,460 @@
+package bigcsv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// LoadPipelineYAML decodes a PipelineSpec from YAML, using the same field
+// names as LoadPipeline, and creates its Pipeline. Unknown fields are
+// rejected, to catch misspelled settings.
+//
+// It reads the YAML used for configuration files: block mappings and
+// sequences, single line flow collections such as [DE, AT], plain and quoted
+// scalars, and comments. Anchors, tags and multi-line scalars are rejected.
+func LoadPipelineYAML(r io.Reader) (*Pipeline, error) {
+	var spec PipelineSpec
+	if err := decodeYAML(r, &spec); err != nil {
+		return nil, fmt.Errorf("invalid pipeline: %w", err)
+	}
+	return NewPipeline(spec)
+}
+
+// yamlNode is a scalar, a mapping or a sequence of a YAML document.
+type yamlNode struct {
+	line   int
+	scalar *string // nil for collections, unquoted
+	null   bool
+	keys   []string // of a mapping, nil for a sequence
+	items  []*yamlNode
+}
+
+// yamlLine is a line of a YAML document without indentation and comment.
+type yamlLine struct {
+	number, indent int
+	text           string
+}
+
+// yamlParser parses the lines of a block.
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// decodeYAML decodes a YAML document into v, a pointer, by the `yaml` tags of
+// its struct fields.
+func decodeYAML(r io.Reader, v any) error {
+	p := &yamlParser{}
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		text := strings.TrimRight(s.Text(), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if strings.HasPrefix(trimmed, "\t") {
+			return fmt.Errorf("yaml: line %d: tabs cannot indent", n)
+		}
+		if trimmed = stripYAMLComment(trimmed); trimmed == "" || n == 1 && trimmed == "---" {
+			continue
+		}
+		p.lines = append(p.lines, yamlLine{n, len(text) - len(strings.TrimLeft(text, " ")), trimmed})
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	if len(p.lines) == 0 {
+		return fmt.Errorf("yaml: empty document")
+	}
+	root, err := p.block(p.lines[0].indent)
+	if err != nil {
+		return err
+	}
+	if p.i < len(p.lines) {
+		return fmt.Errorf("yaml: line %d: unexpected indentation", p.lines[p.i].number)
+	}
+	return root.decode(reflect.ValueOf(v).Elem())
+}
+
+// stripYAMLComment removes a comment from a line, which starts with # at the
+// start or after a space, outside of quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote == '\'' && c == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" [{,:-", s[i-1]) >= 0):
+			quote = c
+		case quote == 0 && c == '#' && (i == 0 || s[i-1] == ' '):
+			return strings.TrimRight(s[:i], " ")
+		}
+	}
+	return s
+}
+
+// block parses a mapping or sequence whose lines have the indent.
+func (p *yamlParser) block(indent int) (*yamlNode, error) {
+	if l := p.lines[p.i]; l.text == "-" || strings.HasPrefix(l.text, "- ") {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+// sequence parses the items of a block sequence.
+func (p *yamlParser) sequence(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.i].number, items: []*yamlNode{}}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent != indent || l.text != "-" && !strings.HasPrefix(l.text, "- ") {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		var item *yamlNode
+		var err error
+		switch {
+		case rest == "":
+			item, err = p.nested(l, indent)
+		case rest == "-" || strings.HasPrefix(rest, "- ") || isYAMLMappingEntry(rest):
+			// The item is a block collection starting on the line of its dash.
+			p.lines[p.i] = yamlLine{l.number, len(l.text) - len(rest) + indent, rest}
+			item, err = p.block(p.lines[p.i].indent)
+		default:
+			item, err = yamlInline(rest, l.number)
+			p.i++
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.items = append(node.items, item)
+	}
+	return node, nil
+}
+
+// mapping parses the entries of a block mapping.
+func (p *yamlParser) mapping(indent int) (*yamlNode, error) {
+	node := &yamlNode{line: p.lines[p.i].number, keys: []string{}}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent || !isYAMLMappingEntry(l.text) {
+			return nil, fmt.Errorf("yaml: line %d: expected a mapping entry", l.number)
+		}
+		key, rest, err := splitYAMLEntry(l.text, l.number)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range node.keys {
+			if k == key {
+				return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.number, key)
+			}
+		}
+		var value *yamlNode
+		if rest == "" {
+			value, err = p.nested(l, indent)
+		} else {
+			value, err = yamlInline(rest, l.number)
+			p.i++
+		}
+		if err != nil {
+			return nil, err
+		}
+		node.keys = append(node.keys, key)
+		node.items = append(node.items, value)
+	}
+	return node, nil
+}
+
+// nested parses the block following the line l at the indent, null if there
+// is none. A sequence may be nested at the indent of its key.
+func (p *yamlParser) nested(l yamlLine, indent int) (*yamlNode, error) {
+	p.i++
+	if p.i < len(p.lines) {
+		next := p.lines[p.i]
+		isSeq := next.text == "-" || strings.HasPrefix(next.text, "- ")
+		if next.indent > indent || next.indent == indent && isSeq && isYAMLMappingEntry(l.text) {
+			return p.block(next.indent)
+		}
+	}
+	return &yamlNode{line: l.number, null: true}, nil
+}
+
+// isYAMLMappingEntry tells whether a line holds a "key: value" entry.
+func isYAMLMappingEntry(s string) bool {
+	if s == "" || strings.IndexByte("[{", s[0]) >= 0 {
+		return false
+	}
+	_, _, err := splitYAMLEntry(s, 0)
+	return err == nil
+}
+
+// splitYAMLEntry splits a mapping entry into its key and value.
+func splitYAMLEntry(s string, line int) (string, string, error) {
+	var key string
+	rest := s
+	if s[0] == '"' || s[0] == '\'' {
+		n, end, err := yamlQuoted(s, line)
+		if err != nil {
+			return "", "", err
+		}
+		key, rest = *n.scalar, s[end:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("yaml: line %d: expected : after key", line)
+		}
+	} else {
+		ix := strings.Index(s, ": ")
+		if ix < 0 && strings.HasSuffix(s, ":") {
+			ix = len(s) - 1
+		}
+		if ix <= 0 {
+			return "", "", fmt.Errorf("yaml: line %d: expected a mapping entry", line)
+		}
+		key, rest = strings.TrimRight(s[:ix], " "), s[ix:]
+	}
+	return key, strings.TrimLeft(rest[1:], " "), nil
+}
+
+// yamlInline parses the value of a line: a flow collection or a scalar.
+func yamlInline(s string, line int) (*yamlNode, error) {
+	switch s[0] {
+	case '|', '>':
+		return nil, fmt.Errorf("yaml: line %d: multi-line scalars are not supported", line)
+	case '&', '*', '!':
+		return nil, fmt.Errorf("yaml: line %d: anchors, aliases and tags are not supported", line)
+	case '[', '{', '"', '\'':
+		f := &yamlFlow{s: s, line: line}
+		node, err := f.value()
+		if err == nil && f.skipSpace() < len(s) {
+			err = fmt.Errorf("yaml: line %d: unexpected %q", line, s[f.pos:])
+		}
+		return node, err
+	}
+	return yamlPlain(s, line), nil
+}
+
+// yamlPlain returns a plain scalar, null for "", "~" and "null".
+func yamlPlain(s string, line int) *yamlNode {
+	if s == "" || s == "~" || s == "null" {
+		return &yamlNode{line: line, null: true}
+	}
+	return &yamlNode{line: line, scalar: &s}
+}
+
+// yamlQuoted parses a quoted scalar at the start of s, returning the end of
+// its quotes.
+func yamlQuoted(s string, line int) (*yamlNode, int, error) {
+	if s[0] == '\'' {
+		var sb strings.Builder
+		for i := 1; i < len(s); i++ {
+			switch {
+			case s[i] == '\'' && i+1 < len(s) && s[i+1] == '\'':
+				sb.WriteByte('\'')
+				i++
+			case s[i] == '\'':
+				v := sb.String()
+				return &yamlNode{line: line, scalar: &v}, i + 1, nil
+			default:
+				sb.WriteByte(s[i])
+			}
+		}
+		return nil, 0, fmt.Errorf("yaml: line %d: unterminated quote", line)
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			v, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return nil, 0, fmt.Errorf("yaml: line %d: invalid quoted string %s", line, s[:i+1])
+			}
+			return &yamlNode{line: line, scalar: &v}, i + 1, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("yaml: line %d: unterminated quote", line)
+}
+
+// yamlFlow parses flow collections within a line.
+type yamlFlow struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (f *yamlFlow) skipSpace() int {
+	for f.pos < len(f.s) && f.s[f.pos] == ' ' {
+		f.pos++
+	}
+	return f.pos
+}
+
+// value parses a collection or scalar within a flow collection.
+func (f *yamlFlow) value() (*yamlNode, error) {
+	if f.skipSpace() == len(f.s) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected end of line", f.line)
+	}
+	switch f.s[f.pos] {
+	case '[', '{':
+		return f.collection()
+	case '"', '\'':
+		node, end, err := yamlQuoted(f.s[f.pos:], f.line)
+		f.pos += end
+		return node, err
+	}
+	start := f.pos
+	for f.pos < len(f.s) && strings.IndexByte(",]}", f.s[f.pos]) < 0 &&
+		!(f.s[f.pos] == ':' && (f.pos+1 == len(f.s) || f.s[f.pos+1] == ' ')) {
+		f.pos++
+	}
+	return yamlPlain(strings.TrimRight(f.s[start:f.pos], " "), f.line), nil
+}
+
+// collection parses a flow sequence or mapping.
+func (f *yamlFlow) collection() (*yamlNode, error) {
+	mapping := f.s[f.pos] == '{'
+	end := byte(']')
+	node := &yamlNode{line: f.line, items: []*yamlNode{}}
+	if mapping {
+		end, node.keys = '}', []string{}
+	}
+	f.pos++
+	for {
+		if f.skipSpace() < len(f.s) && f.s[f.pos] == end {
+			f.pos++
+			return node, nil
+		}
+		if mapping {
+			key, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			if key.scalar == nil || f.skipSpace() == len(f.s) || f.s[f.pos] != ':' {
+				return nil, fmt.Errorf("yaml: line %d: expected a key in flow mapping", f.line)
+			}
+			f.pos++
+			node.keys = append(node.keys, *key.scalar)
+		}
+		item, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		node.items = append(node.items, item)
+		switch f.skipSpace(); {
+		case f.pos < len(f.s) && f.s[f.pos] == ',':
+			f.pos++
+		case f.pos < len(f.s) && f.s[f.pos] == end:
+		default:
+			return nil, fmt.Errorf("yaml: line %d: unterminated flow collection", f.line)
+		}
+	}
+}
+
+// decode stores the node in v by the `yaml` tags of struct fields.
+func (n *yamlNode) decode(v reflect.Value) error {
+	if n.null {
+		v.SetZero()
+		return nil
+	}
+	fail := func(format string, args ...any) error {
+		return fmt.Errorf("yaml: line %d: %s", n.line, fmt.Sprintf(format, args...))
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := n.decode(elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+		return nil
+	case reflect.Struct:
+		if n.keys == nil {
+			return fail("expected a mapping for %s", v.Type())
+		}
+		fields := map[string]int{}
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+			if name == "" {
+				name = f.Name
+			}
+			if f.IsExported() && name != "-" {
+				fields[name] = i
+			}
+		}
+		for i, key := range n.keys {
+			ix, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("yaml: line %d: unknown field %q", n.items[i].line, key)
+			}
+			if err := n.items[i].decode(v.Field(ix)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Map:
+		if n.keys == nil || v.Type().Key().Kind() != reflect.String {
+			return fail("expected a mapping for %s", v.Type())
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(n.keys))
+		for i, key := range n.keys {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := n.items[i].decode(elem); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Slice:
+		if n.scalar != nil || n.keys != nil {
+			return fail("expected a sequence for %s", v.Type())
+		}
+		s := reflect.MakeSlice(v.Type(), len(n.items), len(n.items))
+		for i, item := range n.items {
+			if err := item.decode(s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	if n.scalar == nil {
+		return fail("expected a value for %s", v.Type())
+	}
+	s := *n.scalar
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		switch s {
+		case "true", "True", "TRUE":
+			v.SetBool(true)
+		case "false", "False", "FALSE":
+			v.SetBool(false)
+		default:
+			return fail("invalid boolean %q", s)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fail("invalid integer %q", s)
+		}
+		v.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fail("invalid number %q", s)
+		}
+		v.SetFloat(x)
+	default:
+		return fail("cannot decode into %s", v.Type())
+	}
+	return nil
+}